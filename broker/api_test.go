@@ -2,6 +2,7 @@ package broker_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,8 @@ import (
 	"github.com/alphagov/paas-elasticache-broker/broker"
 	"github.com/alphagov/paas-elasticache-broker/providers"
 	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
 	"github.com/pivotal-cf/brokerapi"
 
 	"errors"
@@ -163,6 +166,86 @@ var _ = Describe("Broker", func() {
 			Expect(resp.Code).To(Equal(422))
 
 		})
+
+		Context("when the request or AWS fails", func() {
+			provision := func(parameters string) *httptest.ResponseRecorder {
+				return DoRequest(brokerAPI, NewRequest(
+					"PUT",
+					"/v2/service_instances/"+uuid.NewV4().String(),
+					strings.NewReader(`{
+						"service_id": "service1",
+						"plan_id": "plan1",
+						"organization_guid": "test-organization-id",
+						"space_guid": "space-id",
+						"parameters": `+parameters+`
+					}`),
+					credentials.Username,
+					credentials.Password,
+					url.Values{"accepts_incomplete": []string{"true"}},
+				))
+			}
+
+			It("responds with a 400 when the parameters are invalid", func() {
+				resp := provision(`{"unknown": true}`)
+
+				Expect(resp.Code).To(Equal(400))
+				Expect(resp.Body.String()).To(ContainSubstring("unknown"))
+			})
+
+			It("responds with a 400 when the snapshot to restore doesn't exist", func() {
+				resp := provision(`{"restore_from_latest_snapshot_of": "` + uuid.NewV4().String() + `"}`)
+
+				Expect(resp.Code).To(Equal(400))
+				Expect(resp.Body.String()).To(ContainSubstring("No snapshots found"))
+			})
+
+			It("responds with a 422 and a readable message when an AWS quota is exceeded", func() {
+				fakeProvider.ProvisionReturns(awserr.New(elasticache.ErrCodeNodeQuotaForCustomerExceededFault, "quota exceeded", nil))
+
+				resp := provision(`{}`)
+
+				Expect(resp.Code).To(Equal(422))
+				var errorResponse brokerapi.ErrorResponse
+				Expect(json.Unmarshal(resp.Body.Bytes(), &errorResponse)).To(Succeed())
+				Expect(errorResponse.Description).To(Equal(
+					"The limit of cache nodes in the AWS account has been reached, please contact support",
+				))
+			})
+
+			It("responds with a 503 when AWS throttles the requests", func() {
+				fakeProvider.ProvisionReturns(awserr.New("Throttling", "Rate exceeded", nil))
+
+				resp := provision(`{}`)
+
+				Expect(resp.Code).To(Equal(503))
+			})
+
+			It("responds with a 503 when the circuit breaker of the AWS client is open", func() {
+				fakeProvider.ProvisionReturns(fmt.Errorf("failed to create cache parameter group: %w", providers.ErrCircuitOpen))
+
+				resp := provision(`{}`)
+
+				Expect(resp.Code).To(Equal(503))
+				Expect(resp.Body.String()).To(ContainSubstring("AWS is not responding to the requests of the broker"))
+			})
+
+			It("responds with a 400 when the provider doesn't support a feature", func() {
+				fakeProvider.ProvisionReturns(providers.NotSupportedError("Memcached does not support snapshots"))
+
+				resp := provision(`{}`)
+
+				Expect(resp.Code).To(Equal(400))
+				Expect(resp.Body.String()).To(ContainSubstring("Memcached does not support snapshots"))
+			})
+
+			It("responds with a 409 when the replication group exists already", func() {
+				fakeProvider.ProvisionReturns(awserr.New(elasticache.ErrCodeReplicationGroupAlreadyExistsFault, "exists", nil))
+
+				resp := provision(`{}`)
+
+				Expect(resp.Code).To(Equal(409))
+			})
+		})
 	})
 
 	Describe("Deprovision", func() {
@@ -174,7 +257,7 @@ var _ = Describe("Broker", func() {
 				nil,
 				credentials.Username,
 				credentials.Password,
-				url.Values{"accepts_incomplete": []string{"true"}, "service_id": []string{uuid.NewV4().String()}, "plan_id": []string{uuid.NewV4().String()}},
+				url.Values{"accepts_incomplete": []string{"true"}, "service_id": []string{"service1"}, "plan_id": []string{uuid.NewV4().String()}},
 			))
 
 			Expect(resp.Code).To(Equal(202))
@@ -190,7 +273,7 @@ var _ = Describe("Broker", func() {
 				nil,
 				credentials.Username,
 				credentials.Password,
-				url.Values{"accepts_incomplete": []string{"true"}, "service_id": []string{uuid.NewV4().String()}, "plan_id": []string{uuid.NewV4().String()}},
+				url.Values{"accepts_incomplete": []string{"true"}, "service_id": []string{"service1"}, "plan_id": []string{uuid.NewV4().String()}},
 			))
 
 			Expect(resp.Code).To(Equal(500))
@@ -327,5 +410,34 @@ var _ = Describe("Broker", func() {
 			Expect(resp.Code).To(Equal(422))
 
 		})
+
+		It("responds with a 422 ConcurrencyError when another operation is in progress", func() {
+			fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: providers.Modifying}, nil)
+
+			resp := DoRequest(brokerAPI, NewRequest(
+				"PATCH",
+				"/v2/service_instances/"+uuid.NewV4().String(),
+				strings.NewReader(`{
+					"service_id": "service1",
+					"plan_id": "plan1",
+					"previous_values": {
+						"plan_id": "plan1",
+						"service_id": "service1",
+						"org_id": "test-organization-id",
+						"space_id": "space-id"
+					},
+					"parameters": {"maxmemory_policy": "noeviction"}
+				}`),
+				credentials.Username,
+				credentials.Password,
+				url.Values{"accepts_incomplete": []string{"true"}},
+			))
+
+			Expect(resp.Code).To(Equal(422))
+			var errorResponse brokerapi.ErrorResponse
+			Expect(json.Unmarshal(resp.Body.Bytes(), &errorResponse)).To(Succeed())
+			Expect(errorResponse.Error).To(Equal("ConcurrencyError"))
+			Expect(errorResponse.Description).To(ContainSubstring("The instance is modifying"))
+		})
 	})
 })
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/lager"
@@ -14,11 +15,12 @@ import (
 	"github.com/alphagov/paas-elasticache-broker/providers"
 )
 
-// Broker is the open service broker API implementation for AWS Elasticache Redis
+// Broker is the open service broker API implementation for AWS Elasticache
 type Broker struct {
-	config   Config
-	provider providers.Provider
-	logger   lager.Logger
+	config Config
+	// serviceProviders are the providers of the catalog services keyed by the service IDs
+	serviceProviders map[string]providers.Provider
+	logger           lager.Logger
 }
 
 type action = string
@@ -26,9 +28,18 @@ type action = string
 // Operation is the operation data passed back by the provision/deprovision/update calls and received by the last
 // operation call
 type Operation struct {
-	Action      action `json:"action"`
-	PrimaryNode string `json:"primaryNode"`
-	TimeOut     string `json:"timeOut"`
+	Action               action `json:"action"`
+	PrimaryNode          string `json:"primaryNode"`
+	TimeOut              string `json:"timeOut"`
+	SnapshotName         string `json:"snapshotName,omitempty"`
+	Engine               string `json:"engine,omitempty"`
+	EngineVersion        string `json:"engineVersion,omitempty"`
+	ReplicasPerNodeGroup *int64 `json:"replicasPerNodeGroup,omitempty"`
+	ShardCount           *int64 `json:"shardCount,omitempty"`
+	NodeGroupID          string `json:"nodeGroupId,omitempty"`
+	StartTime            string `json:"startTime,omitempty"`
+	PlanID               string `json:"planId,omitempty"`
+	AuthTokenNotStored   bool   `json:"authTokenNotStored,omitempty"`
 }
 
 func (o Operation) String() string {
@@ -36,22 +47,43 @@ func (o Operation) String() string {
 	return string(b)
 }
 
-// New creates a new broker instance
+// New creates a new broker instance which uses the same provider for every service in the catalog
 func New(config Config, provider providers.Provider, logger lager.Logger) *Broker {
+	serviceProviders := map[string]providers.Provider{}
+	for _, service := range config.Catalog.Services {
+		serviceProviders[service.ID] = provider
+	}
+	return NewWithProviders(config, serviceProviders, logger)
+}
+
+// NewWithProviders creates a new broker instance with the providers of the catalog services keyed by the service IDs
+func NewWithProviders(config Config, serviceProviders map[string]providers.Provider, logger lager.Logger) *Broker {
 	return &Broker{
-		config:   config,
-		provider: provider,
-		logger:   logger,
+		config:           config,
+		serviceProviders: serviceProviders,
+		logger:           logger,
 	}
 }
 
 // Possible actions in the operation data
 const (
-	ActionProvisioning   action = "provisioning"
-	ActionDeprovisioning action = "deprovisioning"
-	ActionUpdating       action = "updating"
-	ActionFailover       action = "failover"
-	FailoverTimeout             = 45 * time.Minute
+	ActionProvisioning     action = "provisioning"
+	ActionDeprovisioning   action = "deprovisioning"
+	ActionUpdating         action = "updating"
+	ActionFailover         action = "failover"
+	ActionTestingFailover  action = "testing-failover"
+	ActionChangingPlan     action = "changing-plan"
+	ActionRotatingToken    action = "rotating-auth-token"
+	ActionSnapshotting     action = "creating-snapshot"
+	ActionUpgrading        action = "upgrading-engine-version"
+	ActionMigratingEngine  action = "migrating-engine"
+	ActionChangingReplicas action = "changing-replicas"
+	ActionResharding       action = "resharding"
+	FailoverTimeout               = 45 * time.Minute
+	// HighAvailabilityCheckInterval is how often RestoreHighAvailability is run
+	HighAvailabilityCheckInterval = 15 * time.Minute
+	// HighAvailabilityCheckTimeout is how long a single run of RestoreHighAvailability can take
+	HighAvailabilityCheckTimeout = 5 * time.Minute
 )
 
 // Sort providers.SnapshotInfo
@@ -65,12 +97,22 @@ func (b *Broker) GetBinding(ctx context.Context, first, second string) (brokerap
 	return brokerapi.GetBindingSpec{}, fmt.Errorf("GetBinding method not implemented")
 }
 
-func (b *Broker) GetInstance(ctx context.Context, instanceID string) (brokerapi.GetInstanceDetailsSpec, error) {
-	instanceParameters, err := b.provider.GetInstanceParameters(ctx, instanceID)
+func (b *Broker) GetInstance(ctx context.Context, instanceID string) (spec brokerapi.GetInstanceDetailsSpec, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
+	provider, err := b.getInstanceProvider(ctx, instanceID, "")
+	if err != nil {
+		return brokerapi.GetInstanceDetailsSpec{}, err
+	}
+	instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
 	if err != nil {
 		return brokerapi.GetInstanceDetailsSpec{}, err
 	}
-	instanceTags, err := b.provider.GetInstanceTags(ctx, instanceID)
+	instanceTags, err := provider.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return brokerapi.GetInstanceDetailsSpec{}, err
+	}
+	instanceParameters.Snapshots, err = provider.ListSnapshots(ctx, instanceID)
 	if err != nil {
 		return brokerapi.GetInstanceDetailsSpec{}, err
 	}
@@ -87,12 +129,27 @@ func (b *Broker) LastBindingOperation(ctx context.Context, first, second string,
 }
 
 // Services returns with the provided services
+// The parameter schemas of the plans are generated from their plan configs.
 func (b *Broker) Services(ctx context.Context) ([]brokerapi.Service, error) {
-	return b.config.Catalog.Services, nil
+	services := make([]brokerapi.Service, len(b.config.Catalog.Services))
+	for i, service := range b.config.Catalog.Services {
+		services[i] = service
+		services[i].Plans = make([]brokerapi.ServicePlan, len(service.Plans))
+		for j, plan := range service.Plans {
+			planConfig, err := b.config.GetPlanConfig(plan.ID)
+			if err == nil {
+				plan.Schemas = planSchemas(planConfig, b.config.ProviderName(service.ID))
+			}
+			services[i].Plans[j] = plan
+		}
+	}
+	return services, nil
 }
 
 // Provision creates a new ElastiCache replication group
-func (b *Broker) Provision(ctx context.Context, instanceID string, details brokerapi.ProvisionDetails, asyncAllowed bool) (brokerapi.ProvisionedServiceSpec, error) {
+func (b *Broker) Provision(ctx context.Context, instanceID string, details brokerapi.ProvisionDetails, asyncAllowed bool) (spec brokerapi.ProvisionedServiceSpec, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("provision-start", lager.Data{
 		"instance-id":        instanceID,
 		"details":            details,
@@ -105,50 +162,84 @@ func (b *Broker) Provision(ctx context.Context, instanceID string, details broke
 
 	planConfig, err := b.config.GetPlanConfig(details.PlanID)
 	if err != nil {
-		return brokerapi.ProvisionedServiceSpec{}, fmt.Errorf("service plan %s: %s", details.PlanID, err)
+		return brokerapi.ProvisionedServiceSpec{}, validationError("service plan %s: %s", details.PlanID, err)
+	}
+
+	provider, err := b.getProvider(details.ServiceID)
+	if err != nil {
+		return brokerapi.ProvisionedServiceSpec{}, err
 	}
 
 	providerCtx, cancelFunc := context.WithTimeout(ctx, 30*time.Second)
 	defer cancelFunc()
 
+	existingInstance, err := provider.FindInstance(providerCtx, instanceID)
+	if err != nil {
+		return brokerapi.ProvisionedServiceSpec{}, fmt.Errorf("error checking if instance %s exists: %w", instanceID, err)
+	}
+	if existingInstance != nil {
+		return b.existingInstanceSpec(instanceID, details, existingInstance)
+	}
+
 	userParameters := &ProvisionParameters{}
 	if len(details.RawParameters) > 0 {
 		var err error
-		userParameters, err = parseProvisionParameters(details.RawParameters)
+		userParameters, err = parseProvisionParameters(details.RawParameters, planConfig, b.config.ProviderName(details.ServiceID))
 		if err != nil {
-			return brokerapi.ProvisionedServiceSpec{}, err
+			return brokerapi.ProvisionedServiceSpec{}, withKind(ErrorKindValidation, err)
 		}
 	}
 
 	// TODO: parsing the user provided parameters should be done in the provider and not in the broker
 	var restoreFromSnapshotName *string
+	if userParameters.RestoreFromSnapshot != nil && userParameters.RestoreFromLatestSnapshotOf != nil {
+		return brokerapi.ProvisionedServiceSpec{},
+			validationError("%s and %s can't be used together", ParamRestoreFromSnapshot, ParamRestoreLatestSnapshotOf)
+	}
+	var snapshot *providers.SnapshotInfo
 	if userParameters.RestoreFromLatestSnapshotOf != nil {
-		snapshots, err := b.provider.FindSnapshots(providerCtx, *userParameters.RestoreFromLatestSnapshotOf)
+		snapshots, err := provider.FindSnapshots(providerCtx, *userParameters.RestoreFromLatestSnapshotOf)
 		if err != nil {
 			return brokerapi.ProvisionedServiceSpec{}, err
 		}
 		if len(snapshots) == 0 {
 			return brokerapi.ProvisionedServiceSpec{},
-				fmt.Errorf("No snapshots found for: %s", *userParameters.RestoreFromLatestSnapshotOf)
+				validationError("No snapshots found for: %s", *userParameters.RestoreFromLatestSnapshotOf)
 		}
 		sort.Sort(ByCreateTime(snapshots))
-		latestSnapshot := snapshots[0]
-
-		if snapshotSpaceId, ok := latestSnapshot.Tags["space-id"]; !ok || snapshotSpaceId != details.SpaceGUID {
+		snapshot = &snapshots[0]
+	}
+	if userParameters.RestoreFromSnapshot != nil {
+		snapshot, err = findRestoreSnapshot(providerCtx, provider, details, *userParameters.RestoreFromSnapshot)
+		if err != nil {
+			return brokerapi.ProvisionedServiceSpec{}, err
+		}
+	}
+	if snapshot != nil {
+		if snapshotSpaceId, ok := snapshot.Tags["space-id"]; !ok || snapshotSpaceId != details.SpaceGUID {
 			return brokerapi.ProvisionedServiceSpec{},
-				fmt.Errorf("The service instance you are getting a snapshot from is not in the same org or space")
+				validationError("The service instance you are getting a snapshot from is not in the same org or space")
 		}
-		if snapshotOrgId, ok := latestSnapshot.Tags["organization-id"]; !ok || snapshotOrgId != details.OrganizationGUID {
+		if snapshotOrgId, ok := snapshot.Tags["organization-id"]; !ok || snapshotOrgId != details.OrganizationGUID {
 			return brokerapi.ProvisionedServiceSpec{},
-				fmt.Errorf("The service instance you are getting a snapshot from is not in the same org or space")
+				validationError("The service instance you are getting a snapshot from is not in the same org or space")
 		}
-		if snapshotPlanId, ok := latestSnapshot.Tags["plan-id"]; !ok || snapshotPlanId != details.PlanID {
+		if snapshotPlanId, ok := snapshot.Tags["plan-id"]; !ok || snapshotPlanId != details.PlanID {
 			return brokerapi.ProvisionedServiceSpec{},
-				fmt.Errorf("You must use the same plan as the service instance you are getting a snapshot from")
+				validationError("You must use the same plan as the service instance you are getting a snapshot from")
 		}
 
-		restoreFromSnapshotName = &snapshots[0].Name
+		restoreFromSnapshotName = &snapshot.Name
+	}
 
+	if userParameters.DailyBackupWindow != "" {
+		if planConfig.SnapshotRetentionLimit == 0 {
+			return brokerapi.ProvisionedServiceSpec{}, validationError("%s can not be set as the plan has no backups", ParamDailyBackupWindow)
+		}
+		err = withKind(ErrorKindValidation, checkBackupAndMaintenanceWindows(userParameters.DailyBackupWindow, userParameters.PreferredMaintenanceWindow))
+		if err != nil {
+			return brokerapi.ProvisionedServiceSpec{}, err
+		}
 	}
 
 	params := make(map[string]string, len(planConfig.Parameters))
@@ -158,6 +249,9 @@ func (b *Broker) Provision(ctx context.Context, instanceID string, details broke
 	if userParameters.MaxMemoryPolicy != nil {
 		params["maxmemory-policy"] = *userParameters.MaxMemoryPolicy
 	}
+	for k, v := range userParameters.RedisParameters {
+		params[k] = v
+	}
 
 	provisionParams := providers.ProvisionParameters{
 		InstanceType:               planConfig.InstanceType,
@@ -165,8 +259,10 @@ func (b *Broker) Provision(ctx context.Context, instanceID string, details broke
 		SecurityGroupIds:           b.config.VpcSecurityGroupIds,
 		CacheSubnetGroupName:       b.config.CacheSubnetGroupName,
 		PreferredMaintenanceWindow: userParameters.PreferredMaintenanceWindow,
+		DailyBackupWindow:          userParameters.DailyBackupWindow,
 		ReplicasPerNodeGroup:       planConfig.ReplicasPerNodeGroup,
 		ShardCount:                 planConfig.ShardCount,
+		NumCacheNodes:              planConfig.NumCacheNodes,
 		SnapshotRetentionLimit:     planConfig.SnapshotRetentionLimit,
 		RestoreFromSnapshot:        restoreFromSnapshotName,
 		AutomaticFailoverEnabled:   planConfig.AutomaticFailoverEnabled,
@@ -183,13 +279,15 @@ func (b *Broker) Provision(ctx context.Context, instanceID string, details broke
 			"instance-id":       instanceID,
 			"chargeable_entity": instanceID, // 'chargeable_entity' is the configured cost allocation tag. It's supposed to be snake_case.
 		},
-		Engine:        planConfig.Engine,
-		EngineVersion: planConfig.EngineVersion,
+		Engine:           planConfig.Engine,
+		EngineVersion:    planConfig.EngineVersion,
+		PerBindingUsers:  planConfig.PerBindingUsers,
+		CacheUsageLimits: planConfig.CacheUsageLimits,
 	}
 
-	err = b.provider.Provision(providerCtx, instanceID, provisionParams)
+	err = provider.Provision(providerCtx, instanceID, provisionParams)
 	if err != nil {
-		return brokerapi.ProvisionedServiceSpec{}, fmt.Errorf("provider %s for plan %s: %s", "redis", details.PlanID, err)
+		return brokerapi.ProvisionedServiceSpec{}, fmt.Errorf("provider %s for plan %s: %w", b.config.ProviderName(details.ServiceID), details.PlanID, err)
 	}
 
 	b.logger.Debug("provision-success", lager.Data{
@@ -199,15 +297,93 @@ func (b *Broker) Provision(ctx context.Context, instanceID string, details broke
 	})
 	return brokerapi.ProvisionedServiceSpec{
 		IsAsync:       true,
-		OperationData: Operation{Action: ActionProvisioning}.String(),
+		OperationData: b.operationData(instanceID, Operation{Action: ActionProvisioning}),
 	}, nil
 }
 
+// existingInstanceSpec returns the response to a provision request for an instance which already exists
+// Cloud Controller retries provision requests, so an instance created with the same service, plan, org and space is
+// reported as already existing, or as being provisioned if it's still being created.
+func (b *Broker) existingInstanceSpec(
+	instanceID string,
+	details brokerapi.ProvisionDetails,
+	existingInstance *providers.ExistingInstance,
+) (brokerapi.ProvisionedServiceSpec, error) {
+	logData := lager.Data{
+		"instance-id": instanceID,
+		"state":       existingInstance.State,
+		"tags":        existingInstance.Tags,
+	}
+
+	tags := existingInstance.Tags
+	if tags["service-id"] != details.ServiceID || tags["plan-id"] != details.PlanID ||
+		tags["organization-id"] != details.OrganizationGUID || tags["space-id"] != details.SpaceGUID {
+		b.logger.Info("provision-instance-exists-with-different-attributes", logData)
+		return brokerapi.ProvisionedServiceSpec{}, brokerapi.ErrInstanceAlreadyExists
+	}
+
+	switch existingInstance.State {
+	case providers.Creating:
+		b.logger.Info("provision-instance-being-created", logData)
+		return brokerapi.ProvisionedServiceSpec{
+			IsAsync:       true,
+			OperationData: b.operationData(instanceID, Operation{Action: ActionProvisioning}),
+		}, nil
+	case providers.Deleting, providers.CreateFailed:
+		b.logger.Info("provision-instance-exists-in-wrong-state", logData)
+		return brokerapi.ProvisionedServiceSpec{}, brokerapi.ErrInstanceAlreadyExists
+	}
+
+	b.logger.Info("provision-instance-exists", logData)
+	return brokerapi.ProvisionedServiceSpec{AlreadyExists: true}, nil
+}
+
+// findRestoreSnapshot returns the snapshot to restore for the restore_from_snapshot parameter
+// The snapshot can be chosen by its name or by a timestamp, in which case the latest snapshot of the same org, space
+// and plan taken at or before that time is used.
+func findRestoreSnapshot(
+	ctx context.Context,
+	provider providers.Provider,
+	details brokerapi.ProvisionDetails,
+	nameOrTime string,
+) (*providers.SnapshotInfo, error) {
+	if restoreTime, err := time.Parse(time.RFC3339, nameOrTime); err == nil {
+		snapshots, err := provider.FindSnapshotsWithTags(ctx, map[string]string{
+			"organization-id": details.OrganizationGUID,
+			"space-id":        details.SpaceGUID,
+			"plan-id":         details.PlanID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(ByCreateTime(snapshots))
+		for _, snapshot := range snapshots {
+			if !snapshot.CreateTime.After(restoreTime) {
+				return &snapshot, nil
+			}
+		}
+		return nil, validationError("No snapshots found taken at or before %s", nameOrTime)
+	}
+
+	snapshot, err := provider.FindSnapshot(ctx, nameOrTime)
+	if err != nil {
+		return nil, err
+	}
+	// The snapshots of other orgs are reported as missing, so their names can't be probed
+	if snapshot == nil || snapshot.Tags["organization-id"] != details.OrganizationGUID {
+		return nil, validationError("Snapshot not found: %s", nameOrTime)
+	}
+	return snapshot, nil
+}
+
 // Update modifies an existing service instance.
-// It can be used to update the maintenance window and / or the maxmemory policy.
+// It can be used to change the plan, update the maintenance window, the maxmemory policy and / or other cache
+// parameters, to rotate the auth token, to create a snapshot or to upgrade the engine version.
 // As this is a synchronous operation, if updating the maintenance window fails
 // the whole operation will fail (ie. it won't try to be smart and carry on)
-func (b *Broker) Update(ctx context.Context, instanceID string, details brokerapi.UpdateDetails, asyncAllowed bool) (brokerapi.UpdateServiceSpec, error) {
+func (b *Broker) Update(ctx context.Context, instanceID string, details brokerapi.UpdateDetails, asyncAllowed bool) (spec brokerapi.UpdateServiceSpec, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("update", lager.Data{
 		"instance-id":        instanceID,
 		"details":            details,
@@ -221,64 +397,385 @@ func (b *Broker) Update(ctx context.Context, instanceID string, details brokerap
 	providerCtx, cancelFunc := context.WithTimeout(ctx, 30*time.Second)
 	defer cancelFunc()
 
-	if details.PlanID != details.PreviousValues.PlanID {
-		return brokerapi.UpdateServiceSpec{}, fmt.Errorf("changing plans is not currently supported")
+	if details.ServiceID != details.PreviousValues.ServiceID {
+		return brokerapi.UpdateServiceSpec{}, validationError("changing the service is not supported")
 	}
 
-	if details.ServiceID != details.PreviousValues.ServiceID {
-		return brokerapi.UpdateServiceSpec{}, fmt.Errorf("changing plans is not currently supported")
+	provider, err := b.getProvider(details.ServiceID)
+	if err != nil {
+		return brokerapi.UpdateServiceSpec{}, err
 	}
 
+	planChanged := details.PlanID != details.PreviousValues.PlanID
+
+	// The parameters of unknown plans are validated without the plan specific ones, the plan is checked later on
+	planConfig, planErr := b.config.GetPlanConfig(details.PlanID)
+
 	userParameters := &UpdateParameters{}
 	if len(details.RawParameters) > 0 {
 		var err error
-		userParameters, err = parseUpdateParameters(details.RawParameters)
+		userParameters, err = parseUpdateParameters(details.RawParameters, planConfig, b.config.ProviderName(details.ServiceID))
 		if err != nil {
-			return brokerapi.UpdateServiceSpec{}, err
+			return brokerapi.UpdateServiceSpec{}, withKind(ErrorKindValidation, err)
 		}
 	}
 
-	if checkIfNoUpdateParametersAreSet(userParameters) {
-		return brokerapi.UpdateServiceSpec{}, fmt.Errorf("no parameters provided")
+	if !planChanged && checkIfNoUpdateParametersAreSet(userParameters) {
+		return brokerapi.UpdateServiceSpec{}, validationError("no parameters provided")
+	}
+
+	if userParameters.TestFailoverNodeGroup != "" && userParameters.TestFailover == nil {
+		return brokerapi.UpdateServiceSpec{}, validationError("%s can only be used with %s", ParamTestFailoverNodeGroup, TestFailover)
+	}
+
+	err = withKind(ErrorKindValidation, checkExclusiveUpdateParameters(userParameters, planChanged))
+	if err != nil {
+		return brokerapi.UpdateServiceSpec{}, err
+	}
+	// The operations started by the exclusive parameters depend on the plan
+	if userParameters.hasExclusiveParameter() && planErr != nil {
+		return brokerapi.UpdateServiceSpec{}, withKind(ErrorKindValidation, errors.Wrap(planErr, "Failed to find service plan"))
+	}
+
+	_, err = b.checkNoOperationInProgress(providerCtx, provider, instanceID)
+	if err != nil {
+		return brokerapi.UpdateServiceSpec{}, err
 	}
 
 	if userParameters.TestFailover != nil {
 
-		planConfig, err := b.config.GetPlanConfig(details.PlanID)
-		if err != nil {
-			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Failed to find service plan")
-		}
-		if planConfig.Parameters["cluster-enabled"] == "yes" {
-			return brokerapi.UpdateServiceSpec{}, fmt.Errorf("Test failover is not supported for Redis instances in cluster mode")
+		if userParameters.TestFailoverNodeGroup != "" && planConfig.Parameters["cluster-enabled"] != "yes" {
+			return brokerapi.UpdateServiceSpec{}, validationError("%s is only supported for plans with cluster mode enabled", ParamTestFailoverNodeGroup)
 		}
 		if planConfig.MultiAZEnabled == false || planConfig.AutomaticFailoverEnabled == false {
-			return brokerapi.UpdateServiceSpec{}, fmt.Errorf("Test failover is not supported without MultiAZEnabled and AutomaticFailoverEnabled")
+			return brokerapi.UpdateServiceSpec{}, validationError("Test failover is not supported without MultiAZEnabled and AutomaticFailoverEnabled")
 		}
 		if planConfig.ReplicasPerNodeGroup < 1 {
-			return brokerapi.UpdateServiceSpec{}, fmt.Errorf("Test failover requires one or more replicas")
+			return brokerapi.UpdateServiceSpec{}, validationError("Test failover requires one or more replicas")
 		}
-		if userParameters.MaxMemoryPolicy != nil || userParameters.PreferredMaintenanceWindow != "" {
-			return brokerapi.UpdateServiceSpec{}, fmt.Errorf("Test failover must be used by itself")
+		if planConfig.NativeTestFailover() {
+			startTime := time.Now()
+			nodeGroupID, err := provider.TestFailover(providerCtx, instanceID, userParameters.TestFailoverNodeGroup)
+			if err != nil {
+				return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Test failover failed")
+			}
+			b.logger.Debug("test-failover-success", lager.Data{
+				"instance-id":   instanceID,
+				"node-group-id": nodeGroupID,
+			})
+			return brokerapi.UpdateServiceSpec{
+				IsAsync: true,
+				OperationData: b.operationData(instanceID, Operation{
+					Action:      ActionTestingFailover,
+					NodeGroupID: nodeGroupID,
+					StartTime:   startTime.Format(time.RFC3339),
+					TimeOut:     startTime.Add(FailoverTimeout).Format(time.RFC3339),
+				}),
+			}, nil
 		}
-		primaryNode, err := b.provider.StartFailoverTest(providerCtx, instanceID)
+		primaryNode, err := provider.StartFailoverTest(providerCtx, instanceID)
 		if err != nil {
 			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Test failover failed: ")
 		}
 		return brokerapi.UpdateServiceSpec{
 			IsAsync: true,
-			OperationData: Operation{
+			OperationData: b.operationData(instanceID, Operation{
 				Action:      ActionFailover,
 				PrimaryNode: primaryNode,
 				TimeOut:     time.Now().Add(FailoverTimeout).Format(time.RFC3339),
-			}.String(),
+			}),
 		}, nil
 	}
 
-	if userParameters.PreferredMaintenanceWindow != "" {
-		err := b.provider.UpdateReplicationGroup(providerCtx, instanceID, providers.UpdateReplicationGroupParameters{
-			PreferredMaintenanceWindow: userParameters.PreferredMaintenanceWindow,
+	if userParameters.RotateAuthToken != nil {
+		if !*userParameters.RotateAuthToken {
+			return brokerapi.UpdateServiceSpec{}, validationError("rotate_auth_token can only be set to true")
+		}
+		if planConfig.PerBindingUsers {
+			return brokerapi.UpdateServiceSpec{}, validationError("Rotating the auth token is not supported for plans with per binding users")
+		}
+		err = provider.RotateAuthToken(providerCtx, instanceID)
+		// The rotation has started even if the new token couldn't be stored, it has to be finished with the old token
+		authTokenNotStored := errors.Is(err, providers.ErrAuthTokenNotStored)
+		if err != nil && !authTokenNotStored {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Rotating the auth token failed")
+		}
+		if authTokenNotStored {
+			b.logger.Error("rotate-auth-token-not-stored", err, lager.Data{
+				"instance-id": instanceID,
+			})
+		}
+		b.logger.Debug("rotate-auth-token-success", lager.Data{
+			"instance-id":        instanceID,
+			"details":            details,
+			"accepts-incomplete": asyncAllowed,
 		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync:       true,
+			OperationData: b.operationData(instanceID, Operation{Action: ActionRotatingToken, AuthTokenNotStored: authTokenNotStored}),
+		}, nil
+	}
+
+	if userParameters.CreateSnapshot != nil {
+		if !*userParameters.CreateSnapshot {
+			return brokerapi.UpdateServiceSpec{}, validationError("create_snapshot can only be set to true")
+		}
+		if planConfig.MaxManualSnapshots < 1 {
+			return brokerapi.UpdateServiceSpec{}, validationError("Manual snapshots are not supported for this plan")
+		}
+
+		snapshots, err := provider.ListSnapshots(providerCtx, instanceID)
 		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Listing snapshots failed")
+		}
+		manualSnapshots := 0
+		for _, snapshot := range snapshots {
+			if snapshot.Source == "manual" {
+				manualSnapshots++
+			}
+		}
+		if manualSnapshots >= planConfig.MaxManualSnapshots {
+			return brokerapi.UpdateServiceSpec{}, unprocessableError("The plan allows at most %d manual snapshots", planConfig.MaxManualSnapshots)
+		}
+
+		snapshotName := ManualSnapshotName(instanceID, time.Now())
+		err = provider.CreateSnapshot(providerCtx, instanceID, snapshotName)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Creating a snapshot failed")
+		}
+		b.logger.Debug("create-snapshot-success", lager.Data{
+			"instance-id":   instanceID,
+			"snapshot-name": snapshotName,
+		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync: true,
+			OperationData: b.operationData(instanceID, Operation{
+				Action:       ActionSnapshotting,
+				SnapshotName: snapshotName,
+			}),
+		}, nil
+	}
+
+	if userParameters.EngineVersion != "" {
+		cacheParameterGroupFamily, ok := planConfig.EngineVersionUpgrades[userParameters.EngineVersion]
+		if !ok {
+			return brokerapi.UpdateServiceSpec{}, validationError("Upgrading to engine version %s is not supported for this plan", userParameters.EngineVersion)
+		}
+		instanceParameters, err := provider.GetInstanceParameters(providerCtx, instanceID)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, err
+		}
+		// The upgrades of the plan are versions of the plan's engine
+		if migratedPlanConfig(planConfig, instanceParameters.Engine).Engine != planConfig.Engine {
+			return brokerapi.UpdateServiceSpec{}, validationError("Upgrading to engine version %s is not supported for this plan, the instance was migrated to %s",
+				userParameters.EngineVersion, instanceParameters.Engine)
+		}
+
+		err = provider.UpgradeEngineVersion(providerCtx, instanceID, providers.UpgradeEngineVersionParameters{
+			EngineVersion:             userParameters.EngineVersion,
+			CacheParameterGroupFamily: cacheParameterGroupFamily,
+			// The unused parameter group is deleted by LastOperation once the upgrade has finished
+			Tags: map[string]string{providers.OperationInProgressTag: providers.OperationTagValue(ActionUpgrading, time.Now())},
+		})
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Upgrading the engine version failed")
+		}
+		b.logger.Debug("upgrade-engine-version-success", lager.Data{
+			"instance-id":    instanceID,
+			"engine-version": userParameters.EngineVersion,
+		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync: true,
+			OperationData: b.operationData(instanceID, Operation{
+				Action:        ActionUpgrading,
+				EngineVersion: userParameters.EngineVersion,
+			}),
+		}, nil
+	}
+
+	if userParameters.MigrateEngine != "" {
+		migration, ok := planConfig.EngineMigrations[userParameters.MigrateEngine]
+		if !ok {
+			return brokerapi.UpdateServiceSpec{}, validationError("Migrating to %s is not supported for this plan", userParameters.MigrateEngine)
+		}
+
+		instanceParameters, err := provider.GetInstanceParameters(providerCtx, instanceID)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, err
+		}
+		if instanceParameters.Engine == userParameters.MigrateEngine {
+			return brokerapi.UpdateServiceSpec{}, validationError("The instance already uses %s", userParameters.MigrateEngine)
+		}
+		// ElastiCache can only migrate Redis 7 replication groups in place
+		if instanceParameters.Engine != "redis" || !strings.HasPrefix(instanceParameters.EngineVersion, "7.") {
+			return brokerapi.UpdateServiceSpec{}, validationError("Only Redis 7 instances can be migrated to %s, the instance runs %s %s",
+				userParameters.MigrateEngine, instanceParameters.Engine, instanceParameters.EngineVersion)
+		}
+
+		err = provider.UpgradeEngineVersion(providerCtx, instanceID, providers.UpgradeEngineVersionParameters{
+			Engine:                    userParameters.MigrateEngine,
+			EngineVersion:             migration.EngineVersion,
+			CacheParameterGroupFamily: migration.CacheParameterGroupFamily,
+			Tags:                      map[string]string{providers.OperationInProgressTag: providers.OperationTagValue(ActionMigratingEngine, time.Now())},
+		})
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Migrating the engine failed")
+		}
+		b.logger.Debug("migrate-engine-success", lager.Data{
+			"instance-id":    instanceID,
+			"engine":         userParameters.MigrateEngine,
+			"engine-version": migration.EngineVersion,
+		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync: true,
+			OperationData: b.operationData(instanceID, Operation{
+				Action:        ActionMigratingEngine,
+				Engine:        userParameters.MigrateEngine,
+				EngineVersion: migration.EngineVersion,
+			}),
+		}, nil
+	}
+
+	if userParameters.ReplicasPerNodeGroup != nil {
+		replicas := *userParameters.ReplicasPerNodeGroup
+		if planConfig.MaxReplicasPerNodeGroup < 1 {
+			return brokerapi.UpdateServiceSpec{}, validationError("Changing the number of replicas is not supported for this plan")
+		}
+		if replicas < planConfig.MinReplicasPerNodeGroup || replicas > planConfig.MaxReplicasPerNodeGroup {
+			return brokerapi.UpdateServiceSpec{}, validationError("%s must be between %d and %d for this plan",
+				ParamReplicasPerNodeGroup, planConfig.MinReplicasPerNodeGroup, planConfig.MaxReplicasPerNodeGroup)
+		}
+		// ElastiCache needs a replica to fail over to
+		if replicas < 1 && (planConfig.AutomaticFailoverEnabled || planConfig.MultiAZEnabled) {
+			return brokerapi.UpdateServiceSpec{}, validationError("Plans with automatic failover or Multi-AZ require at least one replica")
+		}
+
+		err = provider.UpdateReplicationGroup(providerCtx, instanceID, providers.UpdateReplicationGroupParameters{
+			ReplicasPerNodeGroup: &replicas,
+		})
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Changing the number of replicas failed")
+		}
+		b.logger.Debug("change-replicas-success", lager.Data{
+			"instance-id": instanceID,
+			"replicas":    replicas,
+		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync: true,
+			OperationData: b.operationData(instanceID, Operation{
+				Action:               ActionChangingReplicas,
+				ReplicasPerNodeGroup: &replicas,
+			}),
+		}, nil
+	}
+
+	if userParameters.ShardCount != nil {
+		shardCount := *userParameters.ShardCount
+		if !clusterEnabled(planConfig) {
+			return brokerapi.UpdateServiceSpec{}, validationError("Changing the shard count is only supported for plans with cluster mode enabled")
+		}
+		if planConfig.MaxShardCount < 1 {
+			return brokerapi.UpdateServiceSpec{}, validationError("Changing the shard count is not supported for this plan")
+		}
+		if shardCount < planConfig.MinShardCount || shardCount > planConfig.MaxShardCount {
+			return brokerapi.UpdateServiceSpec{}, validationError("%s must be between %d and %d for this plan",
+				ParamShardCount, planConfig.MinShardCount, planConfig.MaxShardCount)
+		}
+
+		err = provider.UpdateReplicationGroup(providerCtx, instanceID, providers.UpdateReplicationGroupParameters{
+			ShardCount: &shardCount,
+		})
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Changing the shard count failed")
+		}
+		b.logger.Debug("change-shard-count-success", lager.Data{
+			"instance-id": instanceID,
+			"shard-count": shardCount,
+		})
+		return brokerapi.UpdateServiceSpec{
+			IsAsync: true,
+			OperationData: b.operationData(instanceID, Operation{
+				Action:     ActionResharding,
+				ShardCount: &shardCount,
+			}),
+		}, nil
+	}
+
+	if userParameters.DailyBackupWindow != "" || userParameters.PreferredMaintenanceWindow != "" {
+		err := b.checkUpdatedWindows(providerCtx, provider, instanceID, details.PlanID, userParameters)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, err
+		}
+	}
+
+	replicationGroupParams := providers.UpdateReplicationGroupParameters{
+		PreferredMaintenanceWindow: userParameters.PreferredMaintenanceWindow,
+		DailyBackupWindow:          userParameters.DailyBackupWindow,
+	}
+
+	operation := Operation{Action: ActionUpdating}
+	planParameters := map[string]string{}
+	if planChanged {
+		previousPlanConfig, err := b.config.GetPlanConfig(details.PreviousValues.PlanID)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, validationError("service plan %s: %s", details.PreviousValues.PlanID, err)
+		}
+		planConfig, err := b.config.GetPlanConfig(details.PlanID)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, validationError("service plan %s: %s", details.PlanID, err)
+		}
+		instanceParameters, err := provider.GetInstanceParameters(providerCtx, instanceID)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, err
+		}
+		previousPlanConfig = migratedPlanConfig(previousPlanConfig, instanceParameters.Engine)
+		err = checkPlanChange(previousPlanConfig, planConfig)
+		if err != nil {
+			return brokerapi.UpdateServiceSpec{}, err
+		}
+
+		if planConfig.InstanceType != previousPlanConfig.InstanceType {
+			replicationGroupParams.CacheNodeType = planConfig.InstanceType
+		}
+		// An instance which was upgraded past the engine version of the new plan keeps its version
+		if planConfig.EngineVersion != previousPlanConfig.EngineVersion &&
+			providers.CompareEngineVersions(planConfig.EngineVersion, instanceParameters.EngineVersion) > 0 {
+			replicationGroupParams.EngineVersion = planConfig.EngineVersion
+		}
+		if planConfig.SnapshotRetentionLimit != previousPlanConfig.SnapshotRetentionLimit {
+			replicationGroupParams.SnapshotRetentionLimit = &planConfig.SnapshotRetentionLimit
+		}
+		if planConfig.CacheUsageLimits != previousPlanConfig.CacheUsageLimits {
+			replicationGroupParams.CacheUsageLimits = &planConfig.CacheUsageLimits
+		}
+		for name, value := range planConfig.Parameters {
+			if previousPlanConfig.Parameters[name] != value {
+				planParameters[name] = value
+			}
+		}
+		// The replica count can only be changed once any node type or engine version change has finished, in which
+		// case it is done by LastOperation
+		replicas := planChangeReplicas(planConfig, instanceParameters.ReplicasPerNodeGroup)
+		if replicas != nil && replicationGroupParams.CacheNodeType == "" && replicationGroupParams.EngineVersion == "" {
+			replicationGroupParams.ReplicasPerNodeGroup = replicas
+		} else if replicas != nil {
+			operation.ReplicasPerNodeGroup = replicas
+			replicationGroupParams.Tags = map[string]string{providers.OperationInProgressTag: providers.OperationTagValue(ActionChangingPlan, time.Now())}
+		}
+		// The plan tag is only updated by LastOperation once the change has finished
+		operation.Action = ActionChangingPlan
+		operation.PlanID = details.PlanID
+	}
+
+	if userParameters.PreferredMaintenanceWindow != "" || userParameters.DailyBackupWindow != "" || planChanged {
+		err := provider.UpdateReplicationGroup(providerCtx, instanceID, replicationGroupParams)
+		if err != nil {
+			if planChanged {
+				return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Changing plan failed")
+			}
+			if userParameters.DailyBackupWindow != "" {
+				return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Updating daily backup window failed")
+			}
 			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Updating preferred maintenance window failed")
 		}
 		b.logger.Debug("update-replication-group-success", lager.Data{
@@ -288,15 +785,29 @@ func (b *Broker) Update(ctx context.Context, instanceID string, details brokerap
 		})
 	}
 
-	if userParameters.MaxMemoryPolicy != nil {
+	if userParameters.MaxMemoryPolicy != nil || len(userParameters.RedisParameters) > 0 || len(planParameters) > 0 {
 		params := map[string]string{}
-		params["maxmemory-policy"] = *userParameters.MaxMemoryPolicy
+		for k, v := range planParameters {
+			params[k] = v
+		}
+		for k, v := range userParameters.RedisParameters {
+			params[k] = v
+		}
+		if userParameters.MaxMemoryPolicy != nil {
+			params["maxmemory-policy"] = *userParameters.MaxMemoryPolicy
+		}
 
-		err := b.provider.UpdateParamGroupParameters(providerCtx, instanceID, providers.UpdateParamGroupParameters{
+		err := provider.UpdateParamGroupParameters(providerCtx, instanceID, providers.UpdateParamGroupParameters{
 			Parameters: params,
 		})
 		if err != nil {
-			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Updating maxmemory policy failed")
+			if len(userParameters.RedisParameters) > 0 {
+				return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Updating redis parameters failed")
+			}
+			if userParameters.MaxMemoryPolicy != nil {
+				return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Updating maxmemory policy failed")
+			}
+			return brokerapi.UpdateServiceSpec{}, errors.Wrap(err, "Changing plan failed")
 		}
 		b.logger.Debug("update-parameter-group-success", lager.Data{
 			"instance-id":        instanceID,
@@ -307,12 +818,112 @@ func (b *Broker) Update(ctx context.Context, instanceID string, details brokerap
 
 	return brokerapi.UpdateServiceSpec{
 		IsAsync:       true,
-		OperationData: Operation{Action: ActionUpdating}.String(),
+		OperationData: b.operationData(instanceID, operation),
 	}, nil
 }
 
+// checkUpdatedWindows validates a new daily backup window and checks that it doesn't overlap with the maintenance window
+// If only one of the windows is changed, the other one is taken from the instance. Plans without backups have no daily
+// backup window for the maintenance window to overlap with.
+func (b *Broker) checkUpdatedWindows(ctx context.Context, provider providers.Provider, instanceID string, planID string, userParameters *UpdateParameters) error {
+	planConfig, err := b.config.GetPlanConfig(planID)
+	if userParameters.DailyBackupWindow != "" {
+		if err != nil {
+			return validationError("service plan %s: %s", planID, err)
+		}
+		if planConfig.SnapshotRetentionLimit == 0 {
+			return validationError("%s can not be set as the plan has no backups", ParamDailyBackupWindow)
+		}
+	} else if err == nil && planConfig.SnapshotRetentionLimit == 0 {
+		return nil
+	}
+
+	dailyBackupWindow := userParameters.DailyBackupWindow
+	maintenanceWindow := userParameters.PreferredMaintenanceWindow
+	if dailyBackupWindow == "" || maintenanceWindow == "" {
+		instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		if dailyBackupWindow == "" {
+			dailyBackupWindow = instanceParameters.DailyBackupWindow
+		}
+		if maintenanceWindow == "" {
+			maintenanceWindow = instanceParameters.PreferredMaintenanceWindow
+		}
+	}
+
+	return withKind(ErrorKindValidation, checkBackupAndMaintenanceWindows(dailyBackupWindow, maintenanceWindow))
+}
+
+// migratedPlanConfig returns the plan config of an instance running the given engine
+// Instances migrated to another engine keep their plan, so they run the engine version and parameter group family of
+// the plan's migration instead of the plan's own ones.
+func migratedPlanConfig(planConfig PlanConfig, engine string) PlanConfig {
+	migration, ok := planConfig.EngineMigrations[engine]
+	if !ok || engine == planConfig.Engine {
+		return planConfig
+	}
+	planConfig.Engine = engine
+	planConfig.EngineVersion = migration.EngineVersion
+	planConfig.CacheParameterGroupFamily = migration.CacheParameterGroupFamily
+	return planConfig
+}
+
+// checkPlanChange returns an error if an instance can't be moved between the two plans without recreating it
+func checkPlanChange(from PlanConfig, to PlanConfig) error {
+	if from.Engine != to.Engine {
+		return validationError("changing plans is not supported between different engines")
+	}
+	if from.CacheParameterGroupFamily != to.CacheParameterGroupFamily {
+		return validationError("changing plans is not supported between different cache parameter group families")
+	}
+	if providers.CompareEngineVersions(to.EngineVersion, from.EngineVersion) < 0 {
+		return validationError("changing plans is not supported to a plan with an older engine version")
+	}
+	if clusterEnabled(from) != clusterEnabled(to) {
+		return validationError("changing plans is not supported between cluster mode enabled and disabled plans")
+	}
+	if from.ShardCount != to.ShardCount {
+		return validationError("changing plans is not supported between plans with a different shard count")
+	}
+	if from.NumCacheNodes != to.NumCacheNodes {
+		return validationError("changing plans is not supported between plans with a different number of cache nodes")
+	}
+	if from.AutomaticFailoverEnabled != to.AutomaticFailoverEnabled || from.MultiAZEnabled != to.MultiAZEnabled {
+		return validationError("changing plans is not supported between plans with different automatic failover or Multi-AZ settings")
+	}
+	// The cache parameters of the new plan are set on the instance, but the ones it doesn't set can't be reset
+	for name := range from.Parameters {
+		if _, ok := to.Parameters[name]; !ok {
+			return validationError("changing plans is not supported to a plan which doesn't set the %s cache parameter", name)
+		}
+	}
+	return nil
+}
+
+// planChangeReplicas returns the number of replicas an instance needs on the new plan, or nil if it can keep its
+// replicas. The replica count chosen by the user is kept if the new plan allows it.
+func planChangeReplicas(planConfig PlanConfig, currentReplicas int64) *int64 {
+	if currentReplicas == planConfig.ReplicasPerNodeGroup {
+		return nil
+	}
+	if planConfig.MaxReplicasPerNodeGroup > 0 &&
+		currentReplicas >= planConfig.MinReplicasPerNodeGroup && currentReplicas <= planConfig.MaxReplicasPerNodeGroup {
+		return nil
+	}
+	replicas := planConfig.ReplicasPerNodeGroup
+	return &replicas
+}
+
+func clusterEnabled(planConfig PlanConfig) bool {
+	return planConfig.Parameters["cluster-enabled"] == "yes"
+}
+
 // Deprovision deletes a service instance
-func (b *Broker) Deprovision(ctx context.Context, instanceID string, details brokerapi.DeprovisionDetails, asyncAllowed bool) (brokerapi.DeprovisionServiceSpec, error) {
+func (b *Broker) Deprovision(ctx context.Context, instanceID string, details brokerapi.DeprovisionDetails, asyncAllowed bool) (spec brokerapi.DeprovisionServiceSpec, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("deprovision-start", lager.Data{
 		"instance-id":        instanceID,
 		"details":            details,
@@ -326,9 +937,39 @@ func (b *Broker) Deprovision(ctx context.Context, instanceID string, details bro
 	providerCtx, cancelFunc := context.WithTimeout(ctx, 30*time.Second)
 	defer cancelFunc()
 
-	err := b.provider.Deprovision(providerCtx, instanceID, providers.DeprovisionParameters{})
+	provider, err := b.getProvider(details.ServiceID)
+	if err != nil {
+		return brokerapi.DeprovisionServiceSpec{}, err
+	}
+
+	// Deleting an instance again is fine, as well as deleting one which failed to be created. The multi-step operations
+	// don't matter once the instance is deleted, and a stuck one mustn't keep it from being deleted.
+	instance, err := b.checkInstanceState(providerCtx, provider, instanceID, providers.Deleting, providers.CreateFailed)
+	if err != nil {
+		return brokerapi.DeprovisionServiceSpec{}, err
+	}
+
+	deprovisionParams := providers.DeprovisionParameters{}
+	// AWS can't take a snapshot of an instance which was never available
+	createFailed := instance != nil && instance.State == providers.CreateFailed
+	if b.finalSnapshotEnabled(details.PlanID) && !createFailed {
+		deprovisionParams.FinalSnapshotIdentifier = FinalSnapshotName(instanceID)
+	}
+
+	err = provider.Deprovision(providerCtx, instanceID, deprovisionParams)
+	if errors.Is(err, providers.ErrInstanceNotFound) {
+		// The instance was deleted already, e.g. the request is retried after the deletion finished
+		b.logger.Info("deprovision-instance-not-found", lager.Data{
+			"instance-id": instanceID,
+		})
+		err = b.deleteInstanceResources(providerCtx, provider, instanceID)
+		if err != nil {
+			return brokerapi.DeprovisionServiceSpec{}, err
+		}
+		return brokerapi.DeprovisionServiceSpec{}, brokerapi.ErrInstanceDoesNotExist
+	}
 	if err != nil {
-		return brokerapi.DeprovisionServiceSpec{}, fmt.Errorf("provider %s for plan %s: %s", "redis", details.PlanID, err)
+		return brokerapi.DeprovisionServiceSpec{}, fmt.Errorf("provider %s for plan %s: %w", b.config.ProviderName(details.ServiceID), details.PlanID, err)
 	}
 
 	b.logger.Debug("deprovision-success", lager.Data{
@@ -339,19 +980,148 @@ func (b *Broker) Deprovision(ctx context.Context, instanceID string, details bro
 
 	return brokerapi.DeprovisionServiceSpec{
 		IsAsync:       true,
-		OperationData: Operation{Action: ActionDeprovisioning}.String(),
+		OperationData: b.operationData(instanceID, Operation{Action: ActionDeprovisioning}),
 	}, nil
 }
 
+// checkNoOperationInProgress returns a ConcurrencyError if the instance is not available, unless it's in one of the
+// allowed states, or if a multi-step operation is in progress
+// It returns the instance it has checked. An instance which doesn't exist is not checked, the callers handle that.
+func (b *Broker) checkNoOperationInProgress(
+	ctx context.Context,
+	provider providers.Provider,
+	instanceID string,
+	allowedStates ...providers.ServiceState,
+) (*providers.ExistingInstance, error) {
+	return b.checkInstance(ctx, provider, instanceID, true, allowedStates)
+}
+
+// checkInstanceState returns a ConcurrencyError if the instance is not available, unless it's in one of the allowed
+// states, the multi-step operations in progress are not checked
+func (b *Broker) checkInstanceState(
+	ctx context.Context,
+	provider providers.Provider,
+	instanceID string,
+	allowedStates ...providers.ServiceState,
+) (*providers.ExistingInstance, error) {
+	return b.checkInstance(ctx, provider, instanceID, false, allowedStates)
+}
+
+func (b *Broker) checkInstance(
+	ctx context.Context,
+	provider providers.Provider,
+	instanceID string,
+	checkOperation bool,
+	allowedStates []providers.ServiceState,
+) (*providers.ExistingInstance, error) {
+	instance, err := provider.FindInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("error checking the state of instance %s: %w", instanceID, err)
+	}
+	if instance == nil {
+		return nil, nil
+	}
+
+	if checkOperation && instance.Operation != "" && b.operationTimedOut(instance) {
+		b.logger.Info("clear-timed-out-operation", lager.Data{
+			"instance-id": instanceID,
+			"operation":   instance.Operation,
+			"started-at":  instance.OperationStartedAt,
+		})
+		err = provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+			Tags: map[string]string{providers.OperationInProgressTag: ""},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error clearing the timed out operation of instance %s: %w", instanceID, err)
+		}
+		instance.Operation = ""
+	}
+
+	var concurrencyErr error
+	switch {
+	case checkOperation && instance.Operation != "":
+		concurrencyErr = concurrencyError("Another operation is in progress for this instance (%s), try again when it has finished", instance.Operation)
+	case instance.State != providers.Available && !containsState(allowedStates, instance.State):
+		concurrencyErr = concurrencyError("The instance is %s, try again when the current operation has finished", instance.State)
+	default:
+		return instance, nil
+	}
+
+	b.logger.Info("concurrent-operation", lager.Data{
+		"instance-id": instanceID,
+		"state":       instance.State,
+		"operation":   instance.Operation,
+	})
+	return nil, concurrencyErr
+}
+
+// operationTimedOut returns true if the broker operation in progress started longer than the operation timeout ago
+// Its tag is left behind if LastOperation isn't polled until the operation has finished, or if the operation failed
+// after the tag was set, so it would block every later update otherwise.
+func (b *Broker) operationTimedOut(instance *providers.ExistingInstance) bool {
+	return !instance.OperationStartedAt.IsZero() && time.Since(instance.OperationStartedAt) > b.config.OperationTimeout()
+}
+
+func containsState(states []providers.ServiceState, state providers.ServiceState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteInstanceResources deletes the resources which are left behind after the instance itself is deleted
+func (b *Broker) deleteInstanceResources(ctx context.Context, provider providers.Provider, instanceID string) error {
+	err := provider.DeleteCacheParameterGroup(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("error deleting parameter group %s: %w", instanceID, err)
+	}
+	err = provider.DeleteUserGroup(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("error deleting user group %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+func (b *Broker) finalSnapshotEnabled(planID string) bool {
+	if b.config.DisableFinalSnapshots {
+		return false
+	}
+	planConfig, err := b.config.GetPlanConfig(planID)
+	// Instances of plans which were removed from the config still get a final snapshot
+	return err != nil || !planConfig.DisableFinalSnapshot
+}
+
+// FinalSnapshotName returns the name of the snapshot taken when an instance is deprovisioned
+func FinalSnapshotName(instanceID string) string {
+	return "final-" + instanceID
+}
+
+// ManualSnapshotName returns the name of a snapshot created with the create_snapshot parameter
+func ManualSnapshotName(instanceID string, createTime time.Time) string {
+	return fmt.Sprintf("manual-%s-%s", instanceID, createTime.UTC().Format("20060102150405"))
+}
+
 // Bind binds an application and a service instance
-func (b *Broker) Bind(ctx context.Context, instanceID, bindingID string, details brokerapi.BindDetails, asyncAllowed bool) (brokerapi.Binding, error) {
+func (b *Broker) Bind(ctx context.Context, instanceID, bindingID string, details brokerapi.BindDetails, asyncAllowed bool) (binding brokerapi.Binding, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("bind", lager.Data{
 		"instance-id": instanceID,
 		"binding-id":  bindingID,
 		"details":     details,
 	})
 
-	credentials, err := b.provider.GenerateCredentials(ctx, instanceID, bindingID)
+	provider, err := b.getProvider(details.ServiceID)
+	if err != nil {
+		return brokerapi.Binding{}, err
+	}
+
+	credentials, err := provider.GenerateCredentials(ctx, instanceID, bindingID)
+	if errors.Is(err, providers.ErrInstanceNotFound) {
+		return brokerapi.Binding{}, brokerapi.ErrInstanceDoesNotExist
+	}
 	if err != nil {
 		return brokerapi.Binding{}, err
 	}
@@ -362,18 +1132,27 @@ func (b *Broker) Bind(ctx context.Context, instanceID, bindingID string, details
 }
 
 // Unbind removes the binding between an application and a service instance
-func (b *Broker) Unbind(ctx context.Context, instanceID, bindingID string, details brokerapi.UnbindDetails, asyncAllowed bool) (brokerapi.UnbindSpec, error) {
+func (b *Broker) Unbind(ctx context.Context, instanceID, bindingID string, details brokerapi.UnbindDetails, asyncAllowed bool) (spec brokerapi.UnbindSpec, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("unbind", lager.Data{
 		"instance-id": instanceID,
 		"binding-id":  bindingID,
 		"details":     details,
 	})
 
-	return brokerapi.UnbindSpec{}, b.provider.RevokeCredentials(ctx, instanceID, bindingID)
+	provider, err := b.getProvider(details.ServiceID)
+	if err != nil {
+		return brokerapi.UnbindSpec{}, err
+	}
+
+	return brokerapi.UnbindSpec{}, provider.RevokeCredentials(ctx, instanceID, bindingID)
 }
 
 // LastOperation returns with the last known state of the given service instance
-func (b *Broker) LastOperation(ctx context.Context, instanceID string, pollDetails brokerapi.PollDetails) (brokerapi.LastOperation, error) {
+func (b *Broker) LastOperation(ctx context.Context, instanceID string, pollDetails brokerapi.PollDetails) (lastOperation brokerapi.LastOperation, err error) {
+	defer func() { err = b.failureResponse(err) }()
+
 	b.logger.Debug("last-operation", lager.Data{
 		"instance-id":    instanceID,
 		"operation-data": pollDetails.OperationData,
@@ -382,39 +1161,125 @@ func (b *Broker) LastOperation(ctx context.Context, instanceID string, pollDetai
 	// provider call to get operation?
 	var operation Operation
 	if pollDetails.OperationData != "" {
-		err := json.Unmarshal([]byte(pollDetails.OperationData), &operation)
+		var signed bool
+		var err error
+		acceptUnsigned := time.Now().Before(b.config.AcceptUnsignedOperationDataUntil)
+		operation, signed, err = VerifyOperation(b.config.OperationDataKey, instanceID, pollDetails.OperationData, acceptUnsigned)
 		if err != nil {
-			return brokerapi.LastOperation{}, fmt.Errorf("invalid operation data: %s", pollDetails.OperationData)
+			return brokerapi.LastOperation{}, withKind(ErrorKindValidation, err)
+		}
+		if !signed {
+			b.logger.Info("last-operation-unsigned-operation-data", lager.Data{
+				"instance-id":    instanceID,
+				"operation-data": pollDetails.OperationData,
+			})
 		}
 		if operation.Action == "" {
-			return brokerapi.LastOperation{}, fmt.Errorf("invalid operation, action parameter is empty: %s", pollDetails.OperationData)
+			return brokerapi.LastOperation{}, validationError("invalid operation, action parameter is empty: %s", pollDetails.OperationData)
 		}
 	}
 
+	providerCtx, cancelFunc := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFunc()
+
 	if operation.TimeOut != "" {
 		timeOutParsed, err := time.Parse(time.RFC3339, operation.TimeOut)
 		if err != nil {
-			return brokerapi.LastOperation{}, errors.Wrap(err, "Failed to parse time out string")
+			return brokerapi.LastOperation{}, withKind(ErrorKindValidation, errors.Wrap(err, "Failed to parse time out string"))
 		}
 
 		if time.Now().After(timeOutParsed) {
+			if operation.Action == ActionFailover || operation.Action == ActionTestingFailover {
+				return b.failoverTimedOut(providerCtx, instanceID, pollDetails.ServiceID, operation.Action)
+			}
 			return brokerapi.LastOperation{}, fmt.Errorf("Operation %s timed out for %s", operation.Action, instanceID)
 		}
 	}
 
-	providerCtx, cancelFunc := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFunc()
+	provider, err := b.getInstanceProvider(providerCtx, instanceID, pollDetails.ServiceID)
+	if err != nil {
+		return brokerapi.LastOperation{}, err
+	}
 
-	state, stateDescription, err := b.provider.ProgressState(providerCtx, instanceID, operation.Action, operation.PrimaryNode)
+	state, stateDescription, err := provider.ProgressState(providerCtx, instanceID, operation.Action, operation.PrimaryNode)
 	if err != nil {
-		return brokerapi.LastOperation{}, fmt.Errorf("error getting state for %s: %s", instanceID, err)
+		return brokerapi.LastOperation{}, fmt.Errorf("error getting state for %s: %w", instanceID, err)
+	}
+
+	if state == providers.Available && operation.Action == ActionChangingPlan {
+		changing, err := b.continuePlanChange(providerCtx, provider, instanceID, operation)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error changing plan for %s: %w", instanceID, err)
+		}
+		if changing {
+			return brokerapi.LastOperation{
+				State:       brokerapi.InProgress,
+				Description: stateDescription,
+			}, nil
+		}
+	}
+
+	if state == providers.Available && operation.Action == ActionSnapshotting {
+		state, err = b.snapshotState(providerCtx, provider, instanceID, operation.SnapshotName)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error getting snapshot state for %s: %w", instanceID, err)
+		}
+	}
+
+	if state == providers.Available && (operation.Action == ActionUpgrading || operation.Action == ActionMigratingEngine) {
+		state, err = b.finishEngineUpgrade(providerCtx, provider, instanceID, operation.Engine, operation.EngineVersion)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error upgrading engine version for %s: %w", instanceID, err)
+		}
+		if state == providers.UpgradeFailed {
+			target := strings.TrimSpace(operation.Engine + " " + operation.EngineVersion)
+			stateDescription = fmt.Sprintf("The upgrade to %s was rolled back by AWS, the instance runs the old version", target)
+		}
+	}
+
+	if state == providers.Available && operation.Action == ActionChangingReplicas && operation.ReplicasPerNodeGroup != nil {
+		changing, err := b.changingReplicas(providerCtx, provider, instanceID, *operation.ReplicasPerNodeGroup)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error changing replicas for %s: %w", instanceID, err)
+		}
+		if changing {
+			return brokerapi.LastOperation{
+				State:       brokerapi.InProgress,
+				Description: stateDescription,
+			}, nil
+		}
+	}
+
+	if state == providers.Available && operation.Action == ActionResharding && operation.ShardCount != nil {
+		instanceParameters, err := provider.GetInstanceParameters(providerCtx, instanceID)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error resharding %s: %w", instanceID, err)
+		}
+		// The replication group can still be available right after the resharding was requested
+		if instanceParameters.ShardCount != *operation.ShardCount {
+			return brokerapi.LastOperation{
+				State:       brokerapi.InProgress,
+				Description: stateDescription,
+			}, nil
+		}
+	}
+
+	if state != providers.NonExisting && operation.Action == ActionTestingFailover {
+		startTime, err := time.Parse(time.RFC3339, operation.StartTime)
+		if err != nil {
+			return brokerapi.LastOperation{}, errors.Wrap(err, "Failed to parse start time string")
+		}
+		state, stateDescription, err = provider.FailoverTestState(providerCtx, instanceID, operation.NodeGroupID, startTime)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("error getting failover state for %s: %w", instanceID, err)
+		}
 	}
 
 	if state == providers.NonExisting {
 		if operation.Action == ActionDeprovisioning {
-			err = b.provider.DeleteCacheParameterGroup(providerCtx, instanceID)
+			err = b.deleteInstanceResources(providerCtx, provider, instanceID)
 			if err != nil {
-				return brokerapi.LastOperation{}, fmt.Errorf("error deleting parameter group %s: %s", instanceID, err)
+				return brokerapi.LastOperation{}, err
 			}
 		}
 		return brokerapi.LastOperation{}, brokerapi.ErrInstanceDoesNotExist
@@ -427,17 +1292,278 @@ func (b *Broker) LastOperation(ctx context.Context, instanceID string, pollDetai
 		})
 	}
 
+	if lastOperationState == brokerapi.Succeeded && operation.Action == ActionRotatingToken && operation.AuthTokenNotStored {
+		lastOperationState = brokerapi.Failed
+		stateDescription = "The new auth token couldn't be stored, the rotation was finished with the old auth token"
+	}
+
+	// The remaining steps of a failed operation won't be run, so the instance can be updated again
+	if lastOperationState == brokerapi.Failed &&
+		(operation.Action == ActionChangingPlan || operation.Action == ActionUpgrading || operation.Action == ActionMigratingEngine) {
+		err = provider.UpdateReplicationGroup(providerCtx, instanceID, providers.UpdateReplicationGroupParameters{
+			Tags: map[string]string{providers.OperationInProgressTag: ""},
+		})
+		if err != nil {
+			b.logger.Error("last-operation-clear-operation-in-progress", err, lager.Data{
+				"instance-id": instanceID,
+			})
+		}
+	}
+
 	return brokerapi.LastOperation{
 		State:       lastOperationState,
 		Description: stateDescription,
 	}, nil
 }
 
+// continuePlanChange changes the number of replicas of a plan change if it couldn't be done by Update, then updates
+// the plan tag of the instance. It returns true if the instance is still being modified.
+func (b *Broker) continuePlanChange(ctx context.Context, provider providers.Provider, instanceID string, operation Operation) (bool, error) {
+	if operation.ReplicasPerNodeGroup != nil {
+		instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+		if err != nil {
+			return false, err
+		}
+		if instanceParameters.ReplicasPerNodeGroup != *operation.ReplicasPerNodeGroup {
+			b.logger.Info("change-plan-replicas", lager.Data{
+				"instance-id":      instanceID,
+				"current-replicas": instanceParameters.ReplicasPerNodeGroup,
+				"target-replicas":  *operation.ReplicasPerNodeGroup,
+			})
+
+			err = provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				ReplicasPerNodeGroup: operation.ReplicasPerNodeGroup,
+			})
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	tags := map[string]string{"plan-id": operation.PlanID}
+	if operation.ReplicasPerNodeGroup != nil {
+		tags[providers.OperationInProgressTag] = ""
+	}
+	return false, provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+		Tags: tags,
+	})
+}
+
+// changingReplicas returns true until every shard of the instance has the requested number of replicas
+// The replication group can still be available right after the replica count change was requested.
+func (b *Broker) changingReplicas(ctx context.Context, provider providers.Provider, instanceID string, replicasPerNodeGroup int64) (bool, error) {
+	instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+	if err != nil {
+		return false, err
+	}
+	shards := int64(len(instanceParameters.ActiveNodes))
+	return instanceParameters.ReplicasPerNodeGroup != replicasPerNodeGroup ||
+		int64(len(instanceParameters.PassiveNodes)) != shards*replicasPerNodeGroup, nil
+}
+
+// finishEngineUpgrade deletes the unused parameter group once the instance runs the new engine and engine version
+// It returns Modifying while the new version is pending, as the instance can be available before the modification
+// starts. An available instance without a pending version which doesn't run the new one was rolled back by AWS, it
+// returns UpgradeFailed then, and the operation in progress is cleared by the caller.
+func (b *Broker) finishEngineUpgrade(ctx context.Context, provider providers.Provider, instanceID string, engine string, engineVersion string) (providers.ServiceState, error) {
+	instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	// The reported version can contain the patch version as well, e.g. 7.1.0 for 7.1
+	upgraded := (engine == "" || instanceParameters.Engine == engine) &&
+		(instanceParameters.EngineVersion == engineVersion || strings.HasPrefix(instanceParameters.EngineVersion, engineVersion+"."))
+	if !upgraded && instanceParameters.PendingEngineVersion != "" {
+		return providers.Modifying, nil
+	}
+
+	err = provider.DeleteUnusedCacheParameterGroups(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	if !upgraded {
+		b.logger.Info("engine-upgrade-rolled-back", lager.Data{
+			"instance-id":    instanceID,
+			"engine":         instanceParameters.Engine,
+			"engine-version": instanceParameters.EngineVersion,
+		})
+		return providers.UpgradeFailed, nil
+	}
+	return providers.Available, provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+		Tags: map[string]string{providers.OperationInProgressTag: ""},
+	})
+}
+
+// snapshotState returns with the state of a snapshot being created
+// The replication group can be available before the snapshot has started or after it has finished, so we check the
+// status of the snapshot itself.
+func (b *Broker) snapshotState(ctx context.Context, provider providers.Provider, instanceID string, snapshotName string) (providers.ServiceState, error) {
+	snapshots, err := provider.ListSnapshots(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name != snapshotName {
+			continue
+		}
+		switch snapshot.Status {
+		case "available":
+			return providers.Available, nil
+		case "failed":
+			return providers.CreateFailed, nil
+		}
+	}
+	return providers.Snapshotting, nil
+}
+
+// getProvider returns the provider of a catalog service
+func (b *Broker) getProvider(serviceID string) (providers.Provider, error) {
+	provider, ok := b.serviceProviders[serviceID]
+	if !ok {
+		return nil, validationError("no provider found for service %s", serviceID)
+	}
+	return provider, nil
+}
+
+// failoverTimedOut fails a test failover which didn't finish in time
+// The emulated failover disables automatic failover and Multi-AZ, so they are enabled again before the failure is
+// reported. If they can't be enabled yet an error is returned, and it's tried again on the next poll.
+func (b *Broker) failoverTimedOut(ctx context.Context, instanceID string, serviceID string, operationAction action) (brokerapi.LastOperation, error) {
+	description := fmt.Sprintf("Test failover timed out after %s", FailoverTimeout)
+	if operationAction == ActionFailover {
+		provider, err := b.getInstanceProvider(ctx, instanceID, serviceID)
+		if err != nil {
+			return brokerapi.LastOperation{}, err
+		}
+		_, err = provider.RestoreHighAvailability(ctx, instanceID)
+		if err != nil {
+			return brokerapi.LastOperation{}, fmt.Errorf("Operation %s timed out for %s, enabling automatic failover and Multi-AZ failed: %w", operationAction, instanceID, err)
+		}
+		description += ", automatic failover and Multi-AZ have been enabled again"
+	}
+
+	b.logger.Error("failover-timed-out", errors.New(description), lager.Data{
+		"instance-id": instanceID,
+		"action":      operationAction,
+	})
+
+	return brokerapi.LastOperation{
+		State:       brokerapi.Failed,
+		Description: description,
+	}, nil
+}
+
+// RestoreHighAvailability enables automatic failover and Multi-AZ of the instances created by the broker which have
+// them disabled, although their plan has them enabled
+// Instances with a test failover which hasn't timed out yet are left alone. The broker runs it on start up and then
+// every HighAvailabilityCheckInterval, errors are logged.
+func (b *Broker) RestoreHighAvailability(ctx context.Context) {
+	checked := []providers.Provider{}
+	for _, service := range b.config.Catalog.Services {
+		provider, ok := b.serviceProviders[service.ID]
+		if !ok || containsProvider(checked, provider) {
+			continue
+		}
+		checked = append(checked, provider)
+
+		instances, err := provider.FindDegradedInstances(ctx, b.config.BrokerName)
+		if err != nil {
+			b.logger.Error("restore-high-availability", err, lager.Data{
+				"service-id": service.ID,
+			})
+			continue
+		}
+		for _, instance := range instances {
+			b.restoreInstanceHighAvailability(ctx, provider, instance)
+		}
+	}
+}
+
+func (b *Broker) restoreInstanceHighAvailability(ctx context.Context, provider providers.Provider, instance providers.DegradedInstance) {
+	logData := lager.Data{
+		"instance-id": instance.InstanceID,
+		"plan-id":     instance.Tags["plan-id"],
+	}
+
+	planConfig, err := b.config.GetPlanConfig(instance.Tags["plan-id"])
+	if err != nil || !planConfig.AutomaticFailoverEnabled || !planConfig.MultiAZEnabled {
+		return
+	}
+
+	if startedAt, err := time.Parse(time.RFC3339, instance.Tags[providers.FailoverTestStartedTag]); err == nil {
+		if time.Since(startedAt) < FailoverTimeout {
+			b.logger.Debug("restore-high-availability-failover-in-progress", logData)
+			return
+		}
+	}
+
+	restored, err := provider.RestoreHighAvailability(ctx, instance.InstanceID)
+	if err != nil {
+		b.logger.Error("restore-high-availability", err, logData)
+		return
+	}
+	if restored {
+		b.logger.Info("restore-high-availability-success", logData)
+	}
+}
+
+// getInstanceProvider returns the provider of an existing instance
+// If the platform didn't send the service ID, it's taken from the service-id tag of the instance. Only the providers
+// which don't have the instance are skipped, any other error is returned, so a failing AWS call isn't reported as a
+// missing instance.
+func (b *Broker) getInstanceProvider(ctx context.Context, instanceID string, serviceID string) (providers.Provider, error) {
+	if serviceID != "" {
+		return b.getProvider(serviceID)
+	}
+
+	candidates := []providers.Provider{}
+	for _, service := range b.config.Catalog.Services {
+		provider, ok := b.serviceProviders[service.ID]
+		if ok && !containsProvider(candidates, provider) {
+			candidates = append(candidates, provider)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	for _, provider := range candidates {
+		tags, err := provider.GetInstanceTags(ctx, instanceID)
+		if isNotFoundError(err) {
+			// The instance is not managed by this provider
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting the tags of instance %s: %w", instanceID, err)
+		}
+		if instanceServiceID, ok := tags["service-id"]; ok {
+			return b.getProvider(instanceServiceID)
+		}
+	}
+	return nil, notFoundError("no provider found for instance %s", instanceID)
+}
+
+func containsProvider(list []providers.Provider, provider providers.Provider) bool {
+	for _, p := range list {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
 func ProviderStatesMapping(state providers.ServiceState) (brokerapi.LastOperationState, error) {
 	switch state {
 	case providers.Available:
 		return brokerapi.Succeeded, nil
 	case providers.CreateFailed:
+		fallthrough
+	case providers.FailoverFailed:
+		fallthrough
+	case providers.UpgradeFailed:
+		fallthrough
+	case providers.AuthTokenRotationFailed:
 		return brokerapi.Failed, nil
 	case providers.Creating:
 		fallthrough
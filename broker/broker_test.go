@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-elasticache-broker/broker"
 	"github.com/alphagov/paas-elasticache-broker/providers"
 	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -23,6 +27,7 @@ var _ = Describe("Broker", func() {
 	BeforeEach(func() {
 		validConfig = broker.Config{
 			BrokerName:           "Broker McBrokerface",
+			OperationDataKey:     "operation-data-key",
 			VpcSecurityGroupIds:  []string{"vpc_security_group_id"},
 			CacheSubnetGroupName: "cache-subnet-group-name",
 			Catalog: brokerapi.CatalogResponse{
@@ -240,6 +245,143 @@ var _ = Describe("Broker", func() {
 			Expect(callParams.Tags).To(HaveKeyWithValue("chargeable_entity", instanceId))
 		})
 
+		Context("when a daily backup window is given", func() {
+			var b *broker.Broker
+
+			BeforeEach(func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.SnapshotRetentionLimit = 7
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("passes it to the Provider", func() {
+				validProvisionDetails.RawParameters = []byte(`{"daily_backup_window": "04:00-05:00", "preferred_maintenance_window": "sun:23:00-mon:01:30"}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.ProvisionArgsForCall(0)
+				Expect(params.DailyBackupWindow).To(Equal("04:00-05:00"))
+			})
+
+			DescribeTable("validates the window",
+				func(dailyBackupWindow, maintenanceWindow, expectedErr string) {
+					validProvisionDetails.RawParameters = []byte(
+						`{"daily_backup_window": "` + dailyBackupWindow + `", "preferred_maintenance_window": "` + maintenanceWindow + `"}`,
+					)
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					if expectedErr == "" {
+						Expect(err).ToNot(HaveOccurred())
+					} else {
+						Expect(err).To(MatchError(expectedErr))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					}
+				},
+				Entry("valid windows", "04:00-05:00", "sun:23:00-mon:01:30", ""),
+				Entry("backup window wrapping around midnight", "23:30-00:30", "wed:03:00-wed:04:00", ""),
+				Entry("adjacent windows", "01:30-02:30", "sun:23:00-mon:01:30", ""),
+				Entry("invalid format", "4:00-5:00", "sun:23:00-mon:01:30",
+					"daily_backup_window must be in the format hh24:mi-hh24:mi (UTC): 4:00-5:00"),
+				Entry("invalid time", "24:00-01:00", "sun:23:00-mon:01:30",
+					"daily_backup_window must be in the format hh24:mi-hh24:mi (UTC): 24:00-01:00"),
+				Entry("too short", "04:00-04:30", "sun:23:00-mon:01:30",
+					"daily_backup_window must be at least 60 minutes long: 04:00-04:30"),
+				Entry("invalid maintenance window", "04:00-05:00", "sunday:23:00-mon:01:30",
+					"preferred_maintenance_window must be in the format ddd:hh24:mi-ddd:hh24:mi (UTC): sunday:23:00-mon:01:30"),
+				Entry("maintenance window starting during the backup", "04:00-06:00", "tue:05:00-tue:07:00",
+					"daily_backup_window 04:00-06:00 overlaps with preferred_maintenance_window tue:05:00-tue:07:00"),
+				Entry("backup starting during the maintenance window", "00:00-01:00", "sun:23:00-mon:01:30",
+					"daily_backup_window 00:00-01:00 overlaps with preferred_maintenance_window sun:23:00-mon:01:30"),
+				Entry("maintenance window wrapping around the week", "23:30-00:30", "sat:23:00-sun:01:00",
+					"daily_backup_window 23:30-00:30 overlaps with preferred_maintenance_window sat:23:00-sun:01:00"),
+			)
+
+			It("is rejected if the plan has no backups", func() {
+				validProvisionDetails.PlanID = "plan2"
+				validProvisionDetails.RawParameters = []byte(`{"daily_backup_window": "04:00-05:00"}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("daily_backup_window can not be set as the plan has no backups"))
+			})
+		})
+
+		Context("when redis parameters are given", func() {
+			var b *broker.Broker
+
+			BeforeEach(func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.UserSettableParameters = map[string]broker.UserSettableParameter{
+					"notify-keyspace-events": {Pattern: "[KEA$lshzxegt]*"},
+					"timeout":                {MinValue: aws.Int64(0), MaxValue: aws.Int64(3600)},
+				}
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("passes them to the Provider", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"notify-keyspace-events": "Ex", "timeout": 300}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.ProvisionArgsForCall(0)
+				Expect(params.Parameters).To(HaveKeyWithValue("notify-keyspace-events", "Ex"))
+				Expect(params.Parameters).To(HaveKeyWithValue("timeout", "300"))
+				Expect(params.Parameters).To(HaveKeyWithValue("maxmemory-policy", "volatile-lru"))
+			})
+
+			It("rejects parameters which are not allowed for the plan", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"cluster-enabled": "yes"}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("unknown parameter: redis_parameters.cluster-enabled, allowed parameters are: notify-keyspace-events, timeout"))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("rejects invalid values", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": 7200}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("redis_parameters.timeout must be at most 3600"))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("accepts numeric values given as strings", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": "300"}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.ProvisionArgsForCall(0)
+				Expect(params.Parameters).To(HaveKeyWithValue("timeout", "300"))
+			})
+
+			It("checks the bounds of numeric values given as strings", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": "7200"}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("redis_parameters.timeout must be at most 3600"))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("rejects all parameters if the plan has none", func() {
+				validProvisionDetails.PlanID = "plan2"
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": 300}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("unknown parameter: redis_parameters.timeout, this plan doesn't allow setting any redis_parameters"))
+			})
+
+			It("rejects values of the wrong type", func() {
+				validProvisionDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": true}}`)
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("redis_parameters.timeout must be an integer or a string"))
+			})
+		})
+
 		Context("given an unknown user provided parameter", func() {
 			It("should return with error", func() {
 				fakeProvider := &mocks.FakeProvider{}
@@ -278,8 +420,85 @@ var _ = Describe("Broker", func() {
 			Expect(b.Provision(context.Background(), "instanceid", validProvisionDetails, true)).
 				To(Equal(brokerapi.ProvisionedServiceSpec{
 					IsAsync:       true,
-					OperationData: broker.Operation{Action: broker.ActionProvisioning}.String(),
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning}),
+				}))
+		})
+
+		Context("when the instance already exists", func() {
+			var (
+				b                *broker.Broker
+				existingInstance *providers.ExistingInstance
+			)
+
+			BeforeEach(func() {
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				existingInstance = &providers.ExistingInstance{
+					State: providers.Available,
+					Tags: map[string]string{
+						"created-by":      validConfig.BrokerName,
+						"service-id":      "service1",
+						"plan-id":         "plan1",
+						"organization-id": "org-guid",
+						"space-id":        "space-guid",
+						"instance-id":     "instanceid",
+					},
+				}
+				fakeProvider.FindInstanceReturns(existingInstance, nil)
+			})
+
+			It("returns that it already exists if it has been created", func() {
+				spec, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(brokerapi.ProvisionedServiceSpec{AlreadyExists: true}))
+
+				_, instanceID := fakeProvider.FindInstanceArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("returns the provisioning operation if it's still being created", func() {
+				existingInstance.State = providers.Creating
+
+				spec, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(brokerapi.ProvisionedServiceSpec{
+					IsAsync:       true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning}),
 				}))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("returns a conflict if it has a different plan", func() {
+				validProvisionDetails.PlanID = "plan2"
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(Equal(brokerapi.ErrInstanceAlreadyExists))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("returns a conflict if it's in another space", func() {
+				validProvisionDetails.SpaceGUID = "other-space-guid"
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(Equal(brokerapi.ErrInstanceAlreadyExists))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("returns a conflict if it's being deleted", func() {
+				existingInstance.State = providers.Deleting
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(Equal(brokerapi.ErrInstanceAlreadyExists))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
+			It("returns the error if checking for the instance fails", func() {
+				fakeProvider.FindInstanceReturns(nil, errors.New("some error"))
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("error checking if instance instanceid exists: some error"))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when restoring from a snapshot", func() {
@@ -447,6 +666,196 @@ var _ = Describe("Broker", func() {
 				})
 			})
 
+			Context("when a snapshot name is given", func() {
+				JustBeforeEach(func() {
+					validProvisionDetails.RawParameters = json.RawMessage(`{"restore_from_snapshot": "origin-instanceid-snapshot-name-2-day-old"}`)
+
+					fakeProvider.FindSnapshotReturns(&providers.SnapshotInfo{
+						Name:       "origin-instanceid-snapshot-name-2-day-old",
+						CreateTime: time.Now().Add(-2 * 24 * time.Hour),
+						Tags: map[string]string{
+							"created-by":      validConfig.BrokerName,
+							"service-id":      validProvisionDetails.ServiceID,
+							"plan-id":         snapshotPlanId,
+							"organization-id": snapshotOrgId,
+							"space-id":        snapshotSpaceId,
+							"instance-id":     "instanceid",
+						},
+					}, nil)
+				})
+
+				It("restores the named snapshot", func() {
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeProvider.FindSnapshotCallCount()).To(Equal(1))
+					_, snapshotName := fakeProvider.FindSnapshotArgsForCall(0)
+					Expect(snapshotName).To(Equal("origin-instanceid-snapshot-name-2-day-old"))
+					Expect(fakeProvider.FindSnapshotsCallCount()).To(Equal(0))
+
+					_, _, params := fakeProvider.ProvisionArgsForCall(0)
+					Expect(params.RestoreFromSnapshot).To(Equal(aws.String("origin-instanceid-snapshot-name-2-day-old")))
+				})
+
+				Context("and the snapshot is in a different space", func() {
+					BeforeEach(func() {
+						snapshotSpaceId = "other-space-id"
+					})
+
+					It("should fail to restore", func() {
+						b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+						_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+						Expect(err).To(MatchError("The service instance you are getting a snapshot from is not in the same org or space"))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("and the snapshot is in a different org", func() {
+					BeforeEach(func() {
+						snapshotOrgId = "other-org-id"
+					})
+
+					It("reports it as not found", func() {
+						b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+						_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+						Expect(err).To(MatchError("Snapshot not found: origin-instanceid-snapshot-name-2-day-old"))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("and the snapshot is of a different plan", func() {
+					BeforeEach(func() {
+						snapshotPlanId = "other-plan-id"
+					})
+
+					It("should fail to restore", func() {
+						b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+						_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+						Expect(err).To(MatchError("You must use the same plan as the service instance you are getting a snapshot from"))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("and the snapshot doesn't exist", func() {
+					JustBeforeEach(func() {
+						fakeProvider.FindSnapshotReturns(nil, nil)
+					})
+
+					It("returns an error", func() {
+						b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+						_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+						Expect(err).To(MatchError("Snapshot not found: origin-instanceid-snapshot-name-2-day-old"))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("and finding the snapshot fails", func() {
+					JustBeforeEach(func() {
+						fakeProvider.FindSnapshotReturns(nil, errors.New("ERROR GETTING SNAPSHOT"))
+					})
+
+					It("returns the error", func() {
+						b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+						_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+						Expect(err).To(MatchError("ERROR GETTING SNAPSHOT"))
+						Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when a timestamp is given", func() {
+				var now time.Time
+
+				JustBeforeEach(func() {
+					now = time.Now().UTC().Truncate(time.Second)
+					validProvisionDetails.RawParameters = json.RawMessage(fmt.Sprintf(
+						`{"restore_from_snapshot": "%s"}`, now.Add(-36*time.Hour).Format(time.RFC3339),
+					))
+
+					tags := map[string]string{
+						"plan-id":         snapshotPlanId,
+						"organization-id": snapshotOrgId,
+						"space-id":        snapshotSpaceId,
+					}
+					fakeProvider.FindSnapshotsWithTagsReturns([]providers.SnapshotInfo{
+						{Name: "snapshot-3-days-old", CreateTime: now.Add(-3 * 24 * time.Hour), Tags: tags},
+						{Name: "snapshot-1-day-old", CreateTime: now.Add(-1 * 24 * time.Hour), Tags: tags},
+						{Name: "snapshot-2-days-old", CreateTime: now.Add(-2 * 24 * time.Hour), Tags: tags},
+					}, nil)
+				})
+
+				It("restores the latest snapshot of the same org, space and plan taken at or before that time", func() {
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeProvider.FindSnapshotsWithTagsCallCount()).To(Equal(1))
+					_, tags := fakeProvider.FindSnapshotsWithTagsArgsForCall(0)
+					Expect(tags).To(Equal(map[string]string{
+						"organization-id": validProvisionDetails.OrganizationGUID,
+						"space-id":        validProvisionDetails.SpaceGUID,
+						"plan-id":         validProvisionDetails.PlanID,
+					}))
+					Expect(fakeProvider.FindSnapshotCallCount()).To(Equal(0))
+
+					_, _, params := fakeProvider.ProvisionArgsForCall(0)
+					Expect(params.RestoreFromSnapshot).To(Equal(aws.String("snapshot-2-days-old")))
+				})
+
+				It("restores a snapshot taken exactly at that time", func() {
+					validProvisionDetails.RawParameters = json.RawMessage(fmt.Sprintf(
+						`{"restore_from_snapshot": "%s"}`, now.Add(-3*24*time.Hour).Format(time.RFC3339),
+					))
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, _, params := fakeProvider.ProvisionArgsForCall(0)
+					Expect(params.RestoreFromSnapshot).To(Equal(aws.String("snapshot-3-days-old")))
+				})
+
+				It("returns an error if there is no snapshot before that time", func() {
+					restoreTime := now.Add(-4 * 24 * time.Hour).Format(time.RFC3339)
+					validProvisionDetails.RawParameters = json.RawMessage(fmt.Sprintf(`{"restore_from_snapshot": "%s"}`, restoreTime))
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					Expect(err).To(MatchError("No snapshots found taken at or before " + restoreTime))
+					Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+				})
+
+				It("returns the error if finding the snapshots fails", func() {
+					fakeProvider.FindSnapshotsWithTagsReturns(nil, errors.New("ERROR GETTING SNAPSHOTS"))
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+					Expect(err).To(MatchError("ERROR GETTING SNAPSHOTS"))
+					Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+				})
+			})
+
+			It("doesn't accept a snapshot name together with the instance", func() {
+				validProvisionDetails.RawParameters = json.RawMessage(
+					`{"restore_from_latest_snapshot_of": "origin-instanceid", "restore_from_snapshot": "some-snapshot"}`,
+				)
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Provision(context.Background(), "instanceid", validProvisionDetails, true)
+				Expect(err).To(MatchError("restore_from_snapshot and restore_from_latest_snapshot_of can't be used together"))
+				Expect(fakeProvider.FindSnapshotsCallCount()).To(Equal(0))
+				Expect(fakeProvider.FindSnapshotCallCount()).To(Equal(0))
+				Expect(fakeProvider.ProvisionCallCount()).To(Equal(0))
+			})
+
 			Context("if it is using a different plan", func() {
 				BeforeEach(func() {
 					snapshotPlanId = "other-plan-id"
@@ -489,6 +898,120 @@ var _ = Describe("Broker", func() {
 			b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
 		})
 
+		Context("when another operation is in progress", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"maxmemory_policy": "noeviction"}`)
+			})
+
+			It("checks the state of the instance", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: providers.Available}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.FindInstanceCallCount()).To(Equal(1))
+				_, instanceID := fakeProvider.FindInstanceArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+			})
+
+			It("returns a ConcurrencyError if the instance is not available", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: providers.Modifying}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("The instance is modifying, try again when the current operation has finished"))
+				failureResponse, ok := err.(*brokerapi.FailureResponse)
+				Expect(ok).To(BeTrue())
+				Expect(failureResponse.ValidatedStatusCode(nil)).To(Equal(http.StatusUnprocessableEntity))
+				Expect(failureResponse.ErrorResponse()).To(Equal(brokerapi.ErrorResponse{
+					Error:       "ConcurrencyError",
+					Description: "The instance is modifying, try again when the current operation has finished",
+				}))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns a ConcurrencyError if a broker operation started within the operation timeout", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:              providers.Available,
+					Operation:          broker.ActionChangingPlan,
+					OperationStartedAt: time.Now().Add(-time.Hour),
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Another operation is in progress for this instance (changing-plan), try again when it has finished"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("clears a broker operation which has timed out and updates the instance", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:              providers.Available,
+					Operation:          broker.ActionChangingPlan,
+					OperationStartedAt: time.Now().Add(-broker.DefaultOperationTimeout - time.Minute),
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					Tags: map[string]string{providers.OperationInProgressTag: ""},
+				}))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(1))
+			})
+
+			It("uses the configured operation timeout", func() {
+				config := validConfig
+				config.OperationTimeoutHours = 1
+				b = broker.New(config, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:              providers.Available,
+					Operation:          broker.ActionChangingPlan,
+					OperationStartedAt: time.Now().Add(-2 * time.Hour),
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+			})
+
+			It("returns the error if the timed out operation can't be cleared", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:              providers.Available,
+					Operation:          broker.ActionChangingPlan,
+					OperationStartedAt: time.Now().Add(-broker.DefaultOperationTimeout - time.Minute),
+				}, nil)
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError(ContainSubstring("some error")))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns a ConcurrencyError if a test failover is in progress", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:     providers.Available,
+					Operation: "test failover",
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Another operation is in progress for this instance (test failover), try again when it has finished"))
+				failureResponse, ok := err.(*brokerapi.FailureResponse)
+				Expect(ok).To(BeTrue())
+				Expect(failureResponse.ValidatedStatusCode(nil)).To(Equal(http.StatusUnprocessableEntity))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("errors if the state of the instance can't be checked", func() {
+				fakeProvider.FindInstanceReturns(nil, errors.New("some error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("error checking the state of instance instanceid: some error"))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+		})
+
 		It("updates the redis parameter group through the Provider", func() {
 			validUpdateDetails.RawParameters = []byte(`{"maxmemory_policy": "noeviction"}`)
 
@@ -510,7 +1033,7 @@ var _ = Describe("Broker", func() {
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       true,
-				OperationData: broker.Operation{Action: broker.ActionUpdating}.String(),
+				OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating}),
 			}))
 		})
 
@@ -533,7 +1056,7 @@ var _ = Describe("Broker", func() {
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       true,
-				OperationData: broker.Operation{Action: broker.ActionUpdating}.String(),
+				OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating}),
 			}))
 
 			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(BeZero())
@@ -554,9 +1077,9 @@ var _ = Describe("Broker", func() {
 
 			Expect(spec.IsAsync).To(Equal(true))
 
-			operation := broker.Operation{}
-			err = json.Unmarshal([]byte(spec.OperationData), &operation)
+			operation, signed, err := broker.VerifyOperation(validConfig.OperationDataKey, "instanceid", spec.OperationData, false)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(signed).To(BeTrue())
 
 			parsedTime, err := time.Parse(time.RFC3339, operation.TimeOut)
 			Expect(operation.Action).To(Equal(broker.ActionFailover))
@@ -607,7 +1130,7 @@ var _ = Describe("Broker", func() {
 			}))
 		})
 
-		It("fails a test_failover for a cluster_mode enabled plan", func() {
+		It("triggers a native test failover of a node group for a cluster_mode enabled plan", func() {
 
 			validUpdateDetails = brokerapi.UpdateDetails{
 				ServiceID: "service1",
@@ -620,19 +1143,85 @@ var _ = Describe("Broker", func() {
 				},
 			}
 
+			validUpdateDetails.RawParameters = []byte(`{"test_failover": true, "test_failover_node_group": "0002"}`)
+
+			fakeProvider.TestFailoverReturns("0002", nil)
+			spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeProvider.StartFailoverTestCallCount()).To(Equal(0))
+			Expect(fakeProvider.TestFailoverCallCount()).To(Equal(1))
+			_, id, nodeGroupID := fakeProvider.TestFailoverArgsForCall(0)
+			Expect(id).To(Equal("instanceid"))
+			Expect(nodeGroupID).To(Equal("0002"))
+
+			Expect(spec.IsAsync).To(Equal(true))
+
+			operation, signed, err := broker.VerifyOperation(validConfig.OperationDataKey, "instanceid", spec.OperationData, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signed).To(BeTrue())
+
+			Expect(operation.Action).To(Equal(broker.ActionTestingFailover))
+			Expect(operation.NodeGroupID).To(Equal("0002"))
+			startTime, err := time.Parse(time.RFC3339, operation.StartTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(startTime).To(BeTemporally("~", time.Now(), 2*time.Second))
+			timeOut, err := time.Parse(time.RFC3339, operation.TimeOut)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeOut).To(BeTemporally(">", time.Now().Add(44*time.Minute)))
+		})
+
+		It("triggers a native test failover for plans with the native test failover mode", func() {
+			plan1 := validConfig.PlanConfigs["plan1"]
+			plan1.TestFailoverMode = broker.TestFailoverModeNative
+			validConfig.PlanConfigs["plan1"] = plan1
+			b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
 			validUpdateDetails.RawParameters = []byte(`{"test_failover": true}`)
 
+			fakeProvider.TestFailoverReturns("0001", nil)
 			spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
-			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(HaveOccurred())
 
-			brokerErr := errors.New("Test failover is not supported for Redis instances in cluster mode")
-			Expect(err).To(MatchError(brokerErr))
+			Expect(fakeProvider.StartFailoverTestCallCount()).To(Equal(0))
+			Expect(fakeProvider.TestFailoverCallCount()).To(Equal(1))
+			_, _, nodeGroupID := fakeProvider.TestFailoverArgsForCall(0)
+			Expect(nodeGroupID).To(BeEmpty())
 
-			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
-				IsAsync:       false,
-				DashboardURL:  "",
-				OperationData: "",
-			}))
+			operation, signed, err := broker.VerifyOperation(validConfig.OperationDataKey, "instanceid", spec.OperationData, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signed).To(BeTrue())
+			Expect(operation.Action).To(Equal(broker.ActionTestingFailover))
+			Expect(operation.NodeGroupID).To(Equal("0001"))
+		})
+
+		It("fails a native test failover if the provider fails", func() {
+			validUpdateDetails.PlanID = "plan3"
+			validUpdateDetails.PreviousValues.PlanID = "plan3"
+			validUpdateDetails.RawParameters = []byte(`{"test_failover": true}`)
+
+			fakeProvider.TestFailoverReturns("", errors.New("node group not found"))
+			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).To(MatchError("Test failover failed: node group not found"))
+		})
+
+		It("fails if test_failover_node_group is used without test_failover", func() {
+			validUpdateDetails.PlanID = "plan3"
+			validUpdateDetails.PreviousValues.PlanID = "plan3"
+			validUpdateDetails.RawParameters = []byte(`{"test_failover_node_group": "0001"}`)
+
+			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).To(MatchError("test_failover_node_group can only be used with test_failover"))
+			Expect(fakeProvider.TestFailoverCallCount()).To(Equal(0))
+		})
+
+		It("fails if test_failover_node_group is used for a plan without cluster mode", func() {
+			validUpdateDetails.RawParameters = []byte(`{"test_failover": true, "test_failover_node_group": "0001"}`)
+
+			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).To(MatchError("test_failover_node_group is only supported for plans with cluster mode enabled"))
+			Expect(fakeProvider.TestFailoverCallCount()).To(Equal(0))
+			Expect(fakeProvider.StartFailoverTestCallCount()).To(Equal(0))
 		})
 
 		It("fails a test_failover for a cluster without HA enabled", func() {
@@ -653,8 +1242,7 @@ var _ = Describe("Broker", func() {
 			spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
 			Expect(err).To(HaveOccurred())
 
-			brokerErr := errors.New("Test failover is not supported for Redis instances in cluster mode")
-			Expect(err).To(MatchError(brokerErr))
+			Expect(err).To(MatchError("Test failover is not supported without MultiAZEnabled and AutomaticFailoverEnabled"))
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       false,
@@ -681,8 +1269,7 @@ var _ = Describe("Broker", func() {
 			spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
 			Expect(err).To(HaveOccurred())
 
-			brokerErr := errors.New("Test failover requires one or more replicas")
-			Expect(err).To(MatchError(brokerErr))
+			Expect(err).To(MatchError("Test failover requires one or more replicas"))
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       false,
@@ -712,7 +1299,7 @@ var _ = Describe("Broker", func() {
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       true,
-				OperationData: broker.Operation{Action: broker.ActionUpdating}.String(),
+				OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating}),
 			}))
 
 			Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
@@ -728,7 +1315,7 @@ var _ = Describe("Broker", func() {
 
 			Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
 				IsAsync:       true,
-				OperationData: broker.Operation{Action: broker.ActionUpdating}.String(),
+				OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating}),
 			}))
 		})
 
@@ -793,274 +1380,1969 @@ var _ = Describe("Broker", func() {
 			Expect(err).To(MatchError(providerErr))
 		})
 
-		It("should return error when attempting to change plan id", func() {
-			validUpdateDetails.PlanID = "plan2"
+		Context("when changing the plan", func() {
+			BeforeEach(func() {
+				plan5 := validConfig.PlanConfigs["plan1"]
+				plan5.InstanceType = "m5.large"
+				plan5.ReplicasPerNodeGroup = 2
+				validConfig.PlanConfigs["plan5"] = plan5
 
-			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
-			Expect(err).To(MatchError("changing plans is not currently supported"))
+				plan6 := validConfig.PlanConfigs["plan1"]
+				plan6.ReplicasPerNodeGroup = 2
+				validConfig.PlanConfigs["plan6"] = plan6
 
-			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
-		})
+				plan7 := validConfig.PlanConfigs["plan1"]
+				plan7.CacheParameterGroupFamily = "default.redis5.0"
+				plan7.EngineVersion = "5.0.6"
+				validConfig.PlanConfigs["plan7"] = plan7
 
-		It("should return error when attempting to change service id", func() {
-			validUpdateDetails.ServiceID = "service2"
+				plan8 := validConfig.PlanConfigs["plan1"]
+				plan8.CacheUsageLimits = providers.CacheUsageLimits{DataStorageMaximumGB: 20, ECPUPerSecondMaximum: 10000}
+				validConfig.PlanConfigs["plan8"] = plan8
 
-			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
-			Expect(err).To(MatchError("changing plans is not currently supported"))
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
 
-			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
-		})
+			It("changes the node type and leaves the replicas and the plan tag to LastOperation", func() {
+				validUpdateDetails.PlanID = "plan5"
 
-		It("sets a deadline by which the Provider request should complete", func() {
-			validUpdateDetails.RawParameters = []byte(`{"maxmemory_policy": "noeviction"}`)
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
 
-			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
-			Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				expectOperationTag(params.Tags, broker.ActionChangingPlan)
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					CacheNodeType: "m5.large",
+					Tags:          map[string]string{},
+				}))
 
-			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(1))
-			receivedContext, _, _ := fakeProvider.UpdateParamGroupParametersArgsForCall(0)
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:               broker.ActionChangingPlan,
+						ReplicasPerNodeGroup: aws.Int64(2),
+						PlanID:               "plan5",
+					}),
+				}))
+			})
 
-			_, hasDeadline := receivedContext.Deadline()
+			It("changes the number of replicas straight away if nothing else changes", func() {
+				validUpdateDetails.PlanID = "plan6"
 
-			Expect(hasDeadline).To(BeTrue())
-		})
-	})
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
 
-	Describe("Deprovision", func() {
-		var validDeprovisionDetails brokerapi.DeprovisionDetails
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.CacheNodeType).To(BeEmpty())
+				Expect(params.ReplicasPerNodeGroup).To(Equal(aws.Int64(2)))
+			})
 
-		BeforeEach(func() {
-			validDeprovisionDetails = brokerapi.DeprovisionDetails{
-				PlanID:    "myplan-id",
-				ServiceID: "myservice-id",
-			}
-		})
+			It("keeps the number of replicas chosen by the user if the new plan allows it", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.MinReplicasPerNodeGroup = 1
+				plan6.MaxReplicasPerNodeGroup = 3
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{ReplicasPerNodeGroup: 3}, nil)
+				validUpdateDetails.PlanID = "plan6"
+
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.ReplicasPerNodeGroup).To(BeNil())
+				Expect(spec.OperationData).To(Equal(broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+					Action: broker.ActionChangingPlan,
+					PlanID: "plan6",
+				})))
+			})
 
-		It("logs a debug message when deprovisioning begins", func() {
-			logger := lager.NewLogger("logger")
-			log := gbytes.NewBuffer()
-			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
-			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
+			It("changes the snapshot retention limit", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.SnapshotRetentionLimit = 7
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.PlanID = "plan6"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.SnapshotRetentionLimit).To(Equal(aws.Int64(7)))
+			})
+
+			It("sets the cache parameters of the new plan", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.Parameters = map[string]string{
+					"maxmemory-policy":             "allkeys-lru",
+					"reserved-memory":              "25",
+					"preferred-maintenance-window": "sun:23:00-mon:01:30",
+				}
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.PlanID = "plan6"
+				validUpdateDetails.RawParameters = []byte(`{"maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateParamGroupParametersArgsForCall(0)
+				Expect(params.Parameters).To(Equal(map[string]string{
+					"maxmemory-policy": "noeviction",
+					"reserved-memory":  "25",
+				}))
+			})
+
+			It("rejects plans which don't set a cache parameter of the current plan", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.Parameters = map[string]string{}
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.PlanID = "plan6"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError(ContainSubstring("changing plans is not supported to a plan which doesn't set the")))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("rejects plans with an older engine version", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.EngineVersion = "3.2.5"
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.PlanID = "plan6"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("changing plans is not supported to a plan with an older engine version"))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("doesn't downgrade an instance which was upgraded past the engine version of the new plan", func() {
+				plan6 := validConfig.PlanConfigs["plan6"]
+				plan6.EngineVersion = "9.9.9"
+				validConfig.PlanConfigs["plan6"] = plan6
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{EngineVersion: "10.0"}, nil)
+				validUpdateDetails.PlanID = "plan6"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.EngineVersion).To(BeEmpty())
+			})
+
+			It("changes the usage limits of serverless plans", func() {
+				validUpdateDetails.PlanID = "plan8"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.CacheUsageLimits).To(Equal(&providers.CacheUsageLimits{
+					DataStorageMaximumGB: 20,
+					ECPUPerSecondMaximum: 10000,
+				}))
+			})
+
+			It("does not require any parameters", func() {
+				validUpdateDetails.PlanID = "plan5"
+				validUpdateDetails.RawParameters = []byte(``)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("rejects plans with a different parameter group family", func() {
+				validUpdateDetails.PlanID = "plan7"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("changing plans is not supported between different cache parameter group families"))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			Context("when the instance was migrated to another engine", func() {
+				BeforeEach(func() {
+					plan1 := validConfig.PlanConfigs["plan1"]
+					plan1.EngineMigrations = map[string]broker.EngineMigration{
+						"valkey": {EngineVersion: "7.2", CacheParameterGroupFamily: "valkey7"},
+					}
+					validConfig.PlanConfigs["plan1"] = plan1
+
+					plan9 := validConfig.PlanConfigs["plan5"]
+					plan9.Engine = "valkey"
+					plan9.EngineVersion = "7.2"
+					plan9.CacheParameterGroupFamily = "valkey7"
+					validConfig.PlanConfigs["plan9"] = plan9
+
+					b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+					fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "valkey", EngineVersion: "7.2.4"}, nil)
+				})
+
+				It("rejects plans of the engine it was migrated from", func() {
+					validUpdateDetails.PlanID = "plan5"
+
+					_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+					Expect(err).To(MatchError("changing plans is not supported between different engines"))
+
+					Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+				})
+
+				It("changes to plans of the engine it was migrated to", func() {
+					validUpdateDetails.PlanID = "plan9"
+
+					_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+					_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+					Expect(params.CacheNodeType).To(Equal("m5.large"))
+					Expect(params.EngineVersion).To(BeEmpty())
+				})
+			})
+
+			It("rejects changing between cluster mode enabled and disabled plans", func() {
+				validUpdateDetails.PlanID = "plan3"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("changing plans is not supported between cluster mode enabled and disabled plans"))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("rejects changing between plans with a different number of cache nodes", func() {
+				plan5 := validConfig.PlanConfigs["plan5"]
+				plan5.NumCacheNodes = 3
+				validConfig.PlanConfigs["plan5"] = plan5
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.PlanID = "plan5"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("changing plans is not supported between plans with a different number of cache nodes"))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("rejects a test failover at the same time", func() {
+				validUpdateDetails.PlanID = "plan5"
+				validUpdateDetails.RawParameters = []byte(`{"test_failover": true}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Test failover must be used by itself"))
+			})
+
+			It("returns an error if the provider fails", func() {
+				validUpdateDetails.PlanID = "plan5"
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing plan failed: some-error"))
+			})
+		})
+
+		Context("when changing the daily backup window", func() {
+			BeforeEach(func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.SnapshotRetentionLimit = 7
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{
+					PreferredMaintenanceWindow: "tue:05:00-tue:07:00",
+					DailyBackupWindow:          "02:00-03:00",
+				}, nil)
+			})
+
+			It("updates the redis replication group through the Provider", func() {
+				validUpdateDetails.RawParameters = []byte(`{"daily_backup_window": "03:00-04:00"}`)
+
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					DailyBackupWindow: "03:00-04:00",
+				}))
+				Expect(spec.OperationData).To(Equal(broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating})))
+			})
+
+			It("rejects a window overlapping the current maintenance window", func() {
+				validUpdateDetails.RawParameters = []byte(`{"daily_backup_window": "06:00-07:00"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("daily_backup_window 06:00-07:00 overlaps with preferred_maintenance_window tue:05:00-tue:07:00"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("checks against the new maintenance window if both are changed", func() {
+				validUpdateDetails.RawParameters = []byte(`{"daily_backup_window": "06:00-07:00", "preferred_maintenance_window": "tue:01:00-tue:02:00"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.GetInstanceParametersCallCount()).To(Equal(0))
+			})
+
+			It("rejects a maintenance window overlapping the current backup window", func() {
+				validUpdateDetails.RawParameters = []byte(`{"preferred_maintenance_window": "wed:02:30-wed:03:30"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("daily_backup_window 02:00-03:00 overlaps with preferred_maintenance_window wed:02:30-wed:03:30"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("doesn't check the maintenance window against the backup window if the plan has no backups", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+				validUpdateDetails.RawParameters = []byte(`{"preferred_maintenance_window": "wed:02:30-wed:03:30"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.GetInstanceParametersCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params.PreferredMaintenanceWindow).To(Equal("wed:02:30-wed:03:30"))
+			})
+
+			It("is rejected if the plan has no backups", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+				validUpdateDetails.RawParameters = []byte(`{"daily_backup_window": "03:00-04:00"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("daily_backup_window can not be set as the plan has no backups"))
+			})
+
+			It("returns an error if the provider fails", func() {
+				validUpdateDetails.RawParameters = []byte(`{"daily_backup_window": "03:00-04:00"}`)
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Updating daily backup window failed: some-error"))
+			})
+		})
+
+		Context("when rotating the auth token", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"rotate_auth_token": true}`)
+			})
+
+			It("rotates the auth token through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.RotateAuthTokenCallCount()).To(Equal(1))
+				_, id := fakeProvider.RotateAuthTokenArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync:       true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionRotatingToken}),
+				}))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"rotate_auth_token": true, "maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Rotating the auth token must be done by itself"))
+				Expect(fakeProvider.RotateAuthTokenCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("can only be set to true", func() {
+				validUpdateDetails.RawParameters = []byte(`{"rotate_auth_token": false}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("rotate_auth_token can only be set to true"))
+				Expect(fakeProvider.RotateAuthTokenCallCount()).To(Equal(0))
+			})
+
+			It("is not supported for plans with per binding users", func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.PerBindingUsers = true
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Rotating the auth token is not supported for plans with per binding users"))
+				Expect(fakeProvider.RotateAuthTokenCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.RotateAuthTokenReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Rotating the auth token failed: some-error"))
+			})
+
+			It("finishes the rotation with the old auth token if the new one couldn't be stored", func() {
+				fakeProvider.RotateAuthTokenReturns(fmt.Errorf("%w: some-error", providers.ErrAuthTokenNotStored))
+
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync:       true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionRotatingToken, AuthTokenNotStored: true}),
+				}))
+			})
+		})
+
+		Context("when creating a snapshot", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"create_snapshot": true}`)
+
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.MaxManualSnapshots = 2
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("creates a snapshot through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.CreateSnapshotCallCount()).To(Equal(1))
+				_, id, snapshotName := fakeProvider.CreateSnapshotArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(snapshotName).To(MatchRegexp(`^manual-instanceid-\d{14}$`))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:       broker.ActionSnapshotting,
+						SnapshotName: snapshotName,
+					}),
+				}))
+			})
+
+			It("ignores automated snapshots when counting the snapshots", func() {
+				fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+					{Name: "manual-1", Source: "manual"},
+					{Name: "automatic-1", Source: "automated"},
+					{Name: "automatic-2", Source: "automated"},
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.CreateSnapshotCallCount()).To(Equal(1))
+			})
+
+			It("enforces the maximum number of manual snapshots of the plan", func() {
+				fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+					{Name: "manual-1", Source: "manual"},
+					{Name: "manual-2", Source: "manual"},
+				}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("The plan allows at most 2 manual snapshots"))
+				Expect(fakeProvider.CreateSnapshotCallCount()).To(Equal(0))
+			})
+
+			It("is not supported if the plan doesn't allow manual snapshots", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Manual snapshots are not supported for this plan"))
+				Expect(fakeProvider.CreateSnapshotCallCount()).To(Equal(0))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"create_snapshot": true, "preferred_maintenance_window": "mon:23:00-tue:01:30"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Creating a snapshot must be done by itself"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.CreateSnapshotReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Creating a snapshot failed: some-error"))
+			})
+		})
+
+		Context("when updating redis parameters", func() {
+			BeforeEach(func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.UserSettableParameters = map[string]broker.UserSettableParameter{
+					"maxmemory-samples": {MinValue: aws.Int64(1), MaxValue: aws.Int64(64)},
+					"appendfsync":       {AllowedValues: []string{"always", "everysec", "no"}},
+				}
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("updates the parameter group together with the maxmemory policy", func() {
+				validUpdateDetails.RawParameters = []byte(`{"redis_parameters": {"maxmemory-samples": 10}, "maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateParamGroupParametersArgsForCall(0)
+				Expect(params).To(Equal(providers.UpdateParamGroupParameters{
+					Parameters: map[string]string{
+						"maxmemory-samples": "10",
+						"maxmemory-policy":  "noeviction",
+					},
+				}))
+			})
+
+			It("rejects parameters which are not allowed for the plan", func() {
+				validUpdateDetails.RawParameters = []byte(`{"redis_parameters": {"timeout": 0}}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("unknown parameter: redis_parameters.timeout, allowed parameters are: appendfsync, maxmemory-samples"))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("rejects invalid values", func() {
+				validUpdateDetails.RawParameters = []byte(`{"redis_parameters": {"appendfsync": "sometimes"}}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("redis_parameters.appendfsync must be one of: always, everysec, no"))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				validUpdateDetails.RawParameters = []byte(`{"redis_parameters": {"appendfsync": "no"}}`)
+				fakeProvider.UpdateParamGroupParametersReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Updating redis parameters failed: some-error"))
+			})
+		})
+
+		Context("when upgrading the engine version", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"engine_version": "7.1"}`)
+
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.EngineVersionUpgrades = map[string]string{"7.1": "redis7"}
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("upgrades the engine version through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpgradeEngineVersionArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				expectOperationTag(params.Tags, broker.ActionUpgrading)
+				Expect(params).To(Equal(providers.UpgradeEngineVersionParameters{
+					EngineVersion:             "7.1",
+					CacheParameterGroupFamily: "redis7",
+					Tags:                      map[string]string{},
+				}))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:        broker.ActionUpgrading,
+						EngineVersion: "7.1",
+					}),
+				}))
+			})
+
+			It("only allows the versions listed for the plan", func() {
+				validUpdateDetails.RawParameters = []byte(`{"engine_version": "7.0"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("engine_version must be one of: 7.1"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+			})
+
+			It("is not supported if the plan doesn't list any versions", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Upgrading to engine version 7.1 is not supported for this plan"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+			})
+
+			It("is not supported once the instance was migrated to another engine", func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.EngineMigrations = map[string]broker.EngineMigration{
+					"valkey": {EngineVersion: "7.2", CacheParameterGroupFamily: "valkey7"},
+				}
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "valkey", EngineVersion: "7.2.4"}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Upgrading to engine version 7.1 is not supported for this plan, the instance was migrated to valkey"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"engine_version": "7.1", "maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Upgrading the engine version must be done by itself"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.UpgradeEngineVersionReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Upgrading the engine version failed: some-error"))
+			})
+
+			It("responds with a 400 if the target version isn't newer than the current version", func() {
+				fakeProvider.UpgradeEngineVersionReturns(providers.NotSupportedError("Engine version 7.1 is not newer than the current version 7.1.0"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Upgrading the engine version failed: Engine version 7.1 is not newer than the current version 7.1.0"))
+				failureResponse, ok := err.(*brokerapi.FailureResponse)
+				Expect(ok).To(BeTrue())
+				Expect(failureResponse.ValidatedStatusCode(nil)).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when migrating the engine", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"migrate_engine": "valkey"}`)
+
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.EngineMigrations = map[string]broker.EngineMigration{
+					"valkey": {EngineVersion: "7.2", CacheParameterGroupFamily: "valkey7"},
+				}
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "redis", EngineVersion: "7.1.0"}, nil)
+			})
+
+			It("migrates the instance through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpgradeEngineVersionArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				expectOperationTag(params.Tags, broker.ActionMigratingEngine)
+				Expect(params).To(Equal(providers.UpgradeEngineVersionParameters{
+					Engine:                    "valkey",
+					EngineVersion:             "7.2",
+					CacheParameterGroupFamily: "valkey7",
+					Tags:                      map[string]string{},
+				}))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:        broker.ActionMigratingEngine,
+						Engine:        "valkey",
+						EngineVersion: "7.2",
+					}),
+				}))
+			})
+
+			It("is not supported if the plan doesn't allow it", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Migrating to valkey is not supported for this plan"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+			})
+
+			It("only migrates Redis 7 instances", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "redis", EngineVersion: "6.2.6"}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Only Redis 7 instances can be migrated to valkey, the instance runs redis 6.2.6"))
+				Expect(fakeProvider.UpgradeEngineVersionCallCount()).To(Equal(0))
+			})
+
+			It("doesn't migrate instances which already use the engine", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "valkey", EngineVersion: "7.2.6"}, nil)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("The instance already uses valkey"))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"migrate_engine": "valkey", "maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Migrating the engine must be done by itself"))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.UpgradeEngineVersionReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Migrating the engine failed: some-error"))
+			})
+		})
+
+		Context("when changing the number of replicas", func() {
+			BeforeEach(func() {
+				validUpdateDetails.RawParameters = []byte(`{"replicas_per_node_group": 3}`)
+
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.MinReplicasPerNodeGroup = 1
+				plan1.MaxReplicasPerNodeGroup = 4
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("changes the number of replicas through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					ReplicasPerNodeGroup: aws.Int64(3),
+				}))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:               broker.ActionChangingReplicas,
+						ReplicasPerNodeGroup: aws.Int64(3),
+					}),
+				}))
+			})
+
+			It("is not supported if the plan has no limits", func() {
+				validUpdateDetails.PlanID = "plan2"
+				validUpdateDetails.PreviousValues.PlanID = "plan2"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the number of replicas is not supported for this plan"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("rejects a number of replicas outside the limits of the plan", func() {
+				validUpdateDetails.RawParameters = []byte(`{"replicas_per_node_group": 5}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("replicas_per_node_group must be at most 4"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("keeps a replica for automatic failover", func() {
+				plan1 := validConfig.PlanConfigs["plan1"]
+				plan1.MinReplicasPerNodeGroup = 0
+				validConfig.PlanConfigs["plan1"] = plan1
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				validUpdateDetails.RawParameters = []byte(`{"replicas_per_node_group": 0}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Plans with automatic failover or Multi-AZ require at least one replica"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"replicas_per_node_group": 3, "maxmemory_policy": "noeviction"}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the number of replicas must be done by itself"))
+				Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the number of replicas failed: some-error"))
+			})
+		})
+
+		Context("when changing the shard count", func() {
+			BeforeEach(func() {
+				validUpdateDetails.PlanID = "plan3"
+				validUpdateDetails.PreviousValues.PlanID = "plan3"
+				validUpdateDetails.RawParameters = []byte(`{"shard_count": 3}`)
+
+				plan3 := validConfig.PlanConfigs["plan3"]
+				plan3.MinShardCount = 1
+				plan3.MaxShardCount = 4
+				validConfig.PlanConfigs["plan3"] = plan3
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("reshards the instance through the Provider", func() {
+				spec, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					ShardCount: aws.Int64(3),
+				}))
+
+				Expect(spec).To(Equal(brokerapi.UpdateServiceSpec{
+					IsAsync: true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:     broker.ActionResharding,
+						ShardCount: aws.Int64(3),
+					}),
+				}))
+			})
+
+			It("is only supported for cluster mode plans", func() {
+				validUpdateDetails.PlanID = "plan1"
+				validUpdateDetails.PreviousValues.PlanID = "plan1"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the shard count is only supported for plans with cluster mode enabled"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("is not supported if the plan has no limits", func() {
+				validUpdateDetails.PlanID = "plan4"
+				validUpdateDetails.PreviousValues.PlanID = "plan4"
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the shard count is not supported for this plan"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("rejects a shard count outside the limits of the plan", func() {
+				validUpdateDetails.RawParameters = []byte(`{"shard_count": 5}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("shard_count must be at most 4"))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("must be used by itself", func() {
+				validUpdateDetails.RawParameters = []byte(`{"shard_count": 3, "replicas_per_node_group": 2}`)
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the provider fails", func() {
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some-error"))
+
+				_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+				Expect(err).To(MatchError("Changing the shard count failed: some-error"))
+			})
+		})
+
+		It("should return error when attempting to change service id", func() {
+			validUpdateDetails.ServiceID = "service2"
+
+			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).To(MatchError("changing the service is not supported"))
+
+			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(0))
+		})
+
+		It("sets a deadline by which the Provider request should complete", func() {
+			validUpdateDetails.RawParameters = []byte(`{"maxmemory_policy": "noeviction"}`)
+
+			_, err := b.Update(context.Background(), "instanceid", validUpdateDetails, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeProvider.UpdateParamGroupParametersCallCount()).To(Equal(1))
+			receivedContext, _, _ := fakeProvider.UpdateParamGroupParametersArgsForCall(0)
+
+			_, hasDeadline := receivedContext.Deadline()
+
+			Expect(hasDeadline).To(BeTrue())
+		})
+	})
+
+	Describe("Deprovision", func() {
+		var validDeprovisionDetails brokerapi.DeprovisionDetails
+
+		BeforeEach(func() {
+			validDeprovisionDetails = brokerapi.DeprovisionDetails{
+				PlanID:    "myplan-id",
+				ServiceID: "service1",
+			}
+		})
+
+		It("logs a debug message when deprovisioning begins", func() {
+			logger := lager.NewLogger("logger")
+			log := gbytes.NewBuffer()
+			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
+			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
 
 			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{}, true)
 
-			Expect(log).To(gbytes.Say("deprovision-start"))
+			Expect(log).To(gbytes.Say("deprovision-start"))
+		})
+
+		It("errors if async isn't allowed", func() {
+			b := broker.New(broker.Config{}, &mocks.FakeProvider{}, lager.NewLogger("logger"))
+			asyncAllowed := false
+
+			_, err := b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{}, asyncAllowed)
+
+			Expect(err).To(MatchError("This service plan requires client support for asynchronous service operations."))
+		})
+
+		It("sets a deadline by which the AWS request should complete", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			logger := lager.NewLogger("logger")
+			b := broker.New(validConfig, fakeProvider, logger)
+
+			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{ServiceID: "service1"}, true)
+
+			Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
+			receivedContext, _, _ := fakeProvider.DeprovisionArgsForCall(0)
+
+			_, hasDeadline := receivedContext.Deadline()
+
+			Expect(hasDeadline).To(BeTrue())
+		})
+
+		It("passes the correct parameters to the Provider", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+
+			Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
+			_, instanceID, params := fakeProvider.DeprovisionArgsForCall(0)
+
+			expectedParams := providers.DeprovisionParameters{
+				FinalSnapshotIdentifier: "final-instanceid",
+			}
+
+			Expect(instanceID).To(Equal("instanceid"))
+			Expect(params).To(Equal(expectedParams))
+		})
+
+		It("does not take a final snapshot if the plan disables it", func() {
+			plan1 := validConfig.PlanConfigs["plan1"]
+			plan1.DisableFinalSnapshot = true
+			validConfig.PlanConfigs["plan1"] = plan1
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{ServiceID: "service1", PlanID: "plan1"}, true)
+
+			_, _, params := fakeProvider.DeprovisionArgsForCall(0)
+			Expect(params.FinalSnapshotIdentifier).To(BeEmpty())
+		})
+
+		It("does not take a final snapshot if the broker disables it", func() {
+			validConfig.DisableFinalSnapshots = true
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{ServiceID: "service1", PlanID: "plan1"}, true)
+
+			_, _, params := fakeProvider.DeprovisionArgsForCall(0)
+			Expect(params.FinalSnapshotIdentifier).To(BeEmpty())
+		})
+
+		It("does not take a final snapshot if the instance failed to be created", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: providers.CreateFailed}, nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, params := fakeProvider.DeprovisionArgsForCall(0)
+			Expect(params.FinalSnapshotIdentifier).To(BeEmpty())
+		})
+
+		It("errors if deprovisioning fails", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			fakeProvider.DeprovisionReturns(errors.New("foobar"))
+
+			_, err := b.Deprovision(context.Background(), "myinstance", validDeprovisionDetails, true)
+
+			Expect(err).To(MatchError("provider redis for plan myplan-id: foobar"))
+		})
+
+		Context("when another operation is in progress", func() {
+			var fakeProvider *mocks.FakeProvider
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+			})
+
+			It("returns a ConcurrencyError if the instance is being modified", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: providers.Modifying}, nil)
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+				Expect(err).To(MatchError("The instance is modifying, try again when the current operation has finished"))
+				failureResponse, ok := err.(*brokerapi.FailureResponse)
+				Expect(ok).To(BeTrue())
+				Expect(failureResponse.ValidatedStatusCode(nil)).To(Equal(http.StatusUnprocessableEntity))
+				Expect(fakeProvider.DeprovisionCallCount()).To(Equal(0))
+			})
+
+			It("deletes the instance while the tag of a multi-step operation is set", func() {
+				fakeProvider.FindInstanceReturns(&providers.ExistingInstance{
+					State:     providers.Available,
+					Operation: broker.ActionUpgrading,
+				}, nil)
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
+			})
+
+			It("deletes instances which are being deleted or failed to be created", func() {
+				for _, state := range []providers.ServiceState{providers.Deleting, providers.CreateFailed} {
+					fakeProvider = &mocks.FakeProvider{}
+					fakeProvider.FindInstanceReturns(&providers.ExistingInstance{State: state}, nil)
+					b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+					_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
+				}
+			})
+		})
+
+		Context("when the instance doesn't exist", func() {
+			var fakeProvider *mocks.FakeProvider
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.DeprovisionReturns(providers.ErrInstanceNotFound)
+			})
+
+			It("returns ErrInstanceDoesNotExist", func() {
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+			})
+
+			It("deletes the parameter group and the user group of the instance", func() {
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+
+				Expect(fakeProvider.DeleteCacheParameterGroupCallCount()).To(Equal(1))
+				_, instanceID := fakeProvider.DeleteCacheParameterGroupArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+				Expect(fakeProvider.DeleteUserGroupCallCount()).To(Equal(1))
+				_, instanceID = fakeProvider.DeleteUserGroupArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+			})
+
+			It("errors if deleting the parameter group fails", func() {
+				fakeProvider.DeleteCacheParameterGroupReturns(errors.New("some error"))
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+				Expect(err).To(MatchError("error deleting parameter group instanceid: some error"))
+			})
+		})
+
+		It("logs a debug message when deprovisioning succeeds", func() {
+			logger := lager.NewLogger("logger")
+			log := gbytes.NewBuffer()
+			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
+			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
+
+			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{ServiceID: "service1"}, true)
+
+			Expect(log).To(gbytes.Say("deprovision-success"))
+		})
+
+		It("returns the deprovisioned service spec", func() {
+			b := broker.New(validConfig, &mocks.FakeProvider{}, lager.NewLogger("logger"))
+			Expect(b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)).
+				To(Equal(brokerapi.DeprovisionServiceSpec{
+					IsAsync:       true,
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionDeprovisioning}),
+				}))
+		})
+	})
+
+	Describe("LastOperation", func() {
+		It("returns last operation data when the instance is available", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			Expect(b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning})})).
+				To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Succeeded,
+					Description: "i love brokers",
+				}))
+		})
+
+		It("sets a deadline by which the AWS request should complete", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			logger := lager.NewLogger("logger")
+			b := broker.New(validConfig, fakeProvider, logger)
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning})})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeProvider.ProgressStateCallCount()).To(Equal(1))
+			receivedContext, _, _, _ := fakeProvider.ProgressStateArgsForCall(0)
+
+			_, hasDeadline := receivedContext.Deadline()
+
+			Expect(hasDeadline).To(BeTrue())
 		})
 
-		It("errors if async isn't allowed", func() {
-			b := broker.New(broker.Config{}, &mocks.FakeProvider{}, lager.NewLogger("logger"))
-			asyncAllowed := false
+		It("fails an auth token rotation once it has finished if the new auth token couldn't be stored", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			Expect(b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionRotatingToken, AuthTokenNotStored: true})})).
+				To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: "The new auth token couldn't be stored, the rotation was finished with the old auth token",
+				}))
+		})
+
+		It("fails an auth token rotation which has timed out", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.AuthTokenRotationFailed, "The auth token rotation didn't finish within 1h0m0s", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			Expect(b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionRotatingToken})})).
+				To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: "The auth token rotation didn't finish within 1h0m0s",
+				}))
+		})
+
+		It("logs a debug message when starting to get the last operation", func() {
+			logger := lager.NewLogger("logger")
+			log := gbytes.NewBuffer()
+			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
+			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
+
+			b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning})})
+
+			Expect(log).To(gbytes.Say("last-operation"))
+		})
+
+		It("errors if getting the state fails", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			fakeProvider.ProgressStateReturns("", "", errors.New("foobar"))
+
+			_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionProvisioning})})
+
+			Expect(err).To(MatchError("error getting state for myinstance: foobar"))
+		})
+
+		It("accepts empty operation data temporarily", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: ""})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if last operation data is not json", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: "I am not JSON"})
+			Expect(err).To(MatchError("invalid operation data: I am not JSON"))
+		})
+
+		It("accepts signed operation data", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Modifying, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			operationData := broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+				Action:      broker.ActionFailover,
+				PrimaryNode: "primarynode",
+			})
+			lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: operationData})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+
+			_, _, action, primaryNode := fakeProvider.ProgressStateArgsForCall(0)
+			Expect(action).To(Equal(broker.ActionFailover))
+			Expect(primaryNode).To(Equal("primarynode"))
+		})
+
+		It("returns an error if the signature of the operation data is invalid", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			operationData := broker.SignOperation("forged-key", "instanceid", broker.Operation{
+				Action:      broker.ActionFailover,
+				PrimaryNode: "primarynode",
+			})
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: operationData})
+			Expect(err).To(MatchError(HavePrefix("invalid operation data signature")))
+			Expect(fakeProvider.ProgressStateCallCount()).To(Equal(0))
+		})
+
+		It("rejects unsigned operation data", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data is not accepted")))
+			Expect(fakeProvider.ProgressStateCallCount()).To(Equal(0))
+		})
+
+		Context("while unsigned operation data is accepted", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+			)
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				config := validConfig
+				config.AcceptUnsignedOperationDataUntil = time.Now().Add(time.Hour)
+				b = broker.New(config, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("accepts unsigned operation data of older broker versions", func() {
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+			})
+
+			It("accepts the unsigned operation data of a failover of older broker versions", func() {
+				timeOut := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: `{"action": "failover", "primaryNode": "primarynode", "timeOut": "` + timeOut + `"}`,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+
+				_, _, action, primaryNode := fakeProvider.ProgressStateArgsForCall(0)
+				Expect(action).To(Equal(broker.ActionFailover))
+				Expect(primaryNode).To(Equal("primarynode"))
+			})
+
+			It("rejects a forged unsigned payload", func() {
+				_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: `{"action": "failover", "primaryNode": "cf-someoneelse-001", "timeOut": "2099-01-01T00:00:00Z"}`,
+				})
+				Expect(err).To(MatchError(HavePrefix("unsigned operation data can't extend the time out of a failover")))
+				Expect(fakeProvider.ProgressStateCallCount()).To(Equal(0))
+			})
+
+			It("rejects unsigned operation data once the time has passed", func() {
+				config := validConfig
+				config.AcceptUnsignedOperationDataUntil = time.Now().Add(-time.Minute)
+				b = broker.New(config, fakeProvider, lager.NewLogger("logger"))
+
+				_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+				Expect(err).To(MatchError(HavePrefix("unsigned operation data is not accepted")))
+			})
+		})
+
+		It("returns an error if the version of the operation data is unknown", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: "v2.e30.c2lnbmF0dXJl"})
+			Expect(err).To(MatchError("unsupported operation data version: v2"))
+			Expect(fakeProvider.ProgressStateCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if last operation data does not contain an action", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+				OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{}),
+			})
+			Expect(err).To(MatchError(HavePrefix("invalid operation, action parameter is empty")))
+		})
+
+		Context("When provisioning", func() {
+			It("returns ErrInstanceDoesNotExist when instance does not exist", func() {
+				fakeProvider := &mocks.FakeProvider{}
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+
+				_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionProvisioning})})
+				Expect(fakeProvider.DeleteCacheParameterGroupCallCount()).To(Equal(0))
+				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+			})
+		})
+
+		Context("When deprovisioning", func() {
+			It("deletes the cache parameter group if the instance doesn't exist and returns ErrInstanceDoesNotExist", func() {
+				fakeProvider := &mocks.FakeProvider{}
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+				ctx := context.Background()
+				_, err := b.LastOperation(ctx, "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionDeprovisioning})})
+
+				Expect(fakeProvider.DeleteCacheParameterGroupCallCount()).To(Equal(1))
+				receivedContext, receivedInstanceID := fakeProvider.DeleteCacheParameterGroupArgsForCall(0)
+				_, hasDeadline := receivedContext.Deadline()
+				Expect(hasDeadline).To(BeTrue())
+				Expect(receivedInstanceID).To(Equal("myinstance"))
+				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+			})
+
+			It("deletes the user group if the instance doesn't exist", func() {
+				fakeProvider := &mocks.FakeProvider{}
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+				_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionDeprovisioning})})
+
+				Expect(fakeProvider.DeleteUserGroupCallCount()).To(Equal(1))
+				_, receivedInstanceID := fakeProvider.DeleteUserGroupArgsForCall(0)
+				Expect(receivedInstanceID).To(Equal("myinstance"))
+				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+			})
+
+			It("returns an error if deleting the user group fails", func() {
+				fakeProvider := &mocks.FakeProvider{}
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+				fakeProvider.DeleteUserGroupReturns(errors.New("this is an error"))
+				_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionDeprovisioning})})
+
+				Expect(err).To(MatchError("error deleting user group myinstance: this is an error"))
+			})
+
+			It("returns an error if deleting the cache parameter group fails", func() {
+				fakeProvider := &mocks.FakeProvider{}
+				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+				deleteError := errors.New("this is an error")
+				fakeProvider.DeleteCacheParameterGroupReturns(deleteError)
+				ctx := context.Background()
+				_, err := b.LastOperation(ctx, "myinstance", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "myinstance", broker.Operation{Action: broker.ActionDeprovisioning})})
+
+				Expect(err).To(MatchError("error deleting parameter group myinstance: this is an error"))
+			})
+		})
+
+		It("logs an error and reports 'in progress' if it receives an unknown state from the provider", func() {
+			logger := lager.NewLogger("logger")
+			log := gbytes.NewBuffer()
+			logger.RegisterSink(lager.NewWriterSink(log, lager.ERROR))
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.ProgressStateReturns("some-unknown-state", "", nil)
+			b := broker.New(validConfig, fakeProvider, logger)
+
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning})})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log).To(gbytes.Say("Unknown service state: some-unknown-state"))
+		})
+
+		Context("When changing plans", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+			)
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "all good", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			})
+
+			It("changes the number of replicas once the instance is available", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{ReplicasPerNodeGroup: 0}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:               broker.ActionChangingPlan,
+						ReplicasPerNodeGroup: aws.Int64(1),
+						PlanID:               "plan1",
+					}),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, id, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					ReplicasPerNodeGroup: aws.Int64(1),
+				}))
+			})
+
+			It("updates the plan tag once the instance matches the plan", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{ReplicasPerNodeGroup: 1}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:               broker.ActionChangingPlan,
+						ReplicasPerNodeGroup: aws.Int64(1),
+						PlanID:               "plan1",
+					}),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					Tags: map[string]string{"plan-id": "plan1", providers.OperationInProgressTag: ""},
+				}))
+			})
+
+			It("doesn't update the plan tag while the instance is being modified", func() {
+				fakeProvider.ProgressStateReturns(providers.Modifying, "modifying", nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action: broker.ActionChangingPlan,
+						PlanID: "plan1",
+					}),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the replica count change fails", func() {
+				fakeProvider.UpdateReplicationGroupReturns(errors.New("some-error"))
+
+				_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:               broker.ActionChangingPlan,
+						ReplicasPerNodeGroup: aws.Int64(1),
+						PlanID:               "plan1",
+					}),
+				})
+				Expect(err).To(MatchError("error changing plan for instanceid: some-error"))
+			})
+		})
+
+		Context("When creating a snapshot", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+			)
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionSnapshotting, SnapshotName: "snapshot-1"}),
+				}
+			})
+
+			It("is in progress until the snapshot exists", func() {
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+			})
+
+			It("is in progress while the snapshot is being created", func() {
+				fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+					{Name: "snapshot-1", Status: "creating"},
+				}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+			})
+
+			It("succeeds once the snapshot is available", func() {
+				fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+					{Name: "snapshot-0", Status: "failed"},
+					{Name: "snapshot-1", Status: "available"},
+				}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+			})
+
+			It("fails if the snapshot failed", func() {
+				fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+					{Name: "snapshot-1", Status: "failed"},
+				}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Failed))
+			})
+
+			It("does not check the snapshot while the instance is still snapshotting", func() {
+				fakeProvider.ProgressStateReturns(providers.Snapshotting, "i love brokers", nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+				Expect(fakeProvider.ListSnapshotsCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if listing the snapshots fails", func() {
+				fakeProvider.ListSnapshotsReturns(nil, errors.New("some-error"))
+
+				_, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).To(MatchError("error getting snapshot state for instanceid: some-error"))
+			})
+		})
+
+		Context("When upgrading the engine version", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+			)
+
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpgrading, EngineVersion: "7.1"}),
+				}
+			})
+
+			It("is in progress until the instance runs the new version", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{EngineVersion: "6.2.6", PendingEngineVersion: "7.1.0"}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("fails and clears the operation in progress if AWS rolled the upgrade back", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{EngineVersion: "6.2.6"}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Failed))
+				Expect(lastOperation.Description).To(Equal("The upgrade to 7.1 was rolled back by AWS, the instance runs the old version"))
+
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(1))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					Tags: map[string]string{providers.OperationInProgressTag: ""},
+				}))
+			})
+
+			It("deletes the old parameter group once the instance runs the new version", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{EngineVersion: "7.1.0"}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(1))
+				_, id := fakeProvider.DeleteUnusedCacheParameterGroupsArgsForCall(0)
+				Expect(id).To(Equal("instanceid"))
+
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					Tags: map[string]string{providers.OperationInProgressTag: ""},
+				}))
+			})
+
+			It("does not delete anything while the instance is being modified", func() {
+				fakeProvider.ProgressStateReturns(providers.Modifying, "i love brokers", nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(0))
+			})
+
+			It("clears the operation in progress if the upgrade failed", func() {
+				fakeProvider.ProgressStateReturns(providers.CreateFailed, "failed", nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Failed))
 
-			_, err := b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{}, asyncAllowed)
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(0))
+				Expect(fakeProvider.UpdateReplicationGroupCallCount()).To(Equal(1))
+				_, _, params := fakeProvider.UpdateReplicationGroupArgsForCall(0)
+				Expect(params).To(Equal(providers.UpdateReplicationGroupParameters{
+					Tags: map[string]string{providers.OperationInProgressTag: ""},
+				}))
+			})
 
-			Expect(err).To(MatchError("This service plan requires client support for asynchronous service operations."))
+			It("returns an error if deleting the old parameter group fails", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{EngineVersion: "7.1.0"}, nil)
+				fakeProvider.DeleteUnusedCacheParameterGroupsReturns(errors.New("some-error"))
+
+				_, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).To(MatchError("error upgrading engine version for instanceid: some-error"))
+			})
 		})
 
-		It("sets a deadline by which the AWS request should complete", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			logger := lager.NewLogger("logger")
-			b := broker.New(validConfig, fakeProvider, logger)
+		Context("When migrating the engine", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+			)
 
-			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{}, true)
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionMigratingEngine, Engine: "valkey", EngineVersion: "7.2"}),
+				}
+			})
 
-			Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
-			receivedContext, _, _ := fakeProvider.DeprovisionArgsForCall(0)
+			It("is in progress until the instance runs the new engine", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "redis", EngineVersion: "7.1.0", PendingEngineVersion: "7.2"}, nil)
 
-			_, hasDeadline := receivedContext.Deadline()
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(0))
+			})
 
-			Expect(hasDeadline).To(BeTrue())
+			It("deletes the old parameter group once the instance runs the new engine", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{Engine: "valkey", EngineVersion: "7.2.6"}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+				Expect(fakeProvider.DeleteUnusedCacheParameterGroupsCallCount()).To(Equal(1))
+			})
 		})
 
-		It("passes the correct parameters to the Provider", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+		Context("When changing the number of replicas", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+			)
 
-			b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionChangingReplicas, ReplicasPerNodeGroup: aws.Int64(2)}),
+				}
+			})
 
-			Expect(fakeProvider.DeprovisionCallCount()).To(Equal(1))
-			_, instanceID, params := fakeProvider.DeprovisionArgsForCall(0)
+			It("is in progress while the replication group is modifying", func() {
+				fakeProvider.ProgressStateReturns(providers.Modifying, "i love brokers", nil)
 
-			expectedParams := providers.DeprovisionParameters{}
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+			})
 
-			Expect(instanceID).To(Equal("instanceid"))
-			Expect(params).To(Equal(expectedParams))
-		})
+			It("is in progress until the new replicas are passive nodes", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{
+					ReplicasPerNodeGroup: 2,
+					ActiveNodes:          []string{"node-1"},
+					PassiveNodes:         []string{"node-2"},
+				}, nil)
 
-		It("errors if deprovisioning fails", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			fakeProvider.DeprovisionReturns(errors.New("foobar"))
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+			})
 
-			_, err := b.Deprovision(context.Background(), "myinstance", validDeprovisionDetails, true)
+			It("succeeds once every shard has the new number of replicas", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{
+					ReplicasPerNodeGroup: 2,
+					ActiveNodes:          []string{"node-1"},
+					PassiveNodes:         []string{"node-2", "node-3"},
+				}, nil)
 
-			Expect(err).To(MatchError("provider redis for plan myplan-id: foobar"))
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+			})
 		})
 
-		It("logs a debug message when deprovisioning succeeds", func() {
-			logger := lager.NewLogger("logger")
-			log := gbytes.NewBuffer()
-			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
-			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
+		Context("When resharding", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+			)
 
-			b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{}, true)
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionResharding, ShardCount: aws.Int64(3)}),
+				}
+			})
 
-			Expect(log).To(gbytes.Say("deprovision-success"))
-		})
+			It("returns the resharding progress while the replication group is modifying", func() {
+				fakeProvider.ProgressStateReturns(providers.Modifying, "resharding : 42.5% of slots migrated", nil)
 
-		It("returns the deprovisioned service spec", func() {
-			b := broker.New(validConfig, &mocks.FakeProvider{}, lager.NewLogger("logger"))
-			Expect(b.Deprovision(context.Background(), "instanceid", validDeprovisionDetails, true)).
-				To(Equal(brokerapi.DeprovisionServiceSpec{
-					IsAsync:       true,
-					OperationData: broker.Operation{Action: broker.ActionDeprovisioning}.String(),
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation).To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.InProgress,
+					Description: "resharding : 42.5% of slots migrated",
 				}))
-		})
-	})
+			})
 
-	Describe("LastOperation", func() {
-		It("returns last operation data when the instance is available", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			It("is in progress until the instance has the new number of shards", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{ShardCount: 2}, nil)
 
-			Expect(b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})).
-				To(Equal(brokerapi.LastOperation{
-					State:       brokerapi.Succeeded,
-					Description: "i love brokers",
-				}))
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.InProgress))
+			})
+
+			It("succeeds once the instance has the new number of shards", func() {
+				fakeProvider.GetInstanceParametersReturns(providers.InstanceParameters{ShardCount: 3}, nil)
+
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+			})
 		})
 
-		It("sets a deadline by which the AWS request should complete", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			logger := lager.NewLogger("logger")
-			b := broker.New(validConfig, fakeProvider, logger)
+		Context("When testing failover with the TestFailover API", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+				pollDetails  brokerapi.PollDetails
+				startTime    time.Time
+			)
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+				startTime = time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+				pollDetails = brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{
+						Action:      broker.ActionTestingFailover,
+						NodeGroupID: "0001",
+						StartTime:   startTime.Format(time.RFC3339),
+						TimeOut:     startTime.Add(broker.FailoverTimeout).Format(time.RFC3339),
+					}),
+				}
+			})
 
-			Expect(err).NotTo(HaveOccurred())
-			Expect(fakeProvider.ProgressStateCallCount()).To(Equal(1))
-			receivedContext, _, _, _ := fakeProvider.ProgressStateArgsForCall(0)
+			It("returns the state of the failover from the events", func() {
+				fakeProvider.FailoverTestStateReturns(providers.Modifying, "Failover to replica node 0001-002 completed", nil)
 
-			_, hasDeadline := receivedContext.Deadline()
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation).To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.InProgress,
+					Description: "Failover to replica node 0001-002 completed",
+				}))
 
-			Expect(hasDeadline).To(BeTrue())
-		})
+				Expect(fakeProvider.FailoverTestStateCallCount()).To(Equal(1))
+				_, instanceID, nodeGroupID, receivedStartTime := fakeProvider.FailoverTestStateArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+				Expect(nodeGroupID).To(Equal("0001"))
+				Expect(receivedStartTime).To(BeTemporally("==", startTime))
+			})
 
-		It("logs a debug message when starting to get the last operation", func() {
-			logger := lager.NewLogger("logger")
-			log := gbytes.NewBuffer()
-			logger.RegisterSink(lager.NewWriterSink(log, lager.DEBUG))
-			b := broker.New(validConfig, &mocks.FakeProvider{}, logger)
+			It("succeeds once the failover has finished", func() {
+				fakeProvider.FailoverTestStateReturns(providers.Available, "done", nil)
 
-			b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+			})
 
-			Expect(log).To(gbytes.Say("last-operation"))
-		})
+			It("fails if the failover failed", func() {
+				fakeProvider.FailoverTestStateReturns(providers.FailoverFailed, "Failover failed", nil)
 
-		It("errors if getting the state fails", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			fakeProvider.ProgressStateReturns("", "", errors.New("foobar"))
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation).To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: "Failover failed",
+				}))
+			})
 
-			_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+			It("errors if getting the failover state fails", func() {
+				fakeProvider.FailoverTestStateReturns("", "", errors.New("foobar"))
 
-			Expect(err).To(MatchError("error getting state for myinstance: foobar"))
-		})
+				_, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).To(MatchError("error getting failover state for instanceid: foobar"))
+			})
 
-		It("accepts empty operation data temporarily", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			It("returns ErrInstanceDoesNotExist if the instance has been deleted", func() {
+				fakeProvider.ProgressStateReturns(providers.NonExisting, "", nil)
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: ""})
-			Expect(err).ToNot(HaveOccurred())
+				_, err := b.LastOperation(context.Background(), "instanceid", pollDetails)
+				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+				Expect(fakeProvider.FailoverTestStateCallCount()).To(Equal(0))
+			})
 		})
 
-		It("returns an error if last operation data is not json", func() {
+		It("will error if operation is timed out", func() {
+
 			fakeProvider := &mocks.FakeProvider{}
-			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: "I am not JSON"})
-			Expect(err).To(MatchError("invalid operation data: I am not JSON"))
-		})
+			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionUpdating, TimeOut: "2022-12-09T00:00:00Z"})})
 
-		It("returns an error if last operation data does not contain an action", func() {
-			fakeProvider := &mocks.FakeProvider{}
-			fakeProvider.ProgressStateReturns(providers.Available, "i love brokers", nil)
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(fmt.Errorf("Operation updating timed out for instanceid")))
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: "{}"})
-			Expect(err).To(MatchError("invalid operation, action parameter is empty: {}"))
 		})
 
-		Context("When provisioning", func() {
-			It("returns ErrInstanceDoesNotExist when instance does not exist", func() {
-				fakeProvider := &mocks.FakeProvider{}
-				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
+		Context("when a test failover times out", func() {
+			var (
+				fakeProvider *mocks.FakeProvider
+				b            *broker.Broker
+			)
 
-				_, err := b.LastOperation(context.Background(), "myinstance", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
-				Expect(fakeProvider.DeleteCacheParameterGroupCallCount()).To(Equal(0))
-				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+			BeforeEach(func() {
+				fakeProvider = &mocks.FakeProvider{}
+				b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
 			})
-		})
 
-		Context("When deprovisioning", func() {
-			It("deletes the cache parameter group if the instance doesn't exist and returns ErrInstanceDoesNotExist", func() {
-				fakeProvider := &mocks.FakeProvider{}
-				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
-				ctx := context.Background()
-				_, err := b.LastOperation(ctx, "myinstance", brokerapi.PollDetails{OperationData: `{"action": "deprovisioning"}`})
+			It("enables automatic failover and Multi-AZ again and fails", func() {
+				fakeProvider.RestoreHighAvailabilityReturns(true, nil)
 
-				Expect(fakeProvider.DeleteCacheParameterGroupCallCount()).To(Equal(1))
-				receivedContext, receivedInstanceID := fakeProvider.DeleteCacheParameterGroupArgsForCall(0)
-				_, hasDeadline := receivedContext.Deadline()
-				Expect(hasDeadline).To(BeTrue())
-				Expect(receivedInstanceID).To(Equal("myinstance"))
-				Expect(err).To(MatchError(brokerapi.ErrInstanceDoesNotExist))
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionFailover, TimeOut: "2022-12-09T00:00:00Z"})})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation).To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: "Test failover timed out after 45m0s, automatic failover and Multi-AZ have been enabled again",
+				}))
+
+				Expect(fakeProvider.RestoreHighAvailabilityCallCount()).To(Equal(1))
+				_, instanceID := fakeProvider.RestoreHighAvailabilityArgsForCall(0)
+				Expect(instanceID).To(Equal("instanceid"))
+				Expect(fakeProvider.ProgressStateCallCount()).To(Equal(0))
 			})
 
-			It("returns an error if deleting the cache parameter group fails", func() {
-				fakeProvider := &mocks.FakeProvider{}
-				b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-				fakeProvider.ProgressStateReturns(providers.NonExisting, "it'sgoneya'll", nil)
-				deleteError := errors.New("this is an error")
-				fakeProvider.DeleteCacheParameterGroupReturns(deleteError)
-				ctx := context.Background()
-				_, err := b.LastOperation(ctx, "myinstance", brokerapi.PollDetails{OperationData: `{"action": "deprovisioning"}`})
+			It("errors if automatic failover and Multi-AZ can't be enabled yet", func() {
+				fakeProvider.RestoreHighAvailabilityReturns(false, errors.New("Replication group cf-foo is modifying"))
 
-				Expect(err).To(MatchError("error deleting parameter group myinstance: this is an error"))
+				_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionFailover, TimeOut: "2022-12-09T00:00:00Z"})})
+				Expect(err).To(MatchError("Operation failover timed out for instanceid, enabling automatic failover and Multi-AZ failed: Replication group cf-foo is modifying"))
+			})
+
+			It("fails a native test failover without changing the instance", func() {
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionTestingFailover, TimeOut: "2022-12-09T00:00:00Z"})})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation).To(Equal(brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: "Test failover timed out after 45m0s",
+				}))
+				Expect(fakeProvider.RestoreHighAvailabilityCallCount()).To(Equal(0))
 			})
 		})
 
-		It("logs an error and reports 'in progress' if it receives an unknown state from the provider", func() {
-			logger := lager.NewLogger("logger")
-			log := gbytes.NewBuffer()
-			logger.RegisterSink(lager.NewWriterSink(log, lager.ERROR))
-			fakeProvider := &mocks.FakeProvider{}
-			fakeProvider.ProgressStateReturns("some-unknown-state", "", nil)
-			b := broker.New(validConfig, fakeProvider, logger)
+	})
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "provisioning"}`})
+	Describe("RestoreHighAvailability", func() {
+		var (
+			fakeProvider *mocks.FakeProvider
+			b            *broker.Broker
+		)
 
-			Expect(err).NotTo(HaveOccurred())
-			Expect(log).To(gbytes.Say("Unknown service state: some-unknown-state"))
+		BeforeEach(func() {
+			fakeProvider = &mocks.FakeProvider{}
+			b = broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
 		})
 
-		It("will error if operation is timed out", func() {
+		It("enables automatic failover and Multi-AZ of the instances of plans with high availability", func() {
+			fakeProvider.FindDegradedInstancesReturns([]providers.DegradedInstance{
+				{InstanceID: "instance1", Tags: map[string]string{"plan-id": "plan1"}},
+				{InstanceID: "instance2", Tags: map[string]string{"plan-id": "plan4"}},
+				{InstanceID: "instance3", Tags: map[string]string{"plan-id": "unknown"}},
+			}, nil)
 
-			fakeProvider := &mocks.FakeProvider{}
-			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			b.RestoreHighAvailability(context.Background())
 
-			_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{OperationData: `{"action": "failover", "timeOut": "2022-12-09T00:00:00Z"}`})
+			Expect(fakeProvider.FindDegradedInstancesCallCount()).To(Equal(1))
+			_, brokerName := fakeProvider.FindDegradedInstancesArgsForCall(0)
+			Expect(brokerName).To(Equal(validConfig.BrokerName))
 
-			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError(fmt.Errorf("Operation failover timed out for instanceid")))
+			Expect(fakeProvider.RestoreHighAvailabilityCallCount()).To(Equal(1))
+			_, instanceID := fakeProvider.RestoreHighAvailabilityArgsForCall(0)
+			Expect(instanceID).To(Equal("instance1"))
+		})
+
+		It("leaves instances with a test failover in progress alone", func() {
+			fakeProvider.FindDegradedInstancesReturns([]providers.DegradedInstance{
+				{InstanceID: "instance1", Tags: map[string]string{
+					"plan-id":                        "plan1",
+					providers.FailoverTestStartedTag: time.Now().Add(-10 * time.Minute).Format(time.RFC3339),
+				}},
+				{InstanceID: "instance2", Tags: map[string]string{
+					"plan-id":                        "plan1",
+					providers.FailoverTestStartedTag: time.Now().Add(-time.Hour).Format(time.RFC3339),
+				}},
+			}, nil)
+
+			b.RestoreHighAvailability(context.Background())
+
+			Expect(fakeProvider.RestoreHighAvailabilityCallCount()).To(Equal(1))
+			_, instanceID := fakeProvider.RestoreHighAvailabilityArgsForCall(0)
+			Expect(instanceID).To(Equal("instance2"))
+		})
 
+		It("carries on if an instance can't be repaired", func() {
+			fakeProvider.FindDegradedInstancesReturns([]providers.DegradedInstance{
+				{InstanceID: "instance1", Tags: map[string]string{"plan-id": "plan1"}},
+				{InstanceID: "instance2", Tags: map[string]string{"plan-id": "plan1"}},
+			}, nil)
+			fakeProvider.RestoreHighAvailabilityReturnsOnCall(0, false, errors.New("foobar"))
+
+			b.RestoreHighAvailability(context.Background())
+
+			Expect(fakeProvider.RestoreHighAvailabilityCallCount()).To(Equal(2))
 		})
 
+		It("checks every provider once", func() {
+			otherProvider := &mocks.FakeProvider{}
+			validConfig.Catalog.Services = append(validConfig.Catalog.Services,
+				brokerapi.Service{ID: "service2"},
+				brokerapi.Service{ID: "service3"},
+			)
+			b = broker.NewWithProviders(validConfig, map[string]providers.Provider{
+				"service1": fakeProvider,
+				"service2": otherProvider,
+				"service3": fakeProvider,
+			}, lager.NewLogger("logger"))
+
+			b.RestoreHighAvailability(context.Background())
+
+			Expect(fakeProvider.FindDegradedInstancesCallCount()).To(Equal(1))
+			Expect(otherProvider.FindDegradedInstancesCallCount()).To(Equal(1))
+		})
 	})
 
 	Describe("state mapping from AWS to brokerapi package", func() {
@@ -1070,6 +3352,7 @@ var _ = Describe("Broker", func() {
 			},
 			Entry("available => succeeded", providers.Available, brokerapi.Succeeded),
 			Entry("create-failed => failure", providers.CreateFailed, brokerapi.Failed),
+			Entry("auth-token-rotation-failed => failure", providers.AuthTokenRotationFailed, brokerapi.Failed),
 			Entry("creating => in progress", providers.Creating, brokerapi.InProgress),
 			Entry("modifying => in progress", providers.Modifying, brokerapi.InProgress),
 			Entry("deleting => in progress", providers.Deleting, brokerapi.InProgress),
@@ -1096,7 +3379,7 @@ var _ = Describe("Broker", func() {
 			fakeProvider.GenerateCredentialsReturnsOnCall(0, expectedCredentials, nil)
 
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			binding, err := b.Bind(ctx, instanceID, bindingID, brokerapi.BindDetails{}, false)
+			binding, err := b.Bind(ctx, instanceID, bindingID, brokerapi.BindDetails{ServiceID: "service1"}, false)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(binding).To(Equal(brokerapi.Binding{Credentials: expectedCredentials}))
@@ -1114,11 +3397,21 @@ var _ = Describe("Broker", func() {
 			fakeProvider.GenerateCredentialsReturnsOnCall(0, nil, bindErr)
 
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			binding, err := b.Bind(context.Background(), "test-instance", "test-binding", brokerapi.BindDetails{}, false)
+			binding, err := b.Bind(context.Background(), "test-instance", "test-binding", brokerapi.BindDetails{ServiceID: "service1"}, false)
 
 			Expect(err).To(MatchError(bindErr))
 			Expect(binding).To(Equal(brokerapi.Binding{}))
 		})
+
+		It("returns ErrInstanceDoesNotExist if the instance doesn't exist", func() {
+			fakeProvider := &mocks.FakeProvider{}
+			fakeProvider.GenerateCredentialsReturnsOnCall(0, nil, providers.InstanceNotFoundError("Replication group does not exist: cf-qwkec4pxhft6q"))
+
+			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
+			_, err := b.Bind(context.Background(), "test-instance", "test-binding", brokerapi.BindDetails{ServiceID: "service1"}, false)
+
+			Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+		})
 	})
 
 	Context("when unbinding a service instance", func() {
@@ -1131,7 +3424,7 @@ var _ = Describe("Broker", func() {
 			fakeProvider.RevokeCredentialsReturnsOnCall(0, nil)
 
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			_, err := b.Unbind(ctx, instanceID, bindingID, brokerapi.UnbindDetails{}, false)
+			_, err := b.Unbind(ctx, instanceID, bindingID, brokerapi.UnbindDetails{ServiceID: "service1"}, false)
 
 			Expect(err).ToNot(HaveOccurred())
 
@@ -1148,7 +3441,7 @@ var _ = Describe("Broker", func() {
 			fakeProvider.RevokeCredentialsReturnsOnCall(0, unbindErr)
 
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
-			_, err := b.Unbind(context.Background(), "test-instance", "test-binding", brokerapi.UnbindDetails{}, false)
+			_, err := b.Unbind(context.Background(), "test-instance", "test-binding", brokerapi.UnbindDetails{ServiceID: "service1"}, false)
 
 			Expect(err).To(MatchError(unbindErr))
 		})
@@ -1162,6 +3455,10 @@ var _ = Describe("Broker", func() {
 				DailyBackupWindow:          "5678",
 			}, nil)
 			fakeProvider.GetInstanceTagsReturnsOnCall(0, map[string]string{"service-id": "test-service-id", "plan-id": "test-plan-id"}, nil)
+			snapshotTime := time.Now()
+			fakeProvider.ListSnapshotsReturns([]providers.SnapshotInfo{
+				{Name: "snapshot-1", CreateTime: snapshotTime},
+			}, nil)
 
 			b := broker.New(validConfig, fakeProvider, lager.NewLogger("logger"))
 			instance, err := b.GetInstance(context.Background(), "test-instance")
@@ -1174,6 +3471,9 @@ var _ = Describe("Broker", func() {
 				Parameters: providers.InstanceParameters{
 					PreferredMaintenanceWindow: "1234",
 					DailyBackupWindow:          "5678",
+					Snapshots: []providers.SnapshotInfo{
+						{Name: "snapshot-1", CreateTime: snapshotTime},
+					},
 				},
 			}))
 
@@ -1183,4 +3483,117 @@ var _ = Describe("Broker", func() {
 			Expect(passedInstanceId).To(Equal("test-instance"))
 		})
 	})
+
+	Describe("with multiple services", func() {
+		var (
+			redisProvider     *mocks.FakeProvider
+			memcachedProvider *mocks.FakeProvider
+			b                 *broker.Broker
+		)
+
+		BeforeEach(func() {
+			validConfig.Catalog.Services = append(validConfig.Catalog.Services, brokerapi.Service{
+				ID:    "service2",
+				Name:  "service2",
+				Plans: []brokerapi.ServicePlan{{ID: "plan2", Name: "plan2"}},
+			})
+			validConfig.ServiceProviders = map[string]string{"service2": broker.ProviderMemcached}
+			redisProvider = &mocks.FakeProvider{}
+			memcachedProvider = &mocks.FakeProvider{}
+			b = broker.NewWithProviders(validConfig, map[string]providers.Provider{
+				"service1": redisProvider,
+				"service2": memcachedProvider,
+			}, lager.NewLogger("logger"))
+		})
+
+		It("provisions with the provider of the service", func() {
+			memcachedProvider.ProvisionReturns(errors.New("some error"))
+
+			_, err := b.Provision(context.Background(), "instanceid", brokerapi.ProvisionDetails{
+				ServiceID: "service2",
+				PlanID:    "plan2",
+			}, true)
+			Expect(err).To(MatchError("provider memcached for plan plan2: some error"))
+
+			Expect(memcachedProvider.ProvisionCallCount()).To(Equal(1))
+			Expect(redisProvider.ProvisionCallCount()).To(Equal(0))
+		})
+
+		It("binds with the provider of the service", func() {
+			_, err := b.Bind(context.Background(), "instanceid", "bindingid", brokerapi.BindDetails{ServiceID: "service2"}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(memcachedProvider.GenerateCredentialsCallCount()).To(Equal(1))
+			Expect(redisProvider.GenerateCredentialsCallCount()).To(Equal(0))
+		})
+
+		It("errors if the service has no provider", func() {
+			_, err := b.Deprovision(context.Background(), "instanceid", brokerapi.DeprovisionDetails{ServiceID: "service3"}, true)
+			Expect(err).To(MatchError("no provider found for service service3"))
+		})
+
+		Context("when the platform doesn't send the service ID", func() {
+			BeforeEach(func() {
+				redisProvider.GetInstanceTagsReturns(nil, awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil))
+				memcachedProvider.GetInstanceTagsReturns(map[string]string{"service-id": "service2", "plan-id": "plan2"}, nil)
+				memcachedProvider.ProgressStateReturns(providers.Available, "", nil)
+			})
+
+			It("finds the provider by the tags of the instance", func() {
+				lastOperation, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning}),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperation.State).To(Equal(brokerapi.Succeeded))
+
+				Expect(memcachedProvider.ProgressStateCallCount()).To(Equal(1))
+				Expect(redisProvider.ProgressStateCallCount()).To(Equal(0))
+			})
+
+			It("gets the instance from its provider", func() {
+				instance, err := b.GetInstance(context.Background(), "instanceid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instance.ServiceID).To(Equal("service2"))
+
+				Expect(memcachedProvider.GetInstanceParametersCallCount()).To(Equal(1))
+				Expect(redisProvider.GetInstanceParametersCallCount()).To(Equal(0))
+			})
+
+			It("errors if no provider manages the instance", func() {
+				memcachedProvider.GetInstanceTagsReturns(nil, awserr.New(elasticache.ErrCodeCacheClusterNotFoundFault, "not found", nil))
+
+				_, err := b.GetInstance(context.Background(), "instanceid")
+				Expect(err).To(MatchError("no provider found for instance instanceid"))
+			})
+
+			It("returns the errors other than a missing instance", func() {
+				redisProvider.GetInstanceTagsReturns(nil, awserr.New("Throttling", "Rate exceeded", nil))
+
+				_, err := b.GetInstance(context.Background(), "instanceid")
+				Expect(err).To(MatchError("AWS is throttling the requests of the broker, please try again later"))
+				Expect(memcachedProvider.GetInstanceParametersCallCount()).To(Equal(0))
+			})
+
+			It("returns the error if the circuit breaker of the AWS client is open", func() {
+				redisProvider.GetInstanceTagsReturns(nil, providers.ErrCircuitOpen)
+
+				_, err := b.LastOperation(context.Background(), "instanceid", brokerapi.PollDetails{
+					OperationData: broker.SignOperation(validConfig.OperationDataKey, "instanceid", broker.Operation{Action: broker.ActionProvisioning}),
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err).ToNot(MatchError("no provider found for instance instanceid"))
+				Expect(memcachedProvider.ProgressStateCallCount()).To(Equal(0))
+			})
+		})
+	})
 })
+
+// expectOperationTag checks that the operation in progress tag is set to the action and a recent start time, and
+// removes it from the tags
+func expectOperationTag(tags map[string]string, action string) {
+	operation, startedAt, ok := providers.ParseOperationTagValue(tags[providers.OperationInProgressTag])
+	ExpectWithOffset(1, ok).To(BeTrue())
+	ExpectWithOffset(1, operation).To(Equal(action))
+	ExpectWithOffset(1, startedAt).To(BeTemporally("~", time.Now(), time.Minute))
+	delete(tags, providers.OperationInProgressTag)
+}
@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
+	"time"
 
+	"github.com/alphagov/paas-elasticache-broker/providers"
 	"github.com/pivotal-cf/brokerapi"
 )
 
@@ -18,12 +22,29 @@ type BindParameters struct{}
 
 const (
 	DefaultHost = "0.0.0.0"
+	// DefaultOperationTimeout is the default of OperationTimeoutHours
+	DefaultOperationTimeout = 24 * time.Hour
 )
 
+// Provider names which can be used in the service_providers config
+const (
+	ProviderRedis      = "redis"
+	ProviderMemcached  = "memcached"
+	ProviderServerless = "serverless"
+)
+
+// SupportedProviders are the providers a catalog service can be implemented by
+var SupportedProviders = []string{ProviderRedis, ProviderMemcached, ProviderServerless}
+
 type PlanConfig struct {
 	InstanceType              string            `json:"instance_type"`
 	ReplicasPerNodeGroup      int64             `json:"replicas_per_node_group"`
+	MinReplicasPerNodeGroup   int64             `json:"min_replicas_per_node_group"`
+	MaxReplicasPerNodeGroup   int64             `json:"max_replicas_per_node_group"`
 	ShardCount                int64             `json:"shard_count"`
+	MinShardCount             int64             `json:"min_shard_count"`
+	MaxShardCount             int64             `json:"max_shard_count"`
+	NumCacheNodes             int64             `json:"num_cache_nodes"`
 	SnapshotRetentionLimit    int64             `json:"snapshot_retention_limit"`
 	AutomaticFailoverEnabled  bool              `json:"automatic_failover_enabled"`
 	MultiAZEnabled            bool              `json:"multi_az_enabled"`
@@ -31,6 +52,49 @@ type PlanConfig struct {
 	Engine                    string            `json:"engine"`
 	EngineVersion             string            `json:"engine_version"`
 	CacheParameterGroupFamily string            `json:"cache_parameter_group_family"`
+	PerBindingUsers           bool              `json:"per_binding_users"`
+	DisableFinalSnapshot      bool              `json:"disable_final_snapshot"`
+	MaxManualSnapshots        int               `json:"max_manual_snapshots"`
+	// EngineVersionUpgrades maps the engine versions an instance can be upgraded to to their parameter group family
+	EngineVersionUpgrades map[string]string `json:"engine_version_upgrades"`
+	// UserSettableParameters are the cache parameters users can set with the redis_parameters parameter
+	UserSettableParameters map[string]UserSettableParameter `json:"user_settable_parameters"`
+	// EngineMigrations maps the engines an instance can be migrated to to their target version
+	EngineMigrations map[string]EngineMigration `json:"engine_migrations"`
+	// CacheUsageLimits are the maximum data storage and ECPU usage of serverless plans
+	CacheUsageLimits providers.CacheUsageLimits `json:"cache_usage_limits"`
+	// TestFailoverMode is how test_failover fails over an instance, plans with cluster mode always use native failover
+	TestFailoverMode string `json:"test_failover_mode"`
+}
+
+// Test failover modes which can be used in the test_failover_mode plan config
+const (
+	// TestFailoverModeEmulated switches the primary by disabling and re-enabling automatic failover, it's the default
+	TestFailoverModeEmulated = "emulated"
+	// TestFailoverModeNative uses the TestFailover API of ElastiCache
+	TestFailoverModeNative = "native"
+)
+
+// NativeTestFailover returns true if test failovers of the plan use the TestFailover API
+func (p PlanConfig) NativeTestFailover() bool {
+	return p.TestFailoverMode == TestFailoverModeNative || p.Parameters["cluster-enabled"] == "yes"
+}
+
+// EngineMigration is the target of an engine migration
+// The parameter group family defaults to the family of the engine version if it's not set.
+type EngineMigration struct {
+	EngineVersion             string `json:"engine_version"`
+	CacheParameterGroupFamily string `json:"cache_parameter_group_family"`
+}
+
+// UserSettableParameter restricts the values of a cache parameter set by users
+// A value has to satisfy all the set constraints: be one of the allowed values, an integer between the minimum and
+// maximum value, and match the whole pattern. The values are checked by the parameter schemas of the plan.
+type UserSettableParameter struct {
+	AllowedValues []string `json:"allowed_values"`
+	MinValue      *int64   `json:"min_value"`
+	MaxValue      *int64   `json:"max_value"`
+	Pattern       string   `json:"pattern"`
 }
 
 type Config struct {
@@ -47,6 +111,72 @@ type Config struct {
 	SecretsManagerPath   string                    `json:"secrets_manager_path"`
 	Host                 string                    `json:"host"`
 	TLS                  *TLSConfig                `json:"tls"`
+
+	DisableFinalSnapshots bool `json:"disable_final_snapshots"`
+
+	// OperationTimeoutHours is how long the multi-step operations block other updates of an instance if they are
+	// never finished, it defaults to DefaultOperationTimeout
+	OperationTimeoutHours int `json:"operation_timeout_hours"`
+
+	// OperationDataKey is the key the operation data returned to the platform is signed with
+	OperationDataKey string `json:"operation_data_key"`
+	// AcceptUnsignedOperationDataUntil is when the broker stops accepting the unsigned operation data of older versions of
+	// the broker. It's required, so upgrading from an older version can't fail the operations in progress by mistake: it
+	// should be a time after they are expected to finish, or a past time once there are none.
+	AcceptUnsignedOperationDataUntil time.Time `json:"accept_unsigned_operation_data_until"`
+
+	// ServiceProviders maps the catalog service IDs to the provider implementing them, services default to redis
+	ServiceProviders map[string]string `json:"service_providers"`
+
+	// AWSClient are the retry, rate limit and circuit breaker settings of the AWS clients
+	AWSClient providers.AWSClientConfig `json:"aws_client"`
+}
+
+// OperationTimeout returns how long a multi-step operation can be in progress before it is cleared by the next update
+func (c Config) OperationTimeout() time.Duration {
+	if c.OperationTimeoutHours <= 0 {
+		return DefaultOperationTimeout
+	}
+	return time.Duration(c.OperationTimeoutHours) * time.Hour
+}
+
+// ProviderName returns the name of the provider implementing a catalog service
+func (c Config) ProviderName(serviceID string) string {
+	if providerName, ok := c.ServiceProviders[serviceID]; ok {
+		return providerName
+	}
+	return ProviderRedis
+}
+
+// EngineUpgradeTargets returns the engine versions the instances of the plans can be upgraded or migrated to, in a
+// stable order
+func (c Config) EngineUpgradeTargets() []providers.UpgradeEngineVersionParameters {
+	targets := []providers.UpgradeEngineVersionParameters{}
+	for _, planConfig := range c.PlanConfigs {
+		for engineVersion, cacheParameterGroupFamily := range planConfig.EngineVersionUpgrades {
+			targets = append(targets, providers.UpgradeEngineVersionParameters{
+				EngineVersion:             engineVersion,
+				CacheParameterGroupFamily: cacheParameterGroupFamily,
+			})
+		}
+		for engine, migration := range planConfig.EngineMigrations {
+			targets = append(targets, providers.UpgradeEngineVersionParameters{
+				Engine:                    engine,
+				EngineVersion:             migration.EngineVersion,
+				CacheParameterGroupFamily: migration.CacheParameterGroupFamily,
+			})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Engine != targets[j].Engine {
+			return targets[i].Engine < targets[j].Engine
+		}
+		if targets[i].EngineVersion != targets[j].EngineVersion {
+			return targets[i].EngineVersion < targets[j].EngineVersion
+		}
+		return targets[i].CacheParameterGroupFamily < targets[j].CacheParameterGroupFamily
+	})
+	return targets
 }
 
 func (c Config) GetPlanConfig(planID string) (PlanConfig, error) {
@@ -125,6 +255,14 @@ func (c Config) Validate() error {
 		return errors.New("Must provide a non-empty secrets_manager_path")
 	}
 
+	if c.OperationDataKey == "" {
+		return errors.New("Must provide a non-empty operation_data_key")
+	}
+
+	if c.AcceptUnsignedOperationDataUntil.IsZero() {
+		return errors.New("Must provide accept_unsigned_operation_data_until, a time after the operations started by older broker versions are expected to finish, or a past time")
+	}
+
 	for _, s := range c.Catalog.Services {
 		for _, p := range s.Plans {
 			if !c.hasPlanConfig(p.ID) {
@@ -133,10 +271,33 @@ func (c Config) Validate() error {
 		}
 	}
 
-	for k := range c.PlanConfigs {
+	for k, planConfig := range c.PlanConfigs {
 		if !c.hasPlan(k) {
 			return fmt.Errorf("PlanConfig %v not found in catalog", k)
 		}
+		for name, parameter := range planConfig.UserSettableParameters {
+			if _, err := parameter.pattern(); err != nil {
+				return fmt.Errorf("PlanConfig %v has an invalid pattern for %s: %s", k, name, err)
+			}
+		}
+		switch planConfig.TestFailoverMode {
+		case "", TestFailoverModeEmulated, TestFailoverModeNative:
+		default:
+			return fmt.Errorf("PlanConfig %v has an unsupported test_failover_mode: %s", k, planConfig.TestFailoverMode)
+		}
+	}
+
+	for serviceID, providerName := range c.ServiceProviders {
+		if !c.hasService(serviceID) {
+			return fmt.Errorf("Service %s in service_providers not found in catalog", serviceID)
+		}
+		if !isSupportedProvider(providerName) {
+			return fmt.Errorf("Service %s has an unsupported provider: %s", serviceID, providerName)
+		}
+	}
+
+	if err := c.AWSClient.Validate(); err != nil {
+		return fmt.Errorf("Invalid aws_client: %s", err)
 	}
 
 	if c.TLS != nil {
@@ -158,6 +319,24 @@ func (c Config) TLSEnabled() bool {
 	return c.TLS != nil
 }
 
+func (c Config) hasService(id string) bool {
+	for _, s := range c.Catalog.Services {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func isSupportedProvider(name string) bool {
+	for _, providerName := range SupportedProviders {
+		if providerName == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (c Config) hasPlan(id string) bool {
 	for _, s := range c.Catalog.Services {
 		for _, p := range s.Plans {
@@ -168,3 +347,10 @@ func (c Config) hasPlan(id string) bool {
 	}
 	return false
 }
+
+func (p UserSettableParameter) pattern() (*regexp.Regexp, error) {
+	if p.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + p.Pattern + ")$")
+}
@@ -1,11 +1,15 @@
 package broker_test
 
 import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/brokerapi"
 
 	. "github.com/alphagov/paas-elasticache-broker/broker"
+	"github.com/alphagov/paas-elasticache-broker/providers"
 )
 
 var _ = Describe("Config", func() {
@@ -35,6 +39,9 @@ var _ = Describe("Config", func() {
 			},
 			KmsKeyID:           "my-kms-key",
 			SecretsManagerPath: "elasticache-broker-test",
+			OperationDataKey:   "operation-data-key",
+
+			AcceptUnsignedOperationDataUntil: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
 		}
 	)
 
@@ -93,6 +100,21 @@ var _ = Describe("Config", func() {
 			Expect(config.Validate()).NotTo(Succeed())
 		})
 
+		It("requires an operation data key", func() {
+			config.OperationDataKey = ""
+			Expect(config.Validate()).To(MatchError("Must provide a non-empty operation_data_key"))
+		})
+
+		It("requires the time until which unsigned operation data is accepted", func() {
+			config.AcceptUnsignedOperationDataUntil = time.Time{}
+			Expect(config.Validate()).To(MatchError(ContainSubstring("Must provide accept_unsigned_operation_data_until")))
+		})
+
+		It("rejects negative AWS client settings", func() {
+			config.AWSClient.MaxRetries = aws.Int(-1)
+			Expect(config.Validate()).To(MatchError("Invalid aws_client: the AWS client settings can't be negative"))
+		})
+
 		Describe("tls", func() {
 			It("fails with missing certificate info", func() {
 				config.TLS = &TLSConfig{}
@@ -113,6 +135,56 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		It("errors if the pattern of a user settable parameter is invalid", func() {
+			config.PlanConfigs = map[string]PlanConfig{
+				"plan1": {
+					UserSettableParameters: map[string]UserSettableParameter{
+						"notify-keyspace-events": {Pattern: "[KEA"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("PlanConfig plan1 has an invalid pattern for notify-keyspace-events"))
+		})
+
+		It("errors if the test failover mode of a plan is not supported", func() {
+			config.PlanConfigs = map[string]PlanConfig{
+				"plan1": {TestFailoverMode: "manual"},
+			}
+
+			err := config.Validate()
+			Expect(err).To(MatchError("PlanConfig plan1 has an unsupported test_failover_mode: manual"))
+		})
+
+		Context("mapping services to providers", func() {
+			It("defaults to the redis provider", func() {
+				Expect(config.ProviderName("service1")).To(Equal(ProviderRedis))
+			})
+
+			It("returns the configured provider", func() {
+				config.ServiceProviders = map[string]string{"service1": ProviderMemcached}
+
+				Expect(config.Validate()).To(Succeed())
+				Expect(config.ProviderName("service1")).To(Equal(ProviderMemcached))
+			})
+
+			It("errors if the service is not in the catalog", func() {
+				config.ServiceProviders = map[string]string{"this-is-not-in-the-catalog": ProviderRedis}
+
+				err := config.Validate()
+				Expect(err).To(MatchError("Service this-is-not-in-the-catalog in service_providers not found in catalog"))
+			})
+
+			It("errors if the provider is not supported", func() {
+				config.ServiceProviders = map[string]string{"service1": "mongodb"}
+
+				err := config.Validate()
+				Expect(err).To(MatchError("Service service1 has an unsupported provider: mongodb"))
+			})
+		})
+
 		Context("mapping PlanConfigs to Plans", func() {
 			It("errors if the plan config ID does not map to a plan ID in the catalog", func() {
 				config.PlanConfigs["this-is-not-in-the-catalog"] = PlanConfig{}
@@ -132,4 +204,36 @@ var _ = Describe("Config", func() {
 			})
 		})
 	})
+
+	Describe("OperationTimeout", func() {
+		It("defaults to DefaultOperationTimeout", func() {
+			Expect(validConfig.OperationTimeout()).To(Equal(DefaultOperationTimeout))
+		})
+
+		It("returns the configured timeout", func() {
+			config = validConfig
+			config.OperationTimeoutHours = 6
+			Expect(config.OperationTimeout()).To(Equal(6 * time.Hour))
+		})
+	})
+
+	Describe("EngineUpgradeTargets", func() {
+		It("returns the engine version upgrades and the engine migrations of every plan", func() {
+			config = validConfig
+			config.PlanConfigs = map[string]PlanConfig{
+				"plan1": {EngineVersionUpgrades: map[string]string{"7.1": "redis7", "6.2": "redis6.x"}},
+				"plan2": {
+					EngineVersionUpgrades: map[string]string{"7.0": "redis7"},
+					EngineMigrations:      map[string]EngineMigration{"valkey": {EngineVersion: "8.0"}},
+				},
+			}
+
+			Expect(config.EngineUpgradeTargets()).To(Equal([]providers.UpgradeEngineVersionParameters{
+				{EngineVersion: "6.2", CacheParameterGroupFamily: "redis6.x"},
+				{EngineVersion: "7.0", CacheParameterGroupFamily: "redis7"},
+				{EngineVersion: "7.1", CacheParameterGroupFamily: "redis7"},
+				{Engine: "valkey", EngineVersion: "8.0"},
+			}))
+		})
+	})
 })
@@ -0,0 +1,239 @@
+package broker
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pivotal-cf/brokerapi"
+	"github.com/pkg/errors"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+)
+
+// ErrorKind decides the status code an error is returned to the platform with
+type ErrorKind string
+
+// Error kinds
+const (
+	// ErrorKindValidation is an invalid request, like unknown parameters or an unknown plan, returned as 400
+	ErrorKindValidation ErrorKind = "validation"
+	// ErrorKindNotFound is a missing instance, returned as 404
+	ErrorKindNotFound ErrorKind = "not-found"
+	// ErrorKindGone is an instance which doesn't exist any more, returned as 410
+	ErrorKindGone ErrorKind = "gone"
+	// ErrorKindConflict is an instance which exists already with different attributes, returned as 409
+	ErrorKindConflict ErrorKind = "conflict"
+	// ErrorKindConcurrency is another operation in progress for the instance, returned as 422 ConcurrencyError
+	ErrorKindConcurrency ErrorKind = "concurrency"
+	// ErrorKindUnprocessable is a valid request which can't be done, like when an AWS quota is exceeded, returned as 422
+	ErrorKindUnprocessable ErrorKind = "unprocessable"
+	// ErrorKindUnavailable is a temporary failure, like AWS throttling the requests of the broker, returned as 503
+	ErrorKindUnavailable ErrorKind = "unavailable"
+)
+
+var errorKindStatusCodes = map[ErrorKind]int{
+	ErrorKindValidation:    http.StatusBadRequest,
+	ErrorKindNotFound:      http.StatusNotFound,
+	ErrorKindGone:          http.StatusGone,
+	ErrorKindConflict:      http.StatusConflict,
+	ErrorKindConcurrency:   http.StatusUnprocessableEntity,
+	ErrorKindUnprocessable: http.StatusUnprocessableEntity,
+	ErrorKindUnavailable:   http.StatusServiceUnavailable,
+}
+
+// Error is an error of the broker with the kind of the failure
+// The broker methods return it as a brokerapi.FailureResponse, errors without a kind are returned as 500.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind ErrorKind, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func validationError(format string, args ...interface{}) error {
+	return newError(ErrorKindValidation, format, args...)
+}
+
+func notFoundError(format string, args ...interface{}) error {
+	return newError(ErrorKindNotFound, format, args...)
+}
+
+func concurrencyError(format string, args ...interface{}) error {
+	return newError(ErrorKindConcurrency, format, args...)
+}
+
+func unprocessableError(format string, args ...interface{}) error {
+	return newError(ErrorKindUnprocessable, format, args...)
+}
+
+// withKind sets the kind of an error, unless it's nil or has a kind already
+func withKind(kind ErrorKind, err error) error {
+	var brokerErr *Error
+	if err == nil || errors.As(err, &brokerErr) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// awsErrorKinds are the kinds and the user readable messages of the AWS errors which are caused by the request or
+// the state of the AWS account, rather than a fault of the broker
+var awsErrorKinds = map[string]struct {
+	kind    ErrorKind
+	message string
+}{
+	elasticache.ErrCodeNodeQuotaForCustomerExceededFault: {
+		ErrorKindUnprocessable, "The limit of cache nodes in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeNodeQuotaForClusterExceededFault: {
+		ErrorKindUnprocessable, "The limit of cache nodes for an instance has been reached, please choose fewer nodes",
+	},
+	elasticache.ErrCodeClusterQuotaForCustomerExceededFault: {
+		ErrorKindUnprocessable, "The limit of cache clusters in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeNodeGroupsPerReplicationGroupQuotaExceededFault: {
+		ErrorKindUnprocessable, "The limit of shards for an instance has been reached, please choose fewer shards",
+	},
+	elasticache.ErrCodeCacheParameterGroupQuotaExceededFault: {
+		ErrorKindUnprocessable, "The limit of cache parameter groups in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeServerlessCacheQuotaForCustomerExceededFault: {
+		ErrorKindUnprocessable, "The limit of serverless caches in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeUserQuotaExceededFault: {
+		ErrorKindUnprocessable, "The limit of users in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeUserGroupQuotaExceededFault: {
+		ErrorKindUnprocessable, "The limit of user groups in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeSnapshotQuotaExceededFault: {
+		ErrorKindUnprocessable, "The limit of snapshots in the AWS account has been reached, please delete some snapshots or contact support",
+	},
+	secretsmanager.ErrCodeLimitExceededException: {
+		ErrorKindUnprocessable, "The limit of secrets in the AWS account has been reached, please contact support",
+	},
+	elasticache.ErrCodeInsufficientCacheClusterCapacityFault: {
+		ErrorKindUnavailable, "AWS doesn't have enough capacity for the node type at the moment, please try again later",
+	},
+	elasticache.ErrCodeReplicationGroupAlreadyExistsFault: {
+		ErrorKindConflict, "The instance already exists",
+	},
+	elasticache.ErrCodeCacheClusterAlreadyExistsFault: {
+		ErrorKindConflict, "The instance already exists",
+	},
+	elasticache.ErrCodeServerlessCacheAlreadyExistsFault: {
+		ErrorKindConflict, "The instance already exists",
+	},
+	elasticache.ErrCodeReplicationGroupNotFoundFault: {
+		ErrorKindNotFound, "The instance does not exist",
+	},
+	elasticache.ErrCodeCacheClusterNotFoundFault: {
+		ErrorKindNotFound, "The instance does not exist",
+	},
+	elasticache.ErrCodeServerlessCacheNotFoundFault: {
+		ErrorKindNotFound, "The instance does not exist",
+	},
+	elasticache.ErrCodeSnapshotNotFoundFault: {
+		ErrorKindValidation, "The snapshot does not exist",
+	},
+	elasticache.ErrCodeInvalidReplicationGroupStateFault: {
+		ErrorKindConcurrency, "The instance is busy with another operation, try again when it has finished",
+	},
+	elasticache.ErrCodeInvalidCacheClusterStateFault: {
+		ErrorKindConcurrency, "The instance is busy with another operation, try again when it has finished",
+	},
+	elasticache.ErrCodeInvalidServerlessCacheStateFault: {
+		ErrorKindConcurrency, "The instance is busy with another operation, try again when it has finished",
+	},
+	elasticache.ErrCodeInvalidSnapshotStateFault: {
+		ErrorKindConcurrency, "The snapshot is busy with another operation, try again when it has finished",
+	},
+}
+
+// classifyAWSError returns the kind and a user readable message of an AWS error
+// The AWS message is kept for invalid parameters, as it tells which parameter is wrong.
+func classifyAWSError(awsErr awserr.Error) (ErrorKind, string, bool) {
+	if request.IsErrorThrottle(awsErr) {
+		return ErrorKindUnavailable, "AWS is throttling the requests of the broker, please try again later", true
+	}
+	switch awsErr.Code() {
+	case elasticache.ErrCodeInvalidParameterValueException, elasticache.ErrCodeInvalidParameterCombinationException:
+		return ErrorKindValidation, fmt.Sprintf("Invalid parameters: %s", awsErr.Message()), true
+	}
+	if awsErrorKind, ok := awsErrorKinds[awsErr.Code()]; ok {
+		return awsErrorKind.kind, awsErrorKind.message, true
+	}
+	return "", "", false
+}
+
+// isNotFoundError returns true if the error means that an instance doesn't exist
+func isNotFoundError(err error) bool {
+	if errors.Is(err, providers.ErrInstanceNotFound) {
+		return true
+	}
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	kind, _, ok := classifyAWSError(awsErr)
+	return ok && kind == ErrorKindNotFound
+}
+
+// failureResponse turns the errors with a kind, and the AWS errors caused by the request or the AWS account, into
+// brokerapi.FailureResponse, which the platform gets with the matching status code and the error message as description
+// Other errors, and the errors which are brokerapi.FailureResponse already, are returned unchanged.
+func (b *Broker) failureResponse(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*brokerapi.FailureResponse); ok {
+		return err
+	}
+
+	kind, description := ErrorKind(""), err.Error()
+	var brokerErr *Error
+	var awsErr awserr.Error
+	if errors.Is(err, providers.ErrCircuitOpen) {
+		// The AWS requests failed before the circuit breaker opened, so the cause has been logged already
+		kind, description = ErrorKindUnavailable, "AWS is not responding to the requests of the broker, please try again later"
+	} else if errors.As(err, &brokerErr) {
+		kind = brokerErr.Kind
+	} else if errors.Is(err, providers.ErrNotSupported) {
+		// A feature the instances of the provider don't have, like snapshots of Memcached instances
+		kind = ErrorKindValidation
+	} else if errors.As(err, &awsErr) {
+		var ok bool
+		kind, description, ok = classifyAWSError(awsErr)
+		if !ok {
+			return err
+		}
+		// The description doesn't have the details of the AWS error
+		b.logger.Error("aws-error", err, lager.Data{"kind": kind})
+	} else {
+		return err
+	}
+
+	statusCode, ok := errorKindStatusCodes[kind]
+	if !ok {
+		return err
+	}
+	builder := brokerapi.NewFailureResponseBuilder(errors.New(description), statusCode, string(kind)+"-error")
+	if kind == ErrorKindConcurrency {
+		builder = builder.WithErrorKey("ConcurrencyError")
+	}
+	return builder.Build()
+}
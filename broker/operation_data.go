@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OperationDataVersion is the version of the signed operation data format
+// The operation data is "<version>.<base64 encoded operation JSON>.<base64 encoded HMAC-SHA256 signature>", where the
+// signature covers the version, the operation and the instance ID, so it can't be changed or used for another instance.
+const OperationDataVersion = "v1"
+
+// SignOperation returns the signed operation data of an operation of an instance
+func SignOperation(key string, instanceID string, operation Operation) string {
+	payload := OperationDataVersion + "." + base64.RawURLEncoding.EncodeToString([]byte(operation.String()))
+	return payload + "." + operationSignature(key, instanceID, payload)
+}
+
+// VerifyOperation checks the signature of the operation data of an instance and returns the operation
+// Unsigned operation data, which is plain JSON, is only accepted if acceptUnsigned is true, while the operations started
+// by older versions of the broker finish. Only the failover of older versions can set the primary node and the time
+// out, as they change what the broker does to the instance, and its time out can't be later than a failover started
+// now would time out. The second return value is false for unsigned operation data.
+func VerifyOperation(key string, instanceID string, operationData string, acceptUnsigned bool) (Operation, bool, error) {
+	var operation Operation
+	if strings.HasPrefix(operationData, "{") {
+		if !acceptUnsigned {
+			return Operation{}, false, fmt.Errorf("unsigned operation data is not accepted: %s", operationData)
+		}
+		if err := json.Unmarshal([]byte(operationData), &operation); err != nil {
+			return Operation{}, false, fmt.Errorf("invalid operation data: %s", operationData)
+		}
+		if err := verifyUnsignedFailover(operation); err != nil {
+			return Operation{}, false, fmt.Errorf("%s: %s", err, operationData)
+		}
+		return operation, false, nil
+	}
+
+	parts := strings.Split(operationData, ".")
+	if len(parts) != 3 {
+		return Operation{}, false, fmt.Errorf("invalid operation data: %s", operationData)
+	}
+	if parts[0] != OperationDataVersion {
+		return Operation{}, false, fmt.Errorf("unsupported operation data version: %s", parts[0])
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(operationSignature(key, instanceID, payload))) {
+		return Operation{}, false, fmt.Errorf("invalid operation data signature: %s", operationData)
+	}
+
+	operationJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Operation{}, false, fmt.Errorf("invalid operation data: %s", operationData)
+	}
+	if err := json.Unmarshal(operationJSON, &operation); err != nil {
+		return Operation{}, false, fmt.Errorf("invalid operation data: %s", operationData)
+	}
+	return operation, true, nil
+}
+
+// verifyUnsignedFailover checks the primary node and the time out of unsigned operation data, which older versions of
+// the broker only set for a failover. The provider checks that the primary node is a node of the instance.
+func verifyUnsignedFailover(operation Operation) error {
+	if operation.PrimaryNode == "" && operation.TimeOut == "" {
+		return nil
+	}
+	if operation.Action != ActionFailover {
+		return errors.New("unsigned operation data can only set the primary node or the time out of a failover")
+	}
+	timeOut, err := time.Parse(time.RFC3339, operation.TimeOut)
+	if err != nil {
+		return errors.New("unsigned operation data has an invalid time out")
+	}
+	if timeOut.After(time.Now().Add(FailoverTimeout)) {
+		return errors.New("unsigned operation data can't extend the time out of a failover")
+	}
+	return nil
+}
+
+func operationSignature(key string, instanceID string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload + "." + instanceID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (b *Broker) operationData(instanceID string, operation Operation) string {
+	return SignOperation(b.config.OperationDataKey, instanceID, operation)
+}
@@ -0,0 +1,121 @@
+package broker_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/alphagov/paas-elasticache-broker/broker"
+)
+
+var _ = Describe("Operation data", func() {
+	const key = "operation-data-key"
+
+	var operation broker.Operation
+
+	BeforeEach(func() {
+		operation = broker.Operation{
+			Action:      broker.ActionFailover,
+			PrimaryNode: "cf-qwkec4pxhft6q-001",
+			TimeOut:     "2024-05-01T12:45:00Z",
+		}
+	})
+
+	It("round-trips a signed operation", func() {
+		operationData := broker.SignOperation(key, "instanceid", operation)
+		Expect(operationData).To(HavePrefix("v1."))
+
+		verified, signed, err := broker.VerifyOperation(key, "instanceid", operationData, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(verified).To(Equal(operation))
+	})
+
+	Context("when the operation data is unsigned", func() {
+		It("accepts it while unsigned operation data is accepted", func() {
+			unsigned := broker.Operation{Action: broker.ActionProvisioning}
+
+			verified, signed, err := broker.VerifyOperation(key, "instanceid", unsigned.String(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signed).To(BeFalse())
+			Expect(verified).To(Equal(unsigned))
+		})
+
+		It("rejects it when unsigned operation data is not accepted", func() {
+			unsigned := broker.Operation{Action: broker.ActionProvisioning}
+
+			_, _, err := broker.VerifyOperation(key, "instanceid", unsigned.String(), false)
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data is not accepted")))
+		})
+
+		It("accepts the primary node and the time out of a failover of older broker versions", func() {
+			unsigned := broker.Operation{
+				Action:      broker.ActionFailover,
+				PrimaryNode: "cf-qwkec4pxhft6q-001",
+				TimeOut:     time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+			}
+
+			verified, signed, err := broker.VerifyOperation(key, "instanceid", unsigned.String(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signed).To(BeFalse())
+			Expect(verified).To(Equal(unsigned))
+		})
+
+		It("rejects a primary node or time out of other actions", func() {
+			_, _, err := broker.VerifyOperation(key, "instanceid", `{"action": "updating", "primaryNode": "cf-someoneelse-001"}`, true)
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data can only set the primary node or the time out of a failover")))
+
+			_, _, err = broker.VerifyOperation(key, "instanceid", `{"action": "updating", "timeOut": "2099-01-01T00:00:00Z"}`, true)
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data can only set the primary node or the time out of a failover")))
+		})
+
+		It("rejects a failover with an extended or invalid time out", func() {
+			_, _, err := broker.VerifyOperation(key, "instanceid", `{"action": "failover", "primaryNode": "cf-someoneelse-001", "timeOut": "2099-01-01T00:00:00Z"}`, true)
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data can't extend the time out of a failover")))
+
+			_, _, err = broker.VerifyOperation(key, "instanceid", `{"action": "failover", "primaryNode": "cf-someoneelse-001"}`, true)
+			Expect(err).To(MatchError(HavePrefix("unsigned operation data has an invalid time out")))
+		})
+	})
+
+	It("rejects a changed operation", func() {
+		parts := strings.Split(broker.SignOperation(key, "instanceid", operation), ".")
+		operation.PrimaryNode = "cf-someoneelse-001"
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(operation.String()))
+
+		_, _, err := broker.VerifyOperation(key, "instanceid", strings.Join(parts, "."), false)
+		Expect(err).To(MatchError(HavePrefix("invalid operation data signature")))
+	})
+
+	It("rejects the operation of another instance", func() {
+		operationData := broker.SignOperation(key, "instanceid", operation)
+
+		_, _, err := broker.VerifyOperation(key, "otherinstance", operationData, false)
+		Expect(err).To(MatchError(HavePrefix("invalid operation data signature")))
+	})
+
+	It("rejects operation data signed with another key", func() {
+		operationData := broker.SignOperation("another-key", "instanceid", operation)
+
+		_, _, err := broker.VerifyOperation(key, "instanceid", operationData, false)
+		Expect(err).To(MatchError(HavePrefix("invalid operation data signature")))
+	})
+
+	It("rejects unknown versions", func() {
+		operationData := strings.Replace(broker.SignOperation(key, "instanceid", operation), "v1.", "v2.", 1)
+
+		_, _, err := broker.VerifyOperation(key, "instanceid", operationData, false)
+		Expect(err).To(MatchError("unsupported operation data version: v2"))
+	})
+
+	It("rejects malformed operation data", func() {
+		_, _, err := broker.VerifyOperation(key, "instanceid", "v1.foo", false)
+		Expect(err).To(MatchError("invalid operation data: v1.foo"))
+
+		_, _, err = broker.VerifyOperation(key, "instanceid", "{not json", true)
+		Expect(err).To(MatchError("invalid operation data: {not json"))
+	})
+})
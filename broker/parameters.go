@@ -1,22 +1,33 @@
 package broker
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 const ParamRestoreLatestSnapshotOf = "restore_from_latest_snapshot_of"
+const ParamRestoreFromSnapshot = "restore_from_snapshot"
 const ParamMaxMemoryPolicy = "maxmemory_policy"
 const ParamPreferredMaintenanceWindow = "preferred_maintenance_window"
+const ParamDailyBackupWindow = "daily_backup_window"
 const TestFailover = "test_failover"
+const ParamTestFailoverNodeGroup = "test_failover_node_group"
+const ParamRotateAuthToken = "rotate_auth_token"
+const ParamCreateSnapshot = "create_snapshot"
+const ParamEngineVersion = "engine_version"
+const ParamRedisParameters = "redis_parameters"
+const ParamMigrateEngine = "migrate_engine"
+const ParamReplicasPerNodeGroup = "replicas_per_node_group"
+const ParamShardCount = "shard_count"
 
-func parseProvisionParameters(data []byte) (*ProvisionParameters, error) {
+func parseProvisionParameters(data []byte, planConfig PlanConfig, providerName string) (*ProvisionParameters, error) {
 	params := &ProvisionParameters{}
-	err := unmarshalParameters(data, params, []string{
-		ParamRestoreLatestSnapshotOf,
-		ParamMaxMemoryPolicy,
-		ParamPreferredMaintenanceWindow,
-	})
+	err := unmarshalParameters(data, params, ProvisionParametersSchema(planConfig, providerName))
 	if err != nil {
 		return nil, err
 	}
@@ -26,51 +37,247 @@ func parseProvisionParameters(data []byte) (*ProvisionParameters, error) {
 func checkIfNoUpdateParametersAreSet(params *UpdateParameters) bool {
 	return params.MaxMemoryPolicy == nil &&
 		params.PreferredMaintenanceWindow == "" &&
-		params.TestFailover == nil
+		params.DailyBackupWindow == "" &&
+		params.TestFailover == nil &&
+		params.TestFailoverNodeGroup == "" &&
+		params.RotateAuthToken == nil &&
+		params.CreateSnapshot == nil &&
+		params.EngineVersion == "" &&
+		len(params.RedisParameters) == 0 &&
+		params.MigrateEngine == "" &&
+		params.ReplicasPerNodeGroup == nil &&
+		params.ShardCount == nil
 }
 
-func parseUpdateParameters(data []byte) (*UpdateParameters, error) {
+// exclusiveParameterMessages returns the errors of the set update parameters which start an operation of their own, in
+// the order they are handled by Update, for when they are used together with other parameters
+// The node group of a test failover is part of the test failover, so it's not listed.
+func (p *UpdateParameters) exclusiveParameterMessages() []string {
+	exclusive := []struct {
+		set     bool
+		message string
+	}{
+		{p.TestFailover != nil, "Test failover must be used by itself"},
+		{p.RotateAuthToken != nil, "Rotating the auth token must be done by itself"},
+		{p.CreateSnapshot != nil, "Creating a snapshot must be done by itself"},
+		{p.EngineVersion != "", "Upgrading the engine version must be done by itself"},
+		{p.MigrateEngine != "", "Migrating the engine must be done by itself"},
+		{p.ReplicasPerNodeGroup != nil, "Changing the number of replicas must be done by itself"},
+		{p.ShardCount != nil, "Changing the shard count must be done by itself"},
+	}
+	messages := []string{}
+	for _, parameter := range exclusive {
+		if parameter.set {
+			messages = append(messages, parameter.message)
+		}
+	}
+	return messages
+}
+
+func (p *UpdateParameters) hasExclusiveParameter() bool {
+	return len(p.exclusiveParameterMessages()) > 0
+}
+
+// checkExclusiveUpdateParameters returns an error if a parameter which starts an operation of its own is used together
+// with any other parameter or a plan change
+func checkExclusiveUpdateParameters(params *UpdateParameters, planChanged bool) error {
+	messages := params.exclusiveParameterMessages()
+	if len(messages) == 0 {
+		return nil
+	}
+
+	others := len(messages) - 1
+	for _, set := range []bool{
+		planChanged,
+		params.MaxMemoryPolicy != nil,
+		params.PreferredMaintenanceWindow != "",
+		params.DailyBackupWindow != "",
+		len(params.RedisParameters) > 0,
+	} {
+		if set {
+			others++
+		}
+	}
+	if others > 0 {
+		return errors.New(messages[0])
+	}
+	return nil
+}
+
+func parseUpdateParameters(data []byte, planConfig PlanConfig, providerName string) (*UpdateParameters, error) {
 	params := &UpdateParameters{}
-	err := unmarshalParameters(data, params, []string{
-		ParamMaxMemoryPolicy,
-		ParamPreferredMaintenanceWindow,
-		TestFailover,
-	})
+	err := unmarshalParameters(data, params, UpdateParametersSchema(planConfig, providerName))
 	if err != nil {
 		return nil, err
 	}
 	return params, nil
 }
 
-func unmarshalParameters(data []byte, out interface{}, validKeys []string) error {
-	mapParams := map[string]interface{}{}
-	err := json.Unmarshal(data, &mapParams)
+// unmarshalParameters validates the parameters against the schema published in the catalog before unmarshalling them
+func unmarshalParameters(data []byte, out interface{}, schema *Schema) error {
+	err := schema.Validate(data)
 	if err != nil {
 		return err
 	}
-	for key := range mapParams {
-		valid := false
-		for _, validKey := range validKeys {
-			if validKey == key {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("unknown parameter: %s", key)
-		}
-	}
 	return json.Unmarshal(data, out)
 }
 
 type ProvisionParameters struct {
-	RestoreFromLatestSnapshotOf *string `json:"restore_from_latest_snapshot_of"`
-	MaxMemoryPolicy             *string `json:"maxmemory_policy"`
-	PreferredMaintenanceWindow  string  `json:"preferred_maintenance_window"`
+	RestoreFromLatestSnapshotOf *string         `json:"restore_from_latest_snapshot_of" description:"Create the instance from the latest snapshot of this instance GUID"`
+	RestoreFromSnapshot         *string         `json:"restore_from_snapshot" description:"Create the instance from the snapshot with this name, or from the latest snapshot taken at or before this RFC 3339 timestamp, can't be used with restore_from_latest_snapshot_of"`
+	MaxMemoryPolicy             *string         `json:"maxmemory_policy" description:"The eviction policy used when the memory limit is reached"`
+	PreferredMaintenanceWindow  string          `json:"preferred_maintenance_window" description:"Weekly maintenance window in UTC, in the format ddd:hh24:mi-ddd:hh24:mi"`
+	DailyBackupWindow           string          `json:"daily_backup_window" description:"Daily backup window in UTC, in the format hh24:mi-hh24:mi"`
+	RedisParameters             RedisParameters `json:"redis_parameters" description:"Cache parameters allowed by the plan"`
 }
 
 type UpdateParameters struct {
-	MaxMemoryPolicy            *string `json:"maxmemory_policy"`
-	PreferredMaintenanceWindow string  `json:"preferred_maintenance_window"`
-	TestFailover               *bool   `json:"test_failover"`
+	MaxMemoryPolicy            *string         `json:"maxmemory_policy" description:"The eviction policy used when the memory limit is reached"`
+	PreferredMaintenanceWindow string          `json:"preferred_maintenance_window" description:"Weekly maintenance window in UTC, in the format ddd:hh24:mi-ddd:hh24:mi"`
+	DailyBackupWindow          string          `json:"daily_backup_window" description:"Daily backup window in UTC, in the format hh24:mi-hh24:mi"`
+	TestFailover               *bool           `json:"test_failover" description:"Fail over to a replica to test the resilience of the application"`
+	TestFailoverNodeGroup      string          `json:"test_failover_node_group" description:"ID of the node group to fail over with test_failover, only for instances in cluster mode"`
+	RotateAuthToken            *bool           `json:"rotate_auth_token" description:"Generate a new auth token"`
+	CreateSnapshot             *bool           `json:"create_snapshot" description:"Take a manual snapshot of the instance"`
+	EngineVersion              string          `json:"engine_version" description:"Upgrade the engine to this version"`
+	RedisParameters            RedisParameters `json:"redis_parameters" description:"Cache parameters allowed by the plan"`
+	MigrateEngine              string          `json:"migrate_engine" description:"Migrate the instance to this engine"`
+	ReplicasPerNodeGroup       *int64          `json:"replicas_per_node_group" description:"Change the number of replicas of each shard, within the limits of the plan"`
+	ShardCount                 *int64          `json:"shard_count" description:"Change the number of shards of a cluster mode instance, within the limits of the plan"`
+}
+
+// RedisParameters are the cache parameters set by users
+// Numbers are accepted as well, as most cache parameters are numeric.
+type RedisParameters map[string]string
+
+func (r *RedisParameters) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	values := map[string]interface{}{}
+	err := decoder.Decode(&values)
+	if err != nil {
+		return err
+	}
+
+	*r = RedisParameters{}
+	for name, value := range values {
+		switch v := value.(type) {
+		case string:
+			(*r)[name] = v
+		case json.Number:
+			(*r)[name] = v.String()
+		default:
+			return fmt.Errorf("%s: the value of %s must be a string or a number", ParamRedisParameters, name)
+		}
+	}
+	return nil
+}
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+	// ElastiCache requires both the backup and the maintenance window to be at least 60 minutes long
+	minWindowMinutes = 60
+)
+
+var (
+	dailyBackupWindowPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):([0-5][0-9])-([01][0-9]|2[0-3]):([0-5][0-9])$`)
+	maintenanceWindowPattern = regexp.MustCompile(`^(sun|mon|tue|wed|thu|fri|sat):([01][0-9]|2[0-3]):([0-5][0-9])-(sun|mon|tue|wed|thu|fri|sat):([01][0-9]|2[0-3]):([0-5][0-9])$`)
+	weekDays                 = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// timeWindow is a time range in minutes, the end can be before the start if the window wraps around
+type timeWindow struct {
+	start  int
+	end    int
+	period int
+}
+
+func (w timeWindow) length() int {
+	return (w.end - w.start + w.period) % w.period
+}
+
+func (w timeWindow) contains(minute int) bool {
+	return (minute-w.start+w.period)%w.period < w.length()
+}
+
+// parseDailyBackupWindow parses a daily backup window in the hh24:mi-hh24:mi UTC format
+func parseDailyBackupWindow(window string) (timeWindow, error) {
+	matches := dailyBackupWindowPattern.FindStringSubmatch(window)
+	if matches == nil {
+		return timeWindow{}, fmt.Errorf("%s must be in the format hh24:mi-hh24:mi (UTC): %s", ParamDailyBackupWindow, window)
+	}
+	w := timeWindow{
+		start:  minuteOfDay(matches[1], matches[2]),
+		end:    minuteOfDay(matches[3], matches[4]),
+		period: minutesPerDay,
+	}
+	if w.length() < minWindowMinutes {
+		return timeWindow{}, fmt.Errorf("%s must be at least %d minutes long: %s", ParamDailyBackupWindow, minWindowMinutes, window)
+	}
+	return w, nil
+}
+
+// parseMaintenanceWindow parses a weekly maintenance window in the ddd:hh24:mi-ddd:hh24:mi UTC format
+func parseMaintenanceWindow(window string) (timeWindow, error) {
+	matches := maintenanceWindowPattern.FindStringSubmatch(strings.ToLower(window))
+	if matches == nil {
+		return timeWindow{}, fmt.Errorf("%s must be in the format ddd:hh24:mi-ddd:hh24:mi (UTC): %s", ParamPreferredMaintenanceWindow, window)
+	}
+	w := timeWindow{
+		start:  weekDay(matches[1])*minutesPerDay + minuteOfDay(matches[2], matches[3]),
+		end:    weekDay(matches[4])*minutesPerDay + minuteOfDay(matches[5], matches[6]),
+		period: minutesPerWeek,
+	}
+	if w.length() < minWindowMinutes {
+		return timeWindow{}, fmt.Errorf("%s must be at least %d minutes long: %s", ParamPreferredMaintenanceWindow, minWindowMinutes, window)
+	}
+	return w, nil
+}
+
+// checkBackupAndMaintenanceWindows validates the daily backup window and returns an error if it overlaps with the
+// maintenance window. Empty windows are not checked.
+func checkBackupAndMaintenanceWindows(dailyBackupWindow, maintenanceWindow string) error {
+	if dailyBackupWindow == "" {
+		return nil
+	}
+	backup, err := parseDailyBackupWindow(dailyBackupWindow)
+	if err != nil {
+		return err
+	}
+	if maintenanceWindow == "" {
+		return nil
+	}
+	maintenance, err := parseMaintenanceWindow(maintenanceWindow)
+	if err != nil {
+		return err
+	}
+
+	// The windows overlap if either of them starts within the other one on any day of the week
+	for day := 0; day < 7; day++ {
+		dailyBackup := timeWindow{
+			start:  day*minutesPerDay + backup.start,
+			end:    (day*minutesPerDay + backup.start + backup.length()) % minutesPerWeek,
+			period: minutesPerWeek,
+		}
+		if dailyBackup.contains(maintenance.start) || maintenance.contains(dailyBackup.start) {
+			return fmt.Errorf("%s %s overlaps with %s %s", ParamDailyBackupWindow, dailyBackupWindow, ParamPreferredMaintenanceWindow, maintenanceWindow)
+		}
+	}
+	return nil
+}
+
+func minuteOfDay(hours, minutes string) int {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	return h*60 + m
+}
+
+func weekDay(day string) int {
+	for i, d := range weekDays {
+		if d == day {
+			return i
+		}
+	}
+	return 0
 }
@@ -0,0 +1,384 @@
+package broker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/brokerapi"
+)
+
+const jsonSchemaVersion = "http://json-schema.org/draft-04/schema#"
+
+// Schema is the subset of JSON Schema used to describe the user provided parameters
+//
+// The same schemas are published in the catalog and used to validate the parameters, so the two can't get out of sync.
+// The constraints JSON Schema can't express for the type of a property, like the bounds of integers given as strings,
+// are kept in unexported fields, so they are only checked by Validate and not published.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"`
+	Maximum              *int64             `json:"maximum,omitempty"`
+	// OneOf are the schemas of the values of different types a property accepts, instead of its type
+	OneOf []*Schema `json:"oneOf,omitempty"`
+
+	// stringMinimum and stringMaximum are the bounds of the strings of numeric properties
+	stringMinimum *int64
+	stringMaximum *int64
+	// integerPattern and integerEnum are the pattern and the allowed values of the integers of numeric properties
+	integerPattern string
+	integerEnum    []string
+}
+
+// ProvisionParametersSchema returns the schema of the provision parameters of a plan of a provider
+func ProvisionParametersSchema(planConfig PlanConfig, providerName string) *Schema {
+	return parametersSchema(reflect.TypeOf(ProvisionParameters{}), planConfig, providerName)
+}
+
+// UpdateParametersSchema returns the schema of the update parameters of a plan of a provider
+func UpdateParametersSchema(planConfig PlanConfig, providerName string) *Schema {
+	return parametersSchema(reflect.TypeOf(UpdateParameters{}), planConfig, providerName)
+}
+
+// planSchemas returns the schemas of a plan in the format of the catalog
+func planSchemas(planConfig PlanConfig, providerName string) *brokerapi.ServiceSchemas {
+	return &brokerapi.ServiceSchemas{
+		Instance: brokerapi.ServiceInstanceSchema{
+			Create: brokerapi.Schema{Parameters: ProvisionParametersSchema(planConfig, providerName).toMap()},
+			Update: brokerapi.Schema{Parameters: UpdateParametersSchema(planConfig, providerName).toMap()},
+		},
+	}
+}
+
+// providerParameters are the parameters the instances of the providers support, the providers which aren't listed
+// support every parameter
+var providerParameters = map[string]map[string]bool{
+	// The other parameters only apply to the caches which have nodes
+	ProviderServerless: {
+		ParamRestoreLatestSnapshotOf: true,
+		ParamRestoreFromSnapshot:     true,
+		ParamDailyBackupWindow:       true,
+		ParamCreateSnapshot:          true,
+	},
+	// Memcached has no replicas, snapshots, authentication or engine upgrades
+	ProviderMemcached: {
+		ParamPreferredMaintenanceWindow: true,
+		ParamRedisParameters:            true,
+	},
+}
+
+// parametersSchema generates the schema of a parameters struct from its json and description tags
+func parametersSchema(paramsType reflect.Type, planConfig PlanConfig, providerName string) *Schema {
+	schema := &Schema{
+		Schema:               jsonSchemaVersion,
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: newBool(false),
+	}
+
+	for i := 0; i < paramsType.NumField(); i++ {
+		field := paramsType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if supported, ok := providerParameters[providerName]; ok && !supported[name] {
+			continue
+		}
+
+		var property *Schema
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case fieldType == reflect.TypeOf(RedisParameters{}):
+			property = redisParametersSchema(planConfig)
+		case fieldType.Kind() == reflect.Bool:
+			property = &Schema{Type: "boolean"}
+		case fieldType.Kind() == reflect.Int64:
+			property = &Schema{Type: "integer"}
+		default:
+			property = &Schema{Type: "string"}
+		}
+		property.Description = field.Tag.Get("description")
+
+		if name == ParamEngineVersion {
+			for engineVersion := range planConfig.EngineVersionUpgrades {
+				property.Enum = append(property.Enum, engineVersion)
+			}
+			sort.Strings(property.Enum)
+		}
+		if name == ParamMigrateEngine {
+			for engine := range planConfig.EngineMigrations {
+				property.Enum = append(property.Enum, engine)
+			}
+			sort.Strings(property.Enum)
+		}
+		if name == ParamReplicasPerNodeGroup && planConfig.MaxReplicasPerNodeGroup > 0 {
+			property.Minimum = &planConfig.MinReplicasPerNodeGroup
+			property.Maximum = &planConfig.MaxReplicasPerNodeGroup
+		}
+		if name == ParamShardCount && planConfig.MaxShardCount > 0 {
+			property.Minimum = &planConfig.MinShardCount
+			property.Maximum = &planConfig.MaxShardCount
+		}
+
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+func redisParametersSchema(planConfig PlanConfig) *Schema {
+	schema := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: newBool(false),
+	}
+	for name, parameter := range planConfig.UserSettableParameters {
+		property := &Schema{
+			Type: "string",
+			Enum: parameter.AllowedValues,
+		}
+		if parameter.Pattern != "" {
+			// JSON Schema patterns are not anchored, but the user settable parameters have to match the whole value
+			property.Pattern = "^(?:" + parameter.Pattern + ")$"
+		}
+		// Numeric cache parameters can be given as numbers or strings, the bounds, the pattern and the allowed values
+		// apply to both
+		if parameter.MinValue != nil || parameter.MaxValue != nil {
+			integerProperty := &Schema{
+				Type:           "integer",
+				Minimum:        parameter.MinValue,
+				Maximum:        parameter.MaxValue,
+				integerPattern: property.Pattern,
+				integerEnum:    parameter.AllowedValues,
+			}
+			property.stringMinimum = parameter.MinValue
+			property.stringMaximum = parameter.MaxValue
+			if property.Pattern == "" {
+				property.Pattern = integerStringPattern
+			}
+			property = &Schema{OneOf: []*Schema{integerProperty, property}}
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// integerStringPattern matches the strings which are integers, for the parameters which have bounds
+const integerStringPattern = "^-?[0-9]+$"
+
+// Validate checks the given JSON document against the schema
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return err
+	}
+	return s.validate("", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	if len(s.OneOf) > 0 {
+		return s.validateOneOf(path, value)
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return s.typeError(path)
+		}
+		return s.validateObject(path, object)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return s.typeError(path)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return s.typeError(path)
+		}
+		intValue, err := number.Int64()
+		if err != nil {
+			return s.typeError(path)
+		}
+		err = validateBounds(path, intValue, s.Minimum, s.Maximum)
+		if err != nil {
+			return err
+		}
+		err = validatePattern(path, s.integerPattern, number.String())
+		if err != nil {
+			return err
+		}
+		return validateEnum(path, s.integerEnum, number.String())
+	case "string":
+		stringValue, ok := value.(string)
+		if !ok {
+			return s.typeError(path)
+		}
+		err := validatePattern(path, s.Pattern, stringValue)
+		if err != nil {
+			return err
+		}
+		if s.stringMinimum != nil || s.stringMaximum != nil {
+			intValue, err := strconv.ParseInt(stringValue, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer", path)
+			}
+			err = validateBounds(path, intValue, s.stringMinimum, s.stringMaximum)
+			if err != nil {
+				return err
+			}
+		}
+		return validateEnum(path, s.Enum, stringValue)
+	}
+	return nil
+}
+
+// validateOneOf validates the value against the schema of its type
+// The schemas of oneOf have different types, so a value can only be valid for one of them.
+func (s *Schema) validateOneOf(path string, value interface{}) error {
+	for _, schema := range s.OneOf {
+		if schema.matchesType(value) {
+			return schema.validate(path, value)
+		}
+	}
+	return s.typeError(path)
+}
+
+// matchesType returns true if the value is of the JSON type of the schema, all numbers are checked as integers
+func (s *Schema) matchesType(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}:
+		return s.Type == "object"
+	case bool:
+		return s.Type == "boolean"
+	case json.Number:
+		return s.Type == "integer"
+	case string:
+		return s.Type == "string"
+	}
+	return false
+}
+
+func validateBounds(path string, value int64, minimum, maximum *int64) error {
+	if minimum != nil && value < *minimum {
+		return fmt.Errorf("%s must be at least %d", path, *minimum)
+	}
+	if maximum != nil && value > *maximum {
+		return fmt.Errorf("%s must be at most %d", path, *maximum)
+	}
+	return nil
+}
+
+// validatePattern matches the pattern against strings, and the integers of properties which also accept strings
+func validatePattern(path string, pattern string, value string) error {
+	if pattern == "" {
+		return nil
+	}
+	matched, err := regexp.MatchString(pattern, value)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("%s must match %s", path, pattern)
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(path string, object map[string]interface{}) error {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertyPath := key
+		if path != "" {
+			propertyPath = path + "." + key
+		}
+
+		property, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return s.unknownPropertyError(path, propertyPath)
+			}
+			continue
+		}
+		err := property.validate(propertyPath, object[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateEnum(path string, enum []string, value string) error {
+	if len(enum) == 0 {
+		return nil
+	}
+	for _, allowedValue := range enum {
+		if value == allowedValue {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of: %s", path, strings.Join(enum, ", "))
+}
+
+func (s *Schema) typeError(path string) error {
+	if path == "" {
+		path = "parameters"
+	}
+	types := []string{}
+	for _, schema := range append([]*Schema{s}, s.OneOf...) {
+		switch schema.Type {
+		case "":
+		case "object", "integer":
+			types = append(types, "an "+schema.Type)
+		default:
+			types = append(types, "a "+schema.Type)
+		}
+	}
+	return fmt.Errorf("%s must be %s", path, strings.Join(types, " or "))
+}
+
+// unknownPropertyError lists the allowed properties of nested objects, as those depend on the plan
+func (s *Schema) unknownPropertyError(path string, propertyPath string) error {
+	if path == "" {
+		return fmt.Errorf("unknown parameter: %s", propertyPath)
+	}
+	allowed := []string{}
+	for key := range s.Properties {
+		allowed = append(allowed, key)
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("unknown parameter: %s, this plan doesn't allow setting any %s", propertyPath, path)
+	}
+	sort.Strings(allowed)
+	return fmt.Errorf("unknown parameter: %s, allowed parameters are: %s", propertyPath, strings.Join(allowed, ", "))
+}
+
+func (s *Schema) toMap() map[string]interface{} {
+	data, _ := json.Marshal(s)
+	schemaMap := map[string]interface{}{}
+	_ = json.Unmarshal(data, &schemaMap)
+	return schemaMap
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
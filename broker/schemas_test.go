@@ -0,0 +1,248 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/alphagov/paas-elasticache-broker/broker"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+)
+
+var _ = Describe("Schemas", func() {
+	var planConfig broker.PlanConfig
+
+	BeforeEach(func() {
+		planConfig = broker.PlanConfig{
+			EngineVersionUpgrades: map[string]string{
+				"7.1": "redis7",
+				"6.2": "redis6.x",
+			},
+			EngineMigrations: map[string]broker.EngineMigration{
+				"valkey": {EngineVersion: "7.2"},
+			},
+			MinReplicasPerNodeGroup: 1,
+			MaxReplicasPerNodeGroup: 3,
+			UserSettableParameters: map[string]broker.UserSettableParameter{
+				"appendfsync":            {AllowedValues: []string{"always", "everysec", "no"}},
+				"timeout":                {MinValue: aws.Int64(0), MaxValue: aws.Int64(3600)},
+				"notify-keyspace-events": {Pattern: "[KEA]*"},
+				"maxmemory-samples":      {MinValue: aws.Int64(1), MaxValue: aws.Int64(60), Pattern: "[0-9]*0"},
+			},
+		}
+	})
+
+	It("describes every provision parameter", func() {
+		schema := broker.ProvisionParametersSchema(planConfig, broker.ProviderRedis)
+
+		Expect(schema.Type).To(Equal("object"))
+		Expect(*schema.AdditionalProperties).To(BeFalse())
+		Expect(schema.Properties).To(HaveLen(6))
+		Expect(schema.Properties).To(HaveKey(broker.ParamRestoreLatestSnapshotOf))
+		Expect(schema.Properties[broker.ParamMaxMemoryPolicy].Type).To(Equal("string"))
+		Expect(schema.Properties[broker.ParamMaxMemoryPolicy].Description).ToNot(BeEmpty())
+	})
+
+	It("describes the plan specific update parameters", func() {
+		schema := broker.UpdateParametersSchema(planConfig, broker.ProviderRedis)
+
+		Expect(schema.Properties[broker.TestFailover].Type).To(Equal("boolean"))
+		Expect(schema.Properties[broker.ParamEngineVersion].Enum).To(Equal([]string{"6.2", "7.1"}))
+		Expect(schema.Properties[broker.ParamMigrateEngine].Enum).To(Equal([]string{"valkey"}))
+		Expect(schema.Properties[broker.ParamReplicasPerNodeGroup].Type).To(Equal("integer"))
+		Expect(schema.Properties[broker.ParamReplicasPerNodeGroup].Minimum).To(Equal(aws.Int64(1)))
+		Expect(schema.Properties[broker.ParamReplicasPerNodeGroup].Maximum).To(Equal(aws.Int64(3)))
+
+		redisParameters := schema.Properties[broker.ParamRedisParameters]
+		Expect(redisParameters.Properties).To(HaveLen(4))
+		Expect(redisParameters.Properties["appendfsync"].Enum).To(Equal([]string{"always", "everysec", "no"}))
+		Expect(redisParameters.Properties["timeout"].OneOf).To(HaveLen(2))
+		Expect(redisParameters.Properties["notify-keyspace-events"].Pattern).To(Equal("^(?:[KEA]*)$"))
+	})
+
+	It("only describes the parameters of serverless caches for serverless plans", func() {
+		provisionSchema := broker.ProvisionParametersSchema(planConfig, broker.ProviderServerless)
+		Expect(provisionSchema.Properties).To(HaveLen(3))
+		Expect(provisionSchema.Properties).To(HaveKey(broker.ParamRestoreLatestSnapshotOf))
+		Expect(provisionSchema.Properties).To(HaveKey(broker.ParamRestoreFromSnapshot))
+		Expect(provisionSchema.Properties).To(HaveKey(broker.ParamDailyBackupWindow))
+
+		updateSchema := broker.UpdateParametersSchema(planConfig, broker.ProviderServerless)
+		Expect(updateSchema.Properties).To(HaveLen(2))
+		Expect(updateSchema.Properties).To(HaveKey(broker.ParamDailyBackupWindow))
+		Expect(updateSchema.Properties).To(HaveKey(broker.ParamCreateSnapshot))
+
+		err := updateSchema.Validate([]byte(`{"replicas_per_node_group": 2}`))
+		Expect(err).To(MatchError("unknown parameter: replicas_per_node_group"))
+	})
+
+	It("only describes the parameters of Memcached clusters for Memcached plans", func() {
+		provisionSchema := broker.ProvisionParametersSchema(planConfig, broker.ProviderMemcached)
+		Expect(provisionSchema.Properties).To(HaveLen(2))
+		Expect(provisionSchema.Properties).To(HaveKey(broker.ParamPreferredMaintenanceWindow))
+		Expect(provisionSchema.Properties).To(HaveKey(broker.ParamRedisParameters))
+
+		updateSchema := broker.UpdateParametersSchema(planConfig, broker.ProviderMemcached)
+		Expect(updateSchema.Properties).To(HaveLen(2))
+		Expect(updateSchema.Properties).To(HaveKey(broker.ParamPreferredMaintenanceWindow))
+		Expect(updateSchema.Properties).To(HaveKey(broker.ParamRedisParameters))
+
+		for _, parameter := range []string{broker.ParamMaxMemoryPolicy, broker.TestFailover, broker.ParamCreateSnapshot} {
+			err := updateSchema.Validate([]byte(`{"` + parameter + `": true}`))
+			Expect(err).To(MatchError("unknown parameter: " + parameter))
+		}
+	})
+
+	DescribeTable("validating parameters",
+		func(parameters string, expectedErr string) {
+			err := broker.UpdateParametersSchema(planConfig, broker.ProviderRedis).Validate([]byte(parameters))
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		Entry("valid parameters", `{"maxmemory_policy": "noeviction", "redis_parameters": {"timeout": 300}}`, ""),
+		Entry("not an object", `[]`, "parameters must be an object"),
+		Entry("unknown parameter", `{"foo": "bar"}`, "unknown parameter: foo"),
+		Entry("wrong type", `{"test_failover": "yes"}`, "test_failover must be a boolean"),
+		Entry("engine version not allowed", `{"engine_version": "8.0"}`, "engine_version must be one of: 6.2, 7.1"),
+		Entry("too few replicas", `{"replicas_per_node_group": 0}`, "replicas_per_node_group must be at least 1"),
+		Entry("replicas not a number", `{"replicas_per_node_group": "2"}`, "replicas_per_node_group must be an integer"),
+		Entry("unknown redis parameter", `{"redis_parameters": {"cluster-enabled": "yes"}}`,
+			"unknown parameter: redis_parameters.cluster-enabled, allowed parameters are: appendfsync, maxmemory-samples, notify-keyspace-events, timeout"),
+		Entry("allowed value", `{"redis_parameters": {"appendfsync": "no"}}`, ""),
+		Entry("not allowed value", `{"redis_parameters": {"appendfsync": "sometimes"}}`,
+			"redis_parameters.appendfsync must be one of: always, everysec, no"),
+		Entry("integer below the minimum", `{"redis_parameters": {"timeout": -1}}`, "redis_parameters.timeout must be at least 0"),
+		Entry("integer above the maximum", `{"redis_parameters": {"timeout": 3601}}`, "redis_parameters.timeout must be at most 3600"),
+		Entry("not an integer", `{"redis_parameters": {"timeout": 1.5}}`, "redis_parameters.timeout must be an integer"),
+		Entry("not an integer or a string", `{"redis_parameters": {"timeout": true}}`,
+			"redis_parameters.timeout must be an integer or a string"),
+		Entry("integer as a string", `{"redis_parameters": {"timeout": "300"}}`, ""),
+		Entry("string below the minimum", `{"redis_parameters": {"timeout": "-1"}}`, "redis_parameters.timeout must be at least 0"),
+		Entry("string above the maximum", `{"redis_parameters": {"timeout": "3601"}}`, "redis_parameters.timeout must be at most 3600"),
+		Entry("string which isn't an integer", `{"redis_parameters": {"timeout": "5m"}}`,
+			"redis_parameters.timeout must match ^-?[0-9]+$"),
+		Entry("bounded string matching the pattern", `{"redis_parameters": {"maxmemory-samples": "10"}}`, ""),
+		Entry("bounded string not matching the pattern", `{"redis_parameters": {"maxmemory-samples": "11"}}`,
+			"redis_parameters.maxmemory-samples must match ^(?:[0-9]*0)$"),
+		Entry("bounded integer not matching the pattern", `{"redis_parameters": {"maxmemory-samples": 11}}`,
+			"redis_parameters.maxmemory-samples must match ^(?:[0-9]*0)$"),
+		Entry("matching pattern", `{"redis_parameters": {"notify-keyspace-events": "KE"}}`, ""),
+		Entry("partially matching pattern", `{"redis_parameters": {"notify-keyspace-events": "KEx"}}`,
+			"redis_parameters.notify-keyspace-events must match ^(?:[KEA]*)$"),
+	)
+
+	It("rejects all redis parameters if the plan has none", func() {
+		err := broker.UpdateParametersSchema(broker.PlanConfig{}, broker.ProviderRedis).Validate([]byte(`{"redis_parameters": {"timeout": 300}}`))
+		Expect(err).To(MatchError("unknown parameter: redis_parameters.timeout, this plan doesn't allow setting any redis_parameters"))
+	})
+
+	It("adds the schemas to the plans in the catalog", func() {
+		config := broker.Config{
+			Catalog: brokerapi.CatalogResponse{
+				Services: []brokerapi.Service{
+					{
+						ID:    "service1",
+						Plans: []brokerapi.ServicePlan{{ID: "plan1"}},
+					},
+				},
+			},
+			PlanConfigs: map[string]broker.PlanConfig{"plan1": planConfig},
+		}
+		b := broker.New(config, &mocks.FakeProvider{}, lager.NewLogger("logger"))
+
+		services, err := b.Services(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		schemas := services[0].Plans[0].Schemas
+		Expect(schemas).ToNot(BeNil())
+
+		expectedCreate, _ := json.Marshal(broker.ProvisionParametersSchema(planConfig, broker.ProviderRedis))
+		actualCreate, _ := json.Marshal(schemas.Instance.Create.Parameters)
+		Expect(actualCreate).To(MatchJSON(expectedCreate))
+
+		expectedUpdate, _ := json.Marshal(broker.UpdateParametersSchema(planConfig, broker.ProviderRedis))
+		actualUpdate, _ := json.Marshal(schemas.Instance.Update.Parameters)
+		Expect(actualUpdate).To(MatchJSON(expectedUpdate))
+
+		Expect(config.Catalog.Services[0].Plans[0].Schemas).To(BeNil())
+	})
+
+	It("only publishes the constraints JSON Schema applies to the type of the numeric redis parameters", func() {
+		planConfig.UserSettableParameters["maxmemory-samples"] = broker.UserSettableParameter{
+			MinValue: aws.Int64(1), MaxValue: aws.Int64(60), AllowedValues: []string{"10", "20"},
+		}
+		config := broker.Config{
+			Catalog: brokerapi.CatalogResponse{
+				Services: []brokerapi.Service{
+					{
+						ID:    "service1",
+						Plans: []brokerapi.ServicePlan{{ID: "plan1"}},
+					},
+				},
+			},
+			PlanConfigs: map[string]broker.PlanConfig{"plan1": planConfig},
+		}
+		b := broker.New(config, &mocks.FakeProvider{}, lager.NewLogger("logger"))
+
+		services, err := b.Services(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		published, err := json.Marshal(services[0].Plans[0].Schemas.Instance.Update.Parameters["properties"])
+		Expect(err).ToNot(HaveOccurred())
+		var properties map[string]map[string]interface{}
+		Expect(json.Unmarshal(published, &properties)).To(Succeed())
+		redisParameters, err := json.Marshal(properties[broker.ParamRedisParameters]["properties"])
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(redisParameters).To(MatchJSON(`{
+			"appendfsync": {"type": "string", "enum": ["always", "everysec", "no"]},
+			"notify-keyspace-events": {"type": "string", "pattern": "^(?:[KEA]*)$"},
+			"timeout": {"oneOf": [
+				{"type": "integer", "minimum": 0, "maximum": 3600},
+				{"type": "string", "pattern": "^-?[0-9]+$"}
+			]},
+			"maxmemory-samples": {"oneOf": [
+				{"type": "integer", "minimum": 1, "maximum": 60},
+				{"type": "string", "pattern": "^-?[0-9]+$", "enum": ["10", "20"]}
+			]}
+		}`))
+
+		schema := broker.UpdateParametersSchema(planConfig, broker.ProviderRedis)
+		Expect(schema.Validate([]byte(`{"redis_parameters": {"maxmemory-samples": 20}}`))).To(Succeed())
+		Expect(schema.Validate([]byte(`{"redis_parameters": {"maxmemory-samples": 30}}`))).To(
+			MatchError("redis_parameters.maxmemory-samples must be one of: 10, 20"))
+		Expect(schema.Validate([]byte(`{"redis_parameters": {"maxmemory-samples": "70"}}`))).To(
+			MatchError("redis_parameters.maxmemory-samples must be at most 60"))
+	})
+
+	It("uses the schemas of the provider of the service in the catalog", func() {
+		config := broker.Config{
+			Catalog: brokerapi.CatalogResponse{
+				Services: []brokerapi.Service{
+					{
+						ID:    "service1",
+						Plans: []brokerapi.ServicePlan{{ID: "plan1"}},
+					},
+				},
+			},
+			PlanConfigs:      map[string]broker.PlanConfig{"plan1": planConfig},
+			ServiceProviders: map[string]string{"service1": broker.ProviderServerless},
+		}
+		b := broker.New(config, &mocks.FakeProvider{}, lager.NewLogger("logger"))
+
+		services, err := b.Services(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		expectedUpdate, _ := json.Marshal(broker.UpdateParametersSchema(planConfig, broker.ProviderServerless))
+		actualUpdate, _ := json.Marshal(services[0].Plans[0].Schemas.Instance.Update.Parameters)
+		Expect(actualUpdate).To(MatchJSON(expectedUpdate))
+	})
+})
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
@@ -9,10 +10,14 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-elasticache-broker/broker"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/memcached"
 	"github.com/alphagov/paas-elasticache-broker/providers/redis"
+	"github.com/alphagov/paas-elasticache-broker/providers/serverless"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/elasticache"
@@ -53,8 +58,9 @@ func newLogger(logLevel string) lager.Logger {
 func newBroker(config broker.Config, logger lager.Logger) (*broker.Broker, error) {
 	awsConfig := aws.NewConfig().WithRegion(config.Region)
 	awsSession := session.Must(session.NewSession(awsConfig))
-	elastiCache := elasticache.New(awsSession)
-	secretsManager := secretsmanager.New(awsSession)
+	awsClient := providers.NewResilientClient(
+		elasticache.New(awsSession), secretsmanager.New(awsSession), config.AWSClient, logger,
+	)
 
 	awsAccountID, err := userAccount(sts.New(awsSession))
 	if err != nil {
@@ -63,12 +69,26 @@ func newBroker(config broker.Config, logger lager.Logger) (*broker.Broker, error
 	awsPartition := "aws"
 	awsRegion := config.Region
 
-	provider := redis.NewProvider(
-		elastiCache, secretsManager, awsAccountID, awsPartition, awsRegion, logger,
-		config.KmsKeyID, config.SecretsManagerPath,
-	)
+	providersByName := map[string]providers.Provider{
+		broker.ProviderRedis: redis.NewProvider(
+			awsClient, awsClient, awsAccountID, awsPartition, awsRegion, logger,
+			config.KmsKeyID, config.SecretsManagerPath, config.EngineUpgradeTargets(),
+		),
+		broker.ProviderMemcached: memcached.NewProvider(
+			awsClient, awsAccountID, awsPartition, awsRegion, logger,
+		),
+		broker.ProviderServerless: serverless.NewProvider(
+			awsClient, awsClient, awsAccountID, awsPartition, awsRegion, logger,
+			config.KmsKeyID, config.SecretsManagerPath,
+		),
+	}
 
-	return broker.New(config, provider, logger), nil
+	serviceProviders := map[string]providers.Provider{}
+	for _, service := range config.Catalog.Services {
+		serviceProviders[service.ID] = providersByName[config.ProviderName(service.ID)]
+	}
+
+	return broker.NewWithProviders(config, serviceProviders, logger), nil
 }
 
 func userAccount(stssvc *sts.STS) (string, error) {
@@ -113,10 +133,35 @@ func main() {
 	if error != nil {
 		log.Fatalf("Error creating listener: %s", error)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpServer.RegisterOnShutdown(cancel)
+	go restoreHighAvailability(ctx, serviceBroker)
+
 	fmt.Println("ElastiCache Service Broker started on port " + port + "...")
 	httpServer.Serve(*listener)
 }
 
+// restoreHighAvailability repairs the instances left without automatic failover and Multi-AZ on start up, and then
+// periodically until the context is cancelled
+// Every run gets HighAvailabilityCheckTimeout, so AWS requests which don't return can't stop the later runs.
+func restoreHighAvailability(ctx context.Context, serviceBroker *broker.Broker) {
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, broker.HighAvailabilityCheckTimeout)
+		serviceBroker.RestoreHighAvailability(runCtx)
+		cancel()
+
+		timer := time.NewTimer(broker.HighAvailabilityCheckInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
 func CreateListener(serviceBroker *broker.Broker, logger lager.Logger, config broker.Config, portNumber string) (*http.Server, *net.Listener, error) {
 
 	server := newHTTPHandler(serviceBroker, logger, config)
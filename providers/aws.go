@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"encoding/base32"
+	"hash/fnv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// IsAWSError returns true if the error is an AWS error with the given code
+func IsAWSError(err error, code string) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == code
+	}
+	return false
+}
+
+// GenerateName generates a valid ElastiCache name from an ID, e.g. an instance or binding ID
+// A valid name must contain between 1 and 40 alphanumeric characters or hyphens, should start with a letter, and cannot
+// end with a hyphen or contain two consecutive hyphens. The prefix has to start with a letter and end with a hyphen.
+func GenerateName(prefix, id string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+	out := hash.Sum([]byte{})
+	encoder := base32.StdEncoding.WithPadding(base32.NoPadding)
+	return strings.ToLower(prefix + encoder.EncodeToString(out))
+}
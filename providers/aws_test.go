@@ -0,0 +1,27 @@
+package providers_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+)
+
+var _ = Describe("AWS helpers", func() {
+	It("generates valid ElastiCache names", func() {
+		Expect(providers.GenerateName("cf-", "foobar")).To(Equal("cf-qwkec4pxhft6q"))
+		Expect(providers.GenerateName("cf-binding-", "foobar")).To(Equal("cf-binding-qwkec4pxhft6q"))
+	})
+
+	It("checks the code of AWS errors", func() {
+		err := awserr.New(elasticache.ErrCodeCacheClusterNotFoundFault, "not found", nil)
+		Expect(providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault)).To(BeTrue())
+		Expect(providers.IsAWSError(err, elasticache.ErrCodeReplicationGroupNotFoundFault)).To(BeFalse())
+		Expect(providers.IsAWSError(errors.New("some error"), elasticache.ErrCodeCacheClusterNotFoundFault)).To(BeFalse())
+		Expect(providers.IsAWSError(nil, elasticache.ErrCodeCacheClusterNotFoundFault)).To(BeFalse())
+	})
+})
@@ -1,4 +1,4 @@
-package redis
+package providers
 
 import (
 	"crypto/rand"
@@ -10,6 +10,14 @@ var alpha = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
 // var alphaLower = []byte("abcdefghijklmnopqrstuvwxyz")
 var numer = []byte("0123456789")
 
+// PasswordLength is the length of the auth tokens and the passwords of the binding users
+const PasswordLength = 32
+
+// GeneratePassword generates an alphanumeric cryptographically-secure password
+func GeneratePassword() string {
+	return RandomAlphaNum(PasswordLength)
+}
+
 func RandomAlphaNum(length int) string {
 	return randChar(1, alpha) + randChar(length-1, append(alpha, numer...))
 }
@@ -1,10 +1,10 @@
-package redis_test
+package providers_test
 
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
-	. "github.com/alphagov/paas-elasticache-broker/providers/redis"
+	. "github.com/alphagov/paas-elasticache-broker/providers"
 )
 
 var _ = Describe("RandomAlphaNum", func() {
@@ -12,17 +12,39 @@ import (
 //counterfeiter:generate -o mocks/elasticache.go . ElastiCache
 type ElastiCache interface {
 	CreateCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.CreateCacheParameterGroupInput, opts ...request.Option) (*elasticache.CreateCacheParameterGroupOutput, error)
+	CreateCacheClusterWithContext(ctx aws.Context, input *elasticache.CreateCacheClusterInput, opts ...request.Option) (*elasticache.CreateCacheClusterOutput, error)
 	CreateReplicationGroupWithContext(ctx aws.Context, input *elasticache.CreateReplicationGroupInput, opts ...request.Option) (*elasticache.CreateReplicationGroupOutput, error)
 	DeleteCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.DeleteCacheParameterGroupInput, opts ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error)
+	DeleteCacheClusterWithContext(ctx aws.Context, input *elasticache.DeleteCacheClusterInput, opts ...request.Option) (*elasticache.DeleteCacheClusterOutput, error)
 	DeleteReplicationGroupWithContext(ctx aws.Context, input *elasticache.DeleteReplicationGroupInput, opts ...request.Option) (*elasticache.DeleteReplicationGroupOutput, error)
 	DescribeReplicationGroupsWithContext(ctx aws.Context, input *elasticache.DescribeReplicationGroupsInput, opts ...request.Option) (*elasticache.DescribeReplicationGroupsOutput, error)
+	DescribeReplicationGroupsPagesWithContext(ctx aws.Context, input *elasticache.DescribeReplicationGroupsInput, fn func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, opts ...request.Option) error
 	DescribeCacheClustersWithContext(ctx aws.Context, input *elasticache.DescribeCacheClustersInput, opts ...request.Option) (*elasticache.DescribeCacheClustersOutput, error)
+	DescribeCacheSubnetGroupsWithContext(ctx aws.Context, input *elasticache.DescribeCacheSubnetGroupsInput, opts ...request.Option) (*elasticache.DescribeCacheSubnetGroupsOutput, error)
 	DescribeCacheParametersWithContext(ctx aws.Context, input *elasticache.DescribeCacheParametersInput, opts ...request.Option) (*elasticache.DescribeCacheParametersOutput, error)
+	ModifyCacheClusterWithContext(ctx aws.Context, input *elasticache.ModifyCacheClusterInput, opts ...request.Option) (*elasticache.ModifyCacheClusterOutput, error)
 	ModifyReplicationGroupWithContext(ctx aws.Context, input *elasticache.ModifyReplicationGroupInput, opts ...request.Option) (*elasticache.ModifyReplicationGroupOutput, error)
+	ModifyReplicationGroupShardConfigurationWithContext(ctx aws.Context, input *elasticache.ModifyReplicationGroupShardConfigurationInput, opts ...request.Option) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error)
 	ModifyCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.ModifyCacheParameterGroupInput, opts ...request.Option) (*elasticache.CacheParameterGroupNameMessage, error)
+	CreateServerlessCacheWithContext(ctx aws.Context, input *elasticache.CreateServerlessCacheInput, opts ...request.Option) (*elasticache.CreateServerlessCacheOutput, error)
+	DescribeServerlessCachesWithContext(ctx aws.Context, input *elasticache.DescribeServerlessCachesInput, opts ...request.Option) (*elasticache.DescribeServerlessCachesOutput, error)
+	ModifyServerlessCacheWithContext(ctx aws.Context, input *elasticache.ModifyServerlessCacheInput, opts ...request.Option) (*elasticache.ModifyServerlessCacheOutput, error)
+	DeleteServerlessCacheWithContext(ctx aws.Context, input *elasticache.DeleteServerlessCacheInput, opts ...request.Option) (*elasticache.DeleteServerlessCacheOutput, error)
+	CreateServerlessCacheSnapshotWithContext(ctx aws.Context, input *elasticache.CreateServerlessCacheSnapshotInput, opts ...request.Option) (*elasticache.CreateServerlessCacheSnapshotOutput, error)
+	DescribeServerlessCacheSnapshotsPagesWithContext(ctx aws.Context, input *elasticache.DescribeServerlessCacheSnapshotsInput, fn func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, opts ...request.Option) error
+	CreateSnapshotWithContext(ctx aws.Context, input *elasticache.CreateSnapshotInput, opts ...request.Option) (*elasticache.CreateSnapshotOutput, error)
 	DescribeSnapshotsPagesWithContext(ctx aws.Context, input *elasticache.DescribeSnapshotsInput, fn func(*elasticache.DescribeSnapshotsOutput, bool) bool, opts ...request.Option) error
 	ListTagsForResourceWithContext(ctx aws.Context, input *elasticache.ListTagsForResourceInput, opts ...request.Option) (*elasticache.TagListMessage, error)
+	DescribeEventsPagesWithContext(ctx aws.Context, input *elasticache.DescribeEventsInput, fn func(*elasticache.DescribeEventsOutput, bool) bool, opts ...request.Option) error
 	TestFailoverWithContext(ctx aws.Context, input *elasticache.TestFailoverInput, opts ...request.Option) (*elasticache.TestFailoverOutput, error)
 	AddTagsToResourceWithContext(ctx aws.Context, input *elasticache.AddTagsToResourceInput, opts ...request.Option) (*elasticache.TagListMessage, error)
 	RemoveTagsFromResourceWithContext(ctx aws.Context, input *elasticache.RemoveTagsFromResourceInput, opts ...request.Option) (*elasticache.TagListMessage, error)
+	IncreaseReplicaCountWithContext(ctx aws.Context, input *elasticache.IncreaseReplicaCountInput, opts ...request.Option) (*elasticache.IncreaseReplicaCountOutput, error)
+	DecreaseReplicaCountWithContext(ctx aws.Context, input *elasticache.DecreaseReplicaCountInput, opts ...request.Option) (*elasticache.DecreaseReplicaCountOutput, error)
+	CreateUserWithContext(ctx aws.Context, input *elasticache.CreateUserInput, opts ...request.Option) (*elasticache.CreateUserOutput, error)
+	DeleteUserWithContext(ctx aws.Context, input *elasticache.DeleteUserInput, opts ...request.Option) (*elasticache.DeleteUserOutput, error)
+	CreateUserGroupWithContext(ctx aws.Context, input *elasticache.CreateUserGroupInput, opts ...request.Option) (*elasticache.CreateUserGroupOutput, error)
+	DescribeUserGroupsWithContext(ctx aws.Context, input *elasticache.DescribeUserGroupsInput, opts ...request.Option) (*elasticache.DescribeUserGroupsOutput, error)
+	ModifyUserGroupWithContext(ctx aws.Context, input *elasticache.ModifyUserGroupInput, opts ...request.Option) (*elasticache.ModifyUserGroupOutput, error)
+	DeleteUserGroupWithContext(ctx aws.Context, input *elasticache.DeleteUserGroupInput, opts ...request.Option) (*elasticache.DeleteUserGroupOutput, error)
 }
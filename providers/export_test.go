@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"context"
+	"time"
+)
+
+type ExportFakeClock struct {
+	Now    time.Time
+	Sleeps []time.Duration
+}
+
+// ExportUseFakeClock makes the client use a fake clock which sleeping advances, and backoff delays without jitter
+func (c *ResilientClient) ExportUseFakeClock(start time.Time) *ExportFakeClock {
+	clock := &ExportFakeClock{Now: start}
+	c.now = func() time.Time { return clock.Now }
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d > 0 {
+			clock.Sleeps = append(clock.Sleeps, d)
+			clock.Now = clock.Now.Add(d)
+		}
+		return nil
+	}
+	c.jitter = func(max time.Duration) time.Duration { return max }
+	c.bucket = newTokenBucket(*c.config.RequestsPerSecond, *c.config.Burst, start)
+	return clock
+}
+
+// ExportRecordSleeps makes the users record their delays instead of sleeping
+func (u *Users) ExportRecordSleeps() *[]time.Duration {
+	sleeps := []time.Duration{}
+	u.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	return &sleeps
+}
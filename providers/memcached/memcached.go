@@ -0,0 +1,526 @@
+package memcached
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+)
+
+var _ providers.Provider = &MemcachedProvider{}
+
+// Errors returned for the features Memcached doesn't have
+var (
+	ErrSnapshotsNotSupported        = providers.NotSupportedError("Memcached does not support snapshots")
+	ErrFailoverNotSupported         = providers.NotSupportedError("Memcached does not support failover, as it has no replicas")
+	ErrAuthTokenNotSupported        = providers.NotSupportedError("Memcached does not support auth tokens")
+	ErrEngineUpgradeNotSupported    = providers.NotSupportedError("Upgrading the engine version is not supported for Memcached")
+	ErrReplicasNotSupported         = providers.NotSupportedError("Memcached does not support replicas")
+	ErrShardsNotSupported           = providers.NotSupportedError("Memcached does not support shards, the number of nodes is set by the plan")
+	ErrDailyBackupWindowUnsupported = providers.NotSupportedError("Memcached does not support a daily backup window, as it has no snapshots")
+)
+
+// The status of a Memcached cluster while nodes are being added or rebooted
+const statusRebootingNodes = "rebooting cache cluster nodes"
+
+// MemcachedProvider is the Memcached broker provider
+type MemcachedProvider struct {
+	elastiCache  providers.ElastiCache
+	awsAccountID string
+	awsPartition string
+	awsRegion    string
+	logger       lager.Logger
+}
+
+// NewProvider creates a new Memcached provider
+func NewProvider(
+	elastiCache providers.ElastiCache,
+	awsAccountID, awsPartition,
+	awsRegion string,
+	logger lager.Logger,
+) *MemcachedProvider {
+	return &MemcachedProvider{
+		elastiCache:  elastiCache,
+		awsAccountID: awsAccountID,
+		awsPartition: awsPartition,
+		awsRegion:    awsRegion,
+		logger:       logger,
+	}
+}
+
+// DefaultCacheParameterGroupFamily returns the parameter group family of an engine version, e.g. memcached1.6
+// It's used for the plans which don't set a family explicitly.
+func DefaultCacheParameterGroupFamily(engineVersion string) string {
+	versionParts := strings.Split(engineVersion, ".")
+	if len(versionParts) < 2 {
+		return "memcached" + versionParts[0] + ".0"
+	}
+	return "memcached" + versionParts[0] + "." + versionParts[1]
+}
+
+// Provision creates a cache cluster and a cache parameter group
+func (p *MemcachedProvider) Provision(ctx context.Context, instanceID string, params providers.ProvisionParameters) error {
+	if params.RestoreFromSnapshot != nil {
+		return ErrSnapshotsNotSupported
+	}
+
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+
+	if params.CacheParameterGroupFamily == "" {
+		params.CacheParameterGroupFamily = DefaultCacheParameterGroupFamily(params.EngineVersion)
+	}
+
+	numCacheNodes := params.NumCacheNodes
+	if numCacheNodes < 1 {
+		numCacheNodes = 1
+	}
+
+	input := &elasticache.CreateCacheClusterInput{
+		Tags:                       []*elasticache.Tag{},
+		CacheClusterId:             aws.String(cacheClusterID),
+		CacheNodeType:              aws.String(params.InstanceType),
+		CacheParameterGroupName:    aws.String(cacheClusterID),
+		CacheSubnetGroupName:       aws.String(params.CacheSubnetGroupName),
+		SecurityGroupIds:           aws.StringSlice(params.SecurityGroupIds),
+		Engine:                     aws.String("memcached"),
+		EngineVersion:              aws.String(params.EngineVersion),
+		NumCacheNodes:              aws.Int64(numCacheNodes),
+		PreferredMaintenanceWindow: aws.String(params.PreferredMaintenanceWindow),
+		TransitEncryptionEnabled:   aws.Bool(true),
+	}
+
+	// Spread the nodes across the availability zones of the subnet group
+	if numCacheNodes > 1 {
+		input.SetAZMode(elasticache.AZModeCrossAz)
+	}
+
+	for tagName, tagValue := range params.Tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	steps := []providers.Step{
+		{
+			Name: "cache parameter group",
+			Do: func(ctx context.Context) error {
+				_, err := p.elastiCache.CreateCacheParameterGroupWithContext(ctx, &elasticache.CreateCacheParameterGroupInput{
+					CacheParameterGroupFamily: aws.String(params.CacheParameterGroupFamily),
+					CacheParameterGroupName:   aws.String(cacheClusterID),
+					Description:               aws.String("Created by Cloud Foundry"),
+				})
+				if providers.IsAWSError(err, elasticache.ErrCodeCacheParameterGroupAlreadyExistsFault) {
+					// It's left over from an earlier attempt to provision the instance, its parameters are set again
+					p.logger.Info("cache-parameter-group-exists", lager.Data{"cache-parameter-group-name": cacheClusterID})
+					return nil
+				}
+				return err
+			},
+			Undo: func(ctx context.Context) error {
+				return p.DeleteCacheParameterGroup(ctx, instanceID)
+			},
+		},
+		{
+			Name: "cache parameters",
+			Do: func(ctx context.Context) error {
+				return p.modifyCacheParameterGroup(ctx, cacheClusterID, params.Parameters)
+			},
+		},
+		{
+			Name: "cache cluster",
+			Do: func(ctx context.Context) error {
+				_, err := p.elastiCache.CreateCacheClusterWithContext(ctx, input)
+				if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterAlreadyExistsFault) {
+					// A retried or concurrent request has created it already, and it uses the cache parameter group,
+					// so it must not be rolled back
+					p.logger.Info("cache-cluster-exists", lager.Data{"instance-id": instanceID})
+					return nil
+				}
+				return err
+			},
+		},
+	}
+
+	return providers.RunSteps(ctx, p.logger.Session("provision", lager.Data{"instance-id": instanceID}), steps)
+}
+
+// UpdateReplicationGroup modifies the cache cluster and its tags
+// Memcached clusters have no replication group, so only the settings of the cluster itself can be changed.
+func (p *MemcachedProvider) UpdateReplicationGroup(ctx context.Context, instanceID string, params providers.UpdateReplicationGroupParameters) error {
+	if params.ReplicasPerNodeGroup != nil {
+		return ErrReplicasNotSupported
+	}
+	if params.ShardCount != nil {
+		return ErrShardsNotSupported
+	}
+	if params.DailyBackupWindow != "" {
+		return ErrDailyBackupWindowUnsupported
+	}
+	if params.SnapshotRetentionLimit != nil && *params.SnapshotRetentionLimit > 0 {
+		return ErrSnapshotsNotSupported
+	}
+
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+
+	input := &elasticache.ModifyCacheClusterInput{
+		CacheClusterId: aws.String(cacheClusterID),
+	}
+	modified := false
+
+	if len(params.PreferredMaintenanceWindow) > 0 {
+		input.SetPreferredMaintenanceWindow(params.PreferredMaintenanceWindow)
+		modified = true
+	}
+
+	// Node type and engine version changes would otherwise wait for the next maintenance window
+	if len(params.CacheNodeType) > 0 {
+		input.SetCacheNodeType(params.CacheNodeType)
+		input.SetApplyImmediately(true)
+		modified = true
+	}
+	if len(params.EngineVersion) > 0 {
+		input.SetEngineVersion(params.EngineVersion)
+		input.SetApplyImmediately(true)
+		modified = true
+	}
+
+	if modified {
+		_, err := p.elastiCache.ModifyCacheClusterWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.addTags(ctx, cacheClusterID, params.Tags)
+}
+
+func (p *MemcachedProvider) addTags(ctx context.Context, cacheClusterID string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	input := &elasticache.AddTagsToResourceInput{
+		ResourceName: aws.String(p.cacheClusterARN(cacheClusterID)),
+		Tags:         []*elasticache.Tag{},
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	_, err := p.elastiCache.AddTagsToResourceWithContext(ctx, input)
+	return err
+}
+
+// UpdateParamGroupParameters modifies the parameters of the cache parameter group
+func (p *MemcachedProvider) UpdateParamGroupParameters(ctx context.Context, instanceID string, params providers.UpdateParamGroupParameters) error {
+	return p.modifyCacheParameterGroup(ctx, GenerateCacheClusterName(instanceID), params.Parameters)
+}
+
+func (p *MemcachedProvider) modifyCacheParameterGroup(ctx context.Context, cacheParameterGroupName string, params map[string]string) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	pgParams := []*elasticache.ParameterNameValue{}
+	for paramName, paramValue := range params {
+		pgParams = append(pgParams, &elasticache.ParameterNameValue{
+			ParameterName:  aws.String(paramName),
+			ParameterValue: aws.String(paramValue),
+		})
+	}
+
+	_, err := p.elastiCache.ModifyCacheParameterGroupWithContext(ctx, &elasticache.ModifyCacheParameterGroupInput{
+		ParameterNameValues:     pgParams,
+		CacheParameterGroupName: aws.String(cacheParameterGroupName),
+	})
+	return err
+}
+
+// Deprovision deletes the cache cluster
+// Memcached has no snapshots, so the final snapshot identifier is ignored.
+func (p *MemcachedProvider) Deprovision(ctx context.Context, instanceID string, params providers.DeprovisionParameters) error {
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+	_, err := p.elastiCache.DeleteCacheClusterWithContext(ctx, &elasticache.DeleteCacheClusterInput{
+		CacheClusterId: aws.String(cacheClusterID),
+	})
+	if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault) {
+		return providers.ErrInstanceNotFound
+	}
+	if providers.IsAWSError(err, elasticache.ErrCodeInvalidCacheClusterStateFault) {
+		cacheCluster, describeErr := p.describeCacheCluster(ctx, cacheClusterID)
+		if describeErr == nil && aws.StringValue(cacheCluster.CacheClusterStatus) == "deleting" {
+			// A retried request, the cache cluster is already being deleted
+			return nil
+		}
+	}
+	return err
+}
+
+// DeleteCacheParameterGroup deletes the cache parameter group of an instance
+func (p *MemcachedProvider) DeleteCacheParameterGroup(ctx context.Context, instanceID string) error {
+	_, err := p.elastiCache.DeleteCacheParameterGroupWithContext(ctx, &elasticache.DeleteCacheParameterGroupInput{
+		CacheParameterGroupName: aws.String(GenerateCacheClusterName(instanceID)),
+	})
+	if err != nil && !providers.IsAWSError(err, elasticache.ErrCodeCacheParameterGroupNotFoundFault) {
+		return err
+	}
+	return nil
+}
+
+// DeleteUnusedCacheParameterGroups is a no-op, as Memcached instances only ever have a single parameter group
+func (p *MemcachedProvider) DeleteUnusedCacheParameterGroups(ctx context.Context, instanceID string) error {
+	return nil
+}
+
+// DeleteUserGroup is a no-op, as Memcached has no users
+func (p *MemcachedProvider) DeleteUserGroup(ctx context.Context, instanceID string) error {
+	return nil
+}
+
+// ProgressState returns with the state of an existing cluster
+// If the cluster doesn't exist we return with the providers.NonExisting state
+func (p *MemcachedProvider) ProgressState(
+	ctx context.Context,
+	instanceID string,
+	operation string,
+	primaryNode string,
+) (providers.ServiceState, string, error) {
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+
+	cacheCluster, err := p.describeCacheCluster(ctx, cacheClusterID)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault) {
+			return providers.NonExisting, fmt.Sprintf("Cache cluster does not exist: %s", cacheClusterID), nil
+		}
+		return providers.ServiceState(""), "", err
+	}
+
+	if cacheCluster.CacheClusterStatus == nil {
+		return providers.ServiceState(""), "", fmt.Errorf("Invalid response from AWS: status is missing for %s", cacheClusterID)
+	}
+
+	state := providers.ServiceState(*cacheCluster.CacheClusterStatus)
+	if *cacheCluster.CacheClusterStatus == statusRebootingNodes {
+		state = providers.Modifying
+	}
+	return state, p.getMessage(cacheCluster), nil
+}
+
+func (p *MemcachedProvider) getMessage(cacheCluster *elasticache.CacheCluster) string {
+	tmpl := "%-20s : %s"
+	msgs := []string{"---"}
+	msgs = append(msgs, fmt.Sprintf(tmpl, "status", aws.StringValue(cacheCluster.CacheClusterStatus)))
+	msgs = append(msgs, fmt.Sprintf(tmpl, "cluster id", aws.StringValue(cacheCluster.CacheClusterId)))
+	if cacheCluster.EngineVersion != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "engine version", *cacheCluster.EngineVersion))
+	}
+	if cacheCluster.NumCacheNodes != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "cache nodes", fmt.Sprint(*cacheCluster.NumCacheNodes)))
+	}
+	if cacheCluster.PreferredMaintenanceWindow != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "maintenance window", *cacheCluster.PreferredMaintenanceWindow))
+	}
+	return strings.Join(msgs, "\n           ")
+}
+
+func (p *MemcachedProvider) describeCacheCluster(ctx context.Context, cacheClusterID string) (*elasticache.CacheCluster, error) {
+	output, err := p.elastiCache.DescribeCacheClustersWithContext(ctx, &elasticache.DescribeCacheClustersInput{
+		CacheClusterId:    aws.String(cacheClusterID),
+		ShowCacheNodeInfo: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.CacheClusters) == 0 {
+		return nil, fmt.Errorf("Invalid response from AWS: no CacheClusters found for %s", cacheClusterID)
+	}
+
+	return output.CacheClusters[0], nil
+}
+
+// GenerateCredentials returns the configuration endpoint and the nodes of a Memcached instance
+// Memcached has no authentication, so the credentials are the same for every app.
+func (p *MemcachedProvider) GenerateCredentials(ctx context.Context, instanceID, bindingID string) (*providers.Credentials, error) {
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+
+	cacheCluster, err := p.describeCacheCluster(ctx, cacheClusterID)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault) {
+			return nil, providers.InstanceNotFoundError(fmt.Sprintf("Cache cluster does not exist: %s", cacheClusterID))
+		}
+		return nil, err
+	}
+
+	if cacheCluster.ConfigurationEndpoint == nil {
+		return nil, fmt.Errorf("Invalid response from AWS: no configuration endpoint returned for %s", cacheClusterID)
+	}
+	host := aws.StringValue(cacheCluster.ConfigurationEndpoint.Address)
+	port := aws.Int64Value(cacheCluster.ConfigurationEndpoint.Port)
+
+	nodes := []string{}
+	for _, cacheNode := range cacheCluster.CacheNodes {
+		if cacheNode.Endpoint == nil {
+			continue
+		}
+		nodes = append(nodes, fmt.Sprintf("%s:%d", aws.StringValue(cacheNode.Endpoint.Address), aws.Int64Value(cacheNode.Endpoint.Port)))
+	}
+
+	return &providers.Credentials{
+		Host:       host,
+		Port:       port,
+		Name:       cacheClusterID,
+		TLSEnabled: aws.BoolValue(cacheCluster.TransitEncryptionEnabled),
+		URI:        fmt.Sprintf("memcached://%s:%d", host, port),
+		Nodes:      nodes,
+	}, nil
+}
+
+// RevokeCredentials is a no-op, as the credentials of Memcached instances are not per binding
+func (p *MemcachedProvider) RevokeCredentials(ctx context.Context, instanceID, bindingID string) error {
+	return nil
+}
+
+func (p *MemcachedProvider) GetInstanceTags(ctx context.Context, instanceID string) (map[string]string, error) {
+	awsTags, err := p.elastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{
+		ResourceName: aws.String(p.cacheClusterARN(GenerateCacheClusterName(instanceID))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for _, t := range awsTags.TagList {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+// FindInstance returns the state and the tags of the cache cluster of an instance, or nil if it doesn't exist
+func (p *MemcachedProvider) FindInstance(ctx context.Context, instanceID string) (*providers.ExistingInstance, error) {
+	cacheCluster, err := p.describeCacheCluster(ctx, GenerateCacheClusterName(instanceID))
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	state := providers.ServiceState(aws.StringValue(cacheCluster.CacheClusterStatus))
+	if state == statusRebootingNodes {
+		state = providers.Modifying
+	}
+	return &providers.ExistingInstance{State: state, Tags: tags}, nil
+}
+
+func (p *MemcachedProvider) GetInstanceParameters(ctx context.Context, instanceID string) (providers.InstanceParameters, error) {
+	cacheClusterID := GenerateCacheClusterName(instanceID)
+	instanceParameters := providers.InstanceParameters{}
+
+	cacheCluster, err := p.describeCacheCluster(ctx, cacheClusterID)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeCacheClusterNotFoundFault) {
+			return instanceParameters, fmt.Errorf("Cache cluster does not exist: %s", cacheClusterID)
+		}
+		return instanceParameters, err
+	}
+
+	instanceParameters.PreferredMaintenanceWindow = aws.StringValue(cacheCluster.PreferredMaintenanceWindow)
+	instanceParameters.Engine = aws.StringValue(cacheCluster.Engine)
+	instanceParameters.EngineVersion = aws.StringValue(cacheCluster.EngineVersion)
+	instanceParameters.CacheNodeType = aws.StringValue(cacheCluster.CacheNodeType)
+	for _, cacheNode := range cacheCluster.CacheNodes {
+		instanceParameters.ActiveNodes = append(instanceParameters.ActiveNodes, aws.StringValue(cacheNode.CacheNodeId))
+	}
+
+	groupName := cacheClusterID
+	if cacheCluster.CacheParameterGroup != nil && cacheCluster.CacheParameterGroup.CacheParameterGroupName != nil {
+		groupName = *cacheCluster.CacheParameterGroup.CacheParameterGroupName
+	}
+	output, err := p.elastiCache.DescribeCacheParametersWithContext(ctx, &elasticache.DescribeCacheParametersInput{
+		CacheParameterGroupName: aws.String(groupName),
+	})
+	if err != nil {
+		return instanceParameters, err
+	}
+	for _, param := range output.Parameters {
+		instanceParameters.CacheParameters = append(instanceParameters.CacheParameters, providers.CacheParameter{
+			ParameterName:  aws.StringValue(param.ParameterName),
+			ParameterValue: aws.StringValue(param.ParameterValue),
+		})
+	}
+
+	return instanceParameters, nil
+}
+
+// ListSnapshots returns an empty list, as Memcached has no snapshots
+func (p *MemcachedProvider) ListSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	return []providers.SnapshotInfo{}, nil
+}
+
+func (p *MemcachedProvider) FindSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	return nil, ErrSnapshotsNotSupported
+}
+
+func (p *MemcachedProvider) FindSnapshot(ctx context.Context, snapshotName string) (*providers.SnapshotInfo, error) {
+	return nil, ErrSnapshotsNotSupported
+}
+
+func (p *MemcachedProvider) FindSnapshotsWithTags(ctx context.Context, tags map[string]string) ([]providers.SnapshotInfo, error) {
+	return nil, ErrSnapshotsNotSupported
+}
+
+func (p *MemcachedProvider) CreateSnapshot(ctx context.Context, instanceID string, snapshotName string) error {
+	return ErrSnapshotsNotSupported
+}
+
+func (p *MemcachedProvider) StartFailoverTest(ctx context.Context, instanceID string) (string, error) {
+	return "", ErrFailoverNotSupported
+}
+
+func (p *MemcachedProvider) TestFailover(ctx context.Context, instanceID string, nodeGroupID string) (string, error) {
+	return "", ErrFailoverNotSupported
+}
+
+func (p *MemcachedProvider) FailoverTestState(ctx context.Context, instanceID string, nodeGroupID string, startTime time.Time) (providers.ServiceState, string, error) {
+	return providers.ServiceState(""), "", ErrFailoverNotSupported
+}
+
+func (p *MemcachedProvider) RestoreHighAvailability(ctx context.Context, instanceID string) (bool, error) {
+	return false, ErrFailoverNotSupported
+}
+
+// FindDegradedInstances returns no instances, as Memcached clusters don't have automatic failover or Multi-AZ to disable
+func (p *MemcachedProvider) FindDegradedInstances(ctx context.Context, brokerName string) ([]providers.DegradedInstance, error) {
+	return nil, nil
+}
+
+func (p *MemcachedProvider) RotateAuthToken(ctx context.Context, instanceID string) error {
+	return ErrAuthTokenNotSupported
+}
+
+func (p *MemcachedProvider) UpgradeEngineVersion(ctx context.Context, instanceID string, params providers.UpgradeEngineVersionParameters) error {
+	return ErrEngineUpgradeNotSupported
+}
+
+func (p *MemcachedProvider) cacheClusterARN(cacheClusterID string) string {
+	return fmt.Sprintf("arn:%s:elasticache:%s:%s:cluster:%s", p.awsPartition, p.awsRegion, p.awsAccountID, cacheClusterID)
+}
+
+// GenerateCacheClusterName generates a valid ElastiCache cache cluster name
+// A valid name must contain between 1 and 40 alphanumeric characters or hyphens, should start with a letter, and cannot end with a hyphen or contain two consecutive hyphens.
+func GenerateCacheClusterName(instanceID string) string {
+	return providers.GenerateName("cf-", instanceID)
+}
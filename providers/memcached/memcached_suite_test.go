@@ -0,0 +1,13 @@
+package memcached_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMemcached(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Memcached Suite")
+}
@@ -0,0 +1,434 @@
+package memcached_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	. "github.com/alphagov/paas-elasticache-broker/providers/memcached"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Provider", func() {
+	var (
+		mockElasticache *mocks.FakeElastiCache
+		ctx             context.Context
+		instanceID      string
+		cacheClusterID  string
+
+		provider *MemcachedProvider
+	)
+
+	BeforeEach(func() {
+		mockElasticache = &mocks.FakeElastiCache{}
+		ctx = context.Background()
+		instanceID = "foobar"
+		cacheClusterID = "cf-qwkec4pxhft6q"
+
+		provider = NewProvider(mockElasticache, "123456789012", "aws", "eu-west-1", lager.NewLogger("logger"))
+	})
+
+	It("generates the same cluster names as the redis provider", func() {
+		Expect(GenerateCacheClusterName(instanceID)).To(Equal(cacheClusterID))
+	})
+
+	DescribeTable("the default cache parameter group family",
+		func(engineVersion, family string) {
+			Expect(DefaultCacheParameterGroupFamily(engineVersion)).To(Equal(family))
+		},
+		Entry("1.6.22", "1.6.22", "memcached1.6"),
+		Entry("1.5", "1.5", "memcached1.5"),
+		Entry("1", "1", "memcached1.0"),
+	)
+
+	Context("when provisioning", func() {
+		var params providers.ProvisionParameters
+
+		BeforeEach(func() {
+			params = providers.ProvisionParameters{
+				InstanceType:               "cache.t3.micro",
+				CacheParameterGroupFamily:  "memcached1.6",
+				SecurityGroupIds:           []string{"sg-1"},
+				CacheSubnetGroupName:       "subnet-group",
+				PreferredMaintenanceWindow: "sun:23:00-mon:01:30",
+				NumCacheNodes:              2,
+				Parameters:                 map[string]string{"max_item_size": "2097152"},
+				Tags:                       map[string]string{"instance-id": instanceID},
+				EngineVersion:              "1.6.17",
+			}
+		})
+
+		It("creates a cache parameter group with the parameters", func() {
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, createInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
+			Expect(createInput).To(Equal(&elasticache.CreateCacheParameterGroupInput{
+				CacheParameterGroupFamily: aws.String("memcached1.6"),
+				CacheParameterGroupName:   aws.String(cacheClusterID),
+				Description:               aws.String("Created by Cloud Foundry"),
+			}))
+
+			_, modifyInput, _ := mockElasticache.ModifyCacheParameterGroupWithContextArgsForCall(0)
+			Expect(modifyInput).To(Equal(&elasticache.ModifyCacheParameterGroupInput{
+				CacheParameterGroupName: aws.String(cacheClusterID),
+				ParameterNameValues: []*elasticache.ParameterNameValue{
+					{ParameterName: aws.String("max_item_size"), ParameterValue: aws.String("2097152")},
+				},
+			}))
+		})
+
+		It("uses the parameter group family of the engine version if the plan doesn't set one", func() {
+			params.CacheParameterGroupFamily = ""
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, createInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
+			Expect(createInput.CacheParameterGroupFamily).To(Equal(aws.String("memcached1.6")))
+		})
+
+		It("creates a cache cluster with the tags", func() {
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateCacheClusterWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.CreateCacheClusterWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.CreateCacheClusterInput{
+				CacheClusterId:             aws.String(cacheClusterID),
+				CacheNodeType:              aws.String("cache.t3.micro"),
+				CacheParameterGroupName:    aws.String(cacheClusterID),
+				CacheSubnetGroupName:       aws.String("subnet-group"),
+				SecurityGroupIds:           aws.StringSlice([]string{"sg-1"}),
+				Engine:                     aws.String("memcached"),
+				EngineVersion:              aws.String("1.6.17"),
+				NumCacheNodes:              aws.Int64(2),
+				AZMode:                     aws.String(elasticache.AZModeCrossAz),
+				PreferredMaintenanceWindow: aws.String("sun:23:00-mon:01:30"),
+				TransitEncryptionEnabled:   aws.Bool(true),
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}))
+		})
+
+		It("creates a single node by default", func() {
+			params.NumCacheNodes = 0
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.CreateCacheClusterWithContextArgsForCall(0)
+			Expect(input.NumCacheNodes).To(Equal(aws.Int64(1)))
+			Expect(input.AZMode).To(BeNil())
+		})
+
+		It("deletes the cache parameter group if creating the cluster fails", func() {
+			mockElasticache.CreateCacheClusterWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError("some error (cache parameter group: rolled back)"))
+
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+			Expect(input.CacheParameterGroupName).To(Equal(aws.String(cacheClusterID)))
+		})
+
+		It("doesn't delete the cache parameter group if the cluster already exists", func() {
+			mockElasticache.CreateCacheClusterWithContextReturns(nil, awserr.New(elasticache.ErrCodeCacheClusterAlreadyExistsFault, "already exists", nil))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(0))
+		})
+
+		It("deletes the cache parameter group if setting its parameters fails", func() {
+			mockElasticache.ModifyCacheParameterGroupWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError("some error (cache parameter group: rolled back)"))
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.CreateCacheClusterWithContextCallCount()).To(Equal(0))
+		})
+
+		It("reuses a cache parameter group left over from an earlier attempt", func() {
+			mockElasticache.CreateCacheParameterGroupWithContextReturns(nil,
+				awserr.New(elasticache.ErrCodeCacheParameterGroupAlreadyExistsFault, "already exists", nil))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.ModifyCacheParameterGroupWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.CreateCacheClusterWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(0))
+		})
+
+		It("doesn't delete the cache parameter group if creating it fails", func() {
+			mockElasticache.CreateCacheParameterGroupWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError("some error"))
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.CreateCacheClusterWithContextCallCount()).To(Equal(0))
+		})
+
+		It("doesn't restore from snapshots", func() {
+			params.RestoreFromSnapshot = aws.String("some-snapshot")
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError(ErrSnapshotsNotSupported))
+			Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when updating", func() {
+		It("modifies the cache cluster and its tags", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				PreferredMaintenanceWindow: "sun:23:00-mon:01:30",
+				CacheNodeType:              "cache.m5.large",
+				Tags:                       map[string]string{"plan-id": "plan2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.ModifyCacheClusterWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyCacheClusterInput{
+				CacheClusterId:             aws.String(cacheClusterID),
+				PreferredMaintenanceWindow: aws.String("sun:23:00-mon:01:30"),
+				CacheNodeType:              aws.String("cache.m5.large"),
+				ApplyImmediately:           aws.Bool(true),
+			}))
+
+			_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(tagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:cluster:" + cacheClusterID)))
+		})
+
+		It("only updates the tags if nothing else changed", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				Tags: map[string]string{"plan-id": "plan2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.ModifyCacheClusterWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+		})
+
+		It("doesn't change the number of replicas", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				ReplicasPerNodeGroup: aws.Int64(2),
+			})
+			Expect(err).To(MatchError(ErrReplicasNotSupported))
+		})
+
+		It("modifies the cache parameter group", func() {
+			err := provider.UpdateParamGroupParameters(ctx, instanceID, providers.UpdateParamGroupParameters{
+				Parameters: map[string]string{"max_item_size": "2097152"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.ModifyCacheParameterGroupWithContextArgsForCall(0)
+			Expect(input.CacheParameterGroupName).To(Equal(aws.String(cacheClusterID)))
+		})
+	})
+
+	Context("when deprovisioning", func() {
+		It("deletes the cache cluster", func() {
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{FinalSnapshotIdentifier: "final"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.DeleteCacheClusterWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.DeleteCacheClusterInput{
+				CacheClusterId: aws.String(cacheClusterID),
+			}))
+		})
+
+		It("returns ErrInstanceNotFound if the cache cluster doesn't exist", func() {
+			mockElasticache.DeleteCacheClusterWithContextReturns(nil, awserr.New(elasticache.ErrCodeCacheClusterNotFoundFault, "not found", nil))
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).To(MatchError(providers.ErrInstanceNotFound))
+		})
+
+		It("succeeds if the cache cluster is being deleted already", func() {
+			mockElasticache.DeleteCacheClusterWithContextReturns(nil, awserr.New(elasticache.ErrCodeInvalidCacheClusterStateFault, "invalid state", nil))
+			mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+				CacheClusters: []*elasticache.CacheCluster{{CacheClusterStatus: aws.String("deleting")}},
+			}, nil)
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the error if the cache cluster is in another state", func() {
+			mockElasticache.DeleteCacheClusterWithContextReturns(nil, awserr.New(elasticache.ErrCodeInvalidCacheClusterStateFault, "invalid state", nil))
+			mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+				CacheClusters: []*elasticache.CacheCluster{{CacheClusterStatus: aws.String("modifying")}},
+			}, nil)
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).To(MatchError(ContainSubstring("invalid state")))
+		})
+
+		It("ignores a cache parameter group which was deleted already", func() {
+			mockElasticache.DeleteCacheParameterGroupWithContextReturns(nil, awserr.New(elasticache.ErrCodeCacheParameterGroupNotFoundFault, "not found", nil))
+
+			err := provider.DeleteCacheParameterGroup(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when the cache cluster exists", func() {
+		var status string
+
+		BeforeEach(func() {
+			status = "available"
+		})
+
+		JustBeforeEach(func() {
+			mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+				CacheClusters: []*elasticache.CacheCluster{
+					{
+						CacheClusterId:           aws.String(cacheClusterID),
+						CacheClusterStatus:       aws.String(status),
+						CacheNodeType:            aws.String("cache.t3.micro"),
+						EngineVersion:            aws.String("1.6.17"),
+						NumCacheNodes:            aws.Int64(2),
+						TransitEncryptionEnabled: aws.Bool(true),
+						ConfigurationEndpoint: &elasticache.Endpoint{
+							Address: aws.String("cf-qwkec4pxhft6q.cfg.euw1.cache.amazonaws.com"),
+							Port:    aws.Int64(11211),
+						},
+						CacheNodes: []*elasticache.CacheNode{
+							{
+								CacheNodeId: aws.String("0001"),
+								Endpoint: &elasticache.Endpoint{
+									Address: aws.String("cf-qwkec4pxhft6q.0001.euw1.cache.amazonaws.com"),
+									Port:    aws.Int64(11211),
+								},
+							},
+							{
+								CacheNodeId: aws.String("0002"),
+								Endpoint: &elasticache.Endpoint{
+									Address: aws.String("cf-qwkec4pxhft6q.0002.euw1.cache.amazonaws.com"),
+									Port:    aws.Int64(11211),
+								},
+							},
+						},
+					},
+				},
+			}, nil)
+			mockElasticache.DescribeCacheParametersWithContextReturns(&elasticache.DescribeCacheParametersOutput{
+				Parameters: []*elasticache.Parameter{
+					{ParameterName: aws.String("max_item_size"), ParameterValue: aws.String("2097152")},
+				},
+			}, nil)
+		})
+
+		It("returns the state of the cluster", func() {
+			state, message, err := provider.ProgressState(ctx, instanceID, "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Available))
+			Expect(message).To(ContainSubstring("1.6.17"))
+
+			_, input, _ := mockElasticache.DescribeCacheClustersWithContextArgsForCall(0)
+			Expect(input.CacheClusterId).To(Equal(aws.String(cacheClusterID)))
+		})
+
+		Context("when nodes are being rebooted", func() {
+			BeforeEach(func() {
+				status = "rebooting cache cluster nodes"
+			})
+
+			It("reports the cluster as modifying", func() {
+				state, _, err := provider.ProgressState(ctx, instanceID, "", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state).To(Equal(providers.Modifying))
+			})
+		})
+
+		It("finds the instance with its tags", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{Key: aws.String("plan-id"), Value: aws.String("plan1")}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(Equal(&providers.ExistingInstance{
+				State: providers.Available,
+				Tags:  map[string]string{"plan-id": "plan1"},
+			}))
+		})
+
+		It("returns the configuration endpoint and the nodes as credentials", func() {
+			credentials, err := provider.GenerateCredentials(ctx, instanceID, "binding1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credentials).To(Equal(&providers.Credentials{
+				Host:       "cf-qwkec4pxhft6q.cfg.euw1.cache.amazonaws.com",
+				Port:       11211,
+				Name:       cacheClusterID,
+				URI:        "memcached://cf-qwkec4pxhft6q.cfg.euw1.cache.amazonaws.com:11211",
+				TLSEnabled: true,
+				Nodes: []string{
+					"cf-qwkec4pxhft6q.0001.euw1.cache.amazonaws.com:11211",
+					"cf-qwkec4pxhft6q.0002.euw1.cache.amazonaws.com:11211",
+				},
+			}))
+		})
+
+		It("returns the instance parameters", func() {
+			instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceParameters.CacheNodeType).To(Equal("cache.t3.micro"))
+			Expect(instanceParameters.EngineVersion).To(Equal("1.6.17"))
+			Expect(instanceParameters.ActiveNodes).To(Equal([]string{"0001", "0002"}))
+			Expect(instanceParameters.CacheParameters).To(Equal([]providers.CacheParameter{
+				{ParameterName: "max_item_size", ParameterValue: "2097152"},
+			}))
+		})
+	})
+
+	Context("when the cache cluster doesn't exist", func() {
+		BeforeEach(func() {
+			mockElasticache.DescribeCacheClustersWithContextReturns(nil, awserr.New(elasticache.ErrCodeCacheClusterNotFoundFault, "not found", nil))
+		})
+
+		It("returns the non-existing state", func() {
+			state, _, err := provider.ProgressState(ctx, instanceID, "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.NonExisting))
+		})
+
+		It("fails to generate credentials", func() {
+			_, err := provider.GenerateCredentials(ctx, instanceID, "binding1")
+			Expect(err).To(MatchError("Cache cluster does not exist: " + cacheClusterID))
+			Expect(errors.Is(err, providers.ErrInstanceNotFound)).To(BeTrue())
+		})
+
+		It("doesn't find the instance", func() {
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(BeNil())
+		})
+	})
+
+	It("returns an empty list of snapshots", func() {
+		snapshots, err := provider.ListSnapshots(ctx, instanceID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(BeEmpty())
+	})
+
+	It("returns clear errors for the unsupported operations", func() {
+		Expect(provider.CreateSnapshot(ctx, instanceID, "snapshot")).To(MatchError("Memcached does not support snapshots"))
+		_, err := provider.FindSnapshots(ctx, instanceID)
+		Expect(err).To(MatchError("Memcached does not support snapshots"))
+		_, err = provider.FindSnapshot(ctx, "snapshot")
+		Expect(err).To(MatchError("Memcached does not support snapshots"))
+		_, err = provider.FindSnapshotsWithTags(ctx, map[string]string{"space-id": "space"})
+		Expect(err).To(MatchError("Memcached does not support snapshots"))
+		_, err = provider.StartFailoverTest(ctx, instanceID)
+		Expect(err).To(MatchError("Memcached does not support failover, as it has no replicas"))
+		Expect(provider.RotateAuthToken(ctx, instanceID)).To(MatchError(ErrAuthTokenNotSupported))
+		Expect(mockElasticache.Invocations()).To(BeEmpty())
+	})
+})
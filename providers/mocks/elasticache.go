@@ -26,6 +26,21 @@ type FakeElastiCache struct {
 		result1 *elasticache.TagListMessage
 		result2 error
 	}
+	CreateCacheClusterWithContextStub        func(context.Context, *elasticache.CreateCacheClusterInput, ...request.Option) (*elasticache.CreateCacheClusterOutput, error)
+	createCacheClusterWithContextMutex       sync.RWMutex
+	createCacheClusterWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateCacheClusterInput
+		arg3 []request.Option
+	}
+	createCacheClusterWithContextReturns struct {
+		result1 *elasticache.CreateCacheClusterOutput
+		result2 error
+	}
+	createCacheClusterWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateCacheClusterOutput
+		result2 error
+	}
 	CreateCacheParameterGroupWithContextStub        func(context.Context, *elasticache.CreateCacheParameterGroupInput, ...request.Option) (*elasticache.CreateCacheParameterGroupOutput, error)
 	createCacheParameterGroupWithContextMutex       sync.RWMutex
 	createCacheParameterGroupWithContextArgsForCall []struct {
@@ -56,6 +71,111 @@ type FakeElastiCache struct {
 		result1 *elasticache.CreateReplicationGroupOutput
 		result2 error
 	}
+	CreateServerlessCacheSnapshotWithContextStub        func(context.Context, *elasticache.CreateServerlessCacheSnapshotInput, ...request.Option) (*elasticache.CreateServerlessCacheSnapshotOutput, error)
+	createServerlessCacheSnapshotWithContextMutex       sync.RWMutex
+	createServerlessCacheSnapshotWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateServerlessCacheSnapshotInput
+		arg3 []request.Option
+	}
+	createServerlessCacheSnapshotWithContextReturns struct {
+		result1 *elasticache.CreateServerlessCacheSnapshotOutput
+		result2 error
+	}
+	createServerlessCacheSnapshotWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateServerlessCacheSnapshotOutput
+		result2 error
+	}
+	CreateServerlessCacheWithContextStub        func(context.Context, *elasticache.CreateServerlessCacheInput, ...request.Option) (*elasticache.CreateServerlessCacheOutput, error)
+	createServerlessCacheWithContextMutex       sync.RWMutex
+	createServerlessCacheWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateServerlessCacheInput
+		arg3 []request.Option
+	}
+	createServerlessCacheWithContextReturns struct {
+		result1 *elasticache.CreateServerlessCacheOutput
+		result2 error
+	}
+	createServerlessCacheWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateServerlessCacheOutput
+		result2 error
+	}
+	CreateSnapshotWithContextStub        func(context.Context, *elasticache.CreateSnapshotInput, ...request.Option) (*elasticache.CreateSnapshotOutput, error)
+	createSnapshotWithContextMutex       sync.RWMutex
+	createSnapshotWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateSnapshotInput
+		arg3 []request.Option
+	}
+	createSnapshotWithContextReturns struct {
+		result1 *elasticache.CreateSnapshotOutput
+		result2 error
+	}
+	createSnapshotWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateSnapshotOutput
+		result2 error
+	}
+	CreateUserGroupWithContextStub        func(context.Context, *elasticache.CreateUserGroupInput, ...request.Option) (*elasticache.CreateUserGroupOutput, error)
+	createUserGroupWithContextMutex       sync.RWMutex
+	createUserGroupWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateUserGroupInput
+		arg3 []request.Option
+	}
+	createUserGroupWithContextReturns struct {
+		result1 *elasticache.CreateUserGroupOutput
+		result2 error
+	}
+	createUserGroupWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateUserGroupOutput
+		result2 error
+	}
+	CreateUserWithContextStub        func(context.Context, *elasticache.CreateUserInput, ...request.Option) (*elasticache.CreateUserOutput, error)
+	createUserWithContextMutex       sync.RWMutex
+	createUserWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateUserInput
+		arg3 []request.Option
+	}
+	createUserWithContextReturns struct {
+		result1 *elasticache.CreateUserOutput
+		result2 error
+	}
+	createUserWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.CreateUserOutput
+		result2 error
+	}
+	DecreaseReplicaCountWithContextStub        func(context.Context, *elasticache.DecreaseReplicaCountInput, ...request.Option) (*elasticache.DecreaseReplicaCountOutput, error)
+	decreaseReplicaCountWithContextMutex       sync.RWMutex
+	decreaseReplicaCountWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DecreaseReplicaCountInput
+		arg3 []request.Option
+	}
+	decreaseReplicaCountWithContextReturns struct {
+		result1 *elasticache.DecreaseReplicaCountOutput
+		result2 error
+	}
+	decreaseReplicaCountWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DecreaseReplicaCountOutput
+		result2 error
+	}
+	DeleteCacheClusterWithContextStub        func(context.Context, *elasticache.DeleteCacheClusterInput, ...request.Option) (*elasticache.DeleteCacheClusterOutput, error)
+	deleteCacheClusterWithContextMutex       sync.RWMutex
+	deleteCacheClusterWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteCacheClusterInput
+		arg3 []request.Option
+	}
+	deleteCacheClusterWithContextReturns struct {
+		result1 *elasticache.DeleteCacheClusterOutput
+		result2 error
+	}
+	deleteCacheClusterWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DeleteCacheClusterOutput
+		result2 error
+	}
 	DeleteCacheParameterGroupWithContextStub        func(context.Context, *elasticache.DeleteCacheParameterGroupInput, ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error)
 	deleteCacheParameterGroupWithContextMutex       sync.RWMutex
 	deleteCacheParameterGroupWithContextArgsForCall []struct {
@@ -86,6 +206,51 @@ type FakeElastiCache struct {
 		result1 *elasticache.DeleteReplicationGroupOutput
 		result2 error
 	}
+	DeleteServerlessCacheWithContextStub        func(context.Context, *elasticache.DeleteServerlessCacheInput, ...request.Option) (*elasticache.DeleteServerlessCacheOutput, error)
+	deleteServerlessCacheWithContextMutex       sync.RWMutex
+	deleteServerlessCacheWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteServerlessCacheInput
+		arg3 []request.Option
+	}
+	deleteServerlessCacheWithContextReturns struct {
+		result1 *elasticache.DeleteServerlessCacheOutput
+		result2 error
+	}
+	deleteServerlessCacheWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DeleteServerlessCacheOutput
+		result2 error
+	}
+	DeleteUserGroupWithContextStub        func(context.Context, *elasticache.DeleteUserGroupInput, ...request.Option) (*elasticache.DeleteUserGroupOutput, error)
+	deleteUserGroupWithContextMutex       sync.RWMutex
+	deleteUserGroupWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteUserGroupInput
+		arg3 []request.Option
+	}
+	deleteUserGroupWithContextReturns struct {
+		result1 *elasticache.DeleteUserGroupOutput
+		result2 error
+	}
+	deleteUserGroupWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DeleteUserGroupOutput
+		result2 error
+	}
+	DeleteUserWithContextStub        func(context.Context, *elasticache.DeleteUserInput, ...request.Option) (*elasticache.DeleteUserOutput, error)
+	deleteUserWithContextMutex       sync.RWMutex
+	deleteUserWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteUserInput
+		arg3 []request.Option
+	}
+	deleteUserWithContextReturns struct {
+		result1 *elasticache.DeleteUserOutput
+		result2 error
+	}
+	deleteUserWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DeleteUserOutput
+		result2 error
+	}
 	DescribeCacheClustersWithContextStub        func(context.Context, *elasticache.DescribeCacheClustersInput, ...request.Option) (*elasticache.DescribeCacheClustersOutput, error)
 	describeCacheClustersWithContextMutex       sync.RWMutex
 	describeCacheClustersWithContextArgsForCall []struct {
@@ -116,6 +281,49 @@ type FakeElastiCache struct {
 		result1 *elasticache.DescribeCacheParametersOutput
 		result2 error
 	}
+	DescribeCacheSubnetGroupsWithContextStub        func(context.Context, *elasticache.DescribeCacheSubnetGroupsInput, ...request.Option) (*elasticache.DescribeCacheSubnetGroupsOutput, error)
+	describeCacheSubnetGroupsWithContextMutex       sync.RWMutex
+	describeCacheSubnetGroupsWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeCacheSubnetGroupsInput
+		arg3 []request.Option
+	}
+	describeCacheSubnetGroupsWithContextReturns struct {
+		result1 *elasticache.DescribeCacheSubnetGroupsOutput
+		result2 error
+	}
+	describeCacheSubnetGroupsWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DescribeCacheSubnetGroupsOutput
+		result2 error
+	}
+	DescribeEventsPagesWithContextStub        func(context.Context, *elasticache.DescribeEventsInput, func(*elasticache.DescribeEventsOutput, bool) bool, ...request.Option) error
+	describeEventsPagesWithContextMutex       sync.RWMutex
+	describeEventsPagesWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeEventsInput
+		arg3 func(*elasticache.DescribeEventsOutput, bool) bool
+		arg4 []request.Option
+	}
+	describeEventsPagesWithContextReturns struct {
+		result1 error
+	}
+	describeEventsPagesWithContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DescribeReplicationGroupsPagesWithContextStub        func(context.Context, *elasticache.DescribeReplicationGroupsInput, func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, ...request.Option) error
+	describeReplicationGroupsPagesWithContextMutex       sync.RWMutex
+	describeReplicationGroupsPagesWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeReplicationGroupsInput
+		arg3 func(*elasticache.DescribeReplicationGroupsOutput, bool) bool
+		arg4 []request.Option
+	}
+	describeReplicationGroupsPagesWithContextReturns struct {
+		result1 error
+	}
+	describeReplicationGroupsPagesWithContextReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DescribeReplicationGroupsWithContextStub        func(context.Context, *elasticache.DescribeReplicationGroupsInput, ...request.Option) (*elasticache.DescribeReplicationGroupsOutput, error)
 	describeReplicationGroupsWithContextMutex       sync.RWMutex
 	describeReplicationGroupsWithContextArgsForCall []struct {
@@ -131,6 +339,35 @@ type FakeElastiCache struct {
 		result1 *elasticache.DescribeReplicationGroupsOutput
 		result2 error
 	}
+	DescribeServerlessCacheSnapshotsPagesWithContextStub        func(context.Context, *elasticache.DescribeServerlessCacheSnapshotsInput, func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, ...request.Option) error
+	describeServerlessCacheSnapshotsPagesWithContextMutex       sync.RWMutex
+	describeServerlessCacheSnapshotsPagesWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeServerlessCacheSnapshotsInput
+		arg3 func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool
+		arg4 []request.Option
+	}
+	describeServerlessCacheSnapshotsPagesWithContextReturns struct {
+		result1 error
+	}
+	describeServerlessCacheSnapshotsPagesWithContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DescribeServerlessCachesWithContextStub        func(context.Context, *elasticache.DescribeServerlessCachesInput, ...request.Option) (*elasticache.DescribeServerlessCachesOutput, error)
+	describeServerlessCachesWithContextMutex       sync.RWMutex
+	describeServerlessCachesWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeServerlessCachesInput
+		arg3 []request.Option
+	}
+	describeServerlessCachesWithContextReturns struct {
+		result1 *elasticache.DescribeServerlessCachesOutput
+		result2 error
+	}
+	describeServerlessCachesWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DescribeServerlessCachesOutput
+		result2 error
+	}
 	DescribeSnapshotsPagesWithContextStub        func(context.Context, *elasticache.DescribeSnapshotsInput, func(*elasticache.DescribeSnapshotsOutput, bool) bool, ...request.Option) error
 	describeSnapshotsPagesWithContextMutex       sync.RWMutex
 	describeSnapshotsPagesWithContextArgsForCall []struct {
@@ -145,6 +382,36 @@ type FakeElastiCache struct {
 	describeSnapshotsPagesWithContextReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DescribeUserGroupsWithContextStub        func(context.Context, *elasticache.DescribeUserGroupsInput, ...request.Option) (*elasticache.DescribeUserGroupsOutput, error)
+	describeUserGroupsWithContextMutex       sync.RWMutex
+	describeUserGroupsWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeUserGroupsInput
+		arg3 []request.Option
+	}
+	describeUserGroupsWithContextReturns struct {
+		result1 *elasticache.DescribeUserGroupsOutput
+		result2 error
+	}
+	describeUserGroupsWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.DescribeUserGroupsOutput
+		result2 error
+	}
+	IncreaseReplicaCountWithContextStub        func(context.Context, *elasticache.IncreaseReplicaCountInput, ...request.Option) (*elasticache.IncreaseReplicaCountOutput, error)
+	increaseReplicaCountWithContextMutex       sync.RWMutex
+	increaseReplicaCountWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.IncreaseReplicaCountInput
+		arg3 []request.Option
+	}
+	increaseReplicaCountWithContextReturns struct {
+		result1 *elasticache.IncreaseReplicaCountOutput
+		result2 error
+	}
+	increaseReplicaCountWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.IncreaseReplicaCountOutput
+		result2 error
+	}
 	ListTagsForResourceWithContextStub        func(context.Context, *elasticache.ListTagsForResourceInput, ...request.Option) (*elasticache.TagListMessage, error)
 	listTagsForResourceWithContextMutex       sync.RWMutex
 	listTagsForResourceWithContextArgsForCall []struct {
@@ -160,6 +427,21 @@ type FakeElastiCache struct {
 		result1 *elasticache.TagListMessage
 		result2 error
 	}
+	ModifyCacheClusterWithContextStub        func(context.Context, *elasticache.ModifyCacheClusterInput, ...request.Option) (*elasticache.ModifyCacheClusterOutput, error)
+	modifyCacheClusterWithContextMutex       sync.RWMutex
+	modifyCacheClusterWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyCacheClusterInput
+		arg3 []request.Option
+	}
+	modifyCacheClusterWithContextReturns struct {
+		result1 *elasticache.ModifyCacheClusterOutput
+		result2 error
+	}
+	modifyCacheClusterWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.ModifyCacheClusterOutput
+		result2 error
+	}
 	ModifyCacheParameterGroupWithContextStub        func(context.Context, *elasticache.ModifyCacheParameterGroupInput, ...request.Option) (*elasticache.CacheParameterGroupNameMessage, error)
 	modifyCacheParameterGroupWithContextMutex       sync.RWMutex
 	modifyCacheParameterGroupWithContextArgsForCall []struct {
@@ -175,6 +457,21 @@ type FakeElastiCache struct {
 		result1 *elasticache.CacheParameterGroupNameMessage
 		result2 error
 	}
+	ModifyReplicationGroupShardConfigurationWithContextStub        func(context.Context, *elasticache.ModifyReplicationGroupShardConfigurationInput, ...request.Option) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error)
+	modifyReplicationGroupShardConfigurationWithContextMutex       sync.RWMutex
+	modifyReplicationGroupShardConfigurationWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyReplicationGroupShardConfigurationInput
+		arg3 []request.Option
+	}
+	modifyReplicationGroupShardConfigurationWithContextReturns struct {
+		result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput
+		result2 error
+	}
+	modifyReplicationGroupShardConfigurationWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput
+		result2 error
+	}
 	ModifyReplicationGroupWithContextStub        func(context.Context, *elasticache.ModifyReplicationGroupInput, ...request.Option) (*elasticache.ModifyReplicationGroupOutput, error)
 	modifyReplicationGroupWithContextMutex       sync.RWMutex
 	modifyReplicationGroupWithContextArgsForCall []struct {
@@ -190,6 +487,36 @@ type FakeElastiCache struct {
 		result1 *elasticache.ModifyReplicationGroupOutput
 		result2 error
 	}
+	ModifyServerlessCacheWithContextStub        func(context.Context, *elasticache.ModifyServerlessCacheInput, ...request.Option) (*elasticache.ModifyServerlessCacheOutput, error)
+	modifyServerlessCacheWithContextMutex       sync.RWMutex
+	modifyServerlessCacheWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyServerlessCacheInput
+		arg3 []request.Option
+	}
+	modifyServerlessCacheWithContextReturns struct {
+		result1 *elasticache.ModifyServerlessCacheOutput
+		result2 error
+	}
+	modifyServerlessCacheWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.ModifyServerlessCacheOutput
+		result2 error
+	}
+	ModifyUserGroupWithContextStub        func(context.Context, *elasticache.ModifyUserGroupInput, ...request.Option) (*elasticache.ModifyUserGroupOutput, error)
+	modifyUserGroupWithContextMutex       sync.RWMutex
+	modifyUserGroupWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyUserGroupInput
+		arg3 []request.Option
+	}
+	modifyUserGroupWithContextReturns struct {
+		result1 *elasticache.ModifyUserGroupOutput
+		result2 error
+	}
+	modifyUserGroupWithContextReturnsOnCall map[int]struct {
+		result1 *elasticache.ModifyUserGroupOutput
+		result2 error
+	}
 	RemoveTagsFromResourceWithContextStub        func(context.Context, *elasticache.RemoveTagsFromResourceInput, ...request.Option) (*elasticache.TagListMessage, error)
 	removeTagsFromResourceWithContextMutex       sync.RWMutex
 	removeTagsFromResourceWithContextArgsForCall []struct {
@@ -290,6 +617,72 @@ func (fake *FakeElastiCache) AddTagsToResourceWithContextReturnsOnCall(i int, re
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) CreateCacheClusterWithContext(arg1 context.Context, arg2 *elasticache.CreateCacheClusterInput, arg3 ...request.Option) (*elasticache.CreateCacheClusterOutput, error) {
+	fake.createCacheClusterWithContextMutex.Lock()
+	ret, specificReturn := fake.createCacheClusterWithContextReturnsOnCall[len(fake.createCacheClusterWithContextArgsForCall)]
+	fake.createCacheClusterWithContextArgsForCall = append(fake.createCacheClusterWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateCacheClusterInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.CreateCacheClusterWithContextStub
+	fakeReturns := fake.createCacheClusterWithContextReturns
+	fake.recordInvocation("CreateCacheClusterWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createCacheClusterWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) CreateCacheClusterWithContextCallCount() int {
+	fake.createCacheClusterWithContextMutex.RLock()
+	defer fake.createCacheClusterWithContextMutex.RUnlock()
+	return len(fake.createCacheClusterWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) CreateCacheClusterWithContextCalls(stub func(context.Context, *elasticache.CreateCacheClusterInput, ...request.Option) (*elasticache.CreateCacheClusterOutput, error)) {
+	fake.createCacheClusterWithContextMutex.Lock()
+	defer fake.createCacheClusterWithContextMutex.Unlock()
+	fake.CreateCacheClusterWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) CreateCacheClusterWithContextArgsForCall(i int) (context.Context, *elasticache.CreateCacheClusterInput, []request.Option) {
+	fake.createCacheClusterWithContextMutex.RLock()
+	defer fake.createCacheClusterWithContextMutex.RUnlock()
+	argsForCall := fake.createCacheClusterWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) CreateCacheClusterWithContextReturns(result1 *elasticache.CreateCacheClusterOutput, result2 error) {
+	fake.createCacheClusterWithContextMutex.Lock()
+	defer fake.createCacheClusterWithContextMutex.Unlock()
+	fake.CreateCacheClusterWithContextStub = nil
+	fake.createCacheClusterWithContextReturns = struct {
+		result1 *elasticache.CreateCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateCacheClusterWithContextReturnsOnCall(i int, result1 *elasticache.CreateCacheClusterOutput, result2 error) {
+	fake.createCacheClusterWithContextMutex.Lock()
+	defer fake.createCacheClusterWithContextMutex.Unlock()
+	fake.CreateCacheClusterWithContextStub = nil
+	if fake.createCacheClusterWithContextReturnsOnCall == nil {
+		fake.createCacheClusterWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateCacheClusterOutput
+			result2 error
+		})
+	}
+	fake.createCacheClusterWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) CreateCacheParameterGroupWithContext(arg1 context.Context, arg2 *elasticache.CreateCacheParameterGroupInput, arg3 ...request.Option) (*elasticache.CreateCacheParameterGroupOutput, error) {
 	fake.createCacheParameterGroupWithContextMutex.Lock()
 	ret, specificReturn := fake.createCacheParameterGroupWithContextReturnsOnCall[len(fake.createCacheParameterGroupWithContextArgsForCall)]
@@ -422,18 +815,18 @@ func (fake *FakeElastiCache) CreateReplicationGroupWithContextReturnsOnCall(i in
 	}{result1, result2}
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContext(arg1 context.Context, arg2 *elasticache.DeleteCacheParameterGroupInput, arg3 ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error) {
-	fake.deleteCacheParameterGroupWithContextMutex.Lock()
-	ret, specificReturn := fake.deleteCacheParameterGroupWithContextReturnsOnCall[len(fake.deleteCacheParameterGroupWithContextArgsForCall)]
-	fake.deleteCacheParameterGroupWithContextArgsForCall = append(fake.deleteCacheParameterGroupWithContextArgsForCall, struct {
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContext(arg1 context.Context, arg2 *elasticache.CreateServerlessCacheSnapshotInput, arg3 ...request.Option) (*elasticache.CreateServerlessCacheSnapshotOutput, error) {
+	fake.createServerlessCacheSnapshotWithContextMutex.Lock()
+	ret, specificReturn := fake.createServerlessCacheSnapshotWithContextReturnsOnCall[len(fake.createServerlessCacheSnapshotWithContextArgsForCall)]
+	fake.createServerlessCacheSnapshotWithContextArgsForCall = append(fake.createServerlessCacheSnapshotWithContextArgsForCall, struct {
 		arg1 context.Context
-		arg2 *elasticache.DeleteCacheParameterGroupInput
+		arg2 *elasticache.CreateServerlessCacheSnapshotInput
 		arg3 []request.Option
 	}{arg1, arg2, arg3})
-	stub := fake.DeleteCacheParameterGroupWithContextStub
-	fakeReturns := fake.deleteCacheParameterGroupWithContextReturns
-	fake.recordInvocation("DeleteCacheParameterGroupWithContext", []interface{}{arg1, arg2, arg3})
-	fake.deleteCacheParameterGroupWithContextMutex.Unlock()
+	stub := fake.CreateServerlessCacheSnapshotWithContextStub
+	fakeReturns := fake.createServerlessCacheSnapshotWithContextReturns
+	fake.recordInvocation("CreateServerlessCacheSnapshotWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createServerlessCacheSnapshotWithContextMutex.Unlock()
 	if stub != nil {
 		return stub(arg1, arg2, arg3...)
 	}
@@ -443,63 +836,63 @@ func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContext(arg1 context.C
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextCallCount() int {
-	fake.deleteCacheParameterGroupWithContextMutex.RLock()
-	defer fake.deleteCacheParameterGroupWithContextMutex.RUnlock()
-	return len(fake.deleteCacheParameterGroupWithContextArgsForCall)
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContextCallCount() int {
+	fake.createServerlessCacheSnapshotWithContextMutex.RLock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.RUnlock()
+	return len(fake.createServerlessCacheSnapshotWithContextArgsForCall)
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextCalls(stub func(context.Context, *elasticache.DeleteCacheParameterGroupInput, ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error)) {
-	fake.deleteCacheParameterGroupWithContextMutex.Lock()
-	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
-	fake.DeleteCacheParameterGroupWithContextStub = stub
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContextCalls(stub func(context.Context, *elasticache.CreateServerlessCacheSnapshotInput, ...request.Option) (*elasticache.CreateServerlessCacheSnapshotOutput, error)) {
+	fake.createServerlessCacheSnapshotWithContextMutex.Lock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.Unlock()
+	fake.CreateServerlessCacheSnapshotWithContextStub = stub
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteCacheParameterGroupInput, []request.Option) {
-	fake.deleteCacheParameterGroupWithContextMutex.RLock()
-	defer fake.deleteCacheParameterGroupWithContextMutex.RUnlock()
-	argsForCall := fake.deleteCacheParameterGroupWithContextArgsForCall[i]
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContextArgsForCall(i int) (context.Context, *elasticache.CreateServerlessCacheSnapshotInput, []request.Option) {
+	fake.createServerlessCacheSnapshotWithContextMutex.RLock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.RUnlock()
+	argsForCall := fake.createServerlessCacheSnapshotWithContextArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextReturns(result1 *elasticache.DeleteCacheParameterGroupOutput, result2 error) {
-	fake.deleteCacheParameterGroupWithContextMutex.Lock()
-	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
-	fake.DeleteCacheParameterGroupWithContextStub = nil
-	fake.deleteCacheParameterGroupWithContextReturns = struct {
-		result1 *elasticache.DeleteCacheParameterGroupOutput
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContextReturns(result1 *elasticache.CreateServerlessCacheSnapshotOutput, result2 error) {
+	fake.createServerlessCacheSnapshotWithContextMutex.Lock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.Unlock()
+	fake.CreateServerlessCacheSnapshotWithContextStub = nil
+	fake.createServerlessCacheSnapshotWithContextReturns = struct {
+		result1 *elasticache.CreateServerlessCacheSnapshotOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextReturnsOnCall(i int, result1 *elasticache.DeleteCacheParameterGroupOutput, result2 error) {
-	fake.deleteCacheParameterGroupWithContextMutex.Lock()
-	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
-	fake.DeleteCacheParameterGroupWithContextStub = nil
-	if fake.deleteCacheParameterGroupWithContextReturnsOnCall == nil {
-		fake.deleteCacheParameterGroupWithContextReturnsOnCall = make(map[int]struct {
-			result1 *elasticache.DeleteCacheParameterGroupOutput
+func (fake *FakeElastiCache) CreateServerlessCacheSnapshotWithContextReturnsOnCall(i int, result1 *elasticache.CreateServerlessCacheSnapshotOutput, result2 error) {
+	fake.createServerlessCacheSnapshotWithContextMutex.Lock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.Unlock()
+	fake.CreateServerlessCacheSnapshotWithContextStub = nil
+	if fake.createServerlessCacheSnapshotWithContextReturnsOnCall == nil {
+		fake.createServerlessCacheSnapshotWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateServerlessCacheSnapshotOutput
 			result2 error
 		})
 	}
-	fake.deleteCacheParameterGroupWithContextReturnsOnCall[i] = struct {
-		result1 *elasticache.DeleteCacheParameterGroupOutput
+	fake.createServerlessCacheSnapshotWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateServerlessCacheSnapshotOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContext(arg1 context.Context, arg2 *elasticache.DeleteReplicationGroupInput, arg3 ...request.Option) (*elasticache.DeleteReplicationGroupOutput, error) {
-	fake.deleteReplicationGroupWithContextMutex.Lock()
-	ret, specificReturn := fake.deleteReplicationGroupWithContextReturnsOnCall[len(fake.deleteReplicationGroupWithContextArgsForCall)]
-	fake.deleteReplicationGroupWithContextArgsForCall = append(fake.deleteReplicationGroupWithContextArgsForCall, struct {
+func (fake *FakeElastiCache) CreateServerlessCacheWithContext(arg1 context.Context, arg2 *elasticache.CreateServerlessCacheInput, arg3 ...request.Option) (*elasticache.CreateServerlessCacheOutput, error) {
+	fake.createServerlessCacheWithContextMutex.Lock()
+	ret, specificReturn := fake.createServerlessCacheWithContextReturnsOnCall[len(fake.createServerlessCacheWithContextArgsForCall)]
+	fake.createServerlessCacheWithContextArgsForCall = append(fake.createServerlessCacheWithContextArgsForCall, struct {
 		arg1 context.Context
-		arg2 *elasticache.DeleteReplicationGroupInput
+		arg2 *elasticache.CreateServerlessCacheInput
 		arg3 []request.Option
 	}{arg1, arg2, arg3})
-	stub := fake.DeleteReplicationGroupWithContextStub
-	fakeReturns := fake.deleteReplicationGroupWithContextReturns
-	fake.recordInvocation("DeleteReplicationGroupWithContext", []interface{}{arg1, arg2, arg3})
-	fake.deleteReplicationGroupWithContextMutex.Unlock()
+	stub := fake.CreateServerlessCacheWithContextStub
+	fakeReturns := fake.createServerlessCacheWithContextReturns
+	fake.recordInvocation("CreateServerlessCacheWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createServerlessCacheWithContextMutex.Unlock()
 	if stub != nil {
 		return stub(arg1, arg2, arg3...)
 	}
@@ -509,51 +902,711 @@ func (fake *FakeElastiCache) DeleteReplicationGroupWithContext(arg1 context.Cont
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContextCallCount() int {
-	fake.deleteReplicationGroupWithContextMutex.RLock()
-	defer fake.deleteReplicationGroupWithContextMutex.RUnlock()
-	return len(fake.deleteReplicationGroupWithContextArgsForCall)
+func (fake *FakeElastiCache) CreateServerlessCacheWithContextCallCount() int {
+	fake.createServerlessCacheWithContextMutex.RLock()
+	defer fake.createServerlessCacheWithContextMutex.RUnlock()
+	return len(fake.createServerlessCacheWithContextArgsForCall)
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContextCalls(stub func(context.Context, *elasticache.DeleteReplicationGroupInput, ...request.Option) (*elasticache.DeleteReplicationGroupOutput, error)) {
-	fake.deleteReplicationGroupWithContextMutex.Lock()
-	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
-	fake.DeleteReplicationGroupWithContextStub = stub
+func (fake *FakeElastiCache) CreateServerlessCacheWithContextCalls(stub func(context.Context, *elasticache.CreateServerlessCacheInput, ...request.Option) (*elasticache.CreateServerlessCacheOutput, error)) {
+	fake.createServerlessCacheWithContextMutex.Lock()
+	defer fake.createServerlessCacheWithContextMutex.Unlock()
+	fake.CreateServerlessCacheWithContextStub = stub
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteReplicationGroupInput, []request.Option) {
-	fake.deleteReplicationGroupWithContextMutex.RLock()
-	defer fake.deleteReplicationGroupWithContextMutex.RUnlock()
-	argsForCall := fake.deleteReplicationGroupWithContextArgsForCall[i]
+func (fake *FakeElastiCache) CreateServerlessCacheWithContextArgsForCall(i int) (context.Context, *elasticache.CreateServerlessCacheInput, []request.Option) {
+	fake.createServerlessCacheWithContextMutex.RLock()
+	defer fake.createServerlessCacheWithContextMutex.RUnlock()
+	argsForCall := fake.createServerlessCacheWithContextArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContextReturns(result1 *elasticache.DeleteReplicationGroupOutput, result2 error) {
-	fake.deleteReplicationGroupWithContextMutex.Lock()
-	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
-	fake.DeleteReplicationGroupWithContextStub = nil
-	fake.deleteReplicationGroupWithContextReturns = struct {
-		result1 *elasticache.DeleteReplicationGroupOutput
+func (fake *FakeElastiCache) CreateServerlessCacheWithContextReturns(result1 *elasticache.CreateServerlessCacheOutput, result2 error) {
+	fake.createServerlessCacheWithContextMutex.Lock()
+	defer fake.createServerlessCacheWithContextMutex.Unlock()
+	fake.CreateServerlessCacheWithContextStub = nil
+	fake.createServerlessCacheWithContextReturns = struct {
+		result1 *elasticache.CreateServerlessCacheOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeElastiCache) DeleteReplicationGroupWithContextReturnsOnCall(i int, result1 *elasticache.DeleteReplicationGroupOutput, result2 error) {
-	fake.deleteReplicationGroupWithContextMutex.Lock()
-	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
-	fake.DeleteReplicationGroupWithContextStub = nil
-	if fake.deleteReplicationGroupWithContextReturnsOnCall == nil {
-		fake.deleteReplicationGroupWithContextReturnsOnCall = make(map[int]struct {
-			result1 *elasticache.DeleteReplicationGroupOutput
+func (fake *FakeElastiCache) CreateServerlessCacheWithContextReturnsOnCall(i int, result1 *elasticache.CreateServerlessCacheOutput, result2 error) {
+	fake.createServerlessCacheWithContextMutex.Lock()
+	defer fake.createServerlessCacheWithContextMutex.Unlock()
+	fake.CreateServerlessCacheWithContextStub = nil
+	if fake.createServerlessCacheWithContextReturnsOnCall == nil {
+		fake.createServerlessCacheWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateServerlessCacheOutput
 			result2 error
 		})
 	}
-	fake.deleteReplicationGroupWithContextReturnsOnCall[i] = struct {
+	fake.createServerlessCacheWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateServerlessCacheOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContext(arg1 context.Context, arg2 *elasticache.CreateSnapshotInput, arg3 ...request.Option) (*elasticache.CreateSnapshotOutput, error) {
+	fake.createSnapshotWithContextMutex.Lock()
+	ret, specificReturn := fake.createSnapshotWithContextReturnsOnCall[len(fake.createSnapshotWithContextArgsForCall)]
+	fake.createSnapshotWithContextArgsForCall = append(fake.createSnapshotWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateSnapshotInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.CreateSnapshotWithContextStub
+	fakeReturns := fake.createSnapshotWithContextReturns
+	fake.recordInvocation("CreateSnapshotWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createSnapshotWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContextCallCount() int {
+	fake.createSnapshotWithContextMutex.RLock()
+	defer fake.createSnapshotWithContextMutex.RUnlock()
+	return len(fake.createSnapshotWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContextCalls(stub func(context.Context, *elasticache.CreateSnapshotInput, ...request.Option) (*elasticache.CreateSnapshotOutput, error)) {
+	fake.createSnapshotWithContextMutex.Lock()
+	defer fake.createSnapshotWithContextMutex.Unlock()
+	fake.CreateSnapshotWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContextArgsForCall(i int) (context.Context, *elasticache.CreateSnapshotInput, []request.Option) {
+	fake.createSnapshotWithContextMutex.RLock()
+	defer fake.createSnapshotWithContextMutex.RUnlock()
+	argsForCall := fake.createSnapshotWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContextReturns(result1 *elasticache.CreateSnapshotOutput, result2 error) {
+	fake.createSnapshotWithContextMutex.Lock()
+	defer fake.createSnapshotWithContextMutex.Unlock()
+	fake.CreateSnapshotWithContextStub = nil
+	fake.createSnapshotWithContextReturns = struct {
+		result1 *elasticache.CreateSnapshotOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateSnapshotWithContextReturnsOnCall(i int, result1 *elasticache.CreateSnapshotOutput, result2 error) {
+	fake.createSnapshotWithContextMutex.Lock()
+	defer fake.createSnapshotWithContextMutex.Unlock()
+	fake.CreateSnapshotWithContextStub = nil
+	if fake.createSnapshotWithContextReturnsOnCall == nil {
+		fake.createSnapshotWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateSnapshotOutput
+			result2 error
+		})
+	}
+	fake.createSnapshotWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateSnapshotOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContext(arg1 context.Context, arg2 *elasticache.CreateUserGroupInput, arg3 ...request.Option) (*elasticache.CreateUserGroupOutput, error) {
+	fake.createUserGroupWithContextMutex.Lock()
+	ret, specificReturn := fake.createUserGroupWithContextReturnsOnCall[len(fake.createUserGroupWithContextArgsForCall)]
+	fake.createUserGroupWithContextArgsForCall = append(fake.createUserGroupWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateUserGroupInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.CreateUserGroupWithContextStub
+	fakeReturns := fake.createUserGroupWithContextReturns
+	fake.recordInvocation("CreateUserGroupWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createUserGroupWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContextCallCount() int {
+	fake.createUserGroupWithContextMutex.RLock()
+	defer fake.createUserGroupWithContextMutex.RUnlock()
+	return len(fake.createUserGroupWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContextCalls(stub func(context.Context, *elasticache.CreateUserGroupInput, ...request.Option) (*elasticache.CreateUserGroupOutput, error)) {
+	fake.createUserGroupWithContextMutex.Lock()
+	defer fake.createUserGroupWithContextMutex.Unlock()
+	fake.CreateUserGroupWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContextArgsForCall(i int) (context.Context, *elasticache.CreateUserGroupInput, []request.Option) {
+	fake.createUserGroupWithContextMutex.RLock()
+	defer fake.createUserGroupWithContextMutex.RUnlock()
+	argsForCall := fake.createUserGroupWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContextReturns(result1 *elasticache.CreateUserGroupOutput, result2 error) {
+	fake.createUserGroupWithContextMutex.Lock()
+	defer fake.createUserGroupWithContextMutex.Unlock()
+	fake.CreateUserGroupWithContextStub = nil
+	fake.createUserGroupWithContextReturns = struct {
+		result1 *elasticache.CreateUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateUserGroupWithContextReturnsOnCall(i int, result1 *elasticache.CreateUserGroupOutput, result2 error) {
+	fake.createUserGroupWithContextMutex.Lock()
+	defer fake.createUserGroupWithContextMutex.Unlock()
+	fake.CreateUserGroupWithContextStub = nil
+	if fake.createUserGroupWithContextReturnsOnCall == nil {
+		fake.createUserGroupWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateUserGroupOutput
+			result2 error
+		})
+	}
+	fake.createUserGroupWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateUserWithContext(arg1 context.Context, arg2 *elasticache.CreateUserInput, arg3 ...request.Option) (*elasticache.CreateUserOutput, error) {
+	fake.createUserWithContextMutex.Lock()
+	ret, specificReturn := fake.createUserWithContextReturnsOnCall[len(fake.createUserWithContextArgsForCall)]
+	fake.createUserWithContextArgsForCall = append(fake.createUserWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.CreateUserInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.CreateUserWithContextStub
+	fakeReturns := fake.createUserWithContextReturns
+	fake.recordInvocation("CreateUserWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createUserWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) CreateUserWithContextCallCount() int {
+	fake.createUserWithContextMutex.RLock()
+	defer fake.createUserWithContextMutex.RUnlock()
+	return len(fake.createUserWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) CreateUserWithContextCalls(stub func(context.Context, *elasticache.CreateUserInput, ...request.Option) (*elasticache.CreateUserOutput, error)) {
+	fake.createUserWithContextMutex.Lock()
+	defer fake.createUserWithContextMutex.Unlock()
+	fake.CreateUserWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) CreateUserWithContextArgsForCall(i int) (context.Context, *elasticache.CreateUserInput, []request.Option) {
+	fake.createUserWithContextMutex.RLock()
+	defer fake.createUserWithContextMutex.RUnlock()
+	argsForCall := fake.createUserWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) CreateUserWithContextReturns(result1 *elasticache.CreateUserOutput, result2 error) {
+	fake.createUserWithContextMutex.Lock()
+	defer fake.createUserWithContextMutex.Unlock()
+	fake.CreateUserWithContextStub = nil
+	fake.createUserWithContextReturns = struct {
+		result1 *elasticache.CreateUserOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) CreateUserWithContextReturnsOnCall(i int, result1 *elasticache.CreateUserOutput, result2 error) {
+	fake.createUserWithContextMutex.Lock()
+	defer fake.createUserWithContextMutex.Unlock()
+	fake.CreateUserWithContextStub = nil
+	if fake.createUserWithContextReturnsOnCall == nil {
+		fake.createUserWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.CreateUserOutput
+			result2 error
+		})
+	}
+	fake.createUserWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.CreateUserOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContext(arg1 context.Context, arg2 *elasticache.DecreaseReplicaCountInput, arg3 ...request.Option) (*elasticache.DecreaseReplicaCountOutput, error) {
+	fake.decreaseReplicaCountWithContextMutex.Lock()
+	ret, specificReturn := fake.decreaseReplicaCountWithContextReturnsOnCall[len(fake.decreaseReplicaCountWithContextArgsForCall)]
+	fake.decreaseReplicaCountWithContextArgsForCall = append(fake.decreaseReplicaCountWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DecreaseReplicaCountInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DecreaseReplicaCountWithContextStub
+	fakeReturns := fake.decreaseReplicaCountWithContextReturns
+	fake.recordInvocation("DecreaseReplicaCountWithContext", []interface{}{arg1, arg2, arg3})
+	fake.decreaseReplicaCountWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContextCallCount() int {
+	fake.decreaseReplicaCountWithContextMutex.RLock()
+	defer fake.decreaseReplicaCountWithContextMutex.RUnlock()
+	return len(fake.decreaseReplicaCountWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContextCalls(stub func(context.Context, *elasticache.DecreaseReplicaCountInput, ...request.Option) (*elasticache.DecreaseReplicaCountOutput, error)) {
+	fake.decreaseReplicaCountWithContextMutex.Lock()
+	defer fake.decreaseReplicaCountWithContextMutex.Unlock()
+	fake.DecreaseReplicaCountWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContextArgsForCall(i int) (context.Context, *elasticache.DecreaseReplicaCountInput, []request.Option) {
+	fake.decreaseReplicaCountWithContextMutex.RLock()
+	defer fake.decreaseReplicaCountWithContextMutex.RUnlock()
+	argsForCall := fake.decreaseReplicaCountWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContextReturns(result1 *elasticache.DecreaseReplicaCountOutput, result2 error) {
+	fake.decreaseReplicaCountWithContextMutex.Lock()
+	defer fake.decreaseReplicaCountWithContextMutex.Unlock()
+	fake.DecreaseReplicaCountWithContextStub = nil
+	fake.decreaseReplicaCountWithContextReturns = struct {
+		result1 *elasticache.DecreaseReplicaCountOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DecreaseReplicaCountWithContextReturnsOnCall(i int, result1 *elasticache.DecreaseReplicaCountOutput, result2 error) {
+	fake.decreaseReplicaCountWithContextMutex.Lock()
+	defer fake.decreaseReplicaCountWithContextMutex.Unlock()
+	fake.DecreaseReplicaCountWithContextStub = nil
+	if fake.decreaseReplicaCountWithContextReturnsOnCall == nil {
+		fake.decreaseReplicaCountWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DecreaseReplicaCountOutput
+			result2 error
+		})
+	}
+	fake.decreaseReplicaCountWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DecreaseReplicaCountOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContext(arg1 context.Context, arg2 *elasticache.DeleteCacheClusterInput, arg3 ...request.Option) (*elasticache.DeleteCacheClusterOutput, error) {
+	fake.deleteCacheClusterWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteCacheClusterWithContextReturnsOnCall[len(fake.deleteCacheClusterWithContextArgsForCall)]
+	fake.deleteCacheClusterWithContextArgsForCall = append(fake.deleteCacheClusterWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteCacheClusterInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteCacheClusterWithContextStub
+	fakeReturns := fake.deleteCacheClusterWithContextReturns
+	fake.recordInvocation("DeleteCacheClusterWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteCacheClusterWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContextCallCount() int {
+	fake.deleteCacheClusterWithContextMutex.RLock()
+	defer fake.deleteCacheClusterWithContextMutex.RUnlock()
+	return len(fake.deleteCacheClusterWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContextCalls(stub func(context.Context, *elasticache.DeleteCacheClusterInput, ...request.Option) (*elasticache.DeleteCacheClusterOutput, error)) {
+	fake.deleteCacheClusterWithContextMutex.Lock()
+	defer fake.deleteCacheClusterWithContextMutex.Unlock()
+	fake.DeleteCacheClusterWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteCacheClusterInput, []request.Option) {
+	fake.deleteCacheClusterWithContextMutex.RLock()
+	defer fake.deleteCacheClusterWithContextMutex.RUnlock()
+	argsForCall := fake.deleteCacheClusterWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContextReturns(result1 *elasticache.DeleteCacheClusterOutput, result2 error) {
+	fake.deleteCacheClusterWithContextMutex.Lock()
+	defer fake.deleteCacheClusterWithContextMutex.Unlock()
+	fake.DeleteCacheClusterWithContextStub = nil
+	fake.deleteCacheClusterWithContextReturns = struct {
+		result1 *elasticache.DeleteCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteCacheClusterWithContextReturnsOnCall(i int, result1 *elasticache.DeleteCacheClusterOutput, result2 error) {
+	fake.deleteCacheClusterWithContextMutex.Lock()
+	defer fake.deleteCacheClusterWithContextMutex.Unlock()
+	fake.DeleteCacheClusterWithContextStub = nil
+	if fake.deleteCacheClusterWithContextReturnsOnCall == nil {
+		fake.deleteCacheClusterWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteCacheClusterOutput
+			result2 error
+		})
+	}
+	fake.deleteCacheClusterWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContext(arg1 context.Context, arg2 *elasticache.DeleteCacheParameterGroupInput, arg3 ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error) {
+	fake.deleteCacheParameterGroupWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteCacheParameterGroupWithContextReturnsOnCall[len(fake.deleteCacheParameterGroupWithContextArgsForCall)]
+	fake.deleteCacheParameterGroupWithContextArgsForCall = append(fake.deleteCacheParameterGroupWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteCacheParameterGroupInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteCacheParameterGroupWithContextStub
+	fakeReturns := fake.deleteCacheParameterGroupWithContextReturns
+	fake.recordInvocation("DeleteCacheParameterGroupWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteCacheParameterGroupWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextCallCount() int {
+	fake.deleteCacheParameterGroupWithContextMutex.RLock()
+	defer fake.deleteCacheParameterGroupWithContextMutex.RUnlock()
+	return len(fake.deleteCacheParameterGroupWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextCalls(stub func(context.Context, *elasticache.DeleteCacheParameterGroupInput, ...request.Option) (*elasticache.DeleteCacheParameterGroupOutput, error)) {
+	fake.deleteCacheParameterGroupWithContextMutex.Lock()
+	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
+	fake.DeleteCacheParameterGroupWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteCacheParameterGroupInput, []request.Option) {
+	fake.deleteCacheParameterGroupWithContextMutex.RLock()
+	defer fake.deleteCacheParameterGroupWithContextMutex.RUnlock()
+	argsForCall := fake.deleteCacheParameterGroupWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextReturns(result1 *elasticache.DeleteCacheParameterGroupOutput, result2 error) {
+	fake.deleteCacheParameterGroupWithContextMutex.Lock()
+	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
+	fake.DeleteCacheParameterGroupWithContextStub = nil
+	fake.deleteCacheParameterGroupWithContextReturns = struct {
+		result1 *elasticache.DeleteCacheParameterGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteCacheParameterGroupWithContextReturnsOnCall(i int, result1 *elasticache.DeleteCacheParameterGroupOutput, result2 error) {
+	fake.deleteCacheParameterGroupWithContextMutex.Lock()
+	defer fake.deleteCacheParameterGroupWithContextMutex.Unlock()
+	fake.DeleteCacheParameterGroupWithContextStub = nil
+	if fake.deleteCacheParameterGroupWithContextReturnsOnCall == nil {
+		fake.deleteCacheParameterGroupWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteCacheParameterGroupOutput
+			result2 error
+		})
+	}
+	fake.deleteCacheParameterGroupWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteCacheParameterGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContext(arg1 context.Context, arg2 *elasticache.DeleteReplicationGroupInput, arg3 ...request.Option) (*elasticache.DeleteReplicationGroupOutput, error) {
+	fake.deleteReplicationGroupWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteReplicationGroupWithContextReturnsOnCall[len(fake.deleteReplicationGroupWithContextArgsForCall)]
+	fake.deleteReplicationGroupWithContextArgsForCall = append(fake.deleteReplicationGroupWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteReplicationGroupInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteReplicationGroupWithContextStub
+	fakeReturns := fake.deleteReplicationGroupWithContextReturns
+	fake.recordInvocation("DeleteReplicationGroupWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteReplicationGroupWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContextCallCount() int {
+	fake.deleteReplicationGroupWithContextMutex.RLock()
+	defer fake.deleteReplicationGroupWithContextMutex.RUnlock()
+	return len(fake.deleteReplicationGroupWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContextCalls(stub func(context.Context, *elasticache.DeleteReplicationGroupInput, ...request.Option) (*elasticache.DeleteReplicationGroupOutput, error)) {
+	fake.deleteReplicationGroupWithContextMutex.Lock()
+	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
+	fake.DeleteReplicationGroupWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteReplicationGroupInput, []request.Option) {
+	fake.deleteReplicationGroupWithContextMutex.RLock()
+	defer fake.deleteReplicationGroupWithContextMutex.RUnlock()
+	argsForCall := fake.deleteReplicationGroupWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContextReturns(result1 *elasticache.DeleteReplicationGroupOutput, result2 error) {
+	fake.deleteReplicationGroupWithContextMutex.Lock()
+	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
+	fake.DeleteReplicationGroupWithContextStub = nil
+	fake.deleteReplicationGroupWithContextReturns = struct {
 		result1 *elasticache.DeleteReplicationGroupOutput
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) DeleteReplicationGroupWithContextReturnsOnCall(i int, result1 *elasticache.DeleteReplicationGroupOutput, result2 error) {
+	fake.deleteReplicationGroupWithContextMutex.Lock()
+	defer fake.deleteReplicationGroupWithContextMutex.Unlock()
+	fake.DeleteReplicationGroupWithContextStub = nil
+	if fake.deleteReplicationGroupWithContextReturnsOnCall == nil {
+		fake.deleteReplicationGroupWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteReplicationGroupOutput
+			result2 error
+		})
+	}
+	fake.deleteReplicationGroupWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteReplicationGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContext(arg1 context.Context, arg2 *elasticache.DeleteServerlessCacheInput, arg3 ...request.Option) (*elasticache.DeleteServerlessCacheOutput, error) {
+	fake.deleteServerlessCacheWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteServerlessCacheWithContextReturnsOnCall[len(fake.deleteServerlessCacheWithContextArgsForCall)]
+	fake.deleteServerlessCacheWithContextArgsForCall = append(fake.deleteServerlessCacheWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteServerlessCacheInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteServerlessCacheWithContextStub
+	fakeReturns := fake.deleteServerlessCacheWithContextReturns
+	fake.recordInvocation("DeleteServerlessCacheWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteServerlessCacheWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContextCallCount() int {
+	fake.deleteServerlessCacheWithContextMutex.RLock()
+	defer fake.deleteServerlessCacheWithContextMutex.RUnlock()
+	return len(fake.deleteServerlessCacheWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContextCalls(stub func(context.Context, *elasticache.DeleteServerlessCacheInput, ...request.Option) (*elasticache.DeleteServerlessCacheOutput, error)) {
+	fake.deleteServerlessCacheWithContextMutex.Lock()
+	defer fake.deleteServerlessCacheWithContextMutex.Unlock()
+	fake.DeleteServerlessCacheWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteServerlessCacheInput, []request.Option) {
+	fake.deleteServerlessCacheWithContextMutex.RLock()
+	defer fake.deleteServerlessCacheWithContextMutex.RUnlock()
+	argsForCall := fake.deleteServerlessCacheWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContextReturns(result1 *elasticache.DeleteServerlessCacheOutput, result2 error) {
+	fake.deleteServerlessCacheWithContextMutex.Lock()
+	defer fake.deleteServerlessCacheWithContextMutex.Unlock()
+	fake.DeleteServerlessCacheWithContextStub = nil
+	fake.deleteServerlessCacheWithContextReturns = struct {
+		result1 *elasticache.DeleteServerlessCacheOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteServerlessCacheWithContextReturnsOnCall(i int, result1 *elasticache.DeleteServerlessCacheOutput, result2 error) {
+	fake.deleteServerlessCacheWithContextMutex.Lock()
+	defer fake.deleteServerlessCacheWithContextMutex.Unlock()
+	fake.DeleteServerlessCacheWithContextStub = nil
+	if fake.deleteServerlessCacheWithContextReturnsOnCall == nil {
+		fake.deleteServerlessCacheWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteServerlessCacheOutput
+			result2 error
+		})
+	}
+	fake.deleteServerlessCacheWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteServerlessCacheOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContext(arg1 context.Context, arg2 *elasticache.DeleteUserGroupInput, arg3 ...request.Option) (*elasticache.DeleteUserGroupOutput, error) {
+	fake.deleteUserGroupWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteUserGroupWithContextReturnsOnCall[len(fake.deleteUserGroupWithContextArgsForCall)]
+	fake.deleteUserGroupWithContextArgsForCall = append(fake.deleteUserGroupWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteUserGroupInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteUserGroupWithContextStub
+	fakeReturns := fake.deleteUserGroupWithContextReturns
+	fake.recordInvocation("DeleteUserGroupWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteUserGroupWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContextCallCount() int {
+	fake.deleteUserGroupWithContextMutex.RLock()
+	defer fake.deleteUserGroupWithContextMutex.RUnlock()
+	return len(fake.deleteUserGroupWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContextCalls(stub func(context.Context, *elasticache.DeleteUserGroupInput, ...request.Option) (*elasticache.DeleteUserGroupOutput, error)) {
+	fake.deleteUserGroupWithContextMutex.Lock()
+	defer fake.deleteUserGroupWithContextMutex.Unlock()
+	fake.DeleteUserGroupWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteUserGroupInput, []request.Option) {
+	fake.deleteUserGroupWithContextMutex.RLock()
+	defer fake.deleteUserGroupWithContextMutex.RUnlock()
+	argsForCall := fake.deleteUserGroupWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContextReturns(result1 *elasticache.DeleteUserGroupOutput, result2 error) {
+	fake.deleteUserGroupWithContextMutex.Lock()
+	defer fake.deleteUserGroupWithContextMutex.Unlock()
+	fake.DeleteUserGroupWithContextStub = nil
+	fake.deleteUserGroupWithContextReturns = struct {
+		result1 *elasticache.DeleteUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteUserGroupWithContextReturnsOnCall(i int, result1 *elasticache.DeleteUserGroupOutput, result2 error) {
+	fake.deleteUserGroupWithContextMutex.Lock()
+	defer fake.deleteUserGroupWithContextMutex.Unlock()
+	fake.DeleteUserGroupWithContextStub = nil
+	if fake.deleteUserGroupWithContextReturnsOnCall == nil {
+		fake.deleteUserGroupWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteUserGroupOutput
+			result2 error
+		})
+	}
+	fake.deleteUserGroupWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContext(arg1 context.Context, arg2 *elasticache.DeleteUserInput, arg3 ...request.Option) (*elasticache.DeleteUserOutput, error) {
+	fake.deleteUserWithContextMutex.Lock()
+	ret, specificReturn := fake.deleteUserWithContextReturnsOnCall[len(fake.deleteUserWithContextArgsForCall)]
+	fake.deleteUserWithContextArgsForCall = append(fake.deleteUserWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DeleteUserInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteUserWithContextStub
+	fakeReturns := fake.deleteUserWithContextReturns
+	fake.recordInvocation("DeleteUserWithContext", []interface{}{arg1, arg2, arg3})
+	fake.deleteUserWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContextCallCount() int {
+	fake.deleteUserWithContextMutex.RLock()
+	defer fake.deleteUserWithContextMutex.RUnlock()
+	return len(fake.deleteUserWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContextCalls(stub func(context.Context, *elasticache.DeleteUserInput, ...request.Option) (*elasticache.DeleteUserOutput, error)) {
+	fake.deleteUserWithContextMutex.Lock()
+	defer fake.deleteUserWithContextMutex.Unlock()
+	fake.DeleteUserWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContextArgsForCall(i int) (context.Context, *elasticache.DeleteUserInput, []request.Option) {
+	fake.deleteUserWithContextMutex.RLock()
+	defer fake.deleteUserWithContextMutex.RUnlock()
+	argsForCall := fake.deleteUserWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContextReturns(result1 *elasticache.DeleteUserOutput, result2 error) {
+	fake.deleteUserWithContextMutex.Lock()
+	defer fake.deleteUserWithContextMutex.Unlock()
+	fake.DeleteUserWithContextStub = nil
+	fake.deleteUserWithContextReturns = struct {
+		result1 *elasticache.DeleteUserOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DeleteUserWithContextReturnsOnCall(i int, result1 *elasticache.DeleteUserOutput, result2 error) {
+	fake.deleteUserWithContextMutex.Lock()
+	defer fake.deleteUserWithContextMutex.Unlock()
+	fake.DeleteUserWithContextStub = nil
+	if fake.deleteUserWithContextReturnsOnCall == nil {
+		fake.deleteUserWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DeleteUserOutput
+			result2 error
+		})
+	}
+	fake.deleteUserWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DeleteUserOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) DescribeCacheClustersWithContext(arg1 context.Context, arg2 *elasticache.DescribeCacheClustersInput, arg3 ...request.Option) (*elasticache.DescribeCacheClustersOutput, error) {
 	fake.describeCacheClustersWithContextMutex.Lock()
 	ret, specificReturn := fake.describeCacheClustersWithContextReturnsOnCall[len(fake.describeCacheClustersWithContextArgsForCall)]
@@ -670,20 +1723,214 @@ func (fake *FakeElastiCache) DescribeCacheParametersWithContextReturns(result1 *
 	}{result1, result2}
 }
 
-func (fake *FakeElastiCache) DescribeCacheParametersWithContextReturnsOnCall(i int, result1 *elasticache.DescribeCacheParametersOutput, result2 error) {
-	fake.describeCacheParametersWithContextMutex.Lock()
-	defer fake.describeCacheParametersWithContextMutex.Unlock()
-	fake.DescribeCacheParametersWithContextStub = nil
-	if fake.describeCacheParametersWithContextReturnsOnCall == nil {
-		fake.describeCacheParametersWithContextReturnsOnCall = make(map[int]struct {
-			result1 *elasticache.DescribeCacheParametersOutput
-			result2 error
+func (fake *FakeElastiCache) DescribeCacheParametersWithContextReturnsOnCall(i int, result1 *elasticache.DescribeCacheParametersOutput, result2 error) {
+	fake.describeCacheParametersWithContextMutex.Lock()
+	defer fake.describeCacheParametersWithContextMutex.Unlock()
+	fake.DescribeCacheParametersWithContextStub = nil
+	if fake.describeCacheParametersWithContextReturnsOnCall == nil {
+		fake.describeCacheParametersWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DescribeCacheParametersOutput
+			result2 error
+		})
+	}
+	fake.describeCacheParametersWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DescribeCacheParametersOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContext(arg1 context.Context, arg2 *elasticache.DescribeCacheSubnetGroupsInput, arg3 ...request.Option) (*elasticache.DescribeCacheSubnetGroupsOutput, error) {
+	fake.describeCacheSubnetGroupsWithContextMutex.Lock()
+	ret, specificReturn := fake.describeCacheSubnetGroupsWithContextReturnsOnCall[len(fake.describeCacheSubnetGroupsWithContextArgsForCall)]
+	fake.describeCacheSubnetGroupsWithContextArgsForCall = append(fake.describeCacheSubnetGroupsWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeCacheSubnetGroupsInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DescribeCacheSubnetGroupsWithContextStub
+	fakeReturns := fake.describeCacheSubnetGroupsWithContextReturns
+	fake.recordInvocation("DescribeCacheSubnetGroupsWithContext", []interface{}{arg1, arg2, arg3})
+	fake.describeCacheSubnetGroupsWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContextCallCount() int {
+	fake.describeCacheSubnetGroupsWithContextMutex.RLock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.RUnlock()
+	return len(fake.describeCacheSubnetGroupsWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContextCalls(stub func(context.Context, *elasticache.DescribeCacheSubnetGroupsInput, ...request.Option) (*elasticache.DescribeCacheSubnetGroupsOutput, error)) {
+	fake.describeCacheSubnetGroupsWithContextMutex.Lock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.Unlock()
+	fake.DescribeCacheSubnetGroupsWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeCacheSubnetGroupsInput, []request.Option) {
+	fake.describeCacheSubnetGroupsWithContextMutex.RLock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.RUnlock()
+	argsForCall := fake.describeCacheSubnetGroupsWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContextReturns(result1 *elasticache.DescribeCacheSubnetGroupsOutput, result2 error) {
+	fake.describeCacheSubnetGroupsWithContextMutex.Lock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.Unlock()
+	fake.DescribeCacheSubnetGroupsWithContextStub = nil
+	fake.describeCacheSubnetGroupsWithContextReturns = struct {
+		result1 *elasticache.DescribeCacheSubnetGroupsOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DescribeCacheSubnetGroupsWithContextReturnsOnCall(i int, result1 *elasticache.DescribeCacheSubnetGroupsOutput, result2 error) {
+	fake.describeCacheSubnetGroupsWithContextMutex.Lock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.Unlock()
+	fake.DescribeCacheSubnetGroupsWithContextStub = nil
+	if fake.describeCacheSubnetGroupsWithContextReturnsOnCall == nil {
+		fake.describeCacheSubnetGroupsWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DescribeCacheSubnetGroupsOutput
+			result2 error
+		})
+	}
+	fake.describeCacheSubnetGroupsWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DescribeCacheSubnetGroupsOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContext(arg1 context.Context, arg2 *elasticache.DescribeEventsInput, arg3 func(*elasticache.DescribeEventsOutput, bool) bool, arg4 ...request.Option) error {
+	fake.describeEventsPagesWithContextMutex.Lock()
+	ret, specificReturn := fake.describeEventsPagesWithContextReturnsOnCall[len(fake.describeEventsPagesWithContextArgsForCall)]
+	fake.describeEventsPagesWithContextArgsForCall = append(fake.describeEventsPagesWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeEventsInput
+		arg3 func(*elasticache.DescribeEventsOutput, bool) bool
+		arg4 []request.Option
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DescribeEventsPagesWithContextStub
+	fakeReturns := fake.describeEventsPagesWithContextReturns
+	fake.recordInvocation("DescribeEventsPagesWithContext", []interface{}{arg1, arg2, arg3, arg4})
+	fake.describeEventsPagesWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContextCallCount() int {
+	fake.describeEventsPagesWithContextMutex.RLock()
+	defer fake.describeEventsPagesWithContextMutex.RUnlock()
+	return len(fake.describeEventsPagesWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContextCalls(stub func(context.Context, *elasticache.DescribeEventsInput, func(*elasticache.DescribeEventsOutput, bool) bool, ...request.Option) error) {
+	fake.describeEventsPagesWithContextMutex.Lock()
+	defer fake.describeEventsPagesWithContextMutex.Unlock()
+	fake.DescribeEventsPagesWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeEventsInput, func(*elasticache.DescribeEventsOutput, bool) bool, []request.Option) {
+	fake.describeEventsPagesWithContextMutex.RLock()
+	defer fake.describeEventsPagesWithContextMutex.RUnlock()
+	argsForCall := fake.describeEventsPagesWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContextReturns(result1 error) {
+	fake.describeEventsPagesWithContextMutex.Lock()
+	defer fake.describeEventsPagesWithContextMutex.Unlock()
+	fake.DescribeEventsPagesWithContextStub = nil
+	fake.describeEventsPagesWithContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeElastiCache) DescribeEventsPagesWithContextReturnsOnCall(i int, result1 error) {
+	fake.describeEventsPagesWithContextMutex.Lock()
+	defer fake.describeEventsPagesWithContextMutex.Unlock()
+	fake.DescribeEventsPagesWithContextStub = nil
+	if fake.describeEventsPagesWithContextReturnsOnCall == nil {
+		fake.describeEventsPagesWithContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.describeEventsPagesWithContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContext(arg1 context.Context, arg2 *elasticache.DescribeReplicationGroupsInput, arg3 func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, arg4 ...request.Option) error {
+	fake.describeReplicationGroupsPagesWithContextMutex.Lock()
+	ret, specificReturn := fake.describeReplicationGroupsPagesWithContextReturnsOnCall[len(fake.describeReplicationGroupsPagesWithContextArgsForCall)]
+	fake.describeReplicationGroupsPagesWithContextArgsForCall = append(fake.describeReplicationGroupsPagesWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeReplicationGroupsInput
+		arg3 func(*elasticache.DescribeReplicationGroupsOutput, bool) bool
+		arg4 []request.Option
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DescribeReplicationGroupsPagesWithContextStub
+	fakeReturns := fake.describeReplicationGroupsPagesWithContextReturns
+	fake.recordInvocation("DescribeReplicationGroupsPagesWithContext", []interface{}{arg1, arg2, arg3, arg4})
+	fake.describeReplicationGroupsPagesWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContextCallCount() int {
+	fake.describeReplicationGroupsPagesWithContextMutex.RLock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.RUnlock()
+	return len(fake.describeReplicationGroupsPagesWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContextCalls(stub func(context.Context, *elasticache.DescribeReplicationGroupsInput, func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, ...request.Option) error) {
+	fake.describeReplicationGroupsPagesWithContextMutex.Lock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.Unlock()
+	fake.DescribeReplicationGroupsPagesWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeReplicationGroupsInput, func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, []request.Option) {
+	fake.describeReplicationGroupsPagesWithContextMutex.RLock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.RUnlock()
+	argsForCall := fake.describeReplicationGroupsPagesWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContextReturns(result1 error) {
+	fake.describeReplicationGroupsPagesWithContextMutex.Lock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.Unlock()
+	fake.DescribeReplicationGroupsPagesWithContextStub = nil
+	fake.describeReplicationGroupsPagesWithContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeElastiCache) DescribeReplicationGroupsPagesWithContextReturnsOnCall(i int, result1 error) {
+	fake.describeReplicationGroupsPagesWithContextMutex.Lock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.Unlock()
+	fake.DescribeReplicationGroupsPagesWithContextStub = nil
+	if fake.describeReplicationGroupsPagesWithContextReturnsOnCall == nil {
+		fake.describeReplicationGroupsPagesWithContextReturnsOnCall = make(map[int]struct {
+			result1 error
 		})
 	}
-	fake.describeCacheParametersWithContextReturnsOnCall[i] = struct {
-		result1 *elasticache.DescribeCacheParametersOutput
-		result2 error
-	}{result1, result2}
+	fake.describeReplicationGroupsPagesWithContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
 }
 
 func (fake *FakeElastiCache) DescribeReplicationGroupsWithContext(arg1 context.Context, arg2 *elasticache.DescribeReplicationGroupsInput, arg3 ...request.Option) (*elasticache.DescribeReplicationGroupsOutput, error) {
@@ -752,6 +1999,136 @@ func (fake *FakeElastiCache) DescribeReplicationGroupsWithContextReturnsOnCall(i
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContext(arg1 context.Context, arg2 *elasticache.DescribeServerlessCacheSnapshotsInput, arg3 func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, arg4 ...request.Option) error {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Lock()
+	ret, specificReturn := fake.describeServerlessCacheSnapshotsPagesWithContextReturnsOnCall[len(fake.describeServerlessCacheSnapshotsPagesWithContextArgsForCall)]
+	fake.describeServerlessCacheSnapshotsPagesWithContextArgsForCall = append(fake.describeServerlessCacheSnapshotsPagesWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeServerlessCacheSnapshotsInput
+		arg3 func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool
+		arg4 []request.Option
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DescribeServerlessCacheSnapshotsPagesWithContextStub
+	fakeReturns := fake.describeServerlessCacheSnapshotsPagesWithContextReturns
+	fake.recordInvocation("DescribeServerlessCacheSnapshotsPagesWithContext", []interface{}{arg1, arg2, arg3, arg4})
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContextCallCount() int {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RLock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RUnlock()
+	return len(fake.describeServerlessCacheSnapshotsPagesWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContextCalls(stub func(context.Context, *elasticache.DescribeServerlessCacheSnapshotsInput, func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, ...request.Option) error) {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Lock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Unlock()
+	fake.DescribeServerlessCacheSnapshotsPagesWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeServerlessCacheSnapshotsInput, func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, []request.Option) {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RLock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RUnlock()
+	argsForCall := fake.describeServerlessCacheSnapshotsPagesWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContextReturns(result1 error) {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Lock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Unlock()
+	fake.DescribeServerlessCacheSnapshotsPagesWithContextStub = nil
+	fake.describeServerlessCacheSnapshotsPagesWithContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCacheSnapshotsPagesWithContextReturnsOnCall(i int, result1 error) {
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Lock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.Unlock()
+	fake.DescribeServerlessCacheSnapshotsPagesWithContextStub = nil
+	if fake.describeServerlessCacheSnapshotsPagesWithContextReturnsOnCall == nil {
+		fake.describeServerlessCacheSnapshotsPagesWithContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.describeServerlessCacheSnapshotsPagesWithContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContext(arg1 context.Context, arg2 *elasticache.DescribeServerlessCachesInput, arg3 ...request.Option) (*elasticache.DescribeServerlessCachesOutput, error) {
+	fake.describeServerlessCachesWithContextMutex.Lock()
+	ret, specificReturn := fake.describeServerlessCachesWithContextReturnsOnCall[len(fake.describeServerlessCachesWithContextArgsForCall)]
+	fake.describeServerlessCachesWithContextArgsForCall = append(fake.describeServerlessCachesWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeServerlessCachesInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DescribeServerlessCachesWithContextStub
+	fakeReturns := fake.describeServerlessCachesWithContextReturns
+	fake.recordInvocation("DescribeServerlessCachesWithContext", []interface{}{arg1, arg2, arg3})
+	fake.describeServerlessCachesWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContextCallCount() int {
+	fake.describeServerlessCachesWithContextMutex.RLock()
+	defer fake.describeServerlessCachesWithContextMutex.RUnlock()
+	return len(fake.describeServerlessCachesWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContextCalls(stub func(context.Context, *elasticache.DescribeServerlessCachesInput, ...request.Option) (*elasticache.DescribeServerlessCachesOutput, error)) {
+	fake.describeServerlessCachesWithContextMutex.Lock()
+	defer fake.describeServerlessCachesWithContextMutex.Unlock()
+	fake.DescribeServerlessCachesWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeServerlessCachesInput, []request.Option) {
+	fake.describeServerlessCachesWithContextMutex.RLock()
+	defer fake.describeServerlessCachesWithContextMutex.RUnlock()
+	argsForCall := fake.describeServerlessCachesWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContextReturns(result1 *elasticache.DescribeServerlessCachesOutput, result2 error) {
+	fake.describeServerlessCachesWithContextMutex.Lock()
+	defer fake.describeServerlessCachesWithContextMutex.Unlock()
+	fake.DescribeServerlessCachesWithContextStub = nil
+	fake.describeServerlessCachesWithContextReturns = struct {
+		result1 *elasticache.DescribeServerlessCachesOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DescribeServerlessCachesWithContextReturnsOnCall(i int, result1 *elasticache.DescribeServerlessCachesOutput, result2 error) {
+	fake.describeServerlessCachesWithContextMutex.Lock()
+	defer fake.describeServerlessCachesWithContextMutex.Unlock()
+	fake.DescribeServerlessCachesWithContextStub = nil
+	if fake.describeServerlessCachesWithContextReturnsOnCall == nil {
+		fake.describeServerlessCachesWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DescribeServerlessCachesOutput
+			result2 error
+		})
+	}
+	fake.describeServerlessCachesWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DescribeServerlessCachesOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) DescribeSnapshotsPagesWithContext(arg1 context.Context, arg2 *elasticache.DescribeSnapshotsInput, arg3 func(*elasticache.DescribeSnapshotsOutput, bool) bool, arg4 ...request.Option) error {
 	fake.describeSnapshotsPagesWithContextMutex.Lock()
 	ret, specificReturn := fake.describeSnapshotsPagesWithContextReturnsOnCall[len(fake.describeSnapshotsPagesWithContextArgsForCall)]
@@ -816,6 +2193,138 @@ func (fake *FakeElastiCache) DescribeSnapshotsPagesWithContextReturnsOnCall(i in
 	}{result1}
 }
 
+func (fake *FakeElastiCache) DescribeUserGroupsWithContext(arg1 context.Context, arg2 *elasticache.DescribeUserGroupsInput, arg3 ...request.Option) (*elasticache.DescribeUserGroupsOutput, error) {
+	fake.describeUserGroupsWithContextMutex.Lock()
+	ret, specificReturn := fake.describeUserGroupsWithContextReturnsOnCall[len(fake.describeUserGroupsWithContextArgsForCall)]
+	fake.describeUserGroupsWithContextArgsForCall = append(fake.describeUserGroupsWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.DescribeUserGroupsInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DescribeUserGroupsWithContextStub
+	fakeReturns := fake.describeUserGroupsWithContextReturns
+	fake.recordInvocation("DescribeUserGroupsWithContext", []interface{}{arg1, arg2, arg3})
+	fake.describeUserGroupsWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) DescribeUserGroupsWithContextCallCount() int {
+	fake.describeUserGroupsWithContextMutex.RLock()
+	defer fake.describeUserGroupsWithContextMutex.RUnlock()
+	return len(fake.describeUserGroupsWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) DescribeUserGroupsWithContextCalls(stub func(context.Context, *elasticache.DescribeUserGroupsInput, ...request.Option) (*elasticache.DescribeUserGroupsOutput, error)) {
+	fake.describeUserGroupsWithContextMutex.Lock()
+	defer fake.describeUserGroupsWithContextMutex.Unlock()
+	fake.DescribeUserGroupsWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) DescribeUserGroupsWithContextArgsForCall(i int) (context.Context, *elasticache.DescribeUserGroupsInput, []request.Option) {
+	fake.describeUserGroupsWithContextMutex.RLock()
+	defer fake.describeUserGroupsWithContextMutex.RUnlock()
+	argsForCall := fake.describeUserGroupsWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) DescribeUserGroupsWithContextReturns(result1 *elasticache.DescribeUserGroupsOutput, result2 error) {
+	fake.describeUserGroupsWithContextMutex.Lock()
+	defer fake.describeUserGroupsWithContextMutex.Unlock()
+	fake.DescribeUserGroupsWithContextStub = nil
+	fake.describeUserGroupsWithContextReturns = struct {
+		result1 *elasticache.DescribeUserGroupsOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) DescribeUserGroupsWithContextReturnsOnCall(i int, result1 *elasticache.DescribeUserGroupsOutput, result2 error) {
+	fake.describeUserGroupsWithContextMutex.Lock()
+	defer fake.describeUserGroupsWithContextMutex.Unlock()
+	fake.DescribeUserGroupsWithContextStub = nil
+	if fake.describeUserGroupsWithContextReturnsOnCall == nil {
+		fake.describeUserGroupsWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.DescribeUserGroupsOutput
+			result2 error
+		})
+	}
+	fake.describeUserGroupsWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.DescribeUserGroupsOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContext(arg1 context.Context, arg2 *elasticache.IncreaseReplicaCountInput, arg3 ...request.Option) (*elasticache.IncreaseReplicaCountOutput, error) {
+	fake.increaseReplicaCountWithContextMutex.Lock()
+	ret, specificReturn := fake.increaseReplicaCountWithContextReturnsOnCall[len(fake.increaseReplicaCountWithContextArgsForCall)]
+	fake.increaseReplicaCountWithContextArgsForCall = append(fake.increaseReplicaCountWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.IncreaseReplicaCountInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.IncreaseReplicaCountWithContextStub
+	fakeReturns := fake.increaseReplicaCountWithContextReturns
+	fake.recordInvocation("IncreaseReplicaCountWithContext", []interface{}{arg1, arg2, arg3})
+	fake.increaseReplicaCountWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContextCallCount() int {
+	fake.increaseReplicaCountWithContextMutex.RLock()
+	defer fake.increaseReplicaCountWithContextMutex.RUnlock()
+	return len(fake.increaseReplicaCountWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContextCalls(stub func(context.Context, *elasticache.IncreaseReplicaCountInput, ...request.Option) (*elasticache.IncreaseReplicaCountOutput, error)) {
+	fake.increaseReplicaCountWithContextMutex.Lock()
+	defer fake.increaseReplicaCountWithContextMutex.Unlock()
+	fake.IncreaseReplicaCountWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContextArgsForCall(i int) (context.Context, *elasticache.IncreaseReplicaCountInput, []request.Option) {
+	fake.increaseReplicaCountWithContextMutex.RLock()
+	defer fake.increaseReplicaCountWithContextMutex.RUnlock()
+	argsForCall := fake.increaseReplicaCountWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContextReturns(result1 *elasticache.IncreaseReplicaCountOutput, result2 error) {
+	fake.increaseReplicaCountWithContextMutex.Lock()
+	defer fake.increaseReplicaCountWithContextMutex.Unlock()
+	fake.IncreaseReplicaCountWithContextStub = nil
+	fake.increaseReplicaCountWithContextReturns = struct {
+		result1 *elasticache.IncreaseReplicaCountOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) IncreaseReplicaCountWithContextReturnsOnCall(i int, result1 *elasticache.IncreaseReplicaCountOutput, result2 error) {
+	fake.increaseReplicaCountWithContextMutex.Lock()
+	defer fake.increaseReplicaCountWithContextMutex.Unlock()
+	fake.IncreaseReplicaCountWithContextStub = nil
+	if fake.increaseReplicaCountWithContextReturnsOnCall == nil {
+		fake.increaseReplicaCountWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.IncreaseReplicaCountOutput
+			result2 error
+		})
+	}
+	fake.increaseReplicaCountWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.IncreaseReplicaCountOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) ListTagsForResourceWithContext(arg1 context.Context, arg2 *elasticache.ListTagsForResourceInput, arg3 ...request.Option) (*elasticache.TagListMessage, error) {
 	fake.listTagsForResourceWithContextMutex.Lock()
 	ret, specificReturn := fake.listTagsForResourceWithContextReturnsOnCall[len(fake.listTagsForResourceWithContextArgsForCall)]
@@ -882,6 +2391,72 @@ func (fake *FakeElastiCache) ListTagsForResourceWithContextReturnsOnCall(i int,
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) ModifyCacheClusterWithContext(arg1 context.Context, arg2 *elasticache.ModifyCacheClusterInput, arg3 ...request.Option) (*elasticache.ModifyCacheClusterOutput, error) {
+	fake.modifyCacheClusterWithContextMutex.Lock()
+	ret, specificReturn := fake.modifyCacheClusterWithContextReturnsOnCall[len(fake.modifyCacheClusterWithContextArgsForCall)]
+	fake.modifyCacheClusterWithContextArgsForCall = append(fake.modifyCacheClusterWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyCacheClusterInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.ModifyCacheClusterWithContextStub
+	fakeReturns := fake.modifyCacheClusterWithContextReturns
+	fake.recordInvocation("ModifyCacheClusterWithContext", []interface{}{arg1, arg2, arg3})
+	fake.modifyCacheClusterWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) ModifyCacheClusterWithContextCallCount() int {
+	fake.modifyCacheClusterWithContextMutex.RLock()
+	defer fake.modifyCacheClusterWithContextMutex.RUnlock()
+	return len(fake.modifyCacheClusterWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) ModifyCacheClusterWithContextCalls(stub func(context.Context, *elasticache.ModifyCacheClusterInput, ...request.Option) (*elasticache.ModifyCacheClusterOutput, error)) {
+	fake.modifyCacheClusterWithContextMutex.Lock()
+	defer fake.modifyCacheClusterWithContextMutex.Unlock()
+	fake.ModifyCacheClusterWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) ModifyCacheClusterWithContextArgsForCall(i int) (context.Context, *elasticache.ModifyCacheClusterInput, []request.Option) {
+	fake.modifyCacheClusterWithContextMutex.RLock()
+	defer fake.modifyCacheClusterWithContextMutex.RUnlock()
+	argsForCall := fake.modifyCacheClusterWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) ModifyCacheClusterWithContextReturns(result1 *elasticache.ModifyCacheClusterOutput, result2 error) {
+	fake.modifyCacheClusterWithContextMutex.Lock()
+	defer fake.modifyCacheClusterWithContextMutex.Unlock()
+	fake.ModifyCacheClusterWithContextStub = nil
+	fake.modifyCacheClusterWithContextReturns = struct {
+		result1 *elasticache.ModifyCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) ModifyCacheClusterWithContextReturnsOnCall(i int, result1 *elasticache.ModifyCacheClusterOutput, result2 error) {
+	fake.modifyCacheClusterWithContextMutex.Lock()
+	defer fake.modifyCacheClusterWithContextMutex.Unlock()
+	fake.ModifyCacheClusterWithContextStub = nil
+	if fake.modifyCacheClusterWithContextReturnsOnCall == nil {
+		fake.modifyCacheClusterWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.ModifyCacheClusterOutput
+			result2 error
+		})
+	}
+	fake.modifyCacheClusterWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.ModifyCacheClusterOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) ModifyCacheParameterGroupWithContext(arg1 context.Context, arg2 *elasticache.ModifyCacheParameterGroupInput, arg3 ...request.Option) (*elasticache.CacheParameterGroupNameMessage, error) {
 	fake.modifyCacheParameterGroupWithContextMutex.Lock()
 	ret, specificReturn := fake.modifyCacheParameterGroupWithContextReturnsOnCall[len(fake.modifyCacheParameterGroupWithContextArgsForCall)]
@@ -948,6 +2523,72 @@ func (fake *FakeElastiCache) ModifyCacheParameterGroupWithContextReturnsOnCall(i
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContext(arg1 context.Context, arg2 *elasticache.ModifyReplicationGroupShardConfigurationInput, arg3 ...request.Option) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error) {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.Lock()
+	ret, specificReturn := fake.modifyReplicationGroupShardConfigurationWithContextReturnsOnCall[len(fake.modifyReplicationGroupShardConfigurationWithContextArgsForCall)]
+	fake.modifyReplicationGroupShardConfigurationWithContextArgsForCall = append(fake.modifyReplicationGroupShardConfigurationWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyReplicationGroupShardConfigurationInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.ModifyReplicationGroupShardConfigurationWithContextStub
+	fakeReturns := fake.modifyReplicationGroupShardConfigurationWithContextReturns
+	fake.recordInvocation("ModifyReplicationGroupShardConfigurationWithContext", []interface{}{arg1, arg2, arg3})
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContextCallCount() int {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.RLock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.RUnlock()
+	return len(fake.modifyReplicationGroupShardConfigurationWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContextCalls(stub func(context.Context, *elasticache.ModifyReplicationGroupShardConfigurationInput, ...request.Option) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error)) {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.Lock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.Unlock()
+	fake.ModifyReplicationGroupShardConfigurationWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContextArgsForCall(i int) (context.Context, *elasticache.ModifyReplicationGroupShardConfigurationInput, []request.Option) {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.RLock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.RUnlock()
+	argsForCall := fake.modifyReplicationGroupShardConfigurationWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContextReturns(result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput, result2 error) {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.Lock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.Unlock()
+	fake.ModifyReplicationGroupShardConfigurationWithContextStub = nil
+	fake.modifyReplicationGroupShardConfigurationWithContextReturns = struct {
+		result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) ModifyReplicationGroupShardConfigurationWithContextReturnsOnCall(i int, result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput, result2 error) {
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.Lock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.Unlock()
+	fake.ModifyReplicationGroupShardConfigurationWithContextStub = nil
+	if fake.modifyReplicationGroupShardConfigurationWithContextReturnsOnCall == nil {
+		fake.modifyReplicationGroupShardConfigurationWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput
+			result2 error
+		})
+	}
+	fake.modifyReplicationGroupShardConfigurationWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.ModifyReplicationGroupShardConfigurationOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) ModifyReplicationGroupWithContext(arg1 context.Context, arg2 *elasticache.ModifyReplicationGroupInput, arg3 ...request.Option) (*elasticache.ModifyReplicationGroupOutput, error) {
 	fake.modifyReplicationGroupWithContextMutex.Lock()
 	ret, specificReturn := fake.modifyReplicationGroupWithContextReturnsOnCall[len(fake.modifyReplicationGroupWithContextArgsForCall)]
@@ -1014,6 +2655,138 @@ func (fake *FakeElastiCache) ModifyReplicationGroupWithContextReturnsOnCall(i in
 	}{result1, result2}
 }
 
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContext(arg1 context.Context, arg2 *elasticache.ModifyServerlessCacheInput, arg3 ...request.Option) (*elasticache.ModifyServerlessCacheOutput, error) {
+	fake.modifyServerlessCacheWithContextMutex.Lock()
+	ret, specificReturn := fake.modifyServerlessCacheWithContextReturnsOnCall[len(fake.modifyServerlessCacheWithContextArgsForCall)]
+	fake.modifyServerlessCacheWithContextArgsForCall = append(fake.modifyServerlessCacheWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyServerlessCacheInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.ModifyServerlessCacheWithContextStub
+	fakeReturns := fake.modifyServerlessCacheWithContextReturns
+	fake.recordInvocation("ModifyServerlessCacheWithContext", []interface{}{arg1, arg2, arg3})
+	fake.modifyServerlessCacheWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContextCallCount() int {
+	fake.modifyServerlessCacheWithContextMutex.RLock()
+	defer fake.modifyServerlessCacheWithContextMutex.RUnlock()
+	return len(fake.modifyServerlessCacheWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContextCalls(stub func(context.Context, *elasticache.ModifyServerlessCacheInput, ...request.Option) (*elasticache.ModifyServerlessCacheOutput, error)) {
+	fake.modifyServerlessCacheWithContextMutex.Lock()
+	defer fake.modifyServerlessCacheWithContextMutex.Unlock()
+	fake.ModifyServerlessCacheWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContextArgsForCall(i int) (context.Context, *elasticache.ModifyServerlessCacheInput, []request.Option) {
+	fake.modifyServerlessCacheWithContextMutex.RLock()
+	defer fake.modifyServerlessCacheWithContextMutex.RUnlock()
+	argsForCall := fake.modifyServerlessCacheWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContextReturns(result1 *elasticache.ModifyServerlessCacheOutput, result2 error) {
+	fake.modifyServerlessCacheWithContextMutex.Lock()
+	defer fake.modifyServerlessCacheWithContextMutex.Unlock()
+	fake.ModifyServerlessCacheWithContextStub = nil
+	fake.modifyServerlessCacheWithContextReturns = struct {
+		result1 *elasticache.ModifyServerlessCacheOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) ModifyServerlessCacheWithContextReturnsOnCall(i int, result1 *elasticache.ModifyServerlessCacheOutput, result2 error) {
+	fake.modifyServerlessCacheWithContextMutex.Lock()
+	defer fake.modifyServerlessCacheWithContextMutex.Unlock()
+	fake.ModifyServerlessCacheWithContextStub = nil
+	if fake.modifyServerlessCacheWithContextReturnsOnCall == nil {
+		fake.modifyServerlessCacheWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.ModifyServerlessCacheOutput
+			result2 error
+		})
+	}
+	fake.modifyServerlessCacheWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.ModifyServerlessCacheOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContext(arg1 context.Context, arg2 *elasticache.ModifyUserGroupInput, arg3 ...request.Option) (*elasticache.ModifyUserGroupOutput, error) {
+	fake.modifyUserGroupWithContextMutex.Lock()
+	ret, specificReturn := fake.modifyUserGroupWithContextReturnsOnCall[len(fake.modifyUserGroupWithContextArgsForCall)]
+	fake.modifyUserGroupWithContextArgsForCall = append(fake.modifyUserGroupWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *elasticache.ModifyUserGroupInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.ModifyUserGroupWithContextStub
+	fakeReturns := fake.modifyUserGroupWithContextReturns
+	fake.recordInvocation("ModifyUserGroupWithContext", []interface{}{arg1, arg2, arg3})
+	fake.modifyUserGroupWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContextCallCount() int {
+	fake.modifyUserGroupWithContextMutex.RLock()
+	defer fake.modifyUserGroupWithContextMutex.RUnlock()
+	return len(fake.modifyUserGroupWithContextArgsForCall)
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContextCalls(stub func(context.Context, *elasticache.ModifyUserGroupInput, ...request.Option) (*elasticache.ModifyUserGroupOutput, error)) {
+	fake.modifyUserGroupWithContextMutex.Lock()
+	defer fake.modifyUserGroupWithContextMutex.Unlock()
+	fake.ModifyUserGroupWithContextStub = stub
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContextArgsForCall(i int) (context.Context, *elasticache.ModifyUserGroupInput, []request.Option) {
+	fake.modifyUserGroupWithContextMutex.RLock()
+	defer fake.modifyUserGroupWithContextMutex.RUnlock()
+	argsForCall := fake.modifyUserGroupWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContextReturns(result1 *elasticache.ModifyUserGroupOutput, result2 error) {
+	fake.modifyUserGroupWithContextMutex.Lock()
+	defer fake.modifyUserGroupWithContextMutex.Unlock()
+	fake.ModifyUserGroupWithContextStub = nil
+	fake.modifyUserGroupWithContextReturns = struct {
+		result1 *elasticache.ModifyUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeElastiCache) ModifyUserGroupWithContextReturnsOnCall(i int, result1 *elasticache.ModifyUserGroupOutput, result2 error) {
+	fake.modifyUserGroupWithContextMutex.Lock()
+	defer fake.modifyUserGroupWithContextMutex.Unlock()
+	fake.ModifyUserGroupWithContextStub = nil
+	if fake.modifyUserGroupWithContextReturnsOnCall == nil {
+		fake.modifyUserGroupWithContextReturnsOnCall = make(map[int]struct {
+			result1 *elasticache.ModifyUserGroupOutput
+			result2 error
+		})
+	}
+	fake.modifyUserGroupWithContextReturnsOnCall[i] = struct {
+		result1 *elasticache.ModifyUserGroupOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeElastiCache) RemoveTagsFromResourceWithContext(arg1 context.Context, arg2 *elasticache.RemoveTagsFromResourceInput, arg3 ...request.Option) (*elasticache.TagListMessage, error) {
 	fake.removeTagsFromResourceWithContextMutex.Lock()
 	ret, specificReturn := fake.removeTagsFromResourceWithContextReturnsOnCall[len(fake.removeTagsFromResourceWithContextArgsForCall)]
@@ -1151,28 +2924,72 @@ func (fake *FakeElastiCache) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.addTagsToResourceWithContextMutex.RLock()
 	defer fake.addTagsToResourceWithContextMutex.RUnlock()
+	fake.createCacheClusterWithContextMutex.RLock()
+	defer fake.createCacheClusterWithContextMutex.RUnlock()
 	fake.createCacheParameterGroupWithContextMutex.RLock()
 	defer fake.createCacheParameterGroupWithContextMutex.RUnlock()
 	fake.createReplicationGroupWithContextMutex.RLock()
 	defer fake.createReplicationGroupWithContextMutex.RUnlock()
+	fake.createServerlessCacheSnapshotWithContextMutex.RLock()
+	defer fake.createServerlessCacheSnapshotWithContextMutex.RUnlock()
+	fake.createServerlessCacheWithContextMutex.RLock()
+	defer fake.createServerlessCacheWithContextMutex.RUnlock()
+	fake.createSnapshotWithContextMutex.RLock()
+	defer fake.createSnapshotWithContextMutex.RUnlock()
+	fake.createUserGroupWithContextMutex.RLock()
+	defer fake.createUserGroupWithContextMutex.RUnlock()
+	fake.createUserWithContextMutex.RLock()
+	defer fake.createUserWithContextMutex.RUnlock()
+	fake.decreaseReplicaCountWithContextMutex.RLock()
+	defer fake.decreaseReplicaCountWithContextMutex.RUnlock()
+	fake.deleteCacheClusterWithContextMutex.RLock()
+	defer fake.deleteCacheClusterWithContextMutex.RUnlock()
 	fake.deleteCacheParameterGroupWithContextMutex.RLock()
 	defer fake.deleteCacheParameterGroupWithContextMutex.RUnlock()
 	fake.deleteReplicationGroupWithContextMutex.RLock()
 	defer fake.deleteReplicationGroupWithContextMutex.RUnlock()
+	fake.deleteServerlessCacheWithContextMutex.RLock()
+	defer fake.deleteServerlessCacheWithContextMutex.RUnlock()
+	fake.deleteUserGroupWithContextMutex.RLock()
+	defer fake.deleteUserGroupWithContextMutex.RUnlock()
+	fake.deleteUserWithContextMutex.RLock()
+	defer fake.deleteUserWithContextMutex.RUnlock()
 	fake.describeCacheClustersWithContextMutex.RLock()
 	defer fake.describeCacheClustersWithContextMutex.RUnlock()
 	fake.describeCacheParametersWithContextMutex.RLock()
 	defer fake.describeCacheParametersWithContextMutex.RUnlock()
+	fake.describeCacheSubnetGroupsWithContextMutex.RLock()
+	defer fake.describeCacheSubnetGroupsWithContextMutex.RUnlock()
+	fake.describeEventsPagesWithContextMutex.RLock()
+	defer fake.describeEventsPagesWithContextMutex.RUnlock()
+	fake.describeReplicationGroupsPagesWithContextMutex.RLock()
+	defer fake.describeReplicationGroupsPagesWithContextMutex.RUnlock()
 	fake.describeReplicationGroupsWithContextMutex.RLock()
 	defer fake.describeReplicationGroupsWithContextMutex.RUnlock()
+	fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RLock()
+	defer fake.describeServerlessCacheSnapshotsPagesWithContextMutex.RUnlock()
+	fake.describeServerlessCachesWithContextMutex.RLock()
+	defer fake.describeServerlessCachesWithContextMutex.RUnlock()
 	fake.describeSnapshotsPagesWithContextMutex.RLock()
 	defer fake.describeSnapshotsPagesWithContextMutex.RUnlock()
+	fake.describeUserGroupsWithContextMutex.RLock()
+	defer fake.describeUserGroupsWithContextMutex.RUnlock()
+	fake.increaseReplicaCountWithContextMutex.RLock()
+	defer fake.increaseReplicaCountWithContextMutex.RUnlock()
 	fake.listTagsForResourceWithContextMutex.RLock()
 	defer fake.listTagsForResourceWithContextMutex.RUnlock()
+	fake.modifyCacheClusterWithContextMutex.RLock()
+	defer fake.modifyCacheClusterWithContextMutex.RUnlock()
 	fake.modifyCacheParameterGroupWithContextMutex.RLock()
 	defer fake.modifyCacheParameterGroupWithContextMutex.RUnlock()
+	fake.modifyReplicationGroupShardConfigurationWithContextMutex.RLock()
+	defer fake.modifyReplicationGroupShardConfigurationWithContextMutex.RUnlock()
 	fake.modifyReplicationGroupWithContextMutex.RLock()
 	defer fake.modifyReplicationGroupWithContextMutex.RUnlock()
+	fake.modifyServerlessCacheWithContextMutex.RLock()
+	defer fake.modifyServerlessCacheWithContextMutex.RUnlock()
+	fake.modifyUserGroupWithContextMutex.RLock()
+	defer fake.modifyUserGroupWithContextMutex.RUnlock()
 	fake.removeTagsFromResourceWithContextMutex.RLock()
 	defer fake.removeTagsFromResourceWithContextMutex.RUnlock()
 	fake.testFailoverWithContextMutex.RLock()
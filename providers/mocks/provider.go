@@ -4,11 +4,25 @@ package mocks
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/alphagov/paas-elasticache-broker/providers"
 )
 
 type FakeProvider struct {
+	CreateSnapshotStub        func(context.Context, string, string) error
+	createSnapshotMutex       sync.RWMutex
+	createSnapshotArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	createSnapshotReturns struct {
+		result1 error
+	}
+	createSnapshotReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteCacheParameterGroupStub        func(context.Context, string) error
 	deleteCacheParameterGroupMutex       sync.RWMutex
 	deleteCacheParameterGroupArgsForCall []struct {
@@ -21,6 +35,30 @@ type FakeProvider struct {
 	deleteCacheParameterGroupReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteUnusedCacheParameterGroupsStub        func(context.Context, string) error
+	deleteUnusedCacheParameterGroupsMutex       sync.RWMutex
+	deleteUnusedCacheParameterGroupsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteUnusedCacheParameterGroupsReturns struct {
+		result1 error
+	}
+	deleteUnusedCacheParameterGroupsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteUserGroupStub        func(context.Context, string) error
+	deleteUserGroupMutex       sync.RWMutex
+	deleteUserGroupArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteUserGroupReturns struct {
+		result1 error
+	}
+	deleteUserGroupReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeprovisionStub        func(context.Context, string, providers.DeprovisionParameters) error
 	deprovisionMutex       sync.RWMutex
 	deprovisionArgsForCall []struct {
@@ -34,6 +72,66 @@ type FakeProvider struct {
 	deprovisionReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FailoverTestStateStub        func(context.Context, string, string, time.Time) (providers.ServiceState, string, error)
+	failoverTestStateMutex       sync.RWMutex
+	failoverTestStateArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}
+	failoverTestStateReturns struct {
+		result1 providers.ServiceState
+		result2 string
+		result3 error
+	}
+	failoverTestStateReturnsOnCall map[int]struct {
+		result1 providers.ServiceState
+		result2 string
+		result3 error
+	}
+	FindDegradedInstancesStub        func(context.Context, string) ([]providers.DegradedInstance, error)
+	findDegradedInstancesMutex       sync.RWMutex
+	findDegradedInstancesArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	findDegradedInstancesReturns struct {
+		result1 []providers.DegradedInstance
+		result2 error
+	}
+	findDegradedInstancesReturnsOnCall map[int]struct {
+		result1 []providers.DegradedInstance
+		result2 error
+	}
+	FindInstanceStub        func(context.Context, string) (*providers.ExistingInstance, error)
+	findInstanceMutex       sync.RWMutex
+	findInstanceArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	findInstanceReturns struct {
+		result1 *providers.ExistingInstance
+		result2 error
+	}
+	findInstanceReturnsOnCall map[int]struct {
+		result1 *providers.ExistingInstance
+		result2 error
+	}
+	FindSnapshotStub        func(context.Context, string) (*providers.SnapshotInfo, error)
+	findSnapshotMutex       sync.RWMutex
+	findSnapshotArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	findSnapshotReturns struct {
+		result1 *providers.SnapshotInfo
+		result2 error
+	}
+	findSnapshotReturnsOnCall map[int]struct {
+		result1 *providers.SnapshotInfo
+		result2 error
+	}
 	FindSnapshotsStub        func(context.Context, string) ([]providers.SnapshotInfo, error)
 	findSnapshotsMutex       sync.RWMutex
 	findSnapshotsArgsForCall []struct {
@@ -48,6 +146,20 @@ type FakeProvider struct {
 		result1 []providers.SnapshotInfo
 		result2 error
 	}
+	FindSnapshotsWithTagsStub        func(context.Context, map[string]string) ([]providers.SnapshotInfo, error)
+	findSnapshotsWithTagsMutex       sync.RWMutex
+	findSnapshotsWithTagsArgsForCall []struct {
+		arg1 context.Context
+		arg2 map[string]string
+	}
+	findSnapshotsWithTagsReturns struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}
+	findSnapshotsWithTagsReturnsOnCall map[int]struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}
 	GenerateCredentialsStub        func(context.Context, string, string) (*providers.Credentials, error)
 	generateCredentialsMutex       sync.RWMutex
 	generateCredentialsArgsForCall []struct {
@@ -91,6 +203,20 @@ type FakeProvider struct {
 		result1 map[string]string
 		result2 error
 	}
+	ListSnapshotsStub        func(context.Context, string) ([]providers.SnapshotInfo, error)
+	listSnapshotsMutex       sync.RWMutex
+	listSnapshotsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listSnapshotsReturns struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}
+	listSnapshotsReturnsOnCall map[int]struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}
 	ProgressStateStub        func(context.Context, string, string, string) (providers.ServiceState, string, error)
 	progressStateMutex       sync.RWMutex
 	progressStateArgsForCall []struct {
@@ -122,6 +248,20 @@ type FakeProvider struct {
 	provisionReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RestoreHighAvailabilityStub        func(context.Context, string) (bool, error)
+	restoreHighAvailabilityMutex       sync.RWMutex
+	restoreHighAvailabilityArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	restoreHighAvailabilityReturns struct {
+		result1 bool
+		result2 error
+	}
+	restoreHighAvailabilityReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	RevokeCredentialsStub        func(context.Context, string, string) error
 	revokeCredentialsMutex       sync.RWMutex
 	revokeCredentialsArgsForCall []struct {
@@ -135,6 +275,18 @@ type FakeProvider struct {
 	revokeCredentialsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RotateAuthTokenStub        func(context.Context, string) error
+	rotateAuthTokenMutex       sync.RWMutex
+	rotateAuthTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	rotateAuthTokenReturns struct {
+		result1 error
+	}
+	rotateAuthTokenReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StartFailoverTestStub        func(context.Context, string) (string, error)
 	startFailoverTestMutex       sync.RWMutex
 	startFailoverTestArgsForCall []struct {
@@ -149,6 +301,21 @@ type FakeProvider struct {
 		result1 string
 		result2 error
 	}
+	TestFailoverStub        func(context.Context, string, string) (string, error)
+	testFailoverMutex       sync.RWMutex
+	testFailoverArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	testFailoverReturns struct {
+		result1 string
+		result2 error
+	}
+	testFailoverReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	UpdateParamGroupParametersStub        func(context.Context, string, providers.UpdateParamGroupParameters) error
 	updateParamGroupParametersMutex       sync.RWMutex
 	updateParamGroupParametersArgsForCall []struct {
@@ -175,10 +342,86 @@ type FakeProvider struct {
 	updateReplicationGroupReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UpgradeEngineVersionStub        func(context.Context, string, providers.UpgradeEngineVersionParameters) error
+	upgradeEngineVersionMutex       sync.RWMutex
+	upgradeEngineVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 providers.UpgradeEngineVersionParameters
+	}
+	upgradeEngineVersionReturns struct {
+		result1 error
+	}
+	upgradeEngineVersionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeProvider) CreateSnapshot(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.createSnapshotMutex.Lock()
+	ret, specificReturn := fake.createSnapshotReturnsOnCall[len(fake.createSnapshotArgsForCall)]
+	fake.createSnapshotArgsForCall = append(fake.createSnapshotArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.CreateSnapshotStub
+	fakeReturns := fake.createSnapshotReturns
+	fake.recordInvocation("CreateSnapshot", []interface{}{arg1, arg2, arg3})
+	fake.createSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) CreateSnapshotCallCount() int {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	return len(fake.createSnapshotArgsForCall)
+}
+
+func (fake *FakeProvider) CreateSnapshotCalls(stub func(context.Context, string, string) error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = stub
+}
+
+func (fake *FakeProvider) CreateSnapshotArgsForCall(i int) (context.Context, string, string) {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	argsForCall := fake.createSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvider) CreateSnapshotReturns(result1 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	fake.createSnapshotReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) CreateSnapshotReturnsOnCall(i int, result1 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	if fake.createSnapshotReturnsOnCall == nil {
+		fake.createSnapshotReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createSnapshotReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeProvider) DeleteCacheParameterGroup(arg1 context.Context, arg2 string) error {
 	fake.deleteCacheParameterGroupMutex.Lock()
 	ret, specificReturn := fake.deleteCacheParameterGroupReturnsOnCall[len(fake.deleteCacheParameterGroupArgsForCall)]
@@ -241,6 +484,130 @@ func (fake *FakeProvider) DeleteCacheParameterGroupReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroups(arg1 context.Context, arg2 string) error {
+	fake.deleteUnusedCacheParameterGroupsMutex.Lock()
+	ret, specificReturn := fake.deleteUnusedCacheParameterGroupsReturnsOnCall[len(fake.deleteUnusedCacheParameterGroupsArgsForCall)]
+	fake.deleteUnusedCacheParameterGroupsArgsForCall = append(fake.deleteUnusedCacheParameterGroupsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteUnusedCacheParameterGroupsStub
+	fakeReturns := fake.deleteUnusedCacheParameterGroupsReturns
+	fake.recordInvocation("DeleteUnusedCacheParameterGroups", []interface{}{arg1, arg2})
+	fake.deleteUnusedCacheParameterGroupsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroupsCallCount() int {
+	fake.deleteUnusedCacheParameterGroupsMutex.RLock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.RUnlock()
+	return len(fake.deleteUnusedCacheParameterGroupsArgsForCall)
+}
+
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroupsCalls(stub func(context.Context, string) error) {
+	fake.deleteUnusedCacheParameterGroupsMutex.Lock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.Unlock()
+	fake.DeleteUnusedCacheParameterGroupsStub = stub
+}
+
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroupsArgsForCall(i int) (context.Context, string) {
+	fake.deleteUnusedCacheParameterGroupsMutex.RLock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.RUnlock()
+	argsForCall := fake.deleteUnusedCacheParameterGroupsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroupsReturns(result1 error) {
+	fake.deleteUnusedCacheParameterGroupsMutex.Lock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.Unlock()
+	fake.DeleteUnusedCacheParameterGroupsStub = nil
+	fake.deleteUnusedCacheParameterGroupsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) DeleteUnusedCacheParameterGroupsReturnsOnCall(i int, result1 error) {
+	fake.deleteUnusedCacheParameterGroupsMutex.Lock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.Unlock()
+	fake.DeleteUnusedCacheParameterGroupsStub = nil
+	if fake.deleteUnusedCacheParameterGroupsReturnsOnCall == nil {
+		fake.deleteUnusedCacheParameterGroupsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteUnusedCacheParameterGroupsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) DeleteUserGroup(arg1 context.Context, arg2 string) error {
+	fake.deleteUserGroupMutex.Lock()
+	ret, specificReturn := fake.deleteUserGroupReturnsOnCall[len(fake.deleteUserGroupArgsForCall)]
+	fake.deleteUserGroupArgsForCall = append(fake.deleteUserGroupArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteUserGroupStub
+	fakeReturns := fake.deleteUserGroupReturns
+	fake.recordInvocation("DeleteUserGroup", []interface{}{arg1, arg2})
+	fake.deleteUserGroupMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) DeleteUserGroupCallCount() int {
+	fake.deleteUserGroupMutex.RLock()
+	defer fake.deleteUserGroupMutex.RUnlock()
+	return len(fake.deleteUserGroupArgsForCall)
+}
+
+func (fake *FakeProvider) DeleteUserGroupCalls(stub func(context.Context, string) error) {
+	fake.deleteUserGroupMutex.Lock()
+	defer fake.deleteUserGroupMutex.Unlock()
+	fake.DeleteUserGroupStub = stub
+}
+
+func (fake *FakeProvider) DeleteUserGroupArgsForCall(i int) (context.Context, string) {
+	fake.deleteUserGroupMutex.RLock()
+	defer fake.deleteUserGroupMutex.RUnlock()
+	argsForCall := fake.deleteUserGroupArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) DeleteUserGroupReturns(result1 error) {
+	fake.deleteUserGroupMutex.Lock()
+	defer fake.deleteUserGroupMutex.Unlock()
+	fake.DeleteUserGroupStub = nil
+	fake.deleteUserGroupReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) DeleteUserGroupReturnsOnCall(i int, result1 error) {
+	fake.deleteUserGroupMutex.Lock()
+	defer fake.deleteUserGroupMutex.Unlock()
+	fake.DeleteUserGroupStub = nil
+	if fake.deleteUserGroupReturnsOnCall == nil {
+		fake.deleteUserGroupReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteUserGroupReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeProvider) Deprovision(arg1 context.Context, arg2 string, arg3 providers.DeprovisionParameters) error {
 	fake.deprovisionMutex.Lock()
 	ret, specificReturn := fake.deprovisionReturnsOnCall[len(fake.deprovisionArgsForCall)]
@@ -304,6 +671,271 @@ func (fake *FakeProvider) DeprovisionReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeProvider) FailoverTestState(arg1 context.Context, arg2 string, arg3 string, arg4 time.Time) (providers.ServiceState, string, error) {
+	fake.failoverTestStateMutex.Lock()
+	ret, specificReturn := fake.failoverTestStateReturnsOnCall[len(fake.failoverTestStateArgsForCall)]
+	fake.failoverTestStateArgsForCall = append(fake.failoverTestStateArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.FailoverTestStateStub
+	fakeReturns := fake.failoverTestStateReturns
+	fake.recordInvocation("FailoverTestState", []interface{}{arg1, arg2, arg3, arg4})
+	fake.failoverTestStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeProvider) FailoverTestStateCallCount() int {
+	fake.failoverTestStateMutex.RLock()
+	defer fake.failoverTestStateMutex.RUnlock()
+	return len(fake.failoverTestStateArgsForCall)
+}
+
+func (fake *FakeProvider) FailoverTestStateCalls(stub func(context.Context, string, string, time.Time) (providers.ServiceState, string, error)) {
+	fake.failoverTestStateMutex.Lock()
+	defer fake.failoverTestStateMutex.Unlock()
+	fake.FailoverTestStateStub = stub
+}
+
+func (fake *FakeProvider) FailoverTestStateArgsForCall(i int) (context.Context, string, string, time.Time) {
+	fake.failoverTestStateMutex.RLock()
+	defer fake.failoverTestStateMutex.RUnlock()
+	argsForCall := fake.failoverTestStateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeProvider) FailoverTestStateReturns(result1 providers.ServiceState, result2 string, result3 error) {
+	fake.failoverTestStateMutex.Lock()
+	defer fake.failoverTestStateMutex.Unlock()
+	fake.FailoverTestStateStub = nil
+	fake.failoverTestStateReturns = struct {
+		result1 providers.ServiceState
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeProvider) FailoverTestStateReturnsOnCall(i int, result1 providers.ServiceState, result2 string, result3 error) {
+	fake.failoverTestStateMutex.Lock()
+	defer fake.failoverTestStateMutex.Unlock()
+	fake.FailoverTestStateStub = nil
+	if fake.failoverTestStateReturnsOnCall == nil {
+		fake.failoverTestStateReturnsOnCall = make(map[int]struct {
+			result1 providers.ServiceState
+			result2 string
+			result3 error
+		})
+	}
+	fake.failoverTestStateReturnsOnCall[i] = struct {
+		result1 providers.ServiceState
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeProvider) FindDegradedInstances(arg1 context.Context, arg2 string) ([]providers.DegradedInstance, error) {
+	fake.findDegradedInstancesMutex.Lock()
+	ret, specificReturn := fake.findDegradedInstancesReturnsOnCall[len(fake.findDegradedInstancesArgsForCall)]
+	fake.findDegradedInstancesArgsForCall = append(fake.findDegradedInstancesArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.FindDegradedInstancesStub
+	fakeReturns := fake.findDegradedInstancesReturns
+	fake.recordInvocation("FindDegradedInstances", []interface{}{arg1, arg2})
+	fake.findDegradedInstancesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) FindDegradedInstancesCallCount() int {
+	fake.findDegradedInstancesMutex.RLock()
+	defer fake.findDegradedInstancesMutex.RUnlock()
+	return len(fake.findDegradedInstancesArgsForCall)
+}
+
+func (fake *FakeProvider) FindDegradedInstancesCalls(stub func(context.Context, string) ([]providers.DegradedInstance, error)) {
+	fake.findDegradedInstancesMutex.Lock()
+	defer fake.findDegradedInstancesMutex.Unlock()
+	fake.FindDegradedInstancesStub = stub
+}
+
+func (fake *FakeProvider) FindDegradedInstancesArgsForCall(i int) (context.Context, string) {
+	fake.findDegradedInstancesMutex.RLock()
+	defer fake.findDegradedInstancesMutex.RUnlock()
+	argsForCall := fake.findDegradedInstancesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) FindDegradedInstancesReturns(result1 []providers.DegradedInstance, result2 error) {
+	fake.findDegradedInstancesMutex.Lock()
+	defer fake.findDegradedInstancesMutex.Unlock()
+	fake.FindDegradedInstancesStub = nil
+	fake.findDegradedInstancesReturns = struct {
+		result1 []providers.DegradedInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindDegradedInstancesReturnsOnCall(i int, result1 []providers.DegradedInstance, result2 error) {
+	fake.findDegradedInstancesMutex.Lock()
+	defer fake.findDegradedInstancesMutex.Unlock()
+	fake.FindDegradedInstancesStub = nil
+	if fake.findDegradedInstancesReturnsOnCall == nil {
+		fake.findDegradedInstancesReturnsOnCall = make(map[int]struct {
+			result1 []providers.DegradedInstance
+			result2 error
+		})
+	}
+	fake.findDegradedInstancesReturnsOnCall[i] = struct {
+		result1 []providers.DegradedInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindInstance(arg1 context.Context, arg2 string) (*providers.ExistingInstance, error) {
+	fake.findInstanceMutex.Lock()
+	ret, specificReturn := fake.findInstanceReturnsOnCall[len(fake.findInstanceArgsForCall)]
+	fake.findInstanceArgsForCall = append(fake.findInstanceArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.FindInstanceStub
+	fakeReturns := fake.findInstanceReturns
+	fake.recordInvocation("FindInstance", []interface{}{arg1, arg2})
+	fake.findInstanceMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) FindInstanceCallCount() int {
+	fake.findInstanceMutex.RLock()
+	defer fake.findInstanceMutex.RUnlock()
+	return len(fake.findInstanceArgsForCall)
+}
+
+func (fake *FakeProvider) FindInstanceCalls(stub func(context.Context, string) (*providers.ExistingInstance, error)) {
+	fake.findInstanceMutex.Lock()
+	defer fake.findInstanceMutex.Unlock()
+	fake.FindInstanceStub = stub
+}
+
+func (fake *FakeProvider) FindInstanceArgsForCall(i int) (context.Context, string) {
+	fake.findInstanceMutex.RLock()
+	defer fake.findInstanceMutex.RUnlock()
+	argsForCall := fake.findInstanceArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) FindInstanceReturns(result1 *providers.ExistingInstance, result2 error) {
+	fake.findInstanceMutex.Lock()
+	defer fake.findInstanceMutex.Unlock()
+	fake.FindInstanceStub = nil
+	fake.findInstanceReturns = struct {
+		result1 *providers.ExistingInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindInstanceReturnsOnCall(i int, result1 *providers.ExistingInstance, result2 error) {
+	fake.findInstanceMutex.Lock()
+	defer fake.findInstanceMutex.Unlock()
+	fake.FindInstanceStub = nil
+	if fake.findInstanceReturnsOnCall == nil {
+		fake.findInstanceReturnsOnCall = make(map[int]struct {
+			result1 *providers.ExistingInstance
+			result2 error
+		})
+	}
+	fake.findInstanceReturnsOnCall[i] = struct {
+		result1 *providers.ExistingInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindSnapshot(arg1 context.Context, arg2 string) (*providers.SnapshotInfo, error) {
+	fake.findSnapshotMutex.Lock()
+	ret, specificReturn := fake.findSnapshotReturnsOnCall[len(fake.findSnapshotArgsForCall)]
+	fake.findSnapshotArgsForCall = append(fake.findSnapshotArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.FindSnapshotStub
+	fakeReturns := fake.findSnapshotReturns
+	fake.recordInvocation("FindSnapshot", []interface{}{arg1, arg2})
+	fake.findSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) FindSnapshotCallCount() int {
+	fake.findSnapshotMutex.RLock()
+	defer fake.findSnapshotMutex.RUnlock()
+	return len(fake.findSnapshotArgsForCall)
+}
+
+func (fake *FakeProvider) FindSnapshotCalls(stub func(context.Context, string) (*providers.SnapshotInfo, error)) {
+	fake.findSnapshotMutex.Lock()
+	defer fake.findSnapshotMutex.Unlock()
+	fake.FindSnapshotStub = stub
+}
+
+func (fake *FakeProvider) FindSnapshotArgsForCall(i int) (context.Context, string) {
+	fake.findSnapshotMutex.RLock()
+	defer fake.findSnapshotMutex.RUnlock()
+	argsForCall := fake.findSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) FindSnapshotReturns(result1 *providers.SnapshotInfo, result2 error) {
+	fake.findSnapshotMutex.Lock()
+	defer fake.findSnapshotMutex.Unlock()
+	fake.FindSnapshotStub = nil
+	fake.findSnapshotReturns = struct {
+		result1 *providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindSnapshotReturnsOnCall(i int, result1 *providers.SnapshotInfo, result2 error) {
+	fake.findSnapshotMutex.Lock()
+	defer fake.findSnapshotMutex.Unlock()
+	fake.FindSnapshotStub = nil
+	if fake.findSnapshotReturnsOnCall == nil {
+		fake.findSnapshotReturnsOnCall = make(map[int]struct {
+			result1 *providers.SnapshotInfo
+			result2 error
+		})
+	}
+	fake.findSnapshotReturnsOnCall[i] = struct {
+		result1 *providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProvider) FindSnapshots(arg1 context.Context, arg2 string) ([]providers.SnapshotInfo, error) {
 	fake.findSnapshotsMutex.Lock()
 	ret, specificReturn := fake.findSnapshotsReturnsOnCall[len(fake.findSnapshotsArgsForCall)]
@@ -369,6 +1001,71 @@ func (fake *FakeProvider) FindSnapshotsReturnsOnCall(i int, result1 []providers.
 	}{result1, result2}
 }
 
+func (fake *FakeProvider) FindSnapshotsWithTags(arg1 context.Context, arg2 map[string]string) ([]providers.SnapshotInfo, error) {
+	fake.findSnapshotsWithTagsMutex.Lock()
+	ret, specificReturn := fake.findSnapshotsWithTagsReturnsOnCall[len(fake.findSnapshotsWithTagsArgsForCall)]
+	fake.findSnapshotsWithTagsArgsForCall = append(fake.findSnapshotsWithTagsArgsForCall, struct {
+		arg1 context.Context
+		arg2 map[string]string
+	}{arg1, arg2})
+	stub := fake.FindSnapshotsWithTagsStub
+	fakeReturns := fake.findSnapshotsWithTagsReturns
+	fake.recordInvocation("FindSnapshotsWithTags", []interface{}{arg1, arg2})
+	fake.findSnapshotsWithTagsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) FindSnapshotsWithTagsCallCount() int {
+	fake.findSnapshotsWithTagsMutex.RLock()
+	defer fake.findSnapshotsWithTagsMutex.RUnlock()
+	return len(fake.findSnapshotsWithTagsArgsForCall)
+}
+
+func (fake *FakeProvider) FindSnapshotsWithTagsCalls(stub func(context.Context, map[string]string) ([]providers.SnapshotInfo, error)) {
+	fake.findSnapshotsWithTagsMutex.Lock()
+	defer fake.findSnapshotsWithTagsMutex.Unlock()
+	fake.FindSnapshotsWithTagsStub = stub
+}
+
+func (fake *FakeProvider) FindSnapshotsWithTagsArgsForCall(i int) (context.Context, map[string]string) {
+	fake.findSnapshotsWithTagsMutex.RLock()
+	defer fake.findSnapshotsWithTagsMutex.RUnlock()
+	argsForCall := fake.findSnapshotsWithTagsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) FindSnapshotsWithTagsReturns(result1 []providers.SnapshotInfo, result2 error) {
+	fake.findSnapshotsWithTagsMutex.Lock()
+	defer fake.findSnapshotsWithTagsMutex.Unlock()
+	fake.FindSnapshotsWithTagsStub = nil
+	fake.findSnapshotsWithTagsReturns = struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) FindSnapshotsWithTagsReturnsOnCall(i int, result1 []providers.SnapshotInfo, result2 error) {
+	fake.findSnapshotsWithTagsMutex.Lock()
+	defer fake.findSnapshotsWithTagsMutex.Unlock()
+	fake.FindSnapshotsWithTagsStub = nil
+	if fake.findSnapshotsWithTagsReturnsOnCall == nil {
+		fake.findSnapshotsWithTagsReturnsOnCall = make(map[int]struct {
+			result1 []providers.SnapshotInfo
+			result2 error
+		})
+	}
+	fake.findSnapshotsWithTagsReturnsOnCall[i] = struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProvider) GenerateCredentials(arg1 context.Context, arg2 string, arg3 string) (*providers.Credentials, error) {
 	fake.generateCredentialsMutex.Lock()
 	ret, specificReturn := fake.generateCredentialsReturnsOnCall[len(fake.generateCredentialsArgsForCall)]
@@ -565,6 +1262,71 @@ func (fake *FakeProvider) GetInstanceTagsReturnsOnCall(i int, result1 map[string
 	}{result1, result2}
 }
 
+func (fake *FakeProvider) ListSnapshots(arg1 context.Context, arg2 string) ([]providers.SnapshotInfo, error) {
+	fake.listSnapshotsMutex.Lock()
+	ret, specificReturn := fake.listSnapshotsReturnsOnCall[len(fake.listSnapshotsArgsForCall)]
+	fake.listSnapshotsArgsForCall = append(fake.listSnapshotsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListSnapshotsStub
+	fakeReturns := fake.listSnapshotsReturns
+	fake.recordInvocation("ListSnapshots", []interface{}{arg1, arg2})
+	fake.listSnapshotsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) ListSnapshotsCallCount() int {
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
+	return len(fake.listSnapshotsArgsForCall)
+}
+
+func (fake *FakeProvider) ListSnapshotsCalls(stub func(context.Context, string) ([]providers.SnapshotInfo, error)) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = stub
+}
+
+func (fake *FakeProvider) ListSnapshotsArgsForCall(i int) (context.Context, string) {
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
+	argsForCall := fake.listSnapshotsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) ListSnapshotsReturns(result1 []providers.SnapshotInfo, result2 error) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = nil
+	fake.listSnapshotsReturns = struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) ListSnapshotsReturnsOnCall(i int, result1 []providers.SnapshotInfo, result2 error) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = nil
+	if fake.listSnapshotsReturnsOnCall == nil {
+		fake.listSnapshotsReturnsOnCall = make(map[int]struct {
+			result1 []providers.SnapshotInfo
+			result2 error
+		})
+	}
+	fake.listSnapshotsReturnsOnCall[i] = struct {
+		result1 []providers.SnapshotInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProvider) ProgressState(arg1 context.Context, arg2 string, arg3 string, arg4 string) (providers.ServiceState, string, error) {
 	fake.progressStateMutex.Lock()
 	ret, specificReturn := fake.progressStateReturnsOnCall[len(fake.progressStateArgsForCall)]
@@ -698,6 +1460,71 @@ func (fake *FakeProvider) ProvisionReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeProvider) RestoreHighAvailability(arg1 context.Context, arg2 string) (bool, error) {
+	fake.restoreHighAvailabilityMutex.Lock()
+	ret, specificReturn := fake.restoreHighAvailabilityReturnsOnCall[len(fake.restoreHighAvailabilityArgsForCall)]
+	fake.restoreHighAvailabilityArgsForCall = append(fake.restoreHighAvailabilityArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RestoreHighAvailabilityStub
+	fakeReturns := fake.restoreHighAvailabilityReturns
+	fake.recordInvocation("RestoreHighAvailability", []interface{}{arg1, arg2})
+	fake.restoreHighAvailabilityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) RestoreHighAvailabilityCallCount() int {
+	fake.restoreHighAvailabilityMutex.RLock()
+	defer fake.restoreHighAvailabilityMutex.RUnlock()
+	return len(fake.restoreHighAvailabilityArgsForCall)
+}
+
+func (fake *FakeProvider) RestoreHighAvailabilityCalls(stub func(context.Context, string) (bool, error)) {
+	fake.restoreHighAvailabilityMutex.Lock()
+	defer fake.restoreHighAvailabilityMutex.Unlock()
+	fake.RestoreHighAvailabilityStub = stub
+}
+
+func (fake *FakeProvider) RestoreHighAvailabilityArgsForCall(i int) (context.Context, string) {
+	fake.restoreHighAvailabilityMutex.RLock()
+	defer fake.restoreHighAvailabilityMutex.RUnlock()
+	argsForCall := fake.restoreHighAvailabilityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) RestoreHighAvailabilityReturns(result1 bool, result2 error) {
+	fake.restoreHighAvailabilityMutex.Lock()
+	defer fake.restoreHighAvailabilityMutex.Unlock()
+	fake.RestoreHighAvailabilityStub = nil
+	fake.restoreHighAvailabilityReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) RestoreHighAvailabilityReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.restoreHighAvailabilityMutex.Lock()
+	defer fake.restoreHighAvailabilityMutex.Unlock()
+	fake.RestoreHighAvailabilityStub = nil
+	if fake.restoreHighAvailabilityReturnsOnCall == nil {
+		fake.restoreHighAvailabilityReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.restoreHighAvailabilityReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProvider) RevokeCredentials(arg1 context.Context, arg2 string, arg3 string) error {
 	fake.revokeCredentialsMutex.Lock()
 	ret, specificReturn := fake.revokeCredentialsReturnsOnCall[len(fake.revokeCredentialsArgsForCall)]
@@ -761,6 +1588,68 @@ func (fake *FakeProvider) RevokeCredentialsReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeProvider) RotateAuthToken(arg1 context.Context, arg2 string) error {
+	fake.rotateAuthTokenMutex.Lock()
+	ret, specificReturn := fake.rotateAuthTokenReturnsOnCall[len(fake.rotateAuthTokenArgsForCall)]
+	fake.rotateAuthTokenArgsForCall = append(fake.rotateAuthTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RotateAuthTokenStub
+	fakeReturns := fake.rotateAuthTokenReturns
+	fake.recordInvocation("RotateAuthToken", []interface{}{arg1, arg2})
+	fake.rotateAuthTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) RotateAuthTokenCallCount() int {
+	fake.rotateAuthTokenMutex.RLock()
+	defer fake.rotateAuthTokenMutex.RUnlock()
+	return len(fake.rotateAuthTokenArgsForCall)
+}
+
+func (fake *FakeProvider) RotateAuthTokenCalls(stub func(context.Context, string) error) {
+	fake.rotateAuthTokenMutex.Lock()
+	defer fake.rotateAuthTokenMutex.Unlock()
+	fake.RotateAuthTokenStub = stub
+}
+
+func (fake *FakeProvider) RotateAuthTokenArgsForCall(i int) (context.Context, string) {
+	fake.rotateAuthTokenMutex.RLock()
+	defer fake.rotateAuthTokenMutex.RUnlock()
+	argsForCall := fake.rotateAuthTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeProvider) RotateAuthTokenReturns(result1 error) {
+	fake.rotateAuthTokenMutex.Lock()
+	defer fake.rotateAuthTokenMutex.Unlock()
+	fake.RotateAuthTokenStub = nil
+	fake.rotateAuthTokenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) RotateAuthTokenReturnsOnCall(i int, result1 error) {
+	fake.rotateAuthTokenMutex.Lock()
+	defer fake.rotateAuthTokenMutex.Unlock()
+	fake.RotateAuthTokenStub = nil
+	if fake.rotateAuthTokenReturnsOnCall == nil {
+		fake.rotateAuthTokenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.rotateAuthTokenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeProvider) StartFailoverTest(arg1 context.Context, arg2 string) (string, error) {
 	fake.startFailoverTestMutex.Lock()
 	ret, specificReturn := fake.startFailoverTestReturnsOnCall[len(fake.startFailoverTestArgsForCall)]
@@ -826,6 +1715,72 @@ func (fake *FakeProvider) StartFailoverTestReturnsOnCall(i int, result1 string,
 	}{result1, result2}
 }
 
+func (fake *FakeProvider) TestFailover(arg1 context.Context, arg2 string, arg3 string) (string, error) {
+	fake.testFailoverMutex.Lock()
+	ret, specificReturn := fake.testFailoverReturnsOnCall[len(fake.testFailoverArgsForCall)]
+	fake.testFailoverArgsForCall = append(fake.testFailoverArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.TestFailoverStub
+	fakeReturns := fake.testFailoverReturns
+	fake.recordInvocation("TestFailover", []interface{}{arg1, arg2, arg3})
+	fake.testFailoverMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProvider) TestFailoverCallCount() int {
+	fake.testFailoverMutex.RLock()
+	defer fake.testFailoverMutex.RUnlock()
+	return len(fake.testFailoverArgsForCall)
+}
+
+func (fake *FakeProvider) TestFailoverCalls(stub func(context.Context, string, string) (string, error)) {
+	fake.testFailoverMutex.Lock()
+	defer fake.testFailoverMutex.Unlock()
+	fake.TestFailoverStub = stub
+}
+
+func (fake *FakeProvider) TestFailoverArgsForCall(i int) (context.Context, string, string) {
+	fake.testFailoverMutex.RLock()
+	defer fake.testFailoverMutex.RUnlock()
+	argsForCall := fake.testFailoverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvider) TestFailoverReturns(result1 string, result2 error) {
+	fake.testFailoverMutex.Lock()
+	defer fake.testFailoverMutex.Unlock()
+	fake.TestFailoverStub = nil
+	fake.testFailoverReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProvider) TestFailoverReturnsOnCall(i int, result1 string, result2 error) {
+	fake.testFailoverMutex.Lock()
+	defer fake.testFailoverMutex.Unlock()
+	fake.TestFailoverStub = nil
+	if fake.testFailoverReturnsOnCall == nil {
+		fake.testFailoverReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.testFailoverReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProvider) UpdateParamGroupParameters(arg1 context.Context, arg2 string, arg3 providers.UpdateParamGroupParameters) error {
 	fake.updateParamGroupParametersMutex.Lock()
 	ret, specificReturn := fake.updateParamGroupParametersReturnsOnCall[len(fake.updateParamGroupParametersArgsForCall)]
@@ -952,33 +1907,122 @@ func (fake *FakeProvider) UpdateReplicationGroupReturnsOnCall(i int, result1 err
 	}{result1}
 }
 
+func (fake *FakeProvider) UpgradeEngineVersion(arg1 context.Context, arg2 string, arg3 providers.UpgradeEngineVersionParameters) error {
+	fake.upgradeEngineVersionMutex.Lock()
+	ret, specificReturn := fake.upgradeEngineVersionReturnsOnCall[len(fake.upgradeEngineVersionArgsForCall)]
+	fake.upgradeEngineVersionArgsForCall = append(fake.upgradeEngineVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 providers.UpgradeEngineVersionParameters
+	}{arg1, arg2, arg3})
+	stub := fake.UpgradeEngineVersionStub
+	fakeReturns := fake.upgradeEngineVersionReturns
+	fake.recordInvocation("UpgradeEngineVersion", []interface{}{arg1, arg2, arg3})
+	fake.upgradeEngineVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProvider) UpgradeEngineVersionCallCount() int {
+	fake.upgradeEngineVersionMutex.RLock()
+	defer fake.upgradeEngineVersionMutex.RUnlock()
+	return len(fake.upgradeEngineVersionArgsForCall)
+}
+
+func (fake *FakeProvider) UpgradeEngineVersionCalls(stub func(context.Context, string, providers.UpgradeEngineVersionParameters) error) {
+	fake.upgradeEngineVersionMutex.Lock()
+	defer fake.upgradeEngineVersionMutex.Unlock()
+	fake.UpgradeEngineVersionStub = stub
+}
+
+func (fake *FakeProvider) UpgradeEngineVersionArgsForCall(i int) (context.Context, string, providers.UpgradeEngineVersionParameters) {
+	fake.upgradeEngineVersionMutex.RLock()
+	defer fake.upgradeEngineVersionMutex.RUnlock()
+	argsForCall := fake.upgradeEngineVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeProvider) UpgradeEngineVersionReturns(result1 error) {
+	fake.upgradeEngineVersionMutex.Lock()
+	defer fake.upgradeEngineVersionMutex.Unlock()
+	fake.UpgradeEngineVersionStub = nil
+	fake.upgradeEngineVersionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProvider) UpgradeEngineVersionReturnsOnCall(i int, result1 error) {
+	fake.upgradeEngineVersionMutex.Lock()
+	defer fake.upgradeEngineVersionMutex.Unlock()
+	fake.UpgradeEngineVersionStub = nil
+	if fake.upgradeEngineVersionReturnsOnCall == nil {
+		fake.upgradeEngineVersionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.upgradeEngineVersionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
 	fake.deleteCacheParameterGroupMutex.RLock()
 	defer fake.deleteCacheParameterGroupMutex.RUnlock()
+	fake.deleteUnusedCacheParameterGroupsMutex.RLock()
+	defer fake.deleteUnusedCacheParameterGroupsMutex.RUnlock()
+	fake.deleteUserGroupMutex.RLock()
+	defer fake.deleteUserGroupMutex.RUnlock()
 	fake.deprovisionMutex.RLock()
 	defer fake.deprovisionMutex.RUnlock()
+	fake.failoverTestStateMutex.RLock()
+	defer fake.failoverTestStateMutex.RUnlock()
+	fake.findDegradedInstancesMutex.RLock()
+	defer fake.findDegradedInstancesMutex.RUnlock()
+	fake.findInstanceMutex.RLock()
+	defer fake.findInstanceMutex.RUnlock()
+	fake.findSnapshotMutex.RLock()
+	defer fake.findSnapshotMutex.RUnlock()
 	fake.findSnapshotsMutex.RLock()
 	defer fake.findSnapshotsMutex.RUnlock()
+	fake.findSnapshotsWithTagsMutex.RLock()
+	defer fake.findSnapshotsWithTagsMutex.RUnlock()
 	fake.generateCredentialsMutex.RLock()
 	defer fake.generateCredentialsMutex.RUnlock()
 	fake.getInstanceParametersMutex.RLock()
 	defer fake.getInstanceParametersMutex.RUnlock()
 	fake.getInstanceTagsMutex.RLock()
 	defer fake.getInstanceTagsMutex.RUnlock()
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
 	fake.progressStateMutex.RLock()
 	defer fake.progressStateMutex.RUnlock()
 	fake.provisionMutex.RLock()
 	defer fake.provisionMutex.RUnlock()
+	fake.restoreHighAvailabilityMutex.RLock()
+	defer fake.restoreHighAvailabilityMutex.RUnlock()
 	fake.revokeCredentialsMutex.RLock()
 	defer fake.revokeCredentialsMutex.RUnlock()
+	fake.rotateAuthTokenMutex.RLock()
+	defer fake.rotateAuthTokenMutex.RUnlock()
 	fake.startFailoverTestMutex.RLock()
 	defer fake.startFailoverTestMutex.RUnlock()
+	fake.testFailoverMutex.RLock()
+	defer fake.testFailoverMutex.RUnlock()
 	fake.updateParamGroupParametersMutex.RLock()
 	defer fake.updateParamGroupParametersMutex.RUnlock()
 	fake.updateReplicationGroupMutex.RLock()
 	defer fake.updateReplicationGroupMutex.RUnlock()
+	fake.upgradeEngineVersionMutex.RLock()
+	defer fake.upgradeEngineVersionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -56,6 +56,21 @@ type FakeSecretsManager struct {
 		result1 *secretsmanager.GetSecretValueOutput
 		result2 error
 	}
+	PutSecretValueWithContextStub        func(context.Context, *secretsmanager.PutSecretValueInput, ...request.Option) (*secretsmanager.PutSecretValueOutput, error)
+	putSecretValueWithContextMutex       sync.RWMutex
+	putSecretValueWithContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 *secretsmanager.PutSecretValueInput
+		arg3 []request.Option
+	}
+	putSecretValueWithContextReturns struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}
+	putSecretValueWithContextReturnsOnCall map[int]struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -258,6 +273,72 @@ func (fake *FakeSecretsManager) GetSecretValueWithContextReturnsOnCall(i int, re
 	}{result1, result2}
 }
 
+func (fake *FakeSecretsManager) PutSecretValueWithContext(arg1 context.Context, arg2 *secretsmanager.PutSecretValueInput, arg3 ...request.Option) (*secretsmanager.PutSecretValueOutput, error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	ret, specificReturn := fake.putSecretValueWithContextReturnsOnCall[len(fake.putSecretValueWithContextArgsForCall)]
+	fake.putSecretValueWithContextArgsForCall = append(fake.putSecretValueWithContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 *secretsmanager.PutSecretValueInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.PutSecretValueWithContextStub
+	fakeReturns := fake.putSecretValueWithContextReturns
+	fake.recordInvocation("PutSecretValueWithContext", []interface{}{arg1, arg2, arg3})
+	fake.putSecretValueWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSecretsManager) PutSecretValueWithContextCallCount() int {
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
+	return len(fake.putSecretValueWithContextArgsForCall)
+}
+
+func (fake *FakeSecretsManager) PutSecretValueWithContextCalls(stub func(context.Context, *secretsmanager.PutSecretValueInput, ...request.Option) (*secretsmanager.PutSecretValueOutput, error)) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = stub
+}
+
+func (fake *FakeSecretsManager) PutSecretValueWithContextArgsForCall(i int) (context.Context, *secretsmanager.PutSecretValueInput, []request.Option) {
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
+	argsForCall := fake.putSecretValueWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSecretsManager) PutSecretValueWithContextReturns(result1 *secretsmanager.PutSecretValueOutput, result2 error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = nil
+	fake.putSecretValueWithContextReturns = struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSecretsManager) PutSecretValueWithContextReturnsOnCall(i int, result1 *secretsmanager.PutSecretValueOutput, result2 error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = nil
+	if fake.putSecretValueWithContextReturnsOnCall == nil {
+		fake.putSecretValueWithContextReturnsOnCall = make(map[int]struct {
+			result1 *secretsmanager.PutSecretValueOutput
+			result2 error
+		})
+	}
+	fake.putSecretValueWithContextReturnsOnCall[i] = struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSecretsManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -267,6 +348,8 @@ func (fake *FakeSecretsManager) Invocations() map[string][][]interface{} {
 	defer fake.deleteSecretWithContextMutex.RUnlock()
 	fake.getSecretValueWithContextMutex.RLock()
 	defer fake.getSecretValueWithContextMutex.RUnlock()
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -2,6 +2,9 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pivotal-cf/brokerapi/domain"
@@ -19,16 +22,69 @@ const (
 	CreateFailed ServiceState = "create-failed"
 	Snapshotting ServiceState = "snapshotting"
 	NonExisting  ServiceState = "non-existing"
+	// FailoverFailed is only returned by FailoverTestState
+	FailoverFailed ServiceState = "failover-failed"
+	// UpgradeFailed is the state of an engine upgrade which AWS has rolled back
+	UpgradeFailed ServiceState = "upgrade-failed"
+	// AuthTokenRotationFailed is the state of an auth token rotation which hasn't finished in time
+	AuthTokenRotationFailed ServiceState = "auth-token-rotation-failed"
 )
 
+// ErrInstanceNotFound is matched by the errors of Deprovision and GenerateCredentials if the instance doesn't exist
+// The resources left behind by the instance, like its auth token secret, are still deleted by Deprovision.
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// ErrNotSupported is matched by the errors of the providers for the features their instances don't have
+var ErrNotSupported = errors.New("not supported")
+
+type notSupportedError struct {
+	message string
+}
+
+func (e *notSupportedError) Error() string {
+	return e.message
+}
+
+func (e *notSupportedError) Is(target error) bool {
+	return target == ErrNotSupported
+}
+
+// ErrAuthTokenNotStored is returned by RotateAuthToken if the rotation has started but the new auth token couldn't be
+// stored, the rotation is then finished with the old auth token
+var ErrAuthTokenNotStored = errors.New("failed to store the new auth token")
+
+// NotSupportedError returns an error with the message which matches ErrNotSupported
+func NotSupportedError(message string) error {
+	return &notSupportedError{message: message}
+}
+
+type instanceNotFoundError struct {
+	message string
+}
+
+func (e *instanceNotFoundError) Error() string {
+	return e.message
+}
+
+func (e *instanceNotFoundError) Is(target error) bool {
+	return target == ErrInstanceNotFound
+}
+
+// InstanceNotFoundError returns an error with the message which matches ErrInstanceNotFound
+func InstanceNotFoundError(message string) error {
+	return &instanceNotFoundError{message: message}
+}
+
 type ProvisionParameters struct {
 	InstanceType               string
 	CacheParameterGroupFamily  string
 	SecurityGroupIds           []string
 	CacheSubnetGroupName       string
 	PreferredMaintenanceWindow string
+	DailyBackupWindow          string
 	ReplicasPerNodeGroup       int64
 	ShardCount                 int64
+	NumCacheNodes              int64
 	SnapshotRetentionLimit     int64
 	RestoreFromSnapshot        *string
 	AutomaticFailoverEnabled   bool
@@ -38,6 +94,14 @@ type ProvisionParameters struct {
 	Tags                       map[string]string
 	Engine                     string
 	EngineVersion              string
+	PerBindingUsers            bool
+	CacheUsageLimits           CacheUsageLimits
+}
+
+// CacheUsageLimits are the maximum data storage and compute usage of a serverless cache, zero means no limit
+type CacheUsageLimits struct {
+	DataStorageMaximumGB int64 `json:"data_storage_maximum_gb,omitempty"`
+	ECPUPerSecondMaximum int64 `json:"ecpu_per_second_maximum,omitempty"`
 }
 
 type DeprovisionParameters struct {
@@ -46,6 +110,43 @@ type DeprovisionParameters struct {
 
 type UpdateReplicationGroupParameters struct {
 	PreferredMaintenanceWindow string
+	DailyBackupWindow          string
+	CacheNodeType              string
+	EngineVersion              string
+	ReplicasPerNodeGroup       *int64
+	ShardCount                 *int64
+	SnapshotRetentionLimit     *int64
+	CacheUsageLimits           *CacheUsageLimits
+	Tags                       map[string]string
+}
+
+type UpgradeEngineVersionParameters struct {
+	// Engine is the engine to migrate to, the current engine is kept if it's empty
+	Engine                    string
+	EngineVersion             string
+	CacheParameterGroupFamily string
+	Tags                      map[string]string
+}
+
+// CompareEngineVersions compares two engine versions part by part, returning -1, 0 or 1
+// Parts which aren't numbers, like the "x" of "6.x", and missing parts are equal to any other part.
+func CompareEngineVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aPart, aErr := strconv.Atoi(aParts[i])
+		bPart, bErr := strconv.Atoi(bParts[i])
+		if aErr != nil || bErr != nil {
+			continue
+		}
+		if aPart < bPart {
+			return -1
+		}
+		if aPart > bPart {
+			return 1
+		}
+	}
+	return 0
 }
 
 type UpdateParamGroupParameters struct {
@@ -53,9 +154,21 @@ type UpdateParamGroupParameters struct {
 }
 
 type SnapshotInfo struct {
-	Name       string
-	CreateTime time.Time
-	Tags       map[string]string
+	Name       string            `json:"name"`
+	CreateTime time.Time         `json:"create_time"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	Status     string            `json:"status,omitempty"`
+}
+
+// HasTags returns true if tags has all the given tags with the same values
+func HasTags(tags map[string]string, want map[string]string) bool {
+	for k, v := range want {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
 }
 
 type CacheParameter struct {
@@ -63,13 +176,47 @@ type CacheParameter struct {
 	ParameterValue string `json:"parameter_value"`
 }
 type InstanceParameters struct {
-	PreferredMaintenanceWindow string           `json:"preferred_maintenance_window"`
-	DailyBackupWindow          string           `json:"daily_backup_window"`
-	MaxMemoryPolicy            string           `json:"maxmemory_policy"`
-	CacheParameters            []CacheParameter `json:"cache_parameters"`
-	ActiveNodes                []string         `json:"active_nodes"`
-	PassiveNodes               []string         `json:"passive_nodes"`
-	AutoFailover               bool             `json:"auto_failover"`
+	PreferredMaintenanceWindow string            `json:"preferred_maintenance_window"`
+	DailyBackupWindow          string            `json:"daily_backup_window"`
+	MaxMemoryPolicy            string            `json:"maxmemory_policy"`
+	CacheParameters            []CacheParameter  `json:"cache_parameters"`
+	ActiveNodes                []string          `json:"active_nodes"`
+	PassiveNodes               []string          `json:"passive_nodes"`
+	AutoFailover               bool              `json:"auto_failover"`
+	CacheNodeType              string            `json:"cache_node_type"`
+	Engine                     string            `json:"engine"`
+	EngineVersion              string            `json:"engine_version"`
+	PendingEngineVersion       string            `json:"pending_engine_version,omitempty"`
+	ReplicasPerNodeGroup       int64             `json:"replicas_per_node_group"`
+	ShardCount                 int64             `json:"shard_count"`
+	Snapshots                  []SnapshotInfo    `json:"snapshots,omitempty"`
+	CacheUsageLimits           *CacheUsageLimits `json:"cache_usage_limits,omitempty"`
+}
+
+// FailoverTestStartedTag is the tag set to the start time of an emulated test failover, which disables automatic
+// failover and Multi-AZ until it finishes. It's removed once the failover has finished or timed out.
+const FailoverTestStartedTag = "failover-test-started-at"
+
+// OperationInProgressTag is set to the action of a broker operation which has more steps to run once the instance is
+// available again, like the replica count change of a plan change, together with its start time (see
+// OperationTagValue). It's set to an empty value once it has finished.
+const OperationInProgressTag = "operation-in-progress"
+
+// DegradedInstance is an instance with automatic failover or Multi-AZ disabled
+type DegradedInstance struct {
+	InstanceID string
+	Tags       map[string]string
+}
+
+// ExistingInstance is the state and the tags of an instance found by FindInstance
+// Operation is the multi-step operation in progress, like a test failover, which keeps going through several states.
+// It's empty if there is none. OperationStartedAt is when a broker operation recorded by OperationInProgressTag
+// started, it's zero for the other operations.
+type ExistingInstance struct {
+	State              ServiceState
+	Tags               map[string]string
+	Operation          string
+	OperationStartedAt time.Time
 }
 
 type InstanceDetails struct {
@@ -91,19 +238,56 @@ type Provider interface {
 	ProgressState(ctx context.Context, instanceID string, operation string, primaryNode string) (ServiceState, string, error)
 	GetInstanceParameters(ctx context.Context, instanceID string) (InstanceParameters, error)
 	GetInstanceTags(ctx context.Context, instanceID string) (map[string]string, error)
+	FindInstance(ctx context.Context, instanceID string) (*ExistingInstance, error)
 	GenerateCredentials(ctx context.Context, instanceID, bindingID string) (*Credentials, error)
 	RevokeCredentials(ctx context.Context, instanceID, bindingID string) error
 	DeleteCacheParameterGroup(ctx context.Context, instanceID string) error
+	DeleteUnusedCacheParameterGroups(ctx context.Context, instanceID string) error
+	DeleteUserGroup(ctx context.Context, instanceID string) error
 	FindSnapshots(ctx context.Context, instanceID string) ([]SnapshotInfo, error)
+	FindSnapshot(ctx context.Context, snapshotName string) (*SnapshotInfo, error)
+	FindSnapshotsWithTags(ctx context.Context, tags map[string]string) ([]SnapshotInfo, error)
+	ListSnapshots(ctx context.Context, instanceID string) ([]SnapshotInfo, error)
+	CreateSnapshot(ctx context.Context, instanceID string, snapshotName string) error
 	StartFailoverTest(ctx context.Context, instanceID string) (string, error)
+	TestFailover(ctx context.Context, instanceID string, nodeGroupID string) (string, error)
+	FailoverTestState(ctx context.Context, instanceID string, nodeGroupID string, startTime time.Time) (ServiceState, string, error)
+	RestoreHighAvailability(ctx context.Context, instanceID string) (bool, error)
+	FindDegradedInstances(ctx context.Context, brokerName string) ([]DegradedInstance, error)
+	RotateAuthToken(ctx context.Context, instanceID string) error
+	UpgradeEngineVersion(ctx context.Context, instanceID string, params UpgradeEngineVersionParameters) error
 }
 
-// Credentials are the connection parameters for Redis clients
+// Credentials are the connection parameters for Redis and Memcached clients
+// The Password is empty for serverless caches, which only accept TLS connections from within the VPC.
+// Nodes is only set for Memcached, where clients connect to the nodes directly.
 type Credentials struct {
-	Host       string `json:"host"`
-	Port       int64  `json:"port"`
-	Name       string `json:"name"`
-	Password   string `json:"password"`
-	URI        string `json:"uri"`
-	TLSEnabled bool   `json:"tls_enabled"`
+	Host       string   `json:"host"`
+	Port       int64    `json:"port"`
+	Name       string   `json:"name"`
+	Username   string   `json:"username,omitempty"`
+	Password   string   `json:"password"`
+	URI        string   `json:"uri"`
+	TLSEnabled bool     `json:"tls_enabled"`
+	Nodes      []string `json:"nodes,omitempty"`
+}
+
+// OperationTagValue returns the value of a tag marking an operation in progress, together with the time it started,
+// so the tag of an operation which was never finished can be told apart from one which is still running
+func OperationTagValue(operation string, startedAt time.Time) string {
+	return operation + "@" + startedAt.UTC().Format(time.RFC3339)
+}
+
+// ParseOperationTagValue returns the operation and the start time of a tag value set by OperationTagValue
+// It returns false if the value doesn't have a valid start time.
+func ParseOperationTagValue(value string) (string, time.Time, bool) {
+	i := strings.LastIndex(value, "@")
+	if i < 0 {
+		return value, time.Time{}, false
+	}
+	startedAt, err := time.Parse(time.RFC3339, value[i+1:])
+	if err != nil {
+		return value, time.Time{}, false
+	}
+	return value[:i], startedAt, true
 }
@@ -0,0 +1,41 @@
+package providers_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+)
+
+var _ = Describe("Operation tags", func() {
+	It("records the operation and its start time", func() {
+		startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		value := providers.OperationTagValue("changing-plan", startedAt)
+		Expect(value).To(Equal("changing-plan@2026-01-02T03:04:05Z"))
+
+		operation, parsedStartedAt, ok := providers.ParseOperationTagValue(value)
+		Expect(ok).To(BeTrue())
+		Expect(operation).To(Equal("changing-plan"))
+		Expect(parsedStartedAt).To(Equal(startedAt))
+	})
+
+	It("returns false for values without a start time", func() {
+		operation, _, ok := providers.ParseOperationTagValue("changing-plan")
+		Expect(ok).To(BeFalse())
+		Expect(operation).To(Equal("changing-plan"))
+
+		_, _, ok = providers.ParseOperationTagValue("changing-plan@yesterday")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("HasTags", func() {
+	It("returns true only if all the tags have the same values", func() {
+		tags := map[string]string{"space-id": "space", "plan-id": "plan"}
+		Expect(providers.HasTags(tags, map[string]string{"space-id": "space"})).To(BeTrue())
+		Expect(providers.HasTags(tags, map[string]string{"space-id": "space", "plan-id": "other-plan"})).To(BeFalse())
+		Expect(providers.HasTags(tags, map[string]string{"org-id": ""})).To(BeFalse())
+	})
+})
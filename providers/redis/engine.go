@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Engines supported by replication groups
+const (
+	EngineRedis  = "redis"
+	EngineValkey = "valkey"
+)
+
+// DefaultCacheParameterGroupFamily returns the parameter group family of an engine version
+// It's used for the plans which don't set a family explicitly.
+func DefaultCacheParameterGroupFamily(engine, engineVersion string) string {
+	if engine == "" {
+		engine = EngineRedis
+	}
+	versionParts := strings.Split(engineVersion, ".")
+	major, _ := strconv.Atoi(versionParts[0])
+
+	// Valkey and Redis 7+ families only contain the major version, Redis 6 is "redis6.x", older versions have the minor
+	// version as well
+	switch {
+	case engine != EngineRedis || major >= 7:
+		return engine + versionParts[0]
+	case major == 6:
+		return "redis6.x"
+	case len(versionParts) > 1:
+		return engine + versionParts[0] + "." + versionParts[1]
+	default:
+		return engine + versionParts[0] + ".0"
+	}
+}
+
+// withEngine sets the engine of a ModifyReplicationGroup request
+// The Engine field is missing from ModifyReplicationGroupInput in aws-sdk-go v1, so it's added to the query parameters
+// once the request body is built, before the request is signed.
+func withEngine(engine string) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			if r.Error != nil || r.Body == nil {
+				return
+			}
+			if _, err := r.Body.Seek(0, io.SeekStart); err != nil {
+				r.Error = err
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				r.Error = err
+				return
+			}
+			values.Set("Engine", engine)
+			r.SetBufferBody([]byte(values.Encode()))
+		})
+	}
+}
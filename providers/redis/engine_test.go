@@ -0,0 +1,50 @@
+package redis_test
+
+import (
+	"io"
+	"net/url"
+
+	. "github.com/alphagov/paas-elasticache-broker/providers/redis"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Engines", func() {
+	DescribeTable("default cache parameter group families",
+		func(engine, engineVersion, expectedFamily string) {
+			Expect(DefaultCacheParameterGroupFamily(engine, engineVersion)).To(Equal(expectedFamily))
+		},
+		Entry("valkey 7", "valkey", "7.2", "valkey7"),
+		Entry("valkey 8", "valkey", "8.0", "valkey8"),
+		Entry("redis 7", "redis", "7.1", "redis7"),
+		Entry("redis 6", "redis", "6.2", "redis6.x"),
+		Entry("redis 5", "redis", "5.0.6", "redis5.0"),
+		Entry("no engine", "", "4.0.10", "redis4.0"),
+	)
+
+	It("adds the engine to the ModifyReplicationGroup request", func() {
+		sess := session.Must(session.NewSession(aws.NewConfig().
+			WithRegion("eu-west-1").
+			WithCredentials(credentials.NewStaticCredentials("id", "secret", ""))))
+		req, _ := elasticache.New(sess).ModifyReplicationGroupRequest(&elasticache.ModifyReplicationGroupInput{
+			ReplicationGroupId: aws.String("cf-qwkec4pxhft6q"),
+			EngineVersion:      aws.String("7.2"),
+		})
+		req.ApplyOptions(ExportWithEngine("valkey"))
+
+		Expect(req.Build()).To(Succeed())
+
+		body, err := io.ReadAll(req.Body)
+		Expect(err).ToNot(HaveOccurred())
+		values, err := url.ParseQuery(string(body))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(values.Get("Action")).To(Equal("ModifyReplicationGroup"))
+		Expect(values.Get("Engine")).To(Equal("valkey"))
+		Expect(values.Get("EngineVersion")).To(Equal("7.2"))
+		Expect(values.Get("ReplicationGroupId")).To(Equal("cf-qwkec4pxhft6q"))
+	})
+})
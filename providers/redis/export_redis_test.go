@@ -1,3 +1,5 @@
 package redis
 
 var ExportReplicationGroupARN = (*RedisProvider).replicationGroupARN
+
+var ExportWithEngine = withEngine
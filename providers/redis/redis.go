@@ -2,12 +2,13 @@ package redis
 
 import (
 	"context"
-	"encoding/base32"
 	"errors"
 	"fmt"
-	"hash/fnv"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-elasticache-broker/providers"
@@ -19,7 +20,21 @@ import (
 
 var _ providers.Provider = &RedisProvider{}
 
-const PasswordLength = 32
+// The daily backup window of instances which don't set one
+const defaultSnapshotWindow = "02:00-05:00"
+
+// The tag marks replication groups where the old auth token still has to be removed after a rotation
+// It's set to "rotating" with the start time of the rotation, and to "applying" once the ROTATE modification has been
+// seen in progress.
+const (
+	authTokenRotationTag      = "auth-token-rotation"
+	authTokenRotationRotating = "rotating"
+	authTokenRotationApplying = "applying"
+)
+
+// AuthTokenRotationTimeout is how long an auth token rotation can take before it's reported as failed and its tag is
+// removed, so a rotation which AWS never applies doesn't block the instance
+const AuthTokenRotationTimeout = time.Hour
 
 // RedisProvider is the Redis broker provider
 type RedisProvider struct {
@@ -31,9 +46,12 @@ type RedisProvider struct {
 	logger             lager.Logger
 	kmsKeyID           string
 	secretsManagerPath string
+	users              *providers.Users
+	upgradeTargets     []providers.UpgradeEngineVersionParameters
 }
 
 // NewProvider creates a new Redis provider
+// The upgrade targets are the engine versions instances can be upgraded to, which have their own parameter groups.
 func NewProvider(
 	elastiCache providers.ElastiCache,
 	secretsManager providers.SecretsManager,
@@ -42,6 +60,7 @@ func NewProvider(
 	logger lager.Logger,
 	kmsKeyID string,
 	secretsManagerPath string,
+	upgradeTargets []providers.UpgradeEngineVersionParameters,
 ) *RedisProvider {
 	return &RedisProvider{
 		elastiCache:        elastiCache,
@@ -52,6 +71,8 @@ func NewProvider(
 		logger:             logger,
 		kmsKeyID:           kmsKeyID,
 		secretsManagerPath: strings.TrimRight(secretsManagerPath, "/"),
+		users:              providers.NewUsers(elastiCache, secretsManager, logger, kmsKeyID, secretsManagerPath),
+		upgradeTargets:     upgradeTargets,
 	}
 }
 
@@ -76,39 +97,173 @@ func GetPrimaryAndReplicaCacheClusterIds(replicationGroup *elasticache.Replicati
 	return primaryNode, replicaNode, nil
 }
 
+func isNodeGroupMember(replicationGroup *elasticache.ReplicationGroup, cacheClusterID string) bool {
+	for _, nodeGroup := range replicationGroup.NodeGroups {
+		for _, nodeGroupMember := range nodeGroup.NodeGroupMembers {
+			if aws.StringValue(nodeGroupMember.CacheClusterId) == cacheClusterID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getReplicasPerNodeGroup returns the number of replicas in the first node group
+// In cluster mode every node group has the same number of replicas.
+func getReplicasPerNodeGroup(replicationGroup *elasticache.ReplicationGroup) int64 {
+	if len(replicationGroup.NodeGroups) == 0 || len(replicationGroup.NodeGroups[0].NodeGroupMembers) == 0 {
+		return 0
+	}
+	return int64(len(replicationGroup.NodeGroups[0].NodeGroupMembers) - 1)
+}
+
 func (p *RedisProvider) createCacheParameterGroup(ctx context.Context, replicationGroupID string, params providers.ProvisionParameters) error {
 	_, err := p.elastiCache.CreateCacheParameterGroupWithContext(ctx, &elasticache.CreateCacheParameterGroupInput{
 		CacheParameterGroupFamily: aws.String(params.CacheParameterGroupFamily),
 		CacheParameterGroupName:   aws.String(replicationGroupID),
 		Description:               aws.String("Created by Cloud Foundry"),
 	})
+	if providers.IsAWSError(err, elasticache.ErrCodeCacheParameterGroupAlreadyExistsFault) {
+		// It's left over from an earlier attempt to provision the instance, its parameters are set again
+		p.logger.Info("cache-parameter-group-exists", lager.Data{"cache-parameter-group-name": replicationGroupID})
+		return nil
+	}
+	return err
+}
+
+// setCacheParameters sets the parameters of a new cache parameter group, cluster mode is disabled unless it's set
+func (p *RedisProvider) setCacheParameters(ctx context.Context, replicationGroupID string, parameters map[string]string) error {
+	params := map[string]string{"cluster-enabled": "no"}
+	for name, value := range parameters {
+		params[name] = value
+	}
+	return p.modifyCacheParameterGroup(ctx, replicationGroupID, params)
+}
+
+func (p *RedisProvider) modifyReplicationGroup(ctx context.Context, replicationGroupID string, params providers.UpdateReplicationGroupParameters) error {
+	input := &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+	}
+	modified := false
+
+	if len(params.PreferredMaintenanceWindow) > 0 {
+		input.SetPreferredMaintenanceWindow(params.PreferredMaintenanceWindow)
+		modified = true
+	}
+
+	if len(params.DailyBackupWindow) > 0 {
+		input.SetSnapshotWindow(params.DailyBackupWindow)
+		modified = true
+	}
+
+	if params.SnapshotRetentionLimit != nil {
+		input.SetSnapshotRetentionLimit(*params.SnapshotRetentionLimit)
+		modified = true
+	}
+
+	// Node type and engine version changes would otherwise wait for the next maintenance window
+	if len(params.CacheNodeType) > 0 {
+		input.SetCacheNodeType(params.CacheNodeType)
+		input.SetApplyImmediately(true)
+		modified = true
+	}
+	if len(params.EngineVersion) > 0 {
+		input.SetEngineVersion(params.EngineVersion)
+		input.SetApplyImmediately(true)
+		modified = true
+	}
+
+	if !modified {
+		return nil
+	}
+
+	_, err := p.elastiCache.ModifyReplicationGroupWithContext(ctx, input)
+	return err
+}
+
+func (p *RedisProvider) modifyReplicaCount(ctx context.Context, replicationGroupID string, replicasPerNodeGroup int64) error {
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
 	if err != nil {
 		return err
 	}
 
-	if params.Parameters == nil {
-		params.Parameters = map[string]string{}
+	currentReplicas := getReplicasPerNodeGroup(replicationGroup)
+	if replicasPerNodeGroup > currentReplicas {
+		_, err = p.elastiCache.IncreaseReplicaCountWithContext(ctx, &elasticache.IncreaseReplicaCountInput{
+			ReplicationGroupId: aws.String(replicationGroupID),
+			NewReplicaCount:    aws.Int64(replicasPerNodeGroup),
+			ApplyImmediately:   aws.Bool(true),
+		})
+	} else if replicasPerNodeGroup < currentReplicas {
+		_, err = p.elastiCache.DecreaseReplicaCountWithContext(ctx, &elasticache.DecreaseReplicaCountInput{
+			ReplicationGroupId: aws.String(replicationGroupID),
+			NewReplicaCount:    aws.Int64(replicasPerNodeGroup),
+			ApplyImmediately:   aws.Bool(true),
+		})
 	}
-	if _, set := params.Parameters["cluster-enabled"]; !set {
-		params.Parameters["cluster-enabled"] = "no"
+	return err
+}
+
+// modifyShardCount adds or removes shards of a cluster mode replication group
+// ElastiCache rebalances the slots across the new shards, when scaling in the shards with the highest IDs are removed.
+func (p *RedisProvider) modifyShardCount(ctx context.Context, replicationGroupID string, shardCount int64) error {
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return err
 	}
 
-	return p.modifyCacheParameterGroup(ctx, replicationGroupID, params.Parameters)
+	currentShardCount := int64(len(replicationGroup.NodeGroups))
+	if shardCount == currentShardCount {
+		return nil
+	}
+
+	input := &elasticache.ModifyReplicationGroupShardConfigurationInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+		NodeGroupCount:     aws.Int64(shardCount),
+		ApplyImmediately:   aws.Bool(true),
+	}
+	if shardCount < currentShardCount {
+		nodeGroupIDs := []string{}
+		for _, nodeGroup := range replicationGroup.NodeGroups {
+			nodeGroupIDs = append(nodeGroupIDs, aws.StringValue(nodeGroup.NodeGroupId))
+		}
+		sort.Strings(nodeGroupIDs)
+		input.SetNodeGroupsToRemove(aws.StringSlice(nodeGroupIDs[shardCount:]))
+	}
+
+	_, err = p.elastiCache.ModifyReplicationGroupShardConfigurationWithContext(ctx, input)
+	return err
 }
 
-func (p *RedisProvider) modifyReplicationGroup(ctx context.Context, replicationGroupID string, preferredMaintenanceWindow string) error {
-	if len(preferredMaintenanceWindow) == 0 {
+func (p *RedisProvider) addTags(ctx context.Context, replicationGroupID string, tags map[string]string) error {
+	if len(tags) == 0 {
 		return nil
 	}
 
-	_, err := p.elastiCache.ModifyReplicationGroupWithContext(ctx, &elasticache.ModifyReplicationGroupInput{
-		PreferredMaintenanceWindow: aws.String(preferredMaintenanceWindow),
-		ReplicationGroupId:         aws.String(replicationGroupID),
+	input := &elasticache.AddTagsToResourceInput{
+		ResourceName: aws.String(p.replicationGroupARN(replicationGroupID)),
+		Tags:         []*elasticache.Tag{},
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	_, err := p.elastiCache.AddTagsToResourceWithContext(ctx, input)
+	return err
+}
+
+func (p *RedisProvider) removeTags(ctx context.Context, replicationGroupID string, tagKeys ...string) error {
+	_, err := p.elastiCache.RemoveTagsFromResourceWithContext(ctx, &elasticache.RemoveTagsFromResourceInput{
+		ResourceName: aws.String(p.replicationGroupARN(replicationGroupID)),
+		TagKeys:      aws.StringSlice(tagKeys),
 	})
 	return err
 }
 
-func (p *RedisProvider) modifyCacheParameterGroup(ctx context.Context, replicationGroupID string, params map[string]string) error {
+func (p *RedisProvider) modifyCacheParameterGroup(ctx context.Context, cacheParameterGroupName string, params map[string]string) error {
 	if len(params) == 0 {
 		return nil
 	}
@@ -123,58 +278,148 @@ func (p *RedisProvider) modifyCacheParameterGroup(ctx context.Context, replicati
 
 	_, err := p.elastiCache.ModifyCacheParameterGroupWithContext(ctx, &elasticache.ModifyCacheParameterGroupInput{
 		ParameterNameValues:     pgParams,
-		CacheParameterGroupName: aws.String(replicationGroupID),
+		CacheParameterGroupName: aws.String(cacheParameterGroupName),
 	})
 	return err
 }
 
+// DeleteCacheParameterGroup deletes the parameter groups of an instance
+// Instances with an upgraded engine version have parameter groups named after their family as well.
 func (p *RedisProvider) DeleteCacheParameterGroup(ctx context.Context, instanceID string) error {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
-	_, err := p.elastiCache.DeleteCacheParameterGroupWithContext(ctx, &elasticache.DeleteCacheParameterGroupInput{
-		CacheParameterGroupName: aws.String(replicationGroupID),
-	})
-	if err != nil {
-		if awsErr, ok := err.(awserr.Error); ok {
-			if awsErr.Code() == elasticache.ErrCodeCacheParameterGroupNotFoundFault {
-				return nil
-			}
+
+	for _, groupName := range p.cacheParameterGroupNames(replicationGroupID) {
+		err := p.deleteCacheParameterGroup(ctx, groupName)
+		if err != nil {
+			return err
 		}
 	}
-	return err
+	return nil
 }
 
+// UpdateReplicationGroup modifies the replication group and its tags
+//
+// ElastiCache doesn't allow a replica count change while the node type or the engine version is being modified,
+// so callers should not request both at the same time.
 func (p *RedisProvider) UpdateReplicationGroup(ctx context.Context, instanceID string, params providers.UpdateReplicationGroupParameters) error {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
-	return p.modifyReplicationGroup(ctx, replicationGroupID, params.PreferredMaintenanceWindow)
+
+	err := p.modifyReplicationGroup(ctx, replicationGroupID, params)
+	if err != nil {
+		return err
+	}
+
+	if params.ReplicasPerNodeGroup != nil {
+		err = p.modifyReplicaCount(ctx, replicationGroupID, *params.ReplicasPerNodeGroup)
+		if err != nil {
+			return err
+		}
+	}
+
+	if params.ShardCount != nil {
+		err = p.modifyShardCount(ctx, replicationGroupID, *params.ShardCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.addTags(ctx, replicationGroupID, params.Tags)
 }
 
 func (p *RedisProvider) UpdateParamGroupParameters(ctx context.Context, instanceID string, params providers.UpdateParamGroupParameters) error {
+	if len(params.Parameters) == 0 {
+		return nil
+	}
+
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
-	return p.modifyCacheParameterGroup(ctx, replicationGroupID, params.Parameters)
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return err
+	}
+	cacheParameterGroupName, err := p.getCacheParameterGroupName(ctx, replicationGroup)
+	if err != nil {
+		return err
+	}
+	return p.modifyCacheParameterGroup(ctx, cacheParameterGroupName, params.Parameters)
 }
 
 // Provision creates a replication group and a cache parameter group
 func (p *RedisProvider) Provision(ctx context.Context, instanceID string, params providers.ProvisionParameters) error {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
 
-	err := p.createCacheParameterGroup(ctx, replicationGroupID, params)
-	if err != nil {
-		return err
+	if params.Engine == "" {
+		params.Engine = EngineRedis
+	}
+	if params.CacheParameterGroupFamily == "" {
+		params.CacheParameterGroupFamily = DefaultCacheParameterGroupFamily(params.Engine, params.EngineVersion)
 	}
 
 	cacheParameterGroupName := replicationGroupID
-
 	authToken := GenerateAuthToken()
-	err = p.CreateAuthTokenSecret(ctx, instanceID, authToken)
-	if err != nil {
-		return fmt.Errorf("failed to create auth token: %s", err.Error())
+
+	steps := []providers.Step{
+		{
+			Name: "cache parameter group",
+			Do: func(ctx context.Context) error {
+				return p.createCacheParameterGroup(ctx, replicationGroupID, params)
+			},
+			Undo: func(ctx context.Context) error {
+				return p.DeleteCacheParameterGroup(ctx, instanceID)
+			},
+		},
+		{
+			Name: "cache parameters",
+			Do: func(ctx context.Context) error {
+				return p.setCacheParameters(ctx, replicationGroupID, params.Parameters)
+			},
+		},
+		{
+			Name: "auth token secret",
+			Do: func(ctx context.Context) error {
+				err := p.CreateAuthTokenSecret(ctx, instanceID, authToken)
+				if err != nil {
+					return fmt.Errorf("failed to create auth token: %s", err.Error())
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				return p.deleteUnusedAuthTokenSecret(ctx, instanceID)
+			},
+		},
+	}
+
+	if params.PerBindingUsers {
+		steps = append(steps, providers.Step{
+			Name: "default user",
+			Do: func(ctx context.Context) error {
+				err := p.users.CreateDefaultUser(ctx, replicationGroupID, instanceID, params.Engine, authToken)
+				if err != nil {
+					return fmt.Errorf("failed to create default user: %s", err.Error())
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				return p.users.DeleteDefaultUser(ctx, replicationGroupID)
+			},
+		}, providers.Step{
+			Name: "user group",
+			Do: func(ctx context.Context) error {
+				err := p.users.CreateUserGroup(ctx, replicationGroupID, instanceID, params.Engine)
+				if err != nil {
+					return fmt.Errorf("failed to create user group: %s", err.Error())
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				return p.users.DeleteUserGroup(ctx, replicationGroupID)
+			},
+		})
 	}
 
 	input := &elasticache.CreateReplicationGroupInput{
 		Tags:                        []*elasticache.Tag{},
 		AtRestEncryptionEnabled:     aws.Bool(true),
 		TransitEncryptionEnabled:    aws.Bool(true),
-		AuthToken:                   aws.String(authToken),
 		AutomaticFailoverEnabled:    aws.Bool(params.AutomaticFailoverEnabled),
 		MultiAZEnabled:              aws.Bool(params.MultiAZEnabled),
 		CacheNodeType:               aws.String(params.InstanceType),
@@ -191,9 +436,20 @@ func (p *RedisProvider) Provision(ctx context.Context, instanceID string, params
 		SnapshotName:                params.RestoreFromSnapshot,
 	}
 
+	// An instance either uses a single auth token or the users of its user group
+	if params.PerBindingUsers {
+		input.SetUserGroupIds([]*string{aws.String(replicationGroupID)})
+	} else {
+		input.SetAuthToken(authToken)
+	}
+
 	if params.SnapshotRetentionLimit > 0 {
 		input.SetSnapshotRetentionLimit(params.SnapshotRetentionLimit)
-		input.SetSnapshotWindow("02:00-05:00")
+		snapshotWindow := params.DailyBackupWindow
+		if snapshotWindow == "" {
+			snapshotWindow = defaultSnapshotWindow
+		}
+		input.SetSnapshotWindow(snapshotWindow)
 	}
 
 	for tagName, tagValue := range params.Tags {
@@ -203,32 +459,79 @@ func (p *RedisProvider) Provision(ctx context.Context, instanceID string, params
 		})
 	}
 
-	_, createErr := p.elastiCache.CreateReplicationGroupWithContext(ctx, input)
-	if createErr != nil {
-		err := p.DeleteCacheParameterGroup(ctx, instanceID)
-		if err != nil {
-			p.logger.Error("delete-cache-parameter-group", err)
-		}
-		err = p.DeleteAuthTokenSecret(ctx, instanceID, 7)
-		if err != nil {
-			p.logger.Error("delete-auth-token-secret", err)
-		}
+	steps = append(steps, providers.Step{
+		Name: "replication group",
+		Do: func(ctx context.Context) error {
+			_, err := p.elastiCache.CreateReplicationGroupWithContext(ctx, input)
+			if providers.IsAWSError(err, elasticache.ErrCodeReplicationGroupAlreadyExistsFault) {
+				// A retried or concurrent request has created it already, and it uses what the earlier steps created,
+				// so they must not be rolled back
+				p.logger.Info("replication-group-exists", lager.Data{"instance-id": instanceID})
+				return nil
+			}
+			return err
+		},
+	})
+
+	return providers.RunSteps(ctx, p.logger.Session("provision", lager.Data{"instance-id": instanceID}), steps)
+}
+
+// deprovisionDeletedInstance deletes the auth token secret of an instance whose replication group is already gone
+func (p *RedisProvider) deprovisionDeletedInstance(ctx context.Context, instanceID string) error {
+	p.logger.Info("replication-group-not-found", lager.Data{"instance-id": instanceID})
+	err := p.DeleteAuthTokenSecret(ctx, instanceID, 30)
+	if err != nil {
+		return err
 	}
-	return createErr
+	return providers.ErrInstanceNotFound
 }
 
 // Deprovision deletes the replication group
+//
+// If a final snapshot is requested, it gets the tags of the replication group, so it can be found by FindSnapshots.
+// Deprovision fails if the final snapshot can't be tagged, and tagging it is retried when the deprovision is retried
+// while the replication group is being deleted.
 func (p *RedisProvider) Deprovision(ctx context.Context, instanceID string, params providers.DeprovisionParameters) error {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
 
 	input := &elasticache.DeleteReplicationGroupInput{
 		ReplicationGroupId: aws.String(replicationGroupID),
 	}
+	var tags map[string]string
 	if params.FinalSnapshotIdentifier != "" {
+		var err error
+		tags, err = p.GetInstanceTags(ctx, instanceID)
+		if providers.IsAWSError(err, elasticache.ErrCodeReplicationGroupNotFoundFault) {
+			return p.deprovisionDeletedInstance(ctx, instanceID)
+		}
+		if err != nil {
+			return err
+		}
 		input.SetFinalSnapshotIdentifier(params.FinalSnapshotIdentifier)
 	}
 
 	_, err := p.elastiCache.DeleteReplicationGroupWithContext(ctx, input)
+	if providers.IsAWSError(err, elasticache.ErrCodeReplicationGroupNotFoundFault) {
+		return p.deprovisionDeletedInstance(ctx, instanceID)
+	}
+	if providers.IsAWSError(err, elasticache.ErrCodeInvalidReplicationGroupStateFault) {
+		replicationGroup, describeErr := p.describeReplicationGroup(ctx, replicationGroupID)
+		if describeErr == nil && aws.StringValue(replicationGroup.Status) == "deleting" {
+			// A retried request, the replication group is already being deleted
+			p.logger.Info("replication-group-already-deleting", lager.Data{"instance-id": instanceID})
+			err = p.tagFinalSnapshot(ctx, instanceID, params.FinalSnapshotIdentifier, tags)
+			if err != nil {
+				return err
+			}
+			return p.DeleteAuthTokenSecret(ctx, instanceID, 30)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	err = p.tagFinalSnapshot(ctx, instanceID, params.FinalSnapshotIdentifier, tags)
 	if err != nil {
 		return err
 	}
@@ -262,12 +565,17 @@ func (p *RedisProvider) getMessage(ctx context.Context, replicationGroup *elasti
 		cacheClusterId := replicationGroup.MemberClusters[0]
 		if cacheCluster, err := p.describeCacheCluster(ctx, *cacheClusterId); err == nil {
 
+			if cacheCluster.Engine != nil {
+				msgs = append(msgs, fmt.Sprintf(tmpl, "engine", *cacheCluster.Engine))
+			}
+
 			if cacheCluster.EngineVersion != nil {
 				msgs = append(msgs, fmt.Sprintf(tmpl, "engine version", *cacheCluster.EngineVersion))
 			}
 
 			if replicationGroup.ReplicationGroupId != nil {
-				if params, err := p.describeCacheParameters(ctx, *replicationGroup.ReplicationGroupId); err == nil {
+				groupName := cacheParameterGroupName(cacheCluster, *replicationGroup.ReplicationGroupId)
+				if params, err := p.describeCacheParameters(ctx, groupName); err == nil {
 					for _, param := range params {
 						if param.ParameterName == nil {
 							continue
@@ -296,6 +604,15 @@ func (p *RedisProvider) getMessage(ctx context.Context, replicationGroup *elasti
 		msgs = append(msgs, fmt.Sprintf(tmpl, "automatic failover", strings.TrimSpace(*replicationGroup.AutomaticFailover)))
 	}
 
+	if len(replicationGroup.NodeGroups) > 0 {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "shards", fmt.Sprint(len(replicationGroup.NodeGroups))))
+	}
+
+	if pending := replicationGroup.PendingModifiedValues; pending != nil && pending.Resharding != nil &&
+		pending.Resharding.SlotMigration != nil && pending.Resharding.SlotMigration.ProgressPercentage != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "resharding", fmt.Sprintf("%.1f%% of slots migrated", *pending.Resharding.SlotMigration.ProgressPercentage)))
+	}
+
 	return strings.Join(msgs, "\n           ")
 }
 
@@ -327,6 +644,11 @@ func (p *RedisProvider) ProgressState(
 
 		if operation == "failover" {
 
+			// The primary node of the unsigned operation data of older versions of the broker can be forged
+			if oldPrimaryNode != "" && !isNodeGroupMember(replicationGroup, oldPrimaryNode) {
+				return providers.ServiceState(""), "", fmt.Errorf("%s is not a node of %s", oldPrimaryNode, replicationGroupID)
+			}
+
 			primaryNode, nodeToFailOverTo, err := GetPrimaryAndReplicaCacheClusterIds(replicationGroup)
 			if err != nil {
 				return providers.ServiceState(""), "", err
@@ -375,9 +697,27 @@ func (p *RedisProvider) ProgressState(
 				}
 			}
 
+			// The failover has finished, so the high availability check doesn't need to wait for it any more
+			err = p.removeTags(ctx, replicationGroupID, providers.FailoverTestStartedTag)
+			if err != nil {
+				return providers.ServiceState(""), "", err
+			}
 		}
 
 	}
+
+	if operation == "rotating-auth-token" {
+		state, err := p.finishAuthTokenRotation(ctx, instanceID, replicationGroup)
+		if err != nil {
+			return providers.ServiceState(""), "", err
+		}
+		if state == providers.AuthTokenRotationFailed {
+			return state, fmt.Sprintf("The auth token rotation didn't finish within %s", AuthTokenRotationTimeout), nil
+		}
+		if state == providers.Modifying {
+			return state, p.getMessage(ctx, replicationGroup, "modifying"), nil
+		}
+	}
 	message := p.getMessage(ctx, replicationGroup, "")
 	return providers.ServiceState(*replicationGroup.Status), message, nil
 }
@@ -415,15 +755,29 @@ func (p *RedisProvider) describeCacheCluster(ctx context.Context, cacheClusterID
 }
 
 func (p *RedisProvider) describeCacheParameters(ctx context.Context, cacheParameterGroupName string) ([]*elasticache.Parameter, error) {
-	output, err := p.elastiCache.DescribeCacheParametersWithContext(ctx, &elasticache.DescribeCacheParametersInput{
+	return p.describeAllCacheParameters(ctx, &elasticache.DescribeCacheParametersInput{
 		CacheParameterGroupName: aws.String(cacheParameterGroupName),
 	})
+}
 
-	if err != nil {
-		return nil, err
+// describeAllCacheParameters returns the parameters of every page, as parameter groups have more parameters than fit
+// on one page
+func (p *RedisProvider) describeAllCacheParameters(ctx context.Context, input *elasticache.DescribeCacheParametersInput) ([]*elasticache.Parameter, error) {
+	parameters := []*elasticache.Parameter{}
+	var marker *string
+	for {
+		pageInput := *input
+		pageInput.Marker = marker
+		output, err := p.elastiCache.DescribeCacheParametersWithContext(ctx, &pageInput)
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, output.Parameters...)
+		if aws.StringValue(output.Marker) == "" {
+			return parameters, nil
+		}
+		marker = output.Marker
 	}
-
-	return output.Parameters, nil
 }
 
 // GenerateCredentials generates the client credentials for a Redis instance and an app
@@ -434,7 +788,7 @@ func (p *RedisProvider) GenerateCredentials(ctx context.Context, instanceID, bin
 	if err != nil {
 		if awsErr, ok := err.(awserr.Error); ok {
 			if awsErr.Code() == elasticache.ErrCodeReplicationGroupNotFoundFault {
-				return nil, fmt.Errorf("Replication group does not exist: %s", replicationGroupID)
+				return nil, providers.InstanceNotFoundError(fmt.Sprintf("Replication group does not exist: %s", replicationGroupID))
 			}
 		}
 		return nil, err
@@ -454,13 +808,31 @@ func (p *RedisProvider) GenerateCredentials(ctx context.Context, instanceID, bin
 		port = *replicationGroup.NodeGroups[0].PrimaryEndpoint.Port
 	}
 
-	authTokenSecret, err := p.secretsManager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(p.getAuthTokenPath(instanceID)),
-	})
+	if len(replicationGroup.UserGroupIds) > 0 {
+		userName, password, err := p.users.CreateBindingUser(ctx, instanceID, bindingID, aws.StringValue(replicationGroup.UserGroupIds[0]))
+		if err != nil {
+			return nil, err
+		}
+		uri := &url.URL{
+			Scheme: "rediss",
+			Host:   fmt.Sprintf("%s:%d", host, port),
+			User:   url.UserPassword(userName, password),
+		}
+		return &providers.Credentials{
+			Host:       host,
+			Port:       port,
+			Name:       replicationGroupID,
+			Username:   userName,
+			Password:   password,
+			TLSEnabled: true,
+			URI:        uri.String(),
+		}, nil
+	}
+
+	authToken, err := p.getAuthToken(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
-	authToken := aws.StringValue(authTokenSecret.SecretString)
 
 	uri := &url.URL{
 		Scheme: "rediss",
@@ -479,16 +851,49 @@ func (p *RedisProvider) GenerateCredentials(ctx context.Context, instanceID, bin
 	}, nil
 }
 
-// RevokeCredentials revokes the credentials between an app and a Redis instance
-//
-// The method does nothing because we can't revoke the credentials as there is one common password
-// for a Redis service instance
-func (p *RedisProvider) RevokeCredentials(ctx context.Context, instanceID, bindingID string) error {
-	return nil
+// FindSnapshot returns a snapshot with its tags, or nil if it doesn't exist
+func (p *RedisProvider) FindSnapshot(ctx context.Context, snapshotName string) (*providers.SnapshotInfo, error) {
+	snapshots := []*elasticache.Snapshot{}
+	err := p.elastiCache.DescribeSnapshotsPagesWithContext(ctx, &elasticache.DescribeSnapshotsInput{
+		SnapshotName: aws.String(snapshotName),
+	}, func(page *elasticache.DescribeSnapshotsOutput, lastPage bool) bool {
+		snapshots = append(snapshots, page.Snapshots...)
+		return true
+	})
+	if providers.IsAWSError(err, elasticache.ErrCodeSnapshotNotFoundFault) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	snapshot := snapshots[0]
+	if len(snapshot.NodeSnapshots) == 0 || snapshot.NodeSnapshots[0].SnapshotCreateTime == nil {
+		return nil, fmt.Errorf("Invalid response from AWS: Missing values for snapshot %s", snapshotName)
+	}
+	tagList, err := p.elastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{
+		ResourceName: aws.String(p.snapshotARN(snapshotName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &providers.SnapshotInfo{
+		Name:       snapshotName,
+		CreateTime: *snapshot.NodeSnapshots[0].SnapshotCreateTime,
+		Tags:       tagsValues(tagList.TagList),
+	}, nil
 }
 
 // FindSnapshots returns the list of snapshots found for a given instance ID
 func (p *RedisProvider) FindSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	return p.FindSnapshotsWithTags(ctx, map[string]string{"instance-id": instanceID})
+}
+
+// FindSnapshotsWithTags returns the snapshots which have all the given tags
+func (p *RedisProvider) FindSnapshotsWithTags(ctx context.Context, tags map[string]string) ([]providers.SnapshotInfo, error) {
 	describeSnapshotsParams := &elasticache.DescribeSnapshotsInput{}
 	snapshots := []*elasticache.Snapshot{}
 	err := p.elastiCache.DescribeSnapshotsPagesWithContext(ctx, describeSnapshotsParams, func(page *elasticache.DescribeSnapshotsOutput, lastPage bool) bool {
@@ -504,7 +909,7 @@ func (p *RedisProvider) FindSnapshots(ctx context.Context, instanceID string) ([
 		if snapshot.SnapshotName == nil ||
 			len(snapshot.NodeSnapshots) == 0 ||
 			snapshot.NodeSnapshots[0].SnapshotCreateTime == nil {
-			return nil, fmt.Errorf("Invalid response from AWS: Missing values for snapshot for elasticache cluster %s", instanceID)
+			return nil, fmt.Errorf("Invalid response from AWS: Missing values for snapshot %s", aws.StringValue(snapshot.SnapshotName))
 		}
 		tagList, err := p.elastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{
 			ResourceName: aws.String(p.snapshotARN(*snapshot.SnapshotName)),
@@ -512,21 +917,116 @@ func (p *RedisProvider) FindSnapshots(ctx context.Context, instanceID string) ([
 		if err != nil {
 			return nil, err
 		}
-		tags := tagsValues(tagList.TagList)
+		snapshotTags := tagsValues(tagList.TagList)
+
+		if providers.HasTags(snapshotTags, tags) {
+			snapshotInfos = append(snapshotInfos, providers.SnapshotInfo{
+				Name:       *snapshot.SnapshotName,
+				CreateTime: *snapshot.NodeSnapshots[0].SnapshotCreateTime,
+				Tags:       snapshotTags,
+			})
+		}
+	}
+	return snapshotInfos, nil
+}
+
+// CreateSnapshot creates a manual snapshot of the replication group
+// The snapshot gets the tags of the replication group, so it can be found by FindSnapshots.
+func (p *RedisProvider) CreateSnapshot(ctx context.Context, instanceID string, snapshotName string) error {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	input := &elasticache.CreateSnapshotInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+		SnapshotName:       aws.String(snapshotName),
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
 
-		if val, ok := tags["instance-id"]; ok {
-			if val == instanceID {
-				snapshotInfos = append(snapshotInfos, providers.SnapshotInfo{
-					Name:       *snapshot.SnapshotName,
-					CreateTime: *snapshot.NodeSnapshots[0].SnapshotCreateTime,
-					Tags:       tags,
-				})
+	_, err = p.elastiCache.CreateSnapshotWithContext(ctx, input)
+	return err
+}
+
+// ListSnapshots returns the name, creation time, source and status of the snapshots of an existing instance
+// Unlike FindSnapshots it doesn't return the tags, so it only needs a single AWS call per page.
+func (p *RedisProvider) ListSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+
+	snapshotInfos := []providers.SnapshotInfo{}
+	var invalidSnapshot bool
+	err := p.elastiCache.DescribeSnapshotsPagesWithContext(ctx, &elasticache.DescribeSnapshotsInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+	}, func(page *elasticache.DescribeSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range page.Snapshots {
+			if snapshot.SnapshotName == nil ||
+				len(snapshot.NodeSnapshots) == 0 ||
+				snapshot.NodeSnapshots[0].SnapshotCreateTime == nil {
+				invalidSnapshot = true
+				return false
 			}
+			snapshotInfos = append(snapshotInfos, providers.SnapshotInfo{
+				Name:       *snapshot.SnapshotName,
+				CreateTime: *snapshot.NodeSnapshots[0].SnapshotCreateTime,
+				Source:     aws.StringValue(snapshot.SnapshotSource),
+				Status:     aws.StringValue(snapshot.SnapshotStatus),
+			})
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if invalidSnapshot {
+		return nil, fmt.Errorf("Invalid response from AWS: Missing values for snapshot for elasticache cluster %s", instanceID)
 	}
 	return snapshotInfos, nil
 }
 
+// tagFinalSnapshot gives the final snapshot of a deleted instance the tags of its replication group
+// Without them FindSnapshots can't find it, so it is an error instead of only being logged.
+func (p *RedisProvider) tagFinalSnapshot(ctx context.Context, instanceID, snapshotName string, tags map[string]string) error {
+	if snapshotName == "" {
+		return nil
+	}
+	err := p.tagSnapshot(ctx, snapshotName, tags)
+	if err != nil {
+		p.logger.Error("tag-final-snapshot", err, lager.Data{
+			"instance-id":   instanceID,
+			"snapshot-name": snapshotName,
+		})
+		return fmt.Errorf("failed to tag the final snapshot %s: %w", snapshotName, err)
+	}
+	return nil
+}
+
+func (p *RedisProvider) tagSnapshot(ctx context.Context, snapshotName string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	input := &elasticache.AddTagsToResourceInput{
+		ResourceName: aws.String(p.snapshotARN(snapshotName)),
+		Tags:         []*elasticache.Tag{},
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	_, err := p.elastiCache.AddTagsToResourceWithContext(ctx, input)
+	return err
+}
+
 func (p *RedisProvider) snapshotARN(snapshotID string) string {
 	return fmt.Sprintf("arn:%s:elasticache:%s:%s:snapshot:%s", p.awsPartition, p.awsRegion, p.awsAccountID, snapshotID)
 }
@@ -544,6 +1044,14 @@ func (p *RedisProvider) CreateAuthTokenSecret(ctx context.Context, instanceID st
 			},
 		},
 	})
+	if providers.IsAWSError(err, secretsmanager.ErrCodeResourceExistsException) {
+		// It's left over from an earlier attempt to provision the instance, so the new auth token is stored in it
+		p.logger.Info("auth-token-secret-exists", lager.Data{"instance-id": instanceID})
+		_, err = p.secretsManager.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String(name),
+			SecretString: aws.String(authToken),
+		})
+	}
 	return err
 }
 
@@ -553,9 +1061,186 @@ func (p *RedisProvider) DeleteAuthTokenSecret(ctx context.Context, instanceID st
 		SecretId:             aws.String(name),
 		RecoveryWindowInDays: aws.Int64(int64(recoveryWindowInDays)),
 	})
+	if providers.IsAWSError(err, secretsmanager.ErrCodeResourceNotFoundException) || isSecretScheduledForDeletion(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteUnusedAuthTokenSecret deletes the auth token secret of an instance which failed to be created
+// It's deleted without a recovery window, so the secret can be created again when provisioning is retried.
+func (p *RedisProvider) deleteUnusedAuthTokenSecret(ctx context.Context, instanceID string) error {
+	_, err := p.secretsManager.DeleteSecretWithContext(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(p.getAuthTokenPath(instanceID)),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	if providers.IsAWSError(err, secretsmanager.ErrCodeResourceNotFoundException) {
+		return nil
+	}
 	return err
 }
 
+func isSecretScheduledForDeletion(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == secretsmanager.ErrCodeInvalidRequestException &&
+		strings.Contains(awsErr.Message(), "scheduled for deletion")
+}
+
+func (p *RedisProvider) getAuthToken(ctx context.Context, instanceID string) (string, error) {
+	authTokenSecret, err := p.secretsManager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(p.getAuthTokenPath(instanceID)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(authTokenSecret.SecretString), nil
+}
+
+// RotateAuthToken adds a new auth token to the replication group and stores it in the secrets manager
+//
+// Using the ROTATE strategy both the old and the new token are accepted, so existing bindings keep working. The
+// replication group is tagged before the rotation is requested, so ProgressState can remove the old token with the SET
+// strategy once the rotation has finished. If the rotation can't be requested the tag is removed again. If storing the
+// new token fails the tag is kept and ErrAuthTokenNotStored is returned: the old token stays in the secret and the
+// rotation is finished with it, which leaves the instance with a single working token.
+func (p *RedisProvider) RotateAuthToken(ctx context.Context, instanceID string) error {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	authToken := GenerateAuthToken()
+	startedAt := time.Now()
+
+	err := p.addTags(ctx, replicationGroupID, map[string]string{
+		authTokenRotationTag: providers.OperationTagValue(authTokenRotationRotating, startedAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.elastiCache.ModifyReplicationGroupWithContext(ctx, &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId:      aws.String(replicationGroupID),
+		AuthToken:               aws.String(authToken),
+		AuthTokenUpdateStrategy: aws.String(elasticache.AuthTokenUpdateStrategyTypeRotate),
+		ApplyImmediately:        aws.Bool(true),
+	})
+	if err != nil {
+		removeErr := p.removeTags(ctx, replicationGroupID, authTokenRotationTag)
+		if removeErr != nil {
+			p.logger.Error("rotate-auth-token-remove-tag", removeErr, lager.Data{
+				"instance-id":          instanceID,
+				"replication-group-id": replicationGroupID,
+			})
+		}
+		return err
+	}
+
+	_, err = p.secretsManager.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(p.getAuthTokenPath(instanceID)),
+		SecretString: aws.String(authToken),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", providers.ErrAuthTokenNotStored, err)
+	}
+
+	return nil
+}
+
+// finishAuthTokenRotation sets the auth token stored in the secrets manager as the only valid token once the ROTATE
+// modification has been applied
+//
+// The replication group can still be available right after the ROTATE modification was requested, so the old token is
+// only removed once the modification has been seen in progress, or the auth token was modified after the rotation
+// started. It returns Modifying while the rotation hasn't finished and the replication group is available, and an
+// empty state if there is no rotation to finish. A rotation which hasn't finished within AuthTokenRotationTimeout, for
+// example because AWS never applied it, returns AuthTokenRotationFailed and its tag is removed.
+func (p *RedisProvider) finishAuthTokenRotation(ctx context.Context, instanceID string, replicationGroup *elasticache.ReplicationGroup) (providers.ServiceState, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	phase, startedAt, ok := providers.ParseOperationTagValue(tags[authTokenRotationTag])
+	if !ok || (phase != authTokenRotationRotating && phase != authTokenRotationApplying) {
+		return "", nil
+	}
+
+	if time.Since(startedAt) > AuthTokenRotationTimeout {
+		p.logger.Info("auth-token-rotation-timed-out", lager.Data{
+			"instance-id":          instanceID,
+			"replication-group-id": replicationGroupID,
+			"phase":                phase,
+			"started-at":           startedAt,
+		})
+		err = p.removeTags(ctx, replicationGroupID, authTokenRotationTag)
+		if err != nil {
+			return "", err
+		}
+		return providers.AuthTokenRotationFailed, nil
+	}
+
+	available := aws.StringValue(replicationGroup.Status) == "available"
+	if !available || isAuthTokenModificationPending(replicationGroup) {
+		if phase == authTokenRotationRotating {
+			err = p.addTags(ctx, replicationGroupID, map[string]string{
+				authTokenRotationTag: providers.OperationTagValue(authTokenRotationApplying, startedAt),
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+		if available {
+			return providers.Modifying, nil
+		}
+		return "", nil
+	}
+
+	lastModified := replicationGroup.AuthTokenLastModifiedDate
+	if phase == authTokenRotationRotating && (lastModified == nil || lastModified.Before(startedAt)) {
+		p.logger.Info("auth-token-rotation-not-started", lager.Data{
+			"instance-id":          instanceID,
+			"replication-group-id": replicationGroupID,
+		})
+		return providers.Modifying, nil
+	}
+
+	authToken, err := p.getAuthToken(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+
+	p.logger.Info("auth-token-rotation-remove-old-token", lager.Data{
+		"instance-id":          instanceID,
+		"replication-group-id": replicationGroupID,
+	})
+
+	_, err = p.elastiCache.ModifyReplicationGroupWithContext(ctx, &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId:      aws.String(replicationGroupID),
+		AuthToken:               aws.String(authToken),
+		AuthTokenUpdateStrategy: aws.String(elasticache.AuthTokenUpdateStrategyTypeSet),
+		ApplyImmediately:        aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = p.removeTags(ctx, replicationGroupID, authTokenRotationTag)
+	if err != nil {
+		return "", err
+	}
+
+	return providers.Modifying, nil
+}
+
+// authTokenRotationTimedOut returns true if the rotation recorded by the tag value started longer than
+// AuthTokenRotationTimeout ago, it's left behind if LastOperation isn't polled until the rotation has finished
+func authTokenRotationTimedOut(tagValue string) bool {
+	_, startedAt, ok := providers.ParseOperationTagValue(tagValue)
+	return ok && time.Since(startedAt) > AuthTokenRotationTimeout
+}
+
+func isAuthTokenModificationPending(replicationGroup *elasticache.ReplicationGroup) bool {
+	return replicationGroup.PendingModifiedValues != nil && replicationGroup.PendingModifiedValues.AuthTokenStatus != nil
+}
+
 func (p *RedisProvider) getAuthTokenPath(instanceID string) string {
 	return fmt.Sprintf("%s/%s/auth-token", p.secretsManagerPath, instanceID)
 }
@@ -574,16 +1259,12 @@ func tagsValues(elasticacheTags []*elasticache.Tag) map[string]string {
 // GenerateReplicationGroupName generates a valid ElastiCache replication group name
 // A valid name must contain between 1 and 20 alphanumeric characters or hyphens, should start with a letter, and cannot end with a hyphen or contain two consecutive hyphens.
 func GenerateReplicationGroupName(instanceID string) string {
-	hash := fnv.New64a()
-	hash.Write([]byte(instanceID))
-	out := hash.Sum([]byte{})
-	encoder := base32.StdEncoding.WithPadding(base32.NoPadding)
-	return strings.ToLower("cf-" + encoder.EncodeToString(out))
+	return providers.GenerateName("cf-", instanceID)
 }
 
 // GenerateAuthToken generates an alphanumeric cryptographically-secure password
 func GenerateAuthToken() string {
-	return RandomAlphaNum(PasswordLength)
+	return providers.GeneratePassword()
 }
 
 func (p *RedisProvider) replicationGroupARN(replicationGroupID string) string {
@@ -601,6 +1282,49 @@ func (p *RedisProvider) GetInstanceTags(ctx context.Context, instanceID string)
 	return tagsValues(awsTags.TagList), nil
 }
 
+// FindInstance returns the state and the tags of the replication group of an instance, or nil if it doesn't exist
+func (p *RedisProvider) FindInstance(ctx context.Context, instanceID string) (*providers.ExistingInstance, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeReplicationGroupNotFoundFault) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := providers.ServiceState(aws.StringValue(replicationGroup.Status))
+	for _, nodeGroup := range replicationGroup.NodeGroups {
+		// A node group is modified by itself during a test failover
+		if state == providers.Available && aws.StringValue(nodeGroup.Status) != "available" {
+			state = providers.Modifying
+		}
+	}
+
+	operation := ""
+	var operationStartedAt time.Time
+	switch {
+	case tags[authTokenRotationTag] != "" && !authTokenRotationTimedOut(tags[authTokenRotationTag]):
+		operation = "auth token rotation"
+	case tags[providers.FailoverTestStartedTag] != "" && isHighAvailabilityDisabled(replicationGroup):
+		operation = "test failover"
+	case tags[providers.OperationInProgressTag] != "":
+		operation, operationStartedAt, _ = providers.ParseOperationTagValue(tags[providers.OperationInProgressTag])
+	}
+
+	return &providers.ExistingInstance{
+		State:              state,
+		Tags:               tags,
+		Operation:          operation,
+		OperationStartedAt: operationStartedAt,
+	}, nil
+}
+
 func (p *RedisProvider) GetInstanceParameters(ctx context.Context, instanceID string) (providers.InstanceParameters, error) {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
 	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
@@ -615,11 +1339,27 @@ func (p *RedisProvider) GetInstanceParameters(ctx context.Context, instanceID st
 	}
 	if len(replicationGroup.MemberClusters) > 0 && replicationGroup.MemberClusters[0] != nil {
 		cacheClusterId := replicationGroup.MemberClusters[0]
+		groupName := replicationGroupID
 		if cacheCluster, err := p.describeCacheCluster(ctx, *cacheClusterId); err == nil {
+			groupName = cacheParameterGroupName(cacheCluster, replicationGroupID)
 			if cacheCluster.PreferredMaintenanceWindow != nil {
 				instanceParameters.PreferredMaintenanceWindow = *cacheCluster.PreferredMaintenanceWindow
 			}
+			if cacheCluster.Engine != nil {
+				instanceParameters.Engine = *cacheCluster.Engine
+			}
+			if cacheCluster.EngineVersion != nil {
+				instanceParameters.EngineVersion = *cacheCluster.EngineVersion
+			}
+			if cacheCluster.PendingModifiedValues != nil && cacheCluster.PendingModifiedValues.EngineVersion != nil {
+				instanceParameters.PendingEngineVersion = *cacheCluster.PendingModifiedValues.EngineVersion
+			}
+		}
+		if replicationGroup.CacheNodeType != nil {
+			instanceParameters.CacheNodeType = *replicationGroup.CacheNodeType
 		}
+		instanceParameters.ReplicasPerNodeGroup = getReplicasPerNodeGroup(replicationGroup)
+		instanceParameters.ShardCount = int64(len(replicationGroup.NodeGroups))
 		if replicationGroup.SnapshotWindow != nil {
 			instanceParameters.DailyBackupWindow = *replicationGroup.SnapshotWindow
 		}
@@ -634,16 +1374,16 @@ func (p *RedisProvider) GetInstanceParameters(ctx context.Context, instanceID st
 
 		for _, nodeGroup := range replicationGroup.NodeGroups {
 			for _, nodeGroupMember := range nodeGroup.NodeGroupMembers {
-				if *nodeGroupMember.CurrentRole == "primary" {
+				if aws.StringValue(nodeGroupMember.CurrentRole) == "primary" {
 					instanceParameters.ActiveNodes = append(instanceParameters.ActiveNodes, *nodeGroupMember.CacheClusterId)
 				}
-				if *nodeGroupMember.CurrentRole == "replica" {
+				if aws.StringValue(nodeGroupMember.CurrentRole) == "replica" {
 					instanceParameters.PassiveNodes = append(instanceParameters.PassiveNodes, *nodeGroupMember.CacheClusterId)
 				}
 			}
 		}
 
-		cacheParameters, err := p.describeCacheParameters(ctx, replicationGroupID)
+		cacheParameters, err := p.describeCacheParameters(ctx, groupName)
 		if err != nil {
 			return instanceParameters, err
 		}
@@ -663,6 +1403,152 @@ func (p *RedisProvider) GetInstanceParameters(ctx context.Context, instanceID st
 	return instanceParameters, nil
 }
 
+// TestFailover fails over the primary of a node group with the TestFailover API
+// Without a node group ID the first node group is used, which is the only one of instances without cluster mode.
+// It returns the ID of the node group which is failed over.
+func (p *RedisProvider) TestFailover(ctx context.Context, instanceID string, nodeGroupID string) (string, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return "", err
+	}
+
+	if aws.StringValue(replicationGroup.AutomaticFailover) != elasticache.AutomaticFailoverStatusEnabled {
+		return "", errors.New("Test failover requires automatic failover to be enabled")
+	}
+
+	nodeGroupIDs := []string{}
+	for _, nodeGroup := range replicationGroup.NodeGroups {
+		nodeGroupIDs = append(nodeGroupIDs, aws.StringValue(nodeGroup.NodeGroupId))
+	}
+	sort.Strings(nodeGroupIDs)
+	if len(nodeGroupIDs) == 0 {
+		return "", fmt.Errorf("Invalid response from AWS: no node groups returned for %s", replicationGroupID)
+	}
+	if nodeGroupID == "" {
+		nodeGroupID = nodeGroupIDs[0]
+	}
+	if !containsString(nodeGroupIDs, nodeGroupID) {
+		return "", fmt.Errorf("Node group %s not found, the node groups of the instance are: %s", nodeGroupID, strings.Join(nodeGroupIDs, ", "))
+	}
+
+	p.logger.Info("test-failover", lager.Data{
+		"instance-id":          instanceID,
+		"node-group-id":        nodeGroupID,
+		"replication-group-id": replicationGroupID,
+	})
+
+	_, err = p.elastiCache.TestFailoverWithContext(ctx, &elasticache.TestFailoverInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+		NodeGroupId:        aws.String(nodeGroupID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return nodeGroupID, nil
+}
+
+// The events of a test failover, see https://docs.aws.amazon.com/AmazonElastiCache/latest/APIReference/API_TestFailover.html
+var (
+	failoverCompletedEvent = regexp.MustCompile(`^Failover from (?:primary|master) node (\S+) to replica node \S+ completed$`)
+	failoverFailedEvent    = regexp.MustCompile(`^Failover from (?:primary|master) node \S+ to replica node \S+ failed$`)
+	recoveryFinishedEvent  = regexp.MustCompile(`^Finished recovery for cache nodes \S+`)
+)
+
+// FailoverTestState follows a test failover started by TestFailover through the events of the replication group
+// The failover has finished once the old primary has been recovered as a replica and the replication group is available.
+// The recovery is an event of the cache cluster of the old primary, so its events are read once the failover has
+// completed.
+func (p *RedisProvider) FailoverTestState(
+	ctx context.Context,
+	instanceID string,
+	nodeGroupID string,
+	startTime time.Time,
+) (providers.ServiceState, string, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return providers.ServiceState(""), "", err
+	}
+
+	events, err := p.describeEvents(ctx, elasticache.SourceTypeReplicationGroup, replicationGroupID, startTime)
+	if err != nil {
+		return providers.ServiceState(""), "", err
+	}
+
+	var oldPrimary string
+	var failed bool
+	for _, event := range events {
+		message := aws.StringValue(event.Message)
+		if match := failoverCompletedEvent.FindStringSubmatch(message); match != nil {
+			oldPrimary = match[1]
+		}
+		if failoverFailedEvent.MatchString(message) {
+			failed = true
+		}
+	}
+
+	var recovered bool
+	if oldPrimary != "" && !failed {
+		nodeEvents, err := p.describeEvents(ctx, elasticache.SourceTypeCacheCluster, oldPrimary, startTime)
+		if err != nil {
+			return providers.ServiceState(""), "", err
+		}
+		for _, event := range nodeEvents {
+			if recoveryFinishedEvent.MatchString(aws.StringValue(event.Message)) {
+				recovered = true
+			}
+		}
+		events = append(events, nodeEvents...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.TimeValue(events[i].Date).Before(aws.TimeValue(events[j].Date))
+	})
+
+	state := providers.Modifying
+	switch {
+	case failed:
+		state = providers.FailoverFailed
+	case recovered && aws.StringValue(replicationGroup.Status) == "available":
+		state = providers.Available
+	}
+
+	tmpl := "%-20s : %s"
+	msgs := []string{p.getMessage(ctx, replicationGroup, string(state))}
+	msgs = append(msgs, fmt.Sprintf(tmpl, "node group", nodeGroupID))
+	for _, event := range events {
+		msgs = append(msgs, fmt.Sprintf(tmpl, aws.TimeValue(event.Date).UTC().Format(time.RFC3339), aws.StringValue(event.Message)))
+	}
+	return state, strings.Join(msgs, "\n           "), nil
+}
+
+func (p *RedisProvider) describeEvents(ctx context.Context, sourceType, sourceID string, startTime time.Time) ([]*elasticache.Event, error) {
+	events := []*elasticache.Event{}
+	err := p.elastiCache.DescribeEventsPagesWithContext(ctx, &elasticache.DescribeEventsInput{
+		SourceType:       aws.String(sourceType),
+		SourceIdentifier: aws.String(sourceID),
+		StartTime:        aws.Time(startTime),
+	}, func(page *elasticache.DescribeEventsOutput, lastPage bool) bool {
+		events = append(events, page.Events...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *RedisProvider) StartFailoverTest(ctx context.Context, instanceID string) (string, error) {
 	replicationGroupID := GenerateReplicationGroupName(instanceID)
 	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
@@ -679,6 +1565,14 @@ func (p *RedisProvider) StartFailoverTest(ctx context.Context, instanceID string
 		"replication-group-id": replicationGroupID,
 	})
 
+	// The start time lets the high availability check tell a running failover apart from one which never finished
+	err = p.addTags(ctx, replicationGroupID, map[string]string{
+		providers.FailoverTestStartedTag: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+
 	_, err = p.elastiCache.ModifyReplicationGroupWithContext(ctx, &elasticache.ModifyReplicationGroupInput{
 		AutomaticFailoverEnabled: aws.Bool(false),
 		MultiAZEnabled:           aws.Bool(false),
@@ -692,3 +1586,88 @@ func (p *RedisProvider) StartFailoverTest(ctx context.Context, instanceID string
 
 	return primaryNode, nil
 }
+
+// RestoreHighAvailability enables automatic failover and Multi-AZ if either of them is disabled, and removes the tag
+// of an emulated test failover, as it has ended
+// It returns true if the replication group is being modified to enable them. The replication group has to be available.
+func (p *RedisProvider) RestoreHighAvailability(ctx context.Context, instanceID string) (bool, error) {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return false, err
+	}
+
+	if !isHighAvailabilityDisabled(replicationGroup) {
+		return false, p.removeTags(ctx, replicationGroupID, providers.FailoverTestStartedTag)
+	}
+	if status := aws.StringValue(replicationGroup.Status); status != "available" {
+		return false, fmt.Errorf("Replication group %s is %s", replicationGroupID, status)
+	}
+
+	p.logger.Info("restore-ha", lager.Data{
+		"instance-id":          instanceID,
+		"replication-group-id": replicationGroupID,
+		"automatic-failover":   aws.StringValue(replicationGroup.AutomaticFailover),
+		"multi-az":             aws.StringValue(replicationGroup.MultiAZ),
+	})
+
+	_, err = p.elastiCache.ModifyReplicationGroupWithContext(ctx, &elasticache.ModifyReplicationGroupInput{
+		AutomaticFailoverEnabled: aws.Bool(true),
+		MultiAZEnabled:           aws.Bool(true),
+		ReplicationGroupId:       aws.String(replicationGroupID),
+		ApplyImmediately:         aws.Bool(true),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	err = p.removeTags(ctx, replicationGroupID, providers.FailoverTestStartedTag)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindDegradedInstances returns the available instances created by the broker which have automatic failover or
+// Multi-AZ disabled, with their tags
+// Instances of plans without high availability are returned as well, the callers have to check the plan.
+func (p *RedisProvider) FindDegradedInstances(ctx context.Context, brokerName string) ([]providers.DegradedInstance, error) {
+	replicationGroups := []*elasticache.ReplicationGroup{}
+	err := p.elastiCache.DescribeReplicationGroupsPagesWithContext(ctx, &elasticache.DescribeReplicationGroupsInput{},
+		func(page *elasticache.DescribeReplicationGroupsOutput, lastPage bool) bool {
+			for _, replicationGroup := range page.ReplicationGroups {
+				if aws.StringValue(replicationGroup.Status) == "available" && isHighAvailabilityDisabled(replicationGroup) {
+					replicationGroups = append(replicationGroups, replicationGroup)
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []providers.DegradedInstance{}
+	for _, replicationGroup := range replicationGroups {
+		awsTags, err := p.elastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{
+			ResourceName: replicationGroup.ARN,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tags := tagsValues(awsTags.TagList)
+		if tags["created-by"] != brokerName || tags["instance-id"] == "" {
+			continue
+		}
+		instances = append(instances, providers.DegradedInstance{
+			InstanceID: tags["instance-id"],
+			Tags:       tags,
+		})
+	}
+	return instances, nil
+}
+
+func isHighAvailabilityDisabled(replicationGroup *elasticache.ReplicationGroup) bool {
+	return aws.StringValue(replicationGroup.AutomaticFailover) == elasticache.AutomaticFailoverStatusDisabled ||
+		aws.StringValue(replicationGroup.MultiAZ) == elasticache.MultiAZStatusDisabled
+}
@@ -61,6 +61,7 @@ var _ = Describe("Provider", func() {
 			lager.NewLogger("logger"),
 			kmsKeyID,
 			secretsManagerPath,
+			nil,
 		)
 	})
 
@@ -150,6 +151,22 @@ var _ = Describe("Provider", func() {
 			Expect(provisionErr).ToNot(HaveOccurred())
 		})
 
+		Context("when the plan is a valkey plan without a parameter group family", func() {
+			BeforeEach(func() {
+				provisionParams.CacheParameterGroupFamily = ""
+				provisionParams.Engine = "valkey"
+				provisionParams.EngineVersion = "8.0"
+			})
+
+			It("creates a replication group with the default valkey family", func() {
+				_, paramGroupInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
+				Expect(paramGroupInput.CacheParameterGroupFamily).To(Equal(aws.String("valkey8")))
+
+				_, input, _ := mockElasticache.CreateReplicationGroupWithContextArgsForCall(0)
+				Expect(input.Engine).To(Equal(aws.String("valkey")))
+			})
+		})
+
 		It("creates a cache parameter group and sets the parameters", func() {
 			Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(1))
 			receivedCtx, receivedInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
@@ -208,6 +225,39 @@ var _ = Describe("Provider", func() {
 			})
 		})
 
+		Context("when the parameter group is left over from an earlier attempt", func() {
+			BeforeEach(func() {
+				mockElasticache.CreateCacheParameterGroupWithContextReturnsOnCall(0, nil,
+					awserr.New(elasticache.ErrCodeCacheParameterGroupAlreadyExistsFault, "already exists", nil))
+			})
+
+			It("sets the parameters and creates the replication group", func() {
+				Expect(provisionErr).ToNot(HaveOccurred())
+				Expect(mockElasticache.ModifyCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				Expect(mockElasticache.CreateReplicationGroupWithContextCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the auth token secret is left over from an earlier attempt", func() {
+			BeforeEach(func() {
+				mockSecretsManager.CreateSecretWithContextReturnsOnCall(0, nil,
+					awserr.New(secretsmanager.ErrCodeResourceExistsException, "already exists", nil))
+			})
+
+			It("stores the new auth token in the secret", func() {
+				Expect(provisionErr).ToNot(HaveOccurred())
+
+				_, createInput, _ := mockSecretsManager.CreateSecretWithContextArgsForCall(0)
+				Expect(mockSecretsManager.PutSecretValueWithContextCallCount()).To(Equal(1))
+				_, putInput, _ := mockSecretsManager.PutSecretValueWithContextArgsForCall(0)
+				Expect(putInput.SecretId).To(Equal(aws.String("elasticache-broker-test/foobar/auth-token")))
+				Expect(putInput.SecretString).To(Equal(createInput.SecretString))
+
+				_, input, _ := mockElasticache.CreateReplicationGroupWithContextArgsForCall(0)
+				Expect(input.AuthToken).To(Equal(createInput.SecretString))
+			})
+		})
+
 		Context("when modifying a parameter group fails", func() {
 			var modifyErr = errors.New("some error")
 
@@ -220,6 +270,11 @@ var _ = Describe("Provider", func() {
 				Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(1))
 				Expect(mockElasticache.CreateReplicationGroupWithContextCallCount()).To(Equal(0))
 			})
+
+			It("deletes the parameter group", func() {
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				Expect(mockSecretsManager.CreateSecretWithContextCallCount()).To(Equal(0))
+			})
 		})
 
 		It("saves the auth token in the secrets manager", func() {
@@ -259,7 +314,35 @@ var _ = Describe("Provider", func() {
 			})
 
 			It("returns with an error", func() {
-				Expect(provisionErr).To(MatchError("failed to create auth token: " + createErr.Error()))
+				Expect(provisionErr).To(MatchError(HavePrefix("failed to create auth token: " + createErr.Error())))
+				Expect(mockElasticache.CreateReplicationGroupWithContextCallCount()).To(Equal(0))
+			})
+
+			It("deletes the cache parameter group", func() {
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+				Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID)))
+			})
+
+			It("returns the outcome of the rollback", func() {
+				Expect(provisionErr).To(MatchError(
+					"failed to create auth token: error in secrets manager (cache parameter group: rolled back)",
+				))
+				var stepErr *providers.StepError
+				Expect(errors.As(provisionErr, &stepErr)).To(BeTrue())
+				Expect(stepErr.Step).To(Equal("auth token secret"))
+			})
+
+			Context("if deleting the cache parameter group fails", func() {
+				BeforeEach(func() {
+					mockElasticache.DeleteCacheParameterGroupWithContextReturns(nil, errors.New("some error"))
+				})
+
+				It("returns what was left behind", func() {
+					Expect(provisionErr).To(MatchError(
+						"failed to create auth token: error in secrets manager (cache parameter group: rollback failed: some error)",
+					))
+				})
 			})
 		})
 
@@ -293,7 +376,7 @@ var _ = Describe("Provider", func() {
 
 		It("has an auth token with the right length", func() {
 			_, passedInput, _ := mockElasticache.CreateReplicationGroupWithContextArgsForCall(0)
-			Expect(*passedInput.AuthToken).To(HaveLen(PasswordLength))
+			Expect(*passedInput.AuthToken).To(HaveLen(providers.PasswordLength))
 		})
 
 		Context("when tags are set", func() {
@@ -310,6 +393,17 @@ var _ = Describe("Provider", func() {
 			})
 		})
 
+		Context("when a daily backup window is set", func() {
+			BeforeEach(func() {
+				provisionParams.DailyBackupWindow = "04:00-05:00"
+			})
+
+			It("uses it instead of the default window", func() {
+				_, passedInput, _ := mockElasticache.CreateReplicationGroupWithContextArgsForCall(0)
+				Expect(passedInput.SnapshotWindow).To(Equal(aws.String("04:00-05:00")))
+			})
+		})
+
 		Context("when backup and failover is not enabled", func() {
 			BeforeEach(func() {
 				provisionParams.SnapshotRetentionLimit = 0
@@ -333,6 +427,21 @@ var _ = Describe("Provider", func() {
 			Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
 		})
 
+		Context("when the replication group already exists", func() {
+			BeforeEach(func() {
+				mockElasticache.CreateReplicationGroupWithContextReturnsOnCall(0, nil,
+					awserr.New(elasticache.ErrCodeReplicationGroupAlreadyExistsFault, "already exists", nil))
+			})
+
+			It("succeeds without rolling back what the replication group uses", func() {
+				Expect(provisionErr).NotTo(HaveOccurred())
+				Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DeleteUserGroupWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when provision fails", func() {
 			var createErr = errors.New("some err")
 
@@ -349,15 +458,17 @@ var _ = Describe("Provider", func() {
 				passedCtx, input, _ := mockSecretsManager.DeleteSecretWithContextArgsForCall(0)
 				Expect(passedCtx).To(Equal(ctx))
 				Expect(input.SecretId).To(Equal(aws.String("elasticache-broker-test/foobar/auth-token")))
-				Expect(*input.RecoveryWindowInDays).To(Equal(int64(7)))
+				Expect(input.ForceDeleteWithoutRecovery).To(Equal(aws.Bool(true)))
+				Expect(input.RecoveryWindowInDays).To(BeNil())
 			})
 
 			Context("if deleting the auth token fails", func() {
 				BeforeEach(func() {
 					mockSecretsManager.DeleteSecretWithContextReturns(nil, errors.New("some error"))
 				})
-				It("is ignored", func() {
+				It("returns the error of the failed step", func() {
 					Expect(provisionErr).To(MatchError(createErr))
+					Expect(provisionErr).To(MatchError(ContainSubstring("auth token secret: rollback failed: some error")))
 				})
 			})
 
@@ -374,8 +485,9 @@ var _ = Describe("Provider", func() {
 				BeforeEach(func() {
 					mockElasticache.DeleteCacheParameterGroupWithContextReturns(nil, errors.New("some error"))
 				})
-				It("is ignored", func() {
+				It("returns the error of the failed step", func() {
 					Expect(provisionErr).To(MatchError(createErr))
+					Expect(provisionErr).To(MatchError(ContainSubstring("cache parameter group: rollback failed: some error")))
 				})
 			})
 		})
@@ -445,17 +557,63 @@ var _ = Describe("Provider", func() {
 			})
 		})
 
+		It("does not tag any snapshot", func() {
+			Expect(mockElasticache.ListTagsForResourceWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(0))
+		})
+
 		Context("when the final snapshot name is set", func() {
 			BeforeEach(func() {
 				deprovisionParams = providers.DeprovisionParameters{
 					FinalSnapshotIdentifier: "test snapshot",
 				}
+				mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+					TagList: []*elasticache.Tag{
+						{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+					},
+				}, nil)
 			})
 
 			It("sets a parameter for creating a final snapshot", func() {
 				_, passedInput, _ := mockElasticache.DeleteReplicationGroupWithContextArgsForCall(0)
 				Expect(passedInput.FinalSnapshotIdentifier).To(Equal(aws.String("test snapshot")))
 			})
+
+			It("copies the tags of the replication group to the snapshot", func() {
+				_, listInput, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+				Expect(listInput.ResourceName).To(Equal(aws.String(ExportReplicationGroupARN(provider, replicationGroupID))))
+
+				Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+				Expect(input).To(Equal(&elasticache.AddTagsToResourceInput{
+					ResourceName: aws.String(fmt.Sprintf("arn:%s:elasticache:%s:%s:snapshot:test snapshot", awsPartition, awsRegion, awsAccountID)),
+					Tags: []*elasticache.Tag{
+						{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+					},
+				}))
+			})
+
+			Context("when tagging the snapshot fails", func() {
+				BeforeEach(func() {
+					mockElasticache.AddTagsToResourceWithContextReturns(nil, errors.New("some error"))
+				})
+
+				It("returns the error, so the deprovision is retried", func() {
+					Expect(deprovisionErr).To(MatchError("failed to tag the final snapshot test snapshot: some error"))
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when getting the tags of the replication group fails", func() {
+				BeforeEach(func() {
+					mockElasticache.ListTagsForResourceWithContextReturns(nil, errors.New("some error"))
+				})
+
+				It("does not delete the replication group", func() {
+					Expect(deprovisionErr).To(MatchError("some error"))
+					Expect(mockElasticache.DeleteReplicationGroupWithContextCallCount()).To(Equal(0))
+				})
+			})
 		})
 
 		Context("if deleting the replication group fails", func() {
@@ -473,6 +631,139 @@ var _ = Describe("Provider", func() {
 				Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
 			})
 		})
+
+		Context("if the replication group doesn't exist", func() {
+			BeforeEach(func() {
+				mockElasticache.DeleteReplicationGroupWithContextReturnsOnCall(0, nil,
+					awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil))
+			})
+
+			It("returns ErrInstanceNotFound", func() {
+				Expect(deprovisionErr).To(MatchError(providers.ErrInstanceNotFound))
+			})
+
+			It("still deletes the auth token from the Secrets Manager", func() {
+				Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+			})
+
+			Context("and the auth token was deleted already", func() {
+				BeforeEach(func() {
+					mockSecretsManager.DeleteSecretWithContextReturnsOnCall(0, nil,
+						awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil))
+				})
+
+				It("returns ErrInstanceNotFound", func() {
+					Expect(deprovisionErr).To(MatchError(providers.ErrInstanceNotFound))
+				})
+			})
+
+			Context("and the auth token is scheduled for deletion", func() {
+				BeforeEach(func() {
+					mockSecretsManager.DeleteSecretWithContextReturnsOnCall(0, nil,
+						awserr.New(secretsmanager.ErrCodeInvalidRequestException, "You can't perform this operation on the secret because it was marked for deletion. It is scheduled for deletion.", nil))
+				})
+
+				It("returns ErrInstanceNotFound", func() {
+					Expect(deprovisionErr).To(MatchError(providers.ErrInstanceNotFound))
+				})
+			})
+
+			Context("when the final snapshot name is set", func() {
+				BeforeEach(func() {
+					deprovisionParams = providers.DeprovisionParameters{
+						FinalSnapshotIdentifier: "test snapshot",
+					}
+					mockElasticache.ListTagsForResourceWithContextReturns(nil,
+						awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil))
+				})
+
+				It("returns ErrInstanceNotFound and deletes the auth token", func() {
+					Expect(deprovisionErr).To(MatchError(providers.ErrInstanceNotFound))
+					Expect(mockElasticache.DeleteReplicationGroupWithContextCallCount()).To(Equal(0))
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("if the replication group is in an invalid state", func() {
+			BeforeEach(func() {
+				mockElasticache.DeleteReplicationGroupWithContextReturnsOnCall(0, nil,
+					awserr.New(elasticache.ErrCodeInvalidReplicationGroupStateFault, "invalid state", nil))
+			})
+
+			JustBeforeEach(func() {
+				Expect(mockElasticache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DescribeReplicationGroupsWithContextArgsForCall(0)
+				Expect(input.ReplicationGroupId).To(Equal(aws.String(replicationGroupID)))
+			})
+
+			Context("because it's being deleted", func() {
+				BeforeEach(func() {
+					mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+						ReplicationGroups: []*elasticache.ReplicationGroup{{Status: aws.String("deleting")}},
+					}, nil)
+				})
+
+				It("succeeds", func() {
+					Expect(deprovisionErr).ToNot(HaveOccurred())
+				})
+
+				It("deletes the auth token from the Secrets Manager", func() {
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+				})
+
+				It("does not tag any snapshot", func() {
+					Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(0))
+				})
+
+				Context("when the final snapshot name is set", func() {
+					BeforeEach(func() {
+						deprovisionParams = providers.DeprovisionParameters{
+							FinalSnapshotIdentifier: "test snapshot",
+						}
+						mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+							TagList: []*elasticache.Tag{
+								{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+							},
+						}, nil)
+					})
+
+					It("tags the final snapshot again", func() {
+						Expect(deprovisionErr).ToNot(HaveOccurred())
+						Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+						_, input, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+						Expect(input.ResourceName).To(Equal(aws.String(fmt.Sprintf("arn:%s:elasticache:%s:%s:snapshot:test snapshot", awsPartition, awsRegion, awsAccountID))))
+					})
+
+					Context("when tagging the snapshot fails", func() {
+						BeforeEach(func() {
+							mockElasticache.AddTagsToResourceWithContextReturns(nil, errors.New("some error"))
+						})
+
+						It("returns the error", func() {
+							Expect(deprovisionErr).To(MatchError("failed to tag the final snapshot test snapshot: some error"))
+							Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+						})
+					})
+				})
+			})
+
+			Context("because it's being modified", func() {
+				BeforeEach(func() {
+					mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+						ReplicationGroups: []*elasticache.ReplicationGroup{{Status: aws.String("modifying")}},
+					}, nil)
+				})
+
+				It("returns the error", func() {
+					Expect(deprovisionErr).To(MatchError(ContainSubstring("invalid state")))
+				})
+
+				It("does not delete the auth token from the Secrets Manager", func() {
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+				})
+			})
+		})
 	})
 
 	Context("when getting the status of the cluster", func() {
@@ -554,6 +845,7 @@ var _ = Describe("Provider", func() {
 						{
 							CacheClusterId:             aws.String(cacheClusterId),
 							PreferredMaintenanceWindow: maintenanceWindow,
+							Engine:                     aws.String("valkey"),
 							EngineVersion:              aws.String("9.9.9"),
 						},
 					},
@@ -583,6 +875,7 @@ var _ = Describe("Provider", func() {
 
 				Expect(mockElasticache.DescribeCacheParametersWithContextCallCount()).To(Equal(1))
 				Expect(stateMessage).To(ContainSubstring("status               : available"))
+				Expect(stateMessage).To(ContainSubstring("engine               : valkey"))
 				Expect(stateMessage).To(ContainSubstring("engine version       : 9.9.9"))
 				Expect(stateMessage).To(ContainSubstring("maxmemory policy     : test-ttl"))
 				Expect(stateMessage).To(ContainSubstring("daily backup window  : 05:01-09:01"))
@@ -591,6 +884,31 @@ var _ = Describe("Provider", func() {
 				Expect(stateMessage).To(ContainSubstring("automatic failover   : enabled"))
 			})
 
+			It("returns the resharding progress in the message", func() {
+				mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+					ReplicationGroups: []*elasticache.ReplicationGroup{
+						{
+							ReplicationGroupId: aws.String(replicationGroupID),
+							Status:             aws.String("modifying"),
+							NodeGroups: []*elasticache.NodeGroup{
+								{NodeGroupId: aws.String("0001")},
+								{NodeGroupId: aws.String("0002")},
+							},
+							PendingModifiedValues: &elasticache.ReplicationGroupPendingModifiedValues{
+								Resharding: &elasticache.ReshardingStatus{
+									SlotMigration: &elasticache.SlotMigration{ProgressPercentage: aws.Float64(42.5)},
+								},
+							},
+						},
+					},
+				}, nil)
+
+				_, stateMessage, stateErr := provider.ProgressState(context.Background(), instanceID, "", "")
+				Expect(stateErr).ToNot(HaveOccurred())
+				Expect(stateMessage).To(ContainSubstring("shards               : 2"))
+				Expect(stateMessage).To(ContainSubstring("resharding           : 42.5% of slots migrated"))
+			})
+
 			Context("when it doesn't have automated backup", func() {
 				BeforeEach(func() {
 					snapshotWindow = nil
@@ -655,6 +973,165 @@ var _ = Describe("Provider", func() {
 					Expect(stateMessage).To(ContainSubstring("automatic failover   : disabled"))
 					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
 				})
+				It("rejects a primary node which isn't a node of the replication group", func() {
+					_, _, stateErr := provider.ProgressState(
+						context.Background(),
+						instanceID,
+						"failover",
+						"cf-someoneelse-001",
+					)
+
+					Expect(stateErr).To(MatchError("cf-someoneelse-001 is not a node of " + replicationGroupID))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when operation is rotating the auth token", func() {
+				var rotationStartedAt time.Time
+
+				replicationGroupReturns := func(status string, authTokenStatus *string, authTokenLastModified *time.Time) {
+					mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+						ReplicationGroups: []*elasticache.ReplicationGroup{
+							{
+								ReplicationGroupId:        aws.String(replicationGroupID),
+								Status:                    aws.String(status),
+								PendingModifiedValues:     &elasticache.ReplicationGroupPendingModifiedValues{AuthTokenStatus: authTokenStatus},
+								AuthTokenLastModifiedDate: authTokenLastModified,
+							},
+						},
+					}, nil)
+				}
+				rotationTagReturns := func(phase string) {
+					mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+						TagList: []*elasticache.Tag{
+							{Key: aws.String("auth-token-rotation"), Value: aws.String(providers.OperationTagValue(phase, rotationStartedAt))},
+						},
+					}, nil)
+				}
+
+				BeforeEach(func() {
+					rotationStartedAt = time.Now().UTC().Truncate(time.Second).Add(-10 * time.Minute)
+					mockSecretsManager.GetSecretValueWithContextReturns(&secretsmanager.GetSecretValueOutput{
+						SecretString: aws.String("new-auth-token"),
+					}, nil)
+				})
+
+				It("waits while the replication group is available before the rotation has started", func() {
+					rotationTagReturns("rotating")
+					replicationGroupReturns("available", nil, aws.Time(rotationStartedAt.Add(-24*time.Hour)))
+
+					state, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.Modifying))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+					Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(0))
+				})
+
+				It("records that the rotation is being applied", func() {
+					rotationTagReturns("rotating")
+					replicationGroupReturns("modifying", aws.String("ROTATING"), nil)
+
+					state, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.Modifying))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+
+					Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+					_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+					Expect(tagsInput.Tags).To(Equal([]*elasticache.Tag{
+						{Key: aws.String("auth-token-rotation"), Value: aws.String(providers.OperationTagValue("applying", rotationStartedAt))},
+					}))
+				})
+
+				It("waits while the new auth token is pending on an available replication group", func() {
+					rotationTagReturns("applying")
+					replicationGroupReturns("available", aws.String("ROTATING"), nil)
+
+					state, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.Modifying))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+					Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(0))
+				})
+
+				removesTheOldAuthToken := func() {
+					state, stateMessage, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.Modifying))
+					Expect(stateMessage).To(ContainSubstring("status               : modifying"))
+
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+					_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+					Expect(input).To(Equal(&elasticache.ModifyReplicationGroupInput{
+						ReplicationGroupId:      aws.String(replicationGroupID),
+						AuthToken:               aws.String("new-auth-token"),
+						AuthTokenUpdateStrategy: aws.String("SET"),
+						ApplyImmediately:        aws.Bool(true),
+					}))
+
+					Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+					_, tagsInput, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+					Expect(tagsInput.TagKeys).To(Equal(aws.StringSlice([]string{"auth-token-rotation"})))
+				}
+
+				It("removes the old auth token once the rotation has been applied", func() {
+					rotationTagReturns("applying")
+					replicationGroupReturns("available", nil, nil)
+
+					removesTheOldAuthToken()
+				})
+
+				It("removes the old auth token if the rotation was applied between two polls", func() {
+					rotationTagReturns("rotating")
+					replicationGroupReturns("available", nil, aws.Time(rotationStartedAt.Add(5*time.Minute)))
+
+					removesTheOldAuthToken()
+				})
+
+				It("succeeds once the old auth token was removed", func() {
+					mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{}, nil)
+
+					state, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.Available))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+				})
+
+				It("fails the rotation and removes its tag once it has timed out", func() {
+					rotationStartedAt = time.Now().UTC().Add(-AuthTokenRotationTimeout - time.Minute)
+					rotationTagReturns("rotating")
+					replicationGroupReturns("available", nil, aws.Time(rotationStartedAt.Add(-24*time.Hour)))
+
+					state, stateMessage, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).ToNot(HaveOccurred())
+					Expect(state).To(Equal(providers.AuthTokenRotationFailed))
+					Expect(stateMessage).To(Equal("The auth token rotation didn't finish within 1h0m0s"))
+					Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+
+					Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+					_, tagsInput, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+					Expect(tagsInput.TagKeys).To(Equal(aws.StringSlice([]string{"auth-token-rotation"})))
+				})
+
+				It("returns an error if the tag of a timed out rotation can't be removed", func() {
+					rotationStartedAt = time.Now().UTC().Add(-AuthTokenRotationTimeout - time.Minute)
+					rotationTagReturns("applying")
+					replicationGroupReturns("modifying", aws.String("ROTATING"), nil)
+					mockElasticache.RemoveTagsFromResourceWithContextReturns(nil, errors.New("some error"))
+
+					_, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).To(MatchError("some error"))
+				})
+
+				It("returns an error if removing the old auth token fails", func() {
+					rotationTagReturns("applying")
+					replicationGroupReturns("available", nil, nil)
+					mockElasticache.ModifyReplicationGroupWithContextReturns(nil, errors.New("some error"))
+
+					_, _, stateErr := provider.ProgressState(context.Background(), instanceID, "rotating-auth-token", "")
+					Expect(stateErr).To(MatchError("some error"))
+					Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(0))
+				})
 			})
 		})
 
@@ -862,6 +1339,17 @@ var _ = Describe("Provider", func() {
 			})
 		})
 
+		Context("when the replication group doesn't exist", func() {
+			BeforeEach(func() {
+				describeReplicationGroupsErr = awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil)
+			})
+
+			It("should return an instance not found error", func() {
+				Expect(generateErr).To(MatchError("Replication group does not exist: " + replicationGroupID))
+				Expect(errors.Is(generateErr, providers.ErrInstanceNotFound)).To(BeTrue())
+			})
+		})
+
 		Context("when no replication groups are returned", func() {
 			BeforeEach(func() {
 				describeReplicationGroupsOutput = &elasticache.DescribeReplicationGroupsOutput{
@@ -1051,6 +1539,55 @@ var _ = Describe("Provider", func() {
 			))
 		})
 
+		It("finds the snapshots which have all the given tags", func() {
+			now := time.Now()
+			describeSnapshotOutputToReturn = []elasticache.DescribeSnapshotsOutput{
+				{
+					Snapshots: []*elasticache.Snapshot{
+						{
+							SnapshotName: aws.String("snapshot1"),
+							NodeSnapshots: []*elasticache.NodeSnapshot{
+								{SnapshotCreateTime: aws.Time(now.Add(-2 * 24 * time.Hour))},
+							},
+						},
+						{
+							SnapshotName: aws.String("snapshot2"),
+							NodeSnapshots: []*elasticache.NodeSnapshot{
+								{SnapshotCreateTime: aws.Time(now.Add(-1 * 24 * time.Hour))},
+							},
+						},
+					},
+				},
+			}
+			mockElasticache.ListTagsForResourceWithContextStub = func(
+				ctx aws.Context, input *elasticache.ListTagsForResourceInput, opts ...request.Option,
+			) (*elasticache.TagListMessage, error) {
+				spaceID := "other-space"
+				if *input.ResourceName == "arn:aws:elasticache:eu-west-1:123456789012:snapshot:snapshot1" {
+					spaceID = "space"
+				}
+				return &elasticache.TagListMessage{
+					TagList: []*elasticache.Tag{
+						{Key: aws.String("organization-id"), Value: aws.String("org")},
+						{Key: aws.String("space-id"), Value: aws.String(spaceID)},
+					},
+				}, nil
+			}
+
+			snapshots, err := provider.FindSnapshotsWithTags(ctx, map[string]string{
+				"organization-id": "org",
+				"space-id":        "space",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]providers.SnapshotInfo{
+				{
+					Name:       "snapshot1",
+					CreateTime: now.Add(-2 * 24 * time.Hour),
+					Tags:       map[string]string{"organization-id": "org", "space-id": "space"},
+				},
+			}))
+		})
+
 		It("returns and empty list if there are no snapshots", func() {
 			instanceID := "foobar"
 
@@ -1123,20 +1660,209 @@ var _ = Describe("Provider", func() {
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(
-				ContainSubstring("Invalid response from AWS: Missing values for snapshot for elasticache cluster")),
+				ContainSubstring("Invalid response from AWS: Missing values for snapshot")),
 			)
 		})
-	})
-
-	Context("when updating", func() {
-		It("should update the cache parameter group", func() {
-			replicationGroupID := "cf-qwkec4pxhft6q"
-			instanceID := "foobar"
 
-			ctx := context.Background()
-
-			err := provider.UpdateParamGroupParameters(ctx, instanceID, providers.UpdateParamGroupParameters{
-				Parameters: map[string]string{
+		Context("by name", func() {
+			It("returns the snapshot with its tags", func() {
+				now := time.Now()
+				describeSnapshotOutputToReturn = []elasticache.DescribeSnapshotsOutput{
+					{
+						Snapshots: []*elasticache.Snapshot{
+							{
+								SnapshotName: aws.String("snapshot1"),
+								NodeSnapshots: []*elasticache.NodeSnapshot{
+									{SnapshotCreateTime: aws.Time(now.Add(-2 * 24 * time.Hour))},
+								},
+							},
+						},
+					},
+				}
+				mockElasticache.ListTagsForResourceWithContextReturns(
+					&elasticache.TagListMessage{
+						TagList: []*elasticache.Tag{
+							{Key: aws.String("instance-id"), Value: aws.String("foobar")},
+						},
+					},
+					nil,
+				)
+
+				snapshot, err := provider.FindSnapshot(ctx, "snapshot1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshot).To(Equal(&providers.SnapshotInfo{
+					Name:       "snapshot1",
+					CreateTime: now.Add(-2 * 24 * time.Hour),
+					Tags:       map[string]string{"instance-id": "foobar"},
+				}))
+
+				_, input, _, _ := mockElasticache.DescribeSnapshotsPagesWithContextArgsForCall(0)
+				Expect(input.SnapshotName).To(Equal(aws.String("snapshot1")))
+				_, tagsInput, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+				Expect(tagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:snapshot:snapshot1")))
+			})
+
+			It("returns nil if the snapshot doesn't exist", func() {
+				errorToReturn = awserr.New(elasticache.ErrCodeSnapshotNotFoundFault, "not found", nil)
+
+				snapshot, err := provider.FindSnapshot(ctx, "snapshot1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshot).To(BeNil())
+			})
+
+			It("returns nil if no snapshot is returned", func() {
+				snapshot, err := provider.FindSnapshot(ctx, "snapshot1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshot).To(BeNil())
+			})
+
+			It("returns error if the call to AWS to describe the snapshot fails", func() {
+				errorToReturn = errors.New("some error")
+
+				_, err := provider.FindSnapshot(ctx, "snapshot1")
+				Expect(err).To(MatchError("some error"))
+			})
+
+			It("returns error if the snapshot misses some data", func() {
+				describeSnapshotOutputToReturn = []elasticache.DescribeSnapshotsOutput{
+					{Snapshots: []*elasticache.Snapshot{{SnapshotName: aws.String("snapshot1")}}},
+				}
+
+				_, err := provider.FindSnapshot(ctx, "snapshot1")
+				Expect(err).To(MatchError("Invalid response from AWS: Missing values for snapshot snapshot1"))
+			})
+		})
+
+		Context("of a single replication group", func() {
+			It("returns the snapshots of the replication group with their status but without their tags", func() {
+				now := time.Now()
+				describeSnapshotOutputToReturn = []elasticache.DescribeSnapshotsOutput{
+					{
+						Snapshots: []*elasticache.Snapshot{
+							{
+								SnapshotName:   aws.String("snapshot1"),
+								SnapshotSource: aws.String("manual"),
+								SnapshotStatus: aws.String("available"),
+								NodeSnapshots: []*elasticache.NodeSnapshot{
+									{SnapshotCreateTime: aws.Time(now.Add(-2 * 24 * time.Hour))},
+								},
+							},
+						},
+					},
+					{
+						Snapshots: []*elasticache.Snapshot{
+							{
+								SnapshotName: aws.String("snapshot2"),
+								NodeSnapshots: []*elasticache.NodeSnapshot{
+									{SnapshotCreateTime: aws.Time(now.Add(-1 * 24 * time.Hour))},
+								},
+							},
+						},
+					},
+				}
+
+				snapshots, err := provider.ListSnapshots(ctx, instanceID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshots).To(Equal([]providers.SnapshotInfo{
+					{Name: "snapshot1", CreateTime: now.Add(-2 * 24 * time.Hour), Source: "manual", Status: "available"},
+					{Name: "snapshot2", CreateTime: now.Add(-1 * 24 * time.Hour)},
+				}))
+
+				_, input, _, _ := mockElasticache.DescribeSnapshotsPagesWithContextArgsForCall(0)
+				Expect(input.ReplicationGroupId).To(Equal(aws.String(replicationGroupID)))
+				Expect(mockElasticache.ListTagsForResourceWithContextCallCount()).To(Equal(0))
+			})
+
+			It("returns error if the call to AWS to list snapshots fails", func() {
+				errorToReturn = errors.New("some error")
+
+				_, err := provider.ListSnapshots(ctx, instanceID)
+				Expect(err).To(MatchError("some error"))
+			})
+
+			It("returns error if the snapshots miss some data", func() {
+				describeSnapshotOutputToReturn = []elasticache.DescribeSnapshotsOutput{
+					{Snapshots: []*elasticache.Snapshot{{}}},
+				}
+
+				_, err := provider.ListSnapshots(ctx, instanceID)
+				Expect(err).To(MatchError(
+					ContainSubstring("Invalid response from AWS: Missing values for snapshot")),
+				)
+			})
+		})
+	})
+
+	Describe("CreateSnapshot", func() {
+		BeforeEach(func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+		})
+
+		It("creates a snapshot with the tags of the replication group", func() {
+			err := provider.CreateSnapshot(ctx, instanceID, "manual-snapshot")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateSnapshotWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.CreateSnapshotWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.CreateSnapshotInput{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				SnapshotName:       aws.String("manual-snapshot"),
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}))
+		})
+
+		It("returns an error if getting the tags fails", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.CreateSnapshot(ctx, instanceID, "manual-snapshot")
+			Expect(err).To(MatchError("some error"))
+			Expect(mockElasticache.CreateSnapshotWithContextCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if creating the snapshot fails", func() {
+			mockElasticache.CreateSnapshotWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.CreateSnapshot(ctx, instanceID, "manual-snapshot")
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
+	Context("when updating", func() {
+		BeforeEach(func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{
+						ReplicationGroupId: aws.String("cf-qwkec4pxhft6q"),
+						MemberClusters:     aws.StringSlice([]string{"cf-qwkec4pxhft6q-001"}),
+					},
+				},
+			}, nil)
+			mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+				CacheClusters: []*elasticache.CacheCluster{
+					{
+						CacheClusterId: aws.String("cf-qwkec4pxhft6q-001"),
+						CacheParameterGroup: &elasticache.CacheParameterGroupStatus{
+							CacheParameterGroupName: aws.String("cf-qwkec4pxhft6q"),
+						},
+					},
+				},
+			}, nil)
+		})
+
+		It("should update the cache parameter group", func() {
+			replicationGroupID := "cf-qwkec4pxhft6q"
+			instanceID := "foobar"
+
+			ctx := context.Background()
+
+			err := provider.UpdateParamGroupParameters(ctx, instanceID, providers.UpdateParamGroupParameters{
+				Parameters: map[string]string{
 					"key1": "value1",
 					"key2": "value2",
 				},
@@ -1198,6 +1924,184 @@ var _ = Describe("Provider", func() {
 			Expect(*replicationGroupInput.PreferredMaintenanceWindow).To(Equal("asdf"))
 		})
 
+		It("should update the daily backup window", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				DailyBackupWindow: "03:00-04:00",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyReplicationGroupInput{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				SnapshotWindow:     aws.String("03:00-04:00"),
+			}))
+		})
+
+		It("should update the snapshot retention limit", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				SnapshotRetentionLimit: aws.Int64(7),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyReplicationGroupInput{
+				ReplicationGroupId:     aws.String(replicationGroupID),
+				SnapshotRetentionLimit: aws.Int64(7),
+			}))
+		})
+
+		It("should change the node type and engine version immediately", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				CacheNodeType: "cache.m5.large",
+				EngineVersion: "6.2",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, replicationGroupInput, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(replicationGroupInput).To(Equal(&elasticache.ModifyReplicationGroupInput{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				CacheNodeType:      aws.String("cache.m5.large"),
+				EngineVersion:      aws.String("6.2"),
+				ApplyImmediately:   aws.Bool(true),
+			}))
+		})
+
+		Context("when changing the number of replicas", func() {
+			BeforeEach(func() {
+				mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+					ReplicationGroups: []*elasticache.ReplicationGroup{
+						{
+							NodeGroups: []*elasticache.NodeGroup{
+								{
+									NodeGroupMembers: []*elasticache.NodeGroupMember{
+										{CacheClusterId: aws.String("cf-qwkec4pxhft6q-001")},
+										{CacheClusterId: aws.String("cf-qwkec4pxhft6q-002")},
+									},
+								},
+							},
+						},
+					},
+				}, nil)
+			})
+
+			It("should increase the replica count", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ReplicasPerNodeGroup: aws.Int64(2),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DecreaseReplicaCountWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.IncreaseReplicaCountWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.IncreaseReplicaCountWithContextArgsForCall(0)
+				Expect(input).To(Equal(&elasticache.IncreaseReplicaCountInput{
+					ReplicationGroupId: aws.String(replicationGroupID),
+					NewReplicaCount:    aws.Int64(2),
+					ApplyImmediately:   aws.Bool(true),
+				}))
+			})
+
+			It("should decrease the replica count", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ReplicasPerNodeGroup: aws.Int64(0),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mockElasticache.IncreaseReplicaCountWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DecreaseReplicaCountWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DecreaseReplicaCountWithContextArgsForCall(0)
+				Expect(input).To(Equal(&elasticache.DecreaseReplicaCountInput{
+					ReplicationGroupId: aws.String(replicationGroupID),
+					NewReplicaCount:    aws.Int64(0),
+					ApplyImmediately:   aws.Bool(true),
+				}))
+			})
+
+			It("should do nothing if the replica count is unchanged", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ReplicasPerNodeGroup: aws.Int64(1),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mockElasticache.IncreaseReplicaCountWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DecreaseReplicaCountWithContextCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when changing the number of shards", func() {
+			BeforeEach(func() {
+				mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+					ReplicationGroups: []*elasticache.ReplicationGroup{
+						{
+							NodeGroups: []*elasticache.NodeGroup{
+								{NodeGroupId: aws.String("0002")},
+								{NodeGroupId: aws.String("0001")},
+								{NodeGroupId: aws.String("0003")},
+							},
+						},
+					},
+				}, nil)
+			})
+
+			It("should add shards", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ShardCount: aws.Int64(5),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.ModifyReplicationGroupShardConfigurationWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.ModifyReplicationGroupShardConfigurationWithContextArgsForCall(0)
+				Expect(input).To(Equal(&elasticache.ModifyReplicationGroupShardConfigurationInput{
+					ReplicationGroupId: aws.String(replicationGroupID),
+					NodeGroupCount:     aws.Int64(5),
+					ApplyImmediately:   aws.Bool(true),
+				}))
+			})
+
+			It("should remove the shards with the highest IDs", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ShardCount: aws.Int64(1),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, input, _ := mockElasticache.ModifyReplicationGroupShardConfigurationWithContextArgsForCall(0)
+				Expect(input).To(Equal(&elasticache.ModifyReplicationGroupShardConfigurationInput{
+					ReplicationGroupId: aws.String(replicationGroupID),
+					NodeGroupCount:     aws.Int64(1),
+					NodeGroupsToRemove: aws.StringSlice([]string{"0002", "0003"}),
+					ApplyImmediately:   aws.Bool(true),
+				}))
+			})
+
+			It("should do nothing if the shard count is unchanged", func() {
+				err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+					ShardCount: aws.Int64(3),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mockElasticache.ModifyReplicationGroupShardConfigurationWithContextCallCount()).To(Equal(0))
+			})
+		})
+
+		It("should update the tags of the replication group", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				Tags: map[string]string{"plan-id": "plan2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.AddTagsToResourceInput{
+				ResourceName: aws.String(ExportReplicationGroupARN(provider, replicationGroupID)),
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("plan-id"), Value: aws.String("plan2")},
+				},
+			}))
+		})
+
 		It("should not update the replication group if no preferredMaintenanceWindow is passed", func() {
 			instanceID := "foobar"
 
@@ -1211,6 +2115,82 @@ var _ = Describe("Provider", func() {
 		})
 	})
 
+	Describe("RotateAuthToken", func() {
+		It("adds a new auth token to the replication group", func() {
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input.ReplicationGroupId).To(Equal(aws.String(replicationGroupID)))
+			Expect(input.AuthTokenUpdateStrategy).To(Equal(aws.String("ROTATE")))
+			Expect(input.ApplyImmediately).To(Equal(aws.Bool(true)))
+			Expect(*input.AuthToken).To(HaveLen(providers.PasswordLength))
+		})
+
+		It("stores the new auth token in the secrets manager", func() {
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, modifyInput, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(mockSecretsManager.PutSecretValueWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockSecretsManager.PutSecretValueWithContextArgsForCall(0)
+			Expect(input).To(Equal(&secretsmanager.PutSecretValueInput{
+				SecretId:     aws.String("elasticache-broker-test/foobar/auth-token"),
+				SecretString: modifyInput.AuthToken,
+			}))
+		})
+
+		It("tags the replication group so the old auth token is removed later", func() {
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(input.ResourceName).To(Equal(aws.String(ExportReplicationGroupARN(provider, replicationGroupID))))
+			Expect(input.Tags).To(HaveLen(1))
+			Expect(input.Tags[0].Key).To(Equal(aws.String("auth-token-rotation")))
+
+			phase, startedAt, ok := providers.ParseOperationTagValue(aws.StringValue(input.Tags[0].Value))
+			Expect(ok).To(BeTrue())
+			Expect(phase).To(Equal("rotating"))
+			Expect(startedAt).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+
+		It("does not rotate the auth token if tagging the replication group fails", func() {
+			mockElasticache.AddTagsToResourceWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).To(MatchError("some error"))
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+			Expect(mockSecretsManager.PutSecretValueWithContextCallCount()).To(Equal(0))
+		})
+
+		It("removes the tag and does not store the new auth token if modifying the replication group fails", func() {
+			mockElasticache.ModifyReplicationGroupWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).To(MatchError("some error"))
+			Expect(mockSecretsManager.PutSecretValueWithContextCallCount()).To(Equal(0))
+
+			Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+			Expect(input.TagKeys).To(Equal(aws.StringSlice([]string{"auth-token-rotation"})))
+		})
+
+		It("keeps the tag so the rotation is finished with the old auth token if storing the new auth token fails", func() {
+			mockSecretsManager.PutSecretValueWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.RotateAuthToken(ctx, instanceID)
+			Expect(err).To(MatchError("failed to store the new auth token: some error"))
+			Expect(errors.Is(err, providers.ErrAuthTokenNotStored)).To(BeTrue())
+
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(0))
+		})
+	})
+
 	Describe("replicationGroupARN", func() {
 		It("should generate the correct ARN string", func() {
 			arn := ExportReplicationGroupARN(provider, replicationGroupID)
@@ -1332,6 +2312,15 @@ var _ = Describe("Provider", func() {
 				Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
 				Expect(mockElasticache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
 
+				Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+				_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+				Expect(tagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-qwkec4pxhft6q")))
+				Expect(tagsInput.Tags).To(HaveLen(1))
+				Expect(tagsInput.Tags[0].Key).To(Equal(aws.String(providers.FailoverTestStartedTag)))
+				startedAt, err := time.Parse(time.RFC3339, aws.StringValue(tagsInput.Tags[0].Value))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(startedAt).To(BeTemporally("~", time.Now(), 2*time.Second))
+
 				receivedCtx, replicationGroupInput, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
 				Expect(receivedCtx).To(Equal(ctx))
 				Expect(replicationGroupInput.ApplyImmediately).To(Equal(aws.Bool(true)))
@@ -1373,6 +2362,11 @@ var _ = Describe("Provider", func() {
 				Expect(replicationGroupInput.AutomaticFailoverEnabled).To(Equal(aws.Bool(true)))
 				Expect(replicationGroupInput.MultiAZEnabled).To(Equal(aws.Bool(true)))
 				Expect(replicationGroupInput.ReplicationGroupId).To(Equal(aws.String(replicationGroupID)))
+
+				Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+				_, removeTagsInput, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+				Expect(removeTagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-qwkec4pxhft6q")))
+				Expect(removeTagsInput.TagKeys).To(Equal(aws.StringSlice([]string{providers.FailoverTestStartedTag})))
 			})
 		})
 
@@ -1407,6 +2401,592 @@ var _ = Describe("Provider", func() {
 
 	})
 
+	Describe("RestoreHighAvailability", func() {
+		var replicationGroup *elasticache.ReplicationGroup
+
+		BeforeEach(func() {
+			replicationGroup = &elasticache.ReplicationGroup{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				Status:             aws.String("available"),
+				AutomaticFailover:  aws.String("disabled"),
+				MultiAZ:            aws.String("disabled"),
+			}
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{replicationGroup},
+			}, nil)
+		})
+
+		It("enables automatic failover and Multi-AZ", func() {
+			restored, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restored).To(BeTrue())
+
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyReplicationGroupInput{
+				AutomaticFailoverEnabled: aws.Bool(true),
+				MultiAZEnabled:           aws.Bool(true),
+				ReplicationGroupId:       aws.String(replicationGroupID),
+				ApplyImmediately:         aws.Bool(true),
+			}))
+		})
+
+		It("removes the tag of the test failover", func() {
+			_, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+			Expect(input.TagKeys).To(Equal(aws.StringSlice([]string{providers.FailoverTestStartedTag})))
+		})
+
+		It("doesn't remove the tag of the test failover if enabling them fails", func() {
+			mockElasticache.ModifyReplicationGroupWithContextReturns(nil, errors.New("some error"))
+
+			_, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).To(MatchError("some error"))
+			Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(0))
+		})
+
+		It("enables Multi-AZ if only Multi-AZ is disabled", func() {
+			replicationGroup.AutomaticFailover = aws.String("enabled")
+
+			restored, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restored).To(BeTrue())
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+		})
+
+		It("does nothing if automatic failover and Multi-AZ are enabled", func() {
+			replicationGroup.AutomaticFailover = aws.String("enabled")
+			replicationGroup.MultiAZ = aws.String("enabled")
+
+			restored, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restored).To(BeFalse())
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+		})
+
+		It("removes the tag of a test failover which has timed out if they are enabled", func() {
+			replicationGroup.AutomaticFailover = aws.String("enabled")
+			replicationGroup.MultiAZ = aws.String("enabled")
+
+			_, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+		})
+
+		It("does nothing if automatic failover and Multi-AZ are being enabled", func() {
+			replicationGroup.Status = aws.String("modifying")
+			replicationGroup.AutomaticFailover = aws.String("enabling")
+			replicationGroup.MultiAZ = aws.String("enabling")
+
+			restored, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restored).To(BeFalse())
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+		})
+
+		It("fails if the replication group is not available", func() {
+			replicationGroup.Status = aws.String("modifying")
+
+			_, err := provider.RestoreHighAvailability(ctx, instanceID)
+			Expect(err).To(MatchError("Replication group cf-qwkec4pxhft6q is modifying"))
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("FindDegradedInstances", func() {
+		var replicationGroups []*elasticache.ReplicationGroup
+
+		tagList := func(createdBy, instanceID string) *elasticache.TagListMessage {
+			return &elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("created-by"), Value: aws.String(createdBy)},
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+					{Key: aws.String("plan-id"), Value: aws.String("plan1")},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			replicationGroups = []*elasticache.ReplicationGroup{
+				{
+					ARN:               aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-1"),
+					Status:            aws.String("available"),
+					AutomaticFailover: aws.String("disabled"),
+					MultiAZ:           aws.String("disabled"),
+				},
+				{
+					ARN:               aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-2"),
+					Status:            aws.String("available"),
+					AutomaticFailover: aws.String("enabled"),
+					MultiAZ:           aws.String("enabled"),
+				},
+				{
+					ARN:               aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-3"),
+					Status:            aws.String("modifying"),
+					AutomaticFailover: aws.String("disabled"),
+					MultiAZ:           aws.String("disabled"),
+				},
+				{
+					ARN:               aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-4"),
+					Status:            aws.String("available"),
+					AutomaticFailover: aws.String("enabled"),
+					MultiAZ:           aws.String("disabled"),
+				},
+			}
+			mockElasticache.DescribeReplicationGroupsPagesWithContextStub = func(
+				ctx context.Context,
+				input *elasticache.DescribeReplicationGroupsInput,
+				fn func(*elasticache.DescribeReplicationGroupsOutput, bool) bool,
+				opts ...request.Option,
+			) error {
+				fn(&elasticache.DescribeReplicationGroupsOutput{ReplicationGroups: replicationGroups[:2]}, false)
+				fn(&elasticache.DescribeReplicationGroupsOutput{ReplicationGroups: replicationGroups[2:]}, true)
+				return nil
+			}
+		})
+
+		It("returns the available instances of the broker with automatic failover or Multi-AZ disabled", func() {
+			mockElasticache.ListTagsForResourceWithContextReturnsOnCall(0, tagList("test-broker", "instance1"), nil)
+			mockElasticache.ListTagsForResourceWithContextReturnsOnCall(1, tagList("test-broker", "instance4"), nil)
+
+			instances, err := provider.FindDegradedInstances(ctx, "test-broker")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(Equal([]providers.DegradedInstance{
+				{InstanceID: "instance1", Tags: map[string]string{"created-by": "test-broker", "instance-id": "instance1", "plan-id": "plan1"}},
+				{InstanceID: "instance4", Tags: map[string]string{"created-by": "test-broker", "instance-id": "instance4", "plan-id": "plan1"}},
+			}))
+
+			Expect(mockElasticache.ListTagsForResourceWithContextCallCount()).To(Equal(2))
+			_, input, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+			Expect(input.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-1")))
+			_, input, _ = mockElasticache.ListTagsForResourceWithContextArgsForCall(1)
+			Expect(input.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:replicationgroup:cf-4")))
+		})
+
+		It("ignores the instances of other brokers", func() {
+			mockElasticache.ListTagsForResourceWithContextReturnsOnCall(0, tagList("other-broker", "instance1"), nil)
+			mockElasticache.ListTagsForResourceWithContextReturnsOnCall(1, &elasticache.TagListMessage{}, nil)
+
+			instances, err := provider.FindDegradedInstances(ctx, "test-broker")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+		})
+
+		It("returns the error of listing the replication groups", func() {
+			mockElasticache.DescribeReplicationGroupsPagesWithContextStub = nil
+			mockElasticache.DescribeReplicationGroupsPagesWithContextReturns(errors.New("some error"))
+
+			_, err := provider.FindDegradedInstances(ctx, "test-broker")
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
+	Describe("TestFailover", func() {
+		var replicationGroup *elasticache.ReplicationGroup
+
+		BeforeEach(func() {
+			replicationGroup = &elasticache.ReplicationGroup{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				Status:             aws.String("available"),
+				AutomaticFailover:  aws.String("enabled"),
+				NodeGroups: []*elasticache.NodeGroup{
+					{NodeGroupId: aws.String("0002")},
+					{NodeGroupId: aws.String("0001")},
+				},
+			}
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{replicationGroup},
+			}, nil)
+		})
+
+		It("fails over the given node group", func() {
+			nodeGroupID, err := provider.TestFailover(ctx, instanceID, "0002")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeGroupID).To(Equal("0002"))
+
+			Expect(mockElasticache.TestFailoverWithContextCallCount()).To(Equal(1))
+			receivedCtx, input, _ := mockElasticache.TestFailoverWithContextArgsForCall(0)
+			Expect(receivedCtx).To(Equal(ctx))
+			Expect(input).To(Equal(&elasticache.TestFailoverInput{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				NodeGroupId:        aws.String("0002"),
+			}))
+		})
+
+		It("fails over the first node group if no node group is given", func() {
+			nodeGroupID, err := provider.TestFailover(ctx, instanceID, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeGroupID).To(Equal("0001"))
+
+			_, input, _ := mockElasticache.TestFailoverWithContextArgsForCall(0)
+			Expect(input.NodeGroupId).To(Equal(aws.String("0001")))
+		})
+
+		It("fails if the node group doesn't exist", func() {
+			_, err := provider.TestFailover(ctx, instanceID, "0003")
+			Expect(err).To(MatchError("Node group 0003 not found, the node groups of the instance are: 0001, 0002"))
+			Expect(mockElasticache.TestFailoverWithContextCallCount()).To(Equal(0))
+		})
+
+		It("fails if automatic failover is not enabled", func() {
+			replicationGroup.AutomaticFailover = aws.String("disabled")
+
+			_, err := provider.TestFailover(ctx, instanceID, "")
+			Expect(err).To(MatchError("Test failover requires automatic failover to be enabled"))
+			Expect(mockElasticache.TestFailoverWithContextCallCount()).To(Equal(0))
+		})
+
+		It("returns the error of the TestFailover call", func() {
+			awsErr := awserr.New(elasticache.ErrCodeTestFailoverNotAvailableFault, "not available", nil)
+			mockElasticache.TestFailoverWithContextReturns(nil, awsErr)
+
+			_, err := provider.TestFailover(ctx, instanceID, "")
+			Expect(err).To(MatchError(awsErr))
+		})
+	})
+
+	Describe("FailoverTestState", func() {
+		var (
+			replicationGroup *elasticache.ReplicationGroup
+			startTime        time.Time
+			events           []*elasticache.Event
+		)
+
+		event := func(minutes int, sourceType, sourceID, message string) *elasticache.Event {
+			return &elasticache.Event{
+				Date:             aws.Time(startTime.Add(time.Duration(minutes) * time.Minute)),
+				SourceIdentifier: aws.String(sourceID),
+				SourceType:       aws.String(sourceType),
+				Message:          aws.String(message),
+			}
+		}
+		groupEvent := func(minutes int, message string) *elasticache.Event {
+			return event(minutes, elasticache.SourceTypeReplicationGroup, replicationGroupID, message)
+		}
+		nodeEvent := func(minutes int, cacheClusterID, message string) *elasticache.Event {
+			return event(minutes, elasticache.SourceTypeCacheCluster, cacheClusterID, message)
+		}
+
+		BeforeEach(func() {
+			startTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			replicationGroup = &elasticache.ReplicationGroup{
+				ReplicationGroupId: aws.String(replicationGroupID),
+				Status:             aws.String("available"),
+			}
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{replicationGroup},
+			}, nil)
+			events = []*elasticache.Event{}
+			// Like AWS, only the events of the requested source are returned
+			mockElasticache.DescribeEventsPagesWithContextStub = func(
+				ctx context.Context,
+				input *elasticache.DescribeEventsInput,
+				fn func(*elasticache.DescribeEventsOutput, bool) bool,
+				opts ...request.Option,
+			) error {
+				sourceEvents := []*elasticache.Event{}
+				for _, event := range events {
+					if *event.SourceType == *input.SourceType && *event.SourceIdentifier == *input.SourceIdentifier {
+						sourceEvents = append(sourceEvents, event)
+					}
+				}
+				fn(&elasticache.DescribeEventsOutput{Events: sourceEvents}, true)
+				return nil
+			}
+		})
+
+		It("reads the events of the replication group since the start of the failover", func() {
+			_, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.DescribeEventsPagesWithContextCallCount()).To(Equal(1))
+			_, input, _, _ := mockElasticache.DescribeEventsPagesWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.DescribeEventsInput{
+				SourceType:       aws.String(elasticache.SourceTypeReplicationGroup),
+				SourceIdentifier: aws.String(replicationGroupID),
+				StartTime:        aws.Time(startTime),
+			}))
+		})
+
+		It("is modifying until the failover has completed", func() {
+			events = append(events, groupEvent(1, "Test Failover API called for node group 0001"))
+
+			state, message, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Modifying))
+			Expect(message).To(ContainSubstring("node group           : 0001"))
+			Expect(message).To(ContainSubstring("Test Failover API called for node group 0001"))
+		})
+
+		It("is modifying until the old primary has been recovered", func() {
+			events = append(events,
+				groupEvent(1, "Test Failover API called for node group 0001"),
+				groupEvent(2, "Failover from primary node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 completed"),
+				nodeEvent(3, "cf-qwkec4pxhft6q-0001-001", "Recovering cache nodes 0001"),
+			)
+
+			state, message, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Modifying))
+			Expect(message).To(ContainSubstring("Recovering cache nodes 0001"))
+		})
+
+		It("reads the events of the old primary once the failover has completed", func() {
+			events = append(events,
+				groupEvent(2, "Failover from primary node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 completed"),
+			)
+
+			_, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.DescribeEventsPagesWithContextCallCount()).To(Equal(2))
+			_, input, _, _ := mockElasticache.DescribeEventsPagesWithContextArgsForCall(1)
+			Expect(input).To(Equal(&elasticache.DescribeEventsInput{
+				SourceType:       aws.String(elasticache.SourceTypeCacheCluster),
+				SourceIdentifier: aws.String("cf-qwkec4pxhft6q-0001-001"),
+				StartTime:        aws.Time(startTime),
+			}))
+		})
+
+		It("is available once the old primary has been recovered", func() {
+			events = append(events,
+				nodeEvent(8, "cf-qwkec4pxhft6q-0001-001", "Finished recovery for cache nodes 0001"),
+				groupEvent(2, "Failover from master node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 completed"),
+				groupEvent(1, "Test Failover API called for node group 0001"),
+			)
+
+			state, message, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Available))
+			Expect(message).To(MatchRegexp("(?s)Test Failover API called.*Failover from master node.*Finished recovery"))
+		})
+
+		It("is modifying while the replication group is not available", func() {
+			replicationGroup.Status = aws.String("modifying")
+			events = append(events,
+				groupEvent(2, "Failover from primary node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 completed"),
+				nodeEvent(8, "cf-qwkec4pxhft6q-0001-001", "Finished recovery for cache nodes 0001"),
+			)
+
+			state, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Modifying))
+		})
+
+		It("ignores the recovery of other nodes", func() {
+			events = append(events,
+				groupEvent(2, "Failover from primary node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 completed"),
+				nodeEvent(8, "cf-qwkec4pxhft6q-0001-003", "Finished recovery for cache nodes 0001"),
+			)
+
+			state, message, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Modifying))
+			Expect(message).ToNot(ContainSubstring("Finished recovery"))
+		})
+
+		It("returns failover failed if the failover failed", func() {
+			events = append(events, groupEvent(2, "Failover from master node cf-qwkec4pxhft6q-0001-001 to replica node cf-qwkec4pxhft6q-0001-002 failed"))
+
+			state, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.FailoverFailed))
+		})
+
+		It("doesn't mistake other events mentioning a failure for a failed failover", func() {
+			events = append(events,
+				groupEvent(1, "Test Failover API called for node group 0001"),
+				groupEvent(2, "Snapshot failed for snapshot cf-qwkec4pxhft6q-manual"),
+			)
+
+			state, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Modifying))
+		})
+
+		It("returns the error of describing the events", func() {
+			mockElasticache.DescribeEventsPagesWithContextStub = nil
+			mockElasticache.DescribeEventsPagesWithContextReturns(errors.New("some error"))
+
+			_, _, err := provider.FailoverTestState(ctx, instanceID, "0001", startTime)
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
+	Describe("FindInstance", func() {
+		It("returns the state and the tags of the replication group", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{ReplicationGroupId: aws.String(replicationGroupID), Status: aws.String("creating")},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{Key: aws.String("plan-id"), Value: aws.String("plan1")}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(Equal(&providers.ExistingInstance{
+				State: providers.Creating,
+				Tags:  map[string]string{"plan-id": "plan1"},
+			}))
+		})
+
+		It("returns modifying if a node group is being modified", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{
+						ReplicationGroupId: aws.String(replicationGroupID),
+						Status:             aws.String("available"),
+						NodeGroups: []*elasticache.NodeGroup{
+							{NodeGroupId: aws.String("0001"), Status: aws.String("available")},
+							{NodeGroupId: aws.String("0002"), Status: aws.String("modifying")},
+						},
+					},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.State).To(Equal(providers.Modifying))
+			Expect(instance.Operation).To(BeEmpty())
+		})
+
+		It("returns a test failover which disabled automatic failover", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{
+						ReplicationGroupId: aws.String(replicationGroupID),
+						Status:             aws.String("available"),
+						AutomaticFailover:  aws.String("disabled"),
+						MultiAZ:            aws.String("disabled"),
+					},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String(providers.FailoverTestStartedTag), Value: aws.String("2024-05-01T12:00:00Z")},
+				},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.State).To(Equal(providers.Available))
+			Expect(instance.Operation).To(Equal("test failover"))
+		})
+
+		It("ignores the tag of a test failover which has finished", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{
+						ReplicationGroupId: aws.String(replicationGroupID),
+						Status:             aws.String("available"),
+						AutomaticFailover:  aws.String("enabled"),
+						MultiAZ:            aws.String("enabled"),
+					},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String(providers.FailoverTestStartedTag), Value: aws.String("2024-05-01T12:00:00Z")},
+				},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Operation).To(BeEmpty())
+		})
+
+		It("returns an auth token rotation", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{ReplicationGroupId: aws.String(replicationGroupID), Status: aws.String("available")},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{Key: aws.String("auth-token-rotation"), Value: aws.String("rotating")}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Operation).To(Equal("auth token rotation"))
+		})
+
+		It("ignores an auth token rotation which has timed out", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{ReplicationGroupId: aws.String(replicationGroupID), Status: aws.String("available")},
+				},
+			}, nil)
+			startedAt := time.Now().Add(-AuthTokenRotationTimeout - time.Minute)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{
+					Key:   aws.String("auth-token-rotation"),
+					Value: aws.String(providers.OperationTagValue("rotating", startedAt)),
+				}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Operation).To(BeEmpty())
+		})
+
+		It("returns a broker operation between two of its steps", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{ReplicationGroupId: aws.String(replicationGroupID), Status: aws.String("available")},
+				},
+			}, nil)
+			startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{
+					Key:   aws.String(providers.OperationInProgressTag),
+					Value: aws.String(providers.OperationTagValue("changing-plan", startedAt)),
+				}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Operation).To(Equal("changing-plan"))
+			Expect(instance.OperationStartedAt).To(Equal(startedAt))
+		})
+
+		It("ignores the tag of a broker operation which has finished", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{ReplicationGroupId: aws.String(replicationGroupID), Status: aws.String("available")},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{Key: aws.String(providers.OperationInProgressTag), Value: aws.String("")}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Operation).To(BeEmpty())
+		})
+
+		It("returns nil if the replication group doesn't exist", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(nil,
+				awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil))
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(BeNil())
+		})
+
+		It("returns other errors", func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(nil, errors.New("some error"))
+
+			_, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
 	Describe("GetInstanceParameters", func() {
 		BeforeEach(func() {
 
@@ -1472,6 +3052,8 @@ var _ = Describe("Provider", func() {
 				ActiveNodes:                []string{"cf-qwkec4pxhft6q-001"},
 				PassiveNodes:               []string{"cf-qwkec4pxhft6q-002"},
 				AutoFailover:               true,
+				ReplicasPerNodeGroup:       1,
+				ShardCount:                 1,
 				CacheParameters: []providers.CacheParameter{
 					{
 						ParameterName:  "some-parameter-name",
@@ -1504,6 +3086,8 @@ var _ = Describe("Provider", func() {
 				ActiveNodes:                []string{"cf-qwkec4pxhft6q-001"},
 				PassiveNodes:               []string{"cf-qwkec4pxhft6q-002"},
 				AutoFailover:               true,
+				ReplicasPerNodeGroup:       1,
+				ShardCount:                 1,
 				CacheParameters: []providers.CacheParameter{
 					{
 						ParameterName:  "some-parameter-name",
@@ -1548,6 +3132,22 @@ var _ = Describe("Provider", func() {
 			}))
 
 		})
+		It("returns the engine version of a pending upgrade", func() {
+			mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+				CacheClusters: []*elasticache.CacheCluster{
+					{
+						CacheClusterId:        aws.String("some-cluster-id"),
+						EngineVersion:         aws.String("6.2.6"),
+						PendingModifiedValues: &elasticache.PendingModifiedValues{EngineVersion: aws.String("7.1")},
+					},
+				},
+			}, nil)
+
+			instanceParams, err := provider.GetInstanceParameters(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceParams.EngineVersion).To(Equal("6.2.6"))
+			Expect(instanceParams.PendingEngineVersion).To(Equal("7.1"))
+		})
 		It("throws an error if the replication group has no members", func() {
 			describeReplicationGroupsOutput := &elasticache.DescribeReplicationGroupsOutput{
 				ReplicationGroups: []*elasticache.ReplicationGroup{{MemberClusters: nil}},
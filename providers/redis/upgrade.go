@@ -0,0 +1,234 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+)
+
+// UpgradeEngineVersion upgrades the engine of a replication group, or migrates it to a different engine
+//
+// The target version can belong to a different cache parameter group family, so the replication group is moved to a
+// new parameter group created for the target family, with the same parameters as the current one. The old parameter
+// group is still in use until the modification finishes, so it has to be deleted later by
+// DeleteUnusedCacheParameterGroups. Upgrades of the same engine have to target a newer version than the current one.
+// The tags are added before the modification is requested and removed again if it fails.
+func (p *RedisProvider) UpgradeEngineVersion(ctx context.Context, instanceID string, params providers.UpgradeEngineVersionParameters) error {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return err
+	}
+	cacheCluster, err := p.describeFirstMemberCluster(ctx, replicationGroup)
+	if err != nil {
+		return err
+	}
+	currentGroupName := cacheParameterGroupName(cacheCluster, replicationGroupID)
+
+	currentVersion := aws.StringValue(cacheCluster.EngineVersion)
+	if params.Engine == "" && providers.CompareEngineVersions(params.EngineVersion, currentVersion) <= 0 {
+		return providers.NotSupportedError(fmt.Sprintf("Engine version %s is not newer than the current version %s", params.EngineVersion, currentVersion))
+	}
+
+	input := &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+		EngineVersion:      aws.String(params.EngineVersion),
+		ApplyImmediately:   aws.Bool(true),
+	}
+
+	cacheParameterGroupFamily := upgradeCacheParameterGroupFamily(params)
+	newGroupName := UpgradedCacheParameterGroupName(replicationGroupID, cacheParameterGroupFamily)
+	if newGroupName != currentGroupName {
+		err = p.copyCacheParameterGroup(ctx, currentGroupName, newGroupName, cacheParameterGroupFamily)
+		if err != nil {
+			return err
+		}
+		input.SetCacheParameterGroupName(newGroupName)
+	}
+
+	// The replication group is tagged before the modification, so an upgrade which has started is always tracked
+	err = p.addTags(ctx, replicationGroupID, params.Tags)
+	if err != nil {
+		p.deleteUpgradeCacheParameterGroup(ctx, instanceID, input)
+		return err
+	}
+
+	opts := []request.Option{}
+	if params.Engine != "" {
+		opts = append(opts, withEngine(params.Engine))
+	}
+	_, err = p.elastiCache.ModifyReplicationGroupWithContext(ctx, input, opts...)
+	if err != nil {
+		if len(params.Tags) > 0 {
+			tagKeys := []string{}
+			for tagKey := range params.Tags {
+				tagKeys = append(tagKeys, tagKey)
+			}
+			if removeErr := p.removeTags(ctx, replicationGroupID, tagKeys...); removeErr != nil {
+				p.logger.Error("upgrade-engine-version-remove-tags", removeErr, lager.Data{
+					"instance-id":          instanceID,
+					"replication-group-id": replicationGroupID,
+				})
+			}
+		}
+		p.deleteUpgradeCacheParameterGroup(ctx, instanceID, input)
+		return err
+	}
+	return nil
+}
+
+// deleteUpgradeCacheParameterGroup deletes the parameter group created for an upgrade which couldn't be started
+func (p *RedisProvider) deleteUpgradeCacheParameterGroup(ctx context.Context, instanceID string, input *elasticache.ModifyReplicationGroupInput) {
+	if input.CacheParameterGroupName == nil {
+		return
+	}
+	if err := p.deleteCacheParameterGroup(ctx, *input.CacheParameterGroupName); err != nil {
+		p.logger.Error("delete-cache-parameter-group", err, lager.Data{
+			"instance-id":                instanceID,
+			"cache-parameter-group-name": *input.CacheParameterGroupName,
+		})
+	}
+}
+
+// DeleteUnusedCacheParameterGroups deletes the parameter groups of an instance which are not used any more
+// It should be called when an engine version upgrade has finished.
+func (p *RedisProvider) DeleteUnusedCacheParameterGroups(ctx context.Context, instanceID string) error {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+
+	replicationGroup, err := p.describeReplicationGroup(ctx, replicationGroupID)
+	if err != nil {
+		return err
+	}
+	currentGroupName, err := p.getCacheParameterGroupName(ctx, replicationGroup)
+	if err != nil {
+		return err
+	}
+
+	for _, groupName := range p.cacheParameterGroupNames(replicationGroupID) {
+		if groupName == currentGroupName {
+			continue
+		}
+		p.logger.Info("delete-unused-cache-parameter-group", lager.Data{
+			"instance-id":                instanceID,
+			"cache-parameter-group-name": groupName,
+		})
+		err = p.deleteCacheParameterGroup(ctx, groupName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpgradedCacheParameterGroupName returns the name of the parameter group created by an engine version upgrade
+func UpgradedCacheParameterGroupName(replicationGroupID, cacheParameterGroupFamily string) string {
+	return replicationGroupID + "-" + strings.ReplaceAll(cacheParameterGroupFamily, ".", "-")
+}
+
+// upgradeCacheParameterGroupFamily returns the parameter group family of an upgrade, which defaults to the family of
+// the target engine version
+func upgradeCacheParameterGroupFamily(params providers.UpgradeEngineVersionParameters) string {
+	if params.CacheParameterGroupFamily != "" {
+		return params.CacheParameterGroupFamily
+	}
+	return DefaultCacheParameterGroupFamily(params.Engine, params.EngineVersion)
+}
+
+// copyCacheParameterGroup creates a parameter group in the given family with the parameters set on the source group
+func (p *RedisProvider) copyCacheParameterGroup(ctx context.Context, sourceName, targetName, cacheParameterGroupFamily string) error {
+	parameters, err := p.describeAllCacheParameters(ctx, &elasticache.DescribeCacheParametersInput{
+		CacheParameterGroupName: aws.String(sourceName),
+		Source:                  aws.String("user"),
+	})
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{}
+	for _, param := range parameters {
+		if param.ParameterName != nil && param.ParameterValue != nil {
+			params[*param.ParameterName] = *param.ParameterValue
+		}
+	}
+
+	_, err = p.elastiCache.CreateCacheParameterGroupWithContext(ctx, &elasticache.CreateCacheParameterGroupInput{
+		CacheParameterGroupFamily: aws.String(cacheParameterGroupFamily),
+		CacheParameterGroupName:   aws.String(targetName),
+		Description:               aws.String("Created by Cloud Foundry"),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = p.modifyCacheParameterGroup(ctx, targetName, params)
+	if err != nil {
+		if deleteErr := p.deleteCacheParameterGroup(ctx, targetName); deleteErr != nil {
+			p.logger.Error("delete-cache-parameter-group", deleteErr, lager.Data{
+				"cache-parameter-group-name": targetName,
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+// getCacheParameterGroupName returns the name of the parameter group used by a replication group
+func (p *RedisProvider) getCacheParameterGroupName(ctx context.Context, replicationGroup *elasticache.ReplicationGroup) (string, error) {
+	cacheCluster, err := p.describeFirstMemberCluster(ctx, replicationGroup)
+	if err != nil {
+		return "", err
+	}
+	return cacheParameterGroupName(cacheCluster, aws.StringValue(replicationGroup.ReplicationGroupId)), nil
+}
+
+// describeFirstMemberCluster returns the first cache cluster of a replication group, every cluster of a replication
+// group has the same parameter group and engine version
+func (p *RedisProvider) describeFirstMemberCluster(ctx context.Context, replicationGroup *elasticache.ReplicationGroup) (*elasticache.CacheCluster, error) {
+	if len(replicationGroup.MemberClusters) == 0 || replicationGroup.MemberClusters[0] == nil {
+		return nil, fmt.Errorf("Replication group does not have any member clusters: %s", aws.StringValue(replicationGroup.ReplicationGroupId))
+	}
+	return p.describeCacheCluster(ctx, *replicationGroup.MemberClusters[0])
+}
+
+// cacheParameterGroupName returns the parameter group of a cache cluster
+// Parameter groups are named after the replication group, unless the engine version was upgraded.
+func cacheParameterGroupName(cacheCluster *elasticache.CacheCluster, replicationGroupID string) string {
+	if cacheCluster.CacheParameterGroup != nil && cacheCluster.CacheParameterGroup.CacheParameterGroupName != nil {
+		return *cacheCluster.CacheParameterGroup.CacheParameterGroupName
+	}
+	return replicationGroupID
+}
+
+// cacheParameterGroupNames returns the names of the parameter groups an instance can have: the one created with the
+// replication group, and one for every engine version it can be upgraded to
+// The parameter groups which don't exist are ignored when they are deleted, so listing every parameter group of the
+// account isn't needed.
+func (p *RedisProvider) cacheParameterGroupNames(replicationGroupID string) []string {
+	groupNames := []string{replicationGroupID}
+	seen := map[string]bool{replicationGroupID: true}
+	for _, target := range p.upgradeTargets {
+		groupName := UpgradedCacheParameterGroupName(replicationGroupID, upgradeCacheParameterGroupFamily(target))
+		if !seen[groupName] {
+			seen[groupName] = true
+			groupNames = append(groupNames, groupName)
+		}
+	}
+	return groupNames
+}
+
+func (p *RedisProvider) deleteCacheParameterGroup(ctx context.Context, cacheParameterGroupName string) error {
+	_, err := p.elastiCache.DeleteCacheParameterGroupWithContext(ctx, &elasticache.DeleteCacheParameterGroupInput{
+		CacheParameterGroupName: aws.String(cacheParameterGroupName),
+	})
+	if err != nil && !providers.IsAWSError(err, elasticache.ErrCodeCacheParameterGroupNotFoundFault) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	. "github.com/alphagov/paas-elasticache-broker/providers/redis"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Engine version upgrades", func() {
+	var (
+		mockElasticache    *mocks.FakeElastiCache
+		mockSecretsManager *mocks.FakeSecretsManager
+		ctx                context.Context
+		instanceID         string
+		replicationGroupID string
+		currentGroupName   string
+		currentVersion     string
+
+		provider *RedisProvider
+	)
+
+	BeforeEach(func() {
+		mockElasticache = &mocks.FakeElastiCache{}
+		mockSecretsManager = &mocks.FakeSecretsManager{}
+		ctx = context.Background()
+		instanceID = "foobar"
+		replicationGroupID = "cf-qwkec4pxhft6q"
+		currentGroupName = replicationGroupID
+		currentVersion = "6.2.6"
+	})
+
+	JustBeforeEach(func() {
+		mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+			ReplicationGroups: []*elasticache.ReplicationGroup{
+				{
+					ReplicationGroupId: aws.String(replicationGroupID),
+					MemberClusters:     aws.StringSlice([]string{replicationGroupID + "-001"}),
+				},
+			},
+		}, nil)
+		mockElasticache.DescribeCacheClustersWithContextReturns(&elasticache.DescribeCacheClustersOutput{
+			CacheClusters: []*elasticache.CacheCluster{
+				{
+					CacheClusterId: aws.String(replicationGroupID + "-001"),
+					EngineVersion:  aws.String(currentVersion),
+					CacheParameterGroup: &elasticache.CacheParameterGroupStatus{
+						CacheParameterGroupName: aws.String(currentGroupName),
+					},
+				},
+			},
+		}, nil)
+
+		provider = NewProvider(
+			mockElasticache,
+			mockSecretsManager,
+			"123456789012",
+			"aws",
+			"eu-west-1",
+			lager.NewLogger("logger"),
+			"my-kms-key",
+			"elasticache-broker-test",
+			[]providers.UpgradeEngineVersionParameters{
+				{EngineVersion: "7.0", CacheParameterGroupFamily: "redis7"},
+				{EngineVersion: "7.1", CacheParameterGroupFamily: "redis7"},
+				{EngineVersion: "6.2", CacheParameterGroupFamily: "redis6.x"},
+				{Engine: "valkey", EngineVersion: "8.0"},
+			},
+		)
+	})
+
+	Context("when upgrading the engine version", func() {
+		var upgradeErr error
+
+		BeforeEach(func() {
+			mockElasticache.DescribeCacheParametersWithContextReturns(&elasticache.DescribeCacheParametersOutput{
+				Parameters: []*elasticache.Parameter{
+					{
+						ParameterName:  aws.String("maxmemory-policy"),
+						ParameterValue: aws.String("allkeys-lru"),
+					},
+					{
+						ParameterName:  aws.String("cluster-enabled"),
+						ParameterValue: aws.String("no"),
+					},
+				},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			upgradeErr = provider.UpgradeEngineVersion(ctx, instanceID, providers.UpgradeEngineVersionParameters{
+				EngineVersion:             "7.1",
+				CacheParameterGroupFamily: "redis7",
+				Tags:                      map[string]string{providers.OperationInProgressTag: "upgrading-engine-version"},
+			})
+		})
+
+		It("creates a parameter group for the new family with the user set parameters", func() {
+			Expect(upgradeErr).ToNot(HaveOccurred())
+
+			_, describeInput, _ := mockElasticache.DescribeCacheParametersWithContextArgsForCall(0)
+			Expect(describeInput).To(Equal(&elasticache.DescribeCacheParametersInput{
+				CacheParameterGroupName: aws.String(replicationGroupID),
+				Source:                  aws.String("user"),
+			}))
+
+			Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(1))
+			_, createInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
+			Expect(createInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			Expect(createInput.CacheParameterGroupFamily).To(Equal(aws.String("redis7")))
+
+			Expect(mockElasticache.ModifyCacheParameterGroupWithContextCallCount()).To(Equal(1))
+			_, modifyInput, _ := mockElasticache.ModifyCacheParameterGroupWithContextArgsForCall(0)
+			Expect(modifyInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			Expect(modifyInput.ParameterNameValues).To(ConsistOf(
+				&elasticache.ParameterNameValue{
+					ParameterName:  aws.String("maxmemory-policy"),
+					ParameterValue: aws.String("allkeys-lru"),
+				},
+				&elasticache.ParameterNameValue{
+					ParameterName:  aws.String("cluster-enabled"),
+					ParameterValue: aws.String("no"),
+				},
+			))
+		})
+
+		Context("when the parameters don't fit on one page", func() {
+			BeforeEach(func() {
+				mockElasticache.DescribeCacheParametersWithContextReturnsOnCall(0, &elasticache.DescribeCacheParametersOutput{
+					Parameters: []*elasticache.Parameter{
+						{ParameterName: aws.String("maxmemory-policy"), ParameterValue: aws.String("allkeys-lru")},
+					},
+					Marker: aws.String("page-2"),
+				}, nil)
+				mockElasticache.DescribeCacheParametersWithContextReturnsOnCall(1, &elasticache.DescribeCacheParametersOutput{
+					Parameters: []*elasticache.Parameter{
+						{ParameterName: aws.String("notify-keyspace-events"), ParameterValue: aws.String("Ex")},
+					},
+				}, nil)
+			})
+
+			It("copies the parameters of every page", func() {
+				Expect(upgradeErr).ToNot(HaveOccurred())
+
+				Expect(mockElasticache.DescribeCacheParametersWithContextCallCount()).To(Equal(2))
+				_, firstInput, _ := mockElasticache.DescribeCacheParametersWithContextArgsForCall(0)
+				Expect(firstInput.Marker).To(BeNil())
+				_, secondInput, _ := mockElasticache.DescribeCacheParametersWithContextArgsForCall(1)
+				Expect(secondInput).To(Equal(&elasticache.DescribeCacheParametersInput{
+					CacheParameterGroupName: aws.String(replicationGroupID),
+					Source:                  aws.String("user"),
+					Marker:                  aws.String("page-2"),
+				}))
+
+				_, modifyInput, _ := mockElasticache.ModifyCacheParameterGroupWithContextArgsForCall(0)
+				Expect(modifyInput.ParameterNameValues).To(ConsistOf(
+					&elasticache.ParameterNameValue{
+						ParameterName:  aws.String("maxmemory-policy"),
+						ParameterValue: aws.String("allkeys-lru"),
+					},
+					&elasticache.ParameterNameValue{
+						ParameterName:  aws.String("notify-keyspace-events"),
+						ParameterValue: aws.String("Ex"),
+					},
+				))
+			})
+		})
+
+		It("modifies the replication group to use the new version and parameter group", func() {
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyReplicationGroupInput{
+				ReplicationGroupId:      aws.String(replicationGroupID),
+				EngineVersion:           aws.String("7.1"),
+				CacheParameterGroupName: aws.String(replicationGroupID + "-redis7"),
+				ApplyImmediately:        aws.Bool(true),
+			}))
+		})
+
+		Context("when tagging the replication group", func() {
+			var taggedBeforeModification bool
+
+			BeforeEach(func() {
+				taggedBeforeModification = false
+				mockElasticache.ModifyReplicationGroupWithContextStub = func(
+					ctx context.Context, input *elasticache.ModifyReplicationGroupInput, opts ...request.Option,
+				) (*elasticache.ModifyReplicationGroupOutput, error) {
+					taggedBeforeModification = mockElasticache.AddTagsToResourceWithContextCallCount() == 1
+					return &elasticache.ModifyReplicationGroupOutput{}, nil
+				}
+			})
+
+			It("tags it before the modification is requested", func() {
+				Expect(upgradeErr).ToNot(HaveOccurred())
+				Expect(taggedBeforeModification).To(BeTrue())
+
+				Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+				Expect(input.Tags).To(Equal([]*elasticache.Tag{
+					{Key: aws.String(providers.OperationInProgressTag), Value: aws.String("upgrading-engine-version")},
+				}))
+			})
+		})
+
+		Context("when tagging the replication group fails", func() {
+			BeforeEach(func() {
+				mockElasticache.AddTagsToResourceWithContextReturns(nil, errors.New("some error"))
+			})
+
+			It("doesn't modify the replication group, deletes the new parameter group and returns the error", func() {
+				Expect(upgradeErr).To(MatchError("some error"))
+				Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+				Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			})
+		})
+
+		It("doesn't delete the current parameter group", func() {
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(0))
+		})
+
+		Context("when the instance already uses a parameter group of the target family", func() {
+			BeforeEach(func() {
+				currentGroupName = replicationGroupID + "-redis7"
+			})
+
+			It("only changes the engine version", func() {
+				Expect(upgradeErr).ToNot(HaveOccurred())
+				Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(0))
+
+				_, input, _ := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+				Expect(input.EngineVersion).To(Equal(aws.String("7.1")))
+				Expect(input.CacheParameterGroupName).To(BeNil())
+			})
+		})
+
+		Context("when copying the parameters fails", func() {
+			BeforeEach(func() {
+				mockElasticache.ModifyCacheParameterGroupWithContextReturns(nil, errors.New("some error"))
+			})
+
+			It("deletes the new parameter group and returns the error", func() {
+				Expect(upgradeErr).To(MatchError("some error"))
+				Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+				Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			})
+		})
+
+		Context("when modifying the replication group fails", func() {
+			BeforeEach(func() {
+				mockElasticache.ModifyReplicationGroupWithContextReturns(nil, errors.New("some error"))
+			})
+
+			It("removes the tags, deletes the new parameter group and returns the error", func() {
+				Expect(upgradeErr).To(MatchError("some error"))
+
+				Expect(mockElasticache.RemoveTagsFromResourceWithContextCallCount()).To(Equal(1))
+				_, tagsInput, _ := mockElasticache.RemoveTagsFromResourceWithContextArgsForCall(0)
+				Expect(tagsInput.TagKeys).To(Equal(aws.StringSlice([]string{providers.OperationInProgressTag})))
+
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				_, input, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+				Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			})
+		})
+	})
+
+	Context("when the target version isn't newer than the current version", func() {
+		BeforeEach(func() {
+			currentVersion = "7.1.0"
+		})
+
+		It("returns a not supported error without modifying the instance", func() {
+			for _, engineVersion := range []string{"7.1", "7.0", "6.2"} {
+				err := provider.UpgradeEngineVersion(ctx, instanceID, providers.UpgradeEngineVersionParameters{
+					EngineVersion: engineVersion,
+				})
+				Expect(err).To(MatchError("Engine version " + engineVersion + " is not newer than the current version 7.1.0"))
+				Expect(errors.Is(err, providers.ErrNotSupported)).To(BeTrue())
+			}
+			Expect(mockElasticache.CreateCacheParameterGroupWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.ModifyReplicationGroupWithContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when migrating to valkey", func() {
+		It("changes the engine and moves the instance to a valkey parameter group", func() {
+			mockElasticache.DescribeCacheParametersWithContextReturns(&elasticache.DescribeCacheParametersOutput{}, nil)
+
+			err := provider.UpgradeEngineVersion(ctx, instanceID, providers.UpgradeEngineVersionParameters{
+				Engine:        "valkey",
+				EngineVersion: "7.2",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, createInput, _ := mockElasticache.CreateCacheParameterGroupWithContextArgsForCall(0)
+			Expect(createInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-valkey7")))
+			Expect(createInput.CacheParameterGroupFamily).To(Equal(aws.String("valkey7")))
+
+			_, input, opts := mockElasticache.ModifyReplicationGroupWithContextArgsForCall(0)
+			Expect(input.EngineVersion).To(Equal(aws.String("7.2")))
+			Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-valkey7")))
+			Expect(opts).To(HaveLen(1))
+		})
+	})
+
+	Context("when deleting the unused parameter groups", func() {
+		BeforeEach(func() {
+			currentGroupName = replicationGroupID + "-redis7"
+		})
+
+		It("deletes the parameter groups the instance can have except the current one", func() {
+			err := provider.DeleteUnusedCacheParameterGroups(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(3))
+			_, firstInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+			Expect(firstInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID)))
+			_, secondInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(1)
+			Expect(secondInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis6-x")))
+			_, thirdInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(2)
+			Expect(thirdInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-valkey8")))
+		})
+
+		It("ignores parameter groups which were deleted already", func() {
+			mockElasticache.DeleteCacheParameterGroupWithContextReturns(nil, awserr.New(elasticache.ErrCodeCacheParameterGroupNotFoundFault, "not found", nil))
+
+			err := provider.DeleteUnusedCacheParameterGroups(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("deletes all parameter groups when the instance is deprovisioned", func() {
+			err := provider.DeleteCacheParameterGroup(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(4))
+			_, firstInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(0)
+			Expect(firstInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID)))
+			_, secondInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(1)
+			Expect(secondInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+			_, thirdInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(2)
+			Expect(thirdInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis6-x")))
+			_, fourthInput, _ := mockElasticache.DeleteCacheParameterGroupWithContextArgsForCall(3)
+			Expect(fourthInput.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-valkey8")))
+		})
+	})
+
+	Context("when the engine version was upgraded", func() {
+		BeforeEach(func() {
+			currentGroupName = replicationGroupID + "-redis7"
+		})
+
+		It("updates the parameters of the current parameter group", func() {
+			err := provider.UpdateParamGroupParameters(ctx, instanceID, providers.UpdateParamGroupParameters{
+				Parameters: map[string]string{"maxmemory-policy": "noeviction"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.ModifyCacheParameterGroupWithContextArgsForCall(0)
+			Expect(input.CacheParameterGroupName).To(Equal(aws.String(replicationGroupID + "-redis7")))
+		})
+	})
+
+	It("names the new parameter groups after the family", func() {
+		Expect(UpgradedCacheParameterGroupName(replicationGroupID, "redis6.x")).To(Equal(replicationGroupID + "-redis6-x"))
+	})
+})
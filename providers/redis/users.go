@@ -0,0 +1,24 @@
+package redis
+
+import (
+	"context"
+)
+
+// DeleteUserGroup deletes the user group and the default user of an instance
+// It can only be called after the replication group was deleted.
+func (p *RedisProvider) DeleteUserGroup(ctx context.Context, instanceID string) error {
+	replicationGroupID := GenerateReplicationGroupName(instanceID)
+	err := p.users.DeleteUserGroup(ctx, replicationGroupID)
+	if err != nil {
+		return err
+	}
+	return p.users.DeleteDefaultUser(ctx, replicationGroupID)
+}
+
+// RevokeCredentials revokes the credentials between an app and a Redis instance
+//
+// It deletes the user of the binding, which also removes it from the user group. Instances which share a single auth
+// token between all bindings have no binding users, so there is nothing to revoke.
+func (p *RedisProvider) RevokeCredentials(ctx context.Context, instanceID, bindingID string) error {
+	return p.users.DeleteBindingUser(ctx, instanceID, bindingID)
+}
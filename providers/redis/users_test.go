@@ -0,0 +1,323 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	. "github.com/alphagov/paas-elasticache-broker/providers/redis"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Binding users", func() {
+	var (
+		mockElasticache    *mocks.FakeElastiCache
+		mockSecretsManager *mocks.FakeSecretsManager
+		ctx                context.Context
+		instanceID         string
+		bindingID          string
+		bindingUserID      string
+		replicationGroupID string
+
+		provider *RedisProvider
+	)
+
+	BeforeEach(func() {
+		mockElasticache = &mocks.FakeElastiCache{}
+		mockSecretsManager = &mocks.FakeSecretsManager{}
+		ctx = context.Background()
+		instanceID = "foobar"
+		bindingID = "test-binding"
+		bindingUserID = providers.GenerateBindingUserID(bindingID)
+		replicationGroupID = "cf-qwkec4pxhft6q"
+	})
+
+	JustBeforeEach(func() {
+		provider = NewProvider(
+			mockElasticache,
+			mockSecretsManager,
+			"123456789012",
+			"aws",
+			"eu-west-1",
+			lager.NewLogger("logger"),
+			"my-kms-key",
+			"elasticache-broker-test",
+			nil,
+		)
+	})
+
+	Context("when provisioning with per binding users", func() {
+		var provisionErr error
+
+		JustBeforeEach(func() {
+			provisionErr = provider.Provision(ctx, instanceID, providers.ProvisionParameters{
+				Engine:          "redis",
+				PerBindingUsers: true,
+			})
+		})
+
+		It("creates a switched off default user and a user group", func() {
+			Expect(provisionErr).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateUserWithContextCallCount()).To(Equal(1))
+			_, userInput, _ := mockElasticache.CreateUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(replicationGroupID + "-default")))
+			Expect(userInput.UserName).To(Equal(aws.String("default")))
+			Expect(userInput.Engine).To(Equal(aws.String("redis")))
+			Expect(userInput.AccessString).To(Equal(aws.String("off -@all")))
+
+			Expect(mockElasticache.CreateUserGroupWithContextCallCount()).To(Equal(1))
+			_, userGroupInput, _ := mockElasticache.CreateUserGroupWithContextArgsForCall(0)
+			Expect(userGroupInput.UserGroupId).To(Equal(aws.String(replicationGroupID)))
+			Expect(userGroupInput.UserIds).To(Equal(aws.StringSlice([]string{replicationGroupID + "-default"})))
+		})
+
+		It("attaches the user group to the replication group instead of the auth token", func() {
+			_, input, _ := mockElasticache.CreateReplicationGroupWithContextArgsForCall(0)
+			Expect(input.UserGroupIds).To(Equal(aws.StringSlice([]string{replicationGroupID})))
+			Expect(input.AuthToken).To(BeNil())
+		})
+
+		Context("when creating the user group fails", func() {
+			BeforeEach(func() {
+				mockElasticache.CreateUserGroupWithContextReturns(nil, errors.New("some error"))
+			})
+
+			It("cleans up and returns the error", func() {
+				Expect(provisionErr).To(MatchError("failed to create user group: some error (default user: rolled back, auth token secret: rolled back, cache parameter group: rolled back)"))
+				Expect(mockElasticache.CreateReplicationGroupWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DeleteCacheParameterGroupWithContextCallCount()).To(Equal(1))
+				Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+				Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(1))
+			})
+
+			It("doesn't delete a user group it didn't create", func() {
+				Expect(mockElasticache.DeleteUserGroupWithContextCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when generating credentials for an instance with a user group", func() {
+		var (
+			credentials *providers.Credentials
+			generateErr error
+		)
+
+		BeforeEach(func() {
+			mockElasticache.DescribeReplicationGroupsWithContextReturns(&elasticache.DescribeReplicationGroupsOutput{
+				ReplicationGroups: []*elasticache.ReplicationGroup{
+					{
+						ConfigurationEndpoint: &elasticache.Endpoint{
+							Address: aws.String("test-host"),
+							Port:    aws.Int64(1234),
+						},
+						UserGroupIds: aws.StringSlice([]string{replicationGroupID}),
+					},
+				},
+			}, nil)
+			mockElasticache.DescribeUserGroupsWithContextReturns(&elasticache.DescribeUserGroupsOutput{
+				UserGroups: []*elasticache.UserGroup{
+					{
+						UserGroupId: aws.String(replicationGroupID),
+						Engine:      aws.String("redis"),
+					},
+				},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			credentials, generateErr = provider.GenerateCredentials(ctx, instanceID, bindingID)
+		})
+
+		It("creates a user for the binding", func() {
+			Expect(generateErr).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateUserWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.CreateUserWithContextArgsForCall(0)
+			Expect(input.UserId).To(Equal(aws.String(bindingUserID)))
+			Expect(input.UserName).To(Equal(aws.String(bindingUserID)))
+			Expect(input.Engine).To(Equal(aws.String("redis")))
+			Expect(input.AccessString).To(Equal(aws.String("on ~* +@all -@dangerous")))
+			Expect(input.Passwords).To(HaveLen(1))
+			Expect(*input.Passwords[0]).To(HaveLen(providers.PasswordLength))
+		})
+
+		It("adds the user to the user group", func() {
+			Expect(mockElasticache.ModifyUserGroupWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyUserGroupWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyUserGroupInput{
+				UserGroupId:  aws.String(replicationGroupID),
+				UserIdsToAdd: aws.StringSlice([]string{bindingUserID}),
+			}))
+		})
+
+		It("stores the password in the secrets manager", func() {
+			Expect(mockSecretsManager.CreateSecretWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockSecretsManager.CreateSecretWithContextArgsForCall(0)
+			Expect(input.Name).To(Equal(aws.String("elasticache-broker-test/foobar/bindings/test-binding")))
+			Expect(input.SecretString).To(Equal(aws.String(credentials.Password)))
+			Expect(input.KmsKeyId).To(Equal(aws.String("my-kms-key")))
+		})
+
+		It("does not use the shared auth token", func() {
+			Expect(mockSecretsManager.GetSecretValueWithContextCallCount()).To(Equal(0))
+		})
+
+		It("returns the credentials of the user", func() {
+			Expect(credentials.Username).To(Equal(bindingUserID))
+			Expect(credentials.Host).To(Equal("test-host"))
+			Expect(credentials.Port).To(Equal(int64(1234)))
+			Expect(credentials.URI).To(Equal("rediss://" + bindingUserID + ":" + credentials.Password + "@test-host:1234"))
+		})
+
+		Context("when the user exists already, as the bind request is retried", func() {
+			BeforeEach(func() {
+				mockElasticache.CreateUserWithContextReturns(nil,
+					awserr.New(elasticache.ErrCodeUserAlreadyExistsFault, "already exists", nil))
+				mockSecretsManager.GetSecretValueWithContextReturns(&secretsmanager.GetSecretValueOutput{
+					SecretString: aws.String("existing-password"),
+				}, nil)
+			})
+
+			It("reuses the user and the password in its secret", func() {
+				Expect(generateErr).ToNot(HaveOccurred())
+				Expect(credentials.Username).To(Equal(bindingUserID))
+				Expect(credentials.Password).To(Equal("existing-password"))
+
+				_, input, _ := mockSecretsManager.GetSecretValueWithContextArgsForCall(0)
+				Expect(input.SecretId).To(Equal(aws.String("elasticache-broker-test/foobar/bindings/test-binding")))
+				Expect(mockSecretsManager.CreateSecretWithContextCallCount()).To(Equal(0))
+				Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+			})
+
+			It("adds the user to the user group", func() {
+				Expect(mockElasticache.ModifyUserGroupWithContextCallCount()).To(Equal(1))
+			})
+
+			Context("and it's in the user group already", func() {
+				BeforeEach(func() {
+					mockElasticache.DescribeUserGroupsWithContextReturns(&elasticache.DescribeUserGroupsOutput{
+						UserGroups: []*elasticache.UserGroup{
+							{
+								UserGroupId: aws.String(replicationGroupID),
+								Engine:      aws.String("redis"),
+								UserIds:     aws.StringSlice([]string{replicationGroupID + "-default", bindingUserID}),
+							},
+						},
+					}, nil)
+				})
+
+				It("doesn't modify the user group", func() {
+					Expect(generateErr).ToNot(HaveOccurred())
+					Expect(mockElasticache.ModifyUserGroupWithContextCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("and its secret doesn't exist", func() {
+				BeforeEach(func() {
+					mockSecretsManager.GetSecretValueWithContextReturns(nil,
+						awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil))
+				})
+
+				It("deletes the user, so the next attempt creates it again", func() {
+					Expect(generateErr).To(HaveOccurred())
+					Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("and reading its secret fails", func() {
+				BeforeEach(func() {
+					mockSecretsManager.GetSecretValueWithContextReturns(nil, errors.New("some error"))
+				})
+
+				It("returns the error without deleting the user", func() {
+					Expect(generateErr).To(MatchError("some error"))
+					Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("and adding it to the user group fails", func() {
+				BeforeEach(func() {
+					mockElasticache.ModifyUserGroupWithContextReturns(nil, errors.New("some error"))
+				})
+
+				It("returns the error without deleting the user", func() {
+					Expect(generateErr).To(MatchError("some error"))
+					Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+					Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when adding the user to the user group fails", func() {
+			BeforeEach(func() {
+				mockElasticache.ModifyUserGroupWithContextReturns(nil, errors.New("some error"))
+			})
+
+			It("deletes the user and the secret", func() {
+				Expect(generateErr).To(MatchError("some error"))
+				Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(1))
+				Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when revoking credentials", func() {
+		It("deletes the user and its secret", func() {
+			err := provider.RevokeCredentials(ctx, instanceID, bindingID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(1))
+			_, userInput, _ := mockElasticache.DeleteUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(bindingUserID)))
+
+			Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+			_, secretInput, _ := mockSecretsManager.DeleteSecretWithContextArgsForCall(0)
+			Expect(secretInput.SecretId).To(Equal(aws.String("elasticache-broker-test/foobar/bindings/test-binding")))
+		})
+
+		It("succeeds if the binding has no user", func() {
+			mockElasticache.DeleteUserWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserNotFoundFault, "not found", nil))
+			mockSecretsManager.DeleteSecretWithContextReturns(nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil))
+
+			err := provider.RevokeCredentials(ctx, instanceID, bindingID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns other errors", func() {
+			deleteErr := errors.New("some error")
+			mockElasticache.DeleteUserWithContextReturns(nil, deleteErr)
+
+			err := provider.RevokeCredentials(ctx, instanceID, bindingID)
+			Expect(err).To(MatchError(deleteErr))
+		})
+	})
+
+	Context("when deleting the user group", func() {
+		It("deletes the user group and the default user", func() {
+			err := provider.DeleteUserGroup(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, groupInput, _ := mockElasticache.DeleteUserGroupWithContextArgsForCall(0)
+			Expect(groupInput.UserGroupId).To(Equal(aws.String(replicationGroupID)))
+			_, userInput, _ := mockElasticache.DeleteUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(replicationGroupID + "-default")))
+		})
+
+		It("succeeds if the instance has no user group", func() {
+			mockElasticache.DeleteUserGroupWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserGroupNotFoundFault, "not found", nil))
+			mockElasticache.DeleteUserWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserNotFoundFault, "not found", nil))
+
+			err := provider.DeleteUserGroup(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
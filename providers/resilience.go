@@ -0,0 +1,321 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrCircuitOpen is returned without calling AWS while the circuit breaker of an AWS service is open
+var ErrCircuitOpen = errors.New("too many AWS requests failed, AWS is not called for a while")
+
+// AWSClientConfig are the retry, rate limit and circuit breaker settings of the AWS clients
+// The settings which aren't set use the defaults, setting them to 0 turns the feature off: no retries, no delay
+// between the retries, no rate limit or no circuit breaker.
+type AWSClientConfig struct {
+	// MaxRetries is how many times a throttled or failed request is retried on top of the retries of the AWS SDK
+	MaxRetries *int `json:"max_retries"`
+	// BaseDelayMs is the maximum delay before the first retry, it doubles with each retry up to MaxDelayMs
+	BaseDelayMs *int `json:"base_delay_ms"`
+	MaxDelayMs  *int `json:"max_delay_ms"`
+	// RequestsPerSecond and Burst are the rate and the size of the token bucket all the AWS requests share, every
+	// page of a paginated request takes a token
+	RequestsPerSecond *float64 `json:"requests_per_second"`
+	Burst             *int     `json:"burst"`
+	// CircuitBreakerFailures is how many failed requests in a row open the circuit breaker of an AWS service
+	CircuitBreakerFailures *int `json:"circuit_breaker_failures"`
+	// CircuitBreakerCooldownMs is how long the circuit breaker stays open before a request is let through again
+	CircuitBreakerCooldownMs *int `json:"circuit_breaker_cooldown_ms"`
+}
+
+// Default AWS client settings
+const (
+	DefaultMaxRetries               = 4
+	DefaultBaseDelayMs              = 200
+	DefaultMaxDelayMs               = 5000
+	DefaultRequestsPerSecond        = 10
+	DefaultBurst                    = 20
+	DefaultCircuitBreakerFailures   = 10
+	DefaultCircuitBreakerCooldownMs = 30000
+)
+
+// WithDefaults returns the config with the default values for the unset settings
+func (c AWSClientConfig) WithDefaults() AWSClientConfig {
+	c.MaxRetries = intOrDefault(c.MaxRetries, DefaultMaxRetries)
+	c.BaseDelayMs = intOrDefault(c.BaseDelayMs, DefaultBaseDelayMs)
+	c.MaxDelayMs = intOrDefault(c.MaxDelayMs, DefaultMaxDelayMs)
+	if c.RequestsPerSecond == nil {
+		requestsPerSecond := float64(DefaultRequestsPerSecond)
+		c.RequestsPerSecond = &requestsPerSecond
+	}
+	c.Burst = intOrDefault(c.Burst, DefaultBurst)
+	c.CircuitBreakerFailures = intOrDefault(c.CircuitBreakerFailures, DefaultCircuitBreakerFailures)
+	c.CircuitBreakerCooldownMs = intOrDefault(c.CircuitBreakerCooldownMs, DefaultCircuitBreakerCooldownMs)
+	return c
+}
+
+func intOrDefault(value *int, defaultValue int) *int {
+	if value == nil {
+		return &defaultValue
+	}
+	return value
+}
+
+// Validate returns an error if any of the settings is negative
+func (c AWSClientConfig) Validate() error {
+	for _, value := range []*int{
+		c.MaxRetries, c.BaseDelayMs, c.MaxDelayMs, c.Burst, c.CircuitBreakerFailures, c.CircuitBreakerCooldownMs,
+	} {
+		if value != nil && *value < 0 {
+			return errors.New("the AWS client settings can't be negative")
+		}
+	}
+	if c.RequestsPerSecond != nil && *c.RequestsPerSecond < 0 {
+		return errors.New("the AWS client settings can't be negative")
+	}
+	return nil
+}
+
+// isRetryable returns true for throttling and transient AWS errors, like server errors and connection failures
+// Errors caused by the request, like a replication group which doesn't exist, are not retried.
+func isRetryable(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	if request.IsErrorThrottle(awsErr) {
+		return true
+	}
+	if reqErr, ok := awsErr.(awserr.RequestFailure); ok && reqErr.StatusCode() >= 500 {
+		return true
+	}
+	return request.IsErrorRetryable(awsErr)
+}
+
+// retryPolicy is which errors of an AWS request are retried
+type retryPolicy int
+
+const (
+	// retryTransient retries the throttling and the transient errors of the requests which only read
+	retryTransient retryPolicy = iota
+	// retryThrottled only retries the throttling errors of the requests which change something, as AWS may have made
+	// the change before a server error or a connection failure, and repeating it would make it twice
+	retryThrottled
+	// retryNever doesn't retry the request, like a paginated one which has returned some pages already
+	retryNever
+)
+
+// isThrottled returns true if AWS throttled the request
+func isThrottled(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && request.IsErrorThrottle(awsErr)
+}
+
+func (p retryPolicy) retries(err error) bool {
+	switch p {
+	case retryTransient:
+		return isRetryable(err)
+	case retryThrottled:
+		return isThrottled(err)
+	default:
+		return false
+	}
+}
+
+// tokenBucket limits the rate of the AWS requests of the process, a zero rate doesn't limit it
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), lastFill: now}
+}
+
+// reserve takes a token and returns how long the caller has to wait until it's its turn
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate == 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.lastFill).Seconds()*b.rate)
+	b.lastFill = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// circuitBreaker stops calling an AWS service after too many failed requests in a row
+// Throttled requests don't count as failed, as AWS is up and the retries and the rate limit handle them already, and
+// the breaker is shared by every request of the process, so one busy instance would otherwise fail them all. Once the
+// cooldown has passed a single request is let through, and the breaker closes again if it succeeds. A zero
+// threshold never opens it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (c *circuitBreaker) allow(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.threshold == 0 || c.failures < c.threshold {
+		return true
+	}
+	if now.Before(c.openUntil) || c.probing {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// release lets another request through after a request which was let through wasn't sent
+func (c *circuitBreaker) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+}
+
+// record returns true if the request opened the breaker
+func (c *circuitBreaker) record(failed bool, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+	if !failed {
+		c.failures = 0
+		return false
+	}
+	c.failures++
+	if c.threshold > 0 && c.failures >= c.threshold {
+		c.openUntil = now.Add(c.cooldown)
+		return true
+	}
+	return false
+}
+
+// resilience runs the AWS requests of a client with rate limiting, retries and a circuit breaker per AWS service
+type resilience struct {
+	config   AWSClientConfig
+	logger   lager.Logger
+	bucket   *tokenBucket
+	breakers map[string]*circuitBreaker
+
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(max time.Duration) time.Duration
+}
+
+func newResilience(config AWSClientConfig, logger lager.Logger, services ...string) *resilience {
+	config = config.WithDefaults()
+	r := &resilience{
+		config:   config,
+		logger:   logger.Session("aws-client"),
+		bucket:   newTokenBucket(*config.RequestsPerSecond, *config.Burst, time.Now()),
+		breakers: map[string]*circuitBreaker{},
+		now:      time.Now,
+		sleep:    sleepWithContext,
+		jitter: func(max time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(max) + 1))
+		},
+	}
+	for _, service := range services {
+		r.breakers[service] = &circuitBreaker{
+			threshold: *config.CircuitBreakerFailures,
+			cooldown:  time.Duration(*config.CircuitBreakerCooldownMs) * time.Millisecond,
+		}
+	}
+	return r
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the delay before a retry, a random delay up to the exponentially growing maximum
+func (r *resilience) backoff(retry int) time.Duration {
+	maxDelay := float64(*r.config.MaxDelayMs) * float64(time.Millisecond)
+	delay := math.Min(maxDelay, float64(*r.config.BaseDelayMs)*float64(time.Millisecond)*math.Pow(2, float64(retry)))
+	return r.jitter(time.Duration(delay))
+}
+
+// do runs an AWS request, retrying it with backoff if its retry policy allows it for the error
+// The request isn't retried once the context is done.
+func (r *resilience) do(ctx context.Context, service string, operation string, call func() (retryPolicy, error)) error {
+	breaker := r.breakers[service]
+	var err error
+	for retry := 0; ; retry++ {
+		if !breaker.allow(r.now()) {
+			if err != nil {
+				// The breaker was opened while retrying
+				return err
+			}
+			return ErrCircuitOpen
+		}
+		if sleepErr := r.sleep(ctx, r.bucket.reserve(r.now())); sleepErr != nil {
+			// The request wasn't sent, so it doesn't count for the circuit breaker
+			breaker.release()
+			if err != nil {
+				return err
+			}
+			return sleepErr
+		}
+
+		var policy retryPolicy
+		policy, err = call()
+		failed := err != nil && isRetryable(err) && !isThrottled(err)
+		if breaker.record(failed, r.now()) {
+			r.logger.Error("circuit-breaker-open", err, lager.Data{"service": service, "operation": operation})
+		}
+		if err == nil || !policy.retries(err) || retry >= *r.config.MaxRetries {
+			return err
+		}
+
+		delay := r.backoff(retry)
+		r.logger.Info("retry", lager.Data{
+			"service":   service,
+			"operation": operation,
+			"retry":     retry + 1,
+			"delay":     delay.String(),
+			"error":     err.Error(),
+		})
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// waitForNextPage waits for the rate limit before the AWS SDK requests the next page of a paginated request
+// If the context is done the request of the next page fails, so the error is left to it.
+func (r *resilience) waitForNextPage(ctx context.Context) {
+	_ = r.sleep(ctx, r.bucket.reserve(r.now()))
+}
@@ -0,0 +1,376 @@
+package providers_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+)
+
+var _ = Describe("ResilientClient", func() {
+	var (
+		ctx                context.Context
+		config             providers.AWSClientConfig
+		mockElastiCache    *mocks.FakeElastiCache
+		mockSecretsManager *mocks.FakeSecretsManager
+		log                *gbytes.Buffer
+		client             *providers.ResilientClient
+		clock              *providers.ExportFakeClock
+		throttlingErr      error
+		serverErr          error
+		notFoundErr        error
+		output             *elasticache.DescribeReplicationGroupsOutput
+	)
+
+	describeReplicationGroups := func() (*elasticache.DescribeReplicationGroupsOutput, error) {
+		return client.DescribeReplicationGroupsWithContext(ctx, &elasticache.DescribeReplicationGroupsInput{
+			ReplicationGroupId: aws.String("cf-qwkec4pxhft6q"),
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		config = providers.AWSClientConfig{
+			MaxRetries:               aws.Int(3),
+			BaseDelayMs:              aws.Int(100),
+			MaxDelayMs:               aws.Int(300),
+			RequestsPerSecond:        aws.Float64(1000),
+			Burst:                    aws.Int(100),
+			CircuitBreakerFailures:   aws.Int(5),
+			CircuitBreakerCooldownMs: aws.Int(10000),
+		}
+		mockElastiCache = &mocks.FakeElastiCache{}
+		mockSecretsManager = &mocks.FakeSecretsManager{}
+		throttlingErr = awserr.New("Throttling", "Rate exceeded", nil)
+		serverErr = awserr.NewRequestFailure(awserr.New("InternalFailure", "internal error", nil), 500, "request-id")
+		notFoundErr = awserr.New(elasticache.ErrCodeReplicationGroupNotFoundFault, "not found", nil)
+		output = &elasticache.DescribeReplicationGroupsOutput{
+			ReplicationGroups: []*elasticache.ReplicationGroup{{ReplicationGroupId: aws.String("cf-qwkec4pxhft6q")}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		logger := lager.NewLogger("logger")
+		log = gbytes.NewBuffer()
+		logger.RegisterSink(lager.NewWriterSink(log, lager.INFO))
+		client = providers.NewResilientClient(mockElastiCache, mockSecretsManager, config, logger)
+		clock = client.ExportUseFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	})
+
+	It("passes the requests and the responses through", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(output, nil)
+
+		out, err := describeReplicationGroups()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(output))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
+		_, input, _ := mockElastiCache.DescribeReplicationGroupsWithContextArgsForCall(0)
+		Expect(input.ReplicationGroupId).To(Equal(aws.String("cf-qwkec4pxhft6q")))
+		Expect(clock.Sleeps).To(BeEmpty())
+	})
+
+	It("retries throttled and failed requests with exponential backoff", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturnsOnCall(0, nil, throttlingErr)
+		mockElastiCache.DescribeReplicationGroupsWithContextReturnsOnCall(1, nil, serverErr)
+		mockElastiCache.DescribeReplicationGroupsWithContextReturnsOnCall(2, output, nil)
+
+		out, err := describeReplicationGroups()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(output))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(3))
+		Expect(clock.Sleeps).To(Equal([]time.Duration{100 * time.Millisecond, 200 * time.Millisecond}))
+		Expect(log).To(gbytes.Say("retry"))
+	})
+
+	Context("when the request changes something", func() {
+		testFailover := func() error {
+			_, err := client.TestFailoverWithContext(ctx, &elasticache.TestFailoverInput{
+				ReplicationGroupId: aws.String("cf-qwkec4pxhft6q"),
+			})
+			return err
+		}
+
+		It("retries it if it's throttled", func() {
+			mockElastiCache.TestFailoverWithContextReturnsOnCall(0, nil, throttlingErr)
+			mockElastiCache.TestFailoverWithContextReturnsOnCall(1, &elasticache.TestFailoverOutput{}, nil)
+
+			Expect(testFailover()).To(Succeed())
+			Expect(mockElastiCache.TestFailoverWithContextCallCount()).To(Equal(2))
+		})
+
+		It("doesn't retry server errors, as AWS may have made the change", func() {
+			mockElastiCache.TestFailoverWithContextReturns(nil, serverErr)
+
+			Expect(testFailover()).To(MatchError(serverErr))
+			Expect(mockElastiCache.TestFailoverWithContextCallCount()).To(Equal(1))
+			Expect(clock.Sleeps).To(BeEmpty())
+		})
+
+		It("doesn't retry connection failures", func() {
+			mockSecretsManager.PutSecretValueWithContextReturns(nil, awserr.New(request.ErrCodeRequestError, "send request failed", nil))
+
+			_, err := client.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{})
+			Expect(err).To(HaveOccurred())
+			Expect(mockSecretsManager.PutSecretValueWithContextCallCount()).To(Equal(1))
+		})
+	})
+
+	It("doesn't retry errors caused by the request", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, notFoundErr)
+
+		_, err := describeReplicationGroups()
+
+		Expect(err).To(MatchError(notFoundErr))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
+		Expect(clock.Sleeps).To(BeEmpty())
+	})
+
+	It("doesn't retry errors which aren't AWS errors", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, errors.New("some error"))
+
+		_, err := describeReplicationGroups()
+
+		Expect(err).To(MatchError("some error"))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
+	})
+
+	It("gives up after the maximum number of retries, with the delay capped", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, throttlingErr)
+
+		_, err := describeReplicationGroups()
+
+		Expect(err).To(MatchError(throttlingErr))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(4))
+		Expect(clock.Sleeps).To(Equal([]time.Duration{
+			100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond,
+		}))
+	})
+
+	It("doesn't retry once the context is done", func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		mockElastiCache.DescribeReplicationGroupsWithContextStub = func(context.Context, *elasticache.DescribeReplicationGroupsInput, ...request.Option) (*elasticache.DescribeReplicationGroupsOutput, error) {
+			cancel()
+			return nil, throttlingErr
+		}
+
+		_, err := describeReplicationGroups()
+
+		Expect(err).To(MatchError(throttlingErr))
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(1))
+	})
+
+	Context("when the requests to a service keep failing", func() {
+		JustBeforeEach(func() {
+			mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, serverErr)
+			_, err := describeReplicationGroups()
+			Expect(err).To(MatchError(serverErr))
+			_, err = describeReplicationGroups()
+			Expect(err).To(MatchError(serverErr))
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(5))
+			Expect(log).To(gbytes.Say("circuit-breaker-open"))
+		})
+
+		It("stops calling the service while the circuit breaker is open", func() {
+			_, err := describeReplicationGroups()
+
+			Expect(err).To(MatchError(providers.ErrCircuitOpen))
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(5))
+		})
+
+		It("keeps calling the other services", func() {
+			_, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockSecretsManager.GetSecretValueWithContextCallCount()).To(Equal(1))
+		})
+
+		It("lets a request through after the cooldown and closes the circuit breaker if it succeeds", func() {
+			clock.Now = clock.Now.Add(10 * time.Second)
+			mockElastiCache.DescribeReplicationGroupsWithContextReturns(output, nil)
+
+			_, err := describeReplicationGroups()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = describeReplicationGroups()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(7))
+		})
+
+		It("opens the circuit breaker again if the request after the cooldown fails", func() {
+			clock.Now = clock.Now.Add(10 * time.Second)
+
+			_, err := describeReplicationGroups()
+			Expect(err).To(MatchError(serverErr))
+			_, err = describeReplicationGroups()
+			Expect(err).To(MatchError(providers.ErrCircuitOpen))
+
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(6))
+		})
+	})
+
+	It("doesn't open the circuit breaker when the requests are throttled", func() {
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, throttlingErr)
+		for i := 0; i < 3; i++ {
+			_, err := describeReplicationGroups()
+			Expect(err).To(MatchError(throttlingErr))
+		}
+		Expect(log).ToNot(gbytes.Say("circuit-breaker-open"))
+
+		mockElastiCache.DescribeReplicationGroupsWithContextReturns(output, nil)
+		_, err := describeReplicationGroups()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(13))
+	})
+
+	Context("when the rate limit is reached", func() {
+		BeforeEach(func() {
+			config.RequestsPerSecond = aws.Float64(2)
+			config.Burst = aws.Int(2)
+		})
+
+		It("waits for the rate limit before sending the request", func() {
+			mockElastiCache.DescribeReplicationGroupsWithContextReturns(output, nil)
+
+			for i := 0; i < 3; i++ {
+				_, err := describeReplicationGroups()
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(3))
+			Expect(clock.Sleeps).To(Equal([]time.Duration{500 * time.Millisecond}))
+		})
+
+		It("waits for the rate limit before requesting every page", func() {
+			mockElastiCache.DescribeSnapshotsPagesWithContextStub = func(ctx context.Context, input *elasticache.DescribeSnapshotsInput, fn func(*elasticache.DescribeSnapshotsOutput, bool) bool, opts ...request.Option) error {
+				for page := 1; page <= 3; page++ {
+					if !fn(&elasticache.DescribeSnapshotsOutput{}, page == 3) {
+						break
+					}
+				}
+				return nil
+			}
+
+			err := client.DescribeSnapshotsPagesWithContext(ctx, &elasticache.DescribeSnapshotsInput{},
+				func(*elasticache.DescribeSnapshotsOutput, bool) bool { return true })
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(clock.Sleeps).To(Equal([]time.Duration{500 * time.Millisecond}))
+		})
+	})
+
+	Context("when the rate limit is turned off", func() {
+		BeforeEach(func() {
+			config.RequestsPerSecond = aws.Float64(0)
+			config.Burst = aws.Int(0)
+		})
+
+		It("doesn't wait", func() {
+			mockElastiCache.DescribeReplicationGroupsWithContextReturns(output, nil)
+
+			for i := 0; i < 3; i++ {
+				_, err := describeReplicationGroups()
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(clock.Sleeps).To(BeEmpty())
+		})
+	})
+
+	Context("when retries and the circuit breaker are turned off", func() {
+		BeforeEach(func() {
+			config.MaxRetries = aws.Int(0)
+			config.CircuitBreakerFailures = aws.Int(0)
+		})
+
+		It("sends every request once", func() {
+			mockElastiCache.DescribeReplicationGroupsWithContextReturns(nil, throttlingErr)
+
+			for i := 0; i < 10; i++ {
+				_, err := describeReplicationGroups()
+				Expect(err).To(MatchError(throttlingErr))
+			}
+
+			Expect(mockElastiCache.DescribeReplicationGroupsWithContextCallCount()).To(Equal(10))
+			Expect(clock.Sleeps).To(BeEmpty())
+		})
+	})
+
+	Context("when a paginated request fails", func() {
+		var pages int
+
+		describeSnapshots := func() error {
+			return client.DescribeSnapshotsPagesWithContext(ctx, &elasticache.DescribeSnapshotsInput{},
+				func(*elasticache.DescribeSnapshotsOutput, bool) bool {
+					pages++
+					return true
+				})
+		}
+
+		BeforeEach(func() {
+			pages = 0
+		})
+
+		It("retries it if no page has been returned yet", func() {
+			mockElastiCache.DescribeSnapshotsPagesWithContextStub = func(ctx context.Context, input *elasticache.DescribeSnapshotsInput, fn func(*elasticache.DescribeSnapshotsOutput, bool) bool, opts ...request.Option) error {
+				if mockElastiCache.DescribeSnapshotsPagesWithContextCallCount() == 1 {
+					return throttlingErr
+				}
+				fn(&elasticache.DescribeSnapshotsOutput{}, true)
+				return nil
+			}
+
+			Expect(describeSnapshots()).To(Succeed())
+			Expect(mockElastiCache.DescribeSnapshotsPagesWithContextCallCount()).To(Equal(2))
+			Expect(pages).To(Equal(1))
+		})
+
+		It("doesn't retry it once a page has been returned", func() {
+			mockElastiCache.DescribeSnapshotsPagesWithContextStub = func(ctx context.Context, input *elasticache.DescribeSnapshotsInput, fn func(*elasticache.DescribeSnapshotsOutput, bool) bool, opts ...request.Option) error {
+				fn(&elasticache.DescribeSnapshotsOutput{}, false)
+				return throttlingErr
+			}
+
+			Expect(describeSnapshots()).To(MatchError(throttlingErr))
+			Expect(mockElastiCache.DescribeSnapshotsPagesWithContextCallCount()).To(Equal(1))
+			Expect(pages).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("AWSClientConfig", func() {
+	It("uses the defaults for the unset settings", func() {
+		config := providers.AWSClientConfig{MaxRetries: aws.Int(2)}.WithDefaults()
+
+		Expect(*config.MaxRetries).To(Equal(2))
+		Expect(*config.BaseDelayMs).To(Equal(providers.DefaultBaseDelayMs))
+		Expect(*config.RequestsPerSecond).To(Equal(float64(providers.DefaultRequestsPerSecond)))
+		Expect(*config.CircuitBreakerFailures).To(Equal(providers.DefaultCircuitBreakerFailures))
+	})
+
+	It("keeps the settings which are set to 0", func() {
+		config := providers.AWSClientConfig{MaxRetries: aws.Int(0), RequestsPerSecond: aws.Float64(0)}.WithDefaults()
+
+		Expect(*config.MaxRetries).To(Equal(0))
+		Expect(*config.RequestsPerSecond).To(Equal(float64(0)))
+	})
+
+	It("rejects negative settings", func() {
+		Expect(providers.AWSClientConfig{Burst: aws.Int(-1)}.Validate()).To(MatchError("the AWS client settings can't be negative"))
+		Expect(providers.AWSClientConfig{RequestsPerSecond: aws.Float64(-1)}.Validate()).To(MatchError("the AWS client settings can't be negative"))
+	})
+})
@@ -0,0 +1,398 @@
+package providers
+
+import (
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// ResilientClient wraps the ElastiCache and SecretsManager clients, and retries their throttled and failed requests
+// with backoff, limits their rate and stops calling a service while its circuit breaker is open
+// The requests which change something are only retried when they're throttled.
+type ResilientClient struct {
+	*resilience
+	elastiCache    ElastiCache
+	secretsManager SecretsManager
+}
+
+var _ ElastiCache = &ResilientClient{}
+var _ SecretsManager = &ResilientClient{}
+
+// NewResilientClient creates a new ResilientClient, the settings which aren't set in the config use the defaults
+func NewResilientClient(elastiCache ElastiCache, secretsManager SecretsManager, config AWSClientConfig, logger lager.Logger) *ResilientClient {
+	return &ResilientClient{
+		resilience:     newResilience(config, logger, "elasticache", "secretsmanager"),
+		elastiCache:    elastiCache,
+		secretsManager: secretsManager,
+	}
+}
+
+func (c *ResilientClient) CreateCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.CreateCacheParameterGroupInput, opts ...request.Option) (out *elasticache.CreateCacheParameterGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateCacheParameterGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateCacheParameterGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateCacheClusterWithContext(ctx aws.Context, input *elasticache.CreateCacheClusterInput, opts ...request.Option) (out *elasticache.CreateCacheClusterOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateCacheCluster", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateCacheClusterWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateReplicationGroupWithContext(ctx aws.Context, input *elasticache.CreateReplicationGroupInput, opts ...request.Option) (out *elasticache.CreateReplicationGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateReplicationGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateReplicationGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.DeleteCacheParameterGroupInput, opts ...request.Option) (out *elasticache.DeleteCacheParameterGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteCacheParameterGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteCacheParameterGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteCacheClusterWithContext(ctx aws.Context, input *elasticache.DeleteCacheClusterInput, opts ...request.Option) (out *elasticache.DeleteCacheClusterOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteCacheCluster", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteCacheClusterWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteReplicationGroupWithContext(ctx aws.Context, input *elasticache.DeleteReplicationGroupInput, opts ...request.Option) (out *elasticache.DeleteReplicationGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteReplicationGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteReplicationGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DescribeReplicationGroupsWithContext(ctx aws.Context, input *elasticache.DescribeReplicationGroupsInput, opts ...request.Option) (out *elasticache.DescribeReplicationGroupsOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeReplicationGroups", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeReplicationGroupsWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+// DescribeReplicationGroupsPagesWithContext isn't retried once a page has been passed to fn, every
+// page waits for the rate limit
+func (c *ResilientClient) DescribeReplicationGroupsPagesWithContext(ctx aws.Context, input *elasticache.DescribeReplicationGroupsInput, fn func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, opts ...request.Option) error {
+	return c.do(ctx, "elasticache", "DescribeReplicationGroupsPages", func() (retryPolicy, error) {
+		called := false
+		err := c.elastiCache.DescribeReplicationGroupsPagesWithContext(ctx, input, func(page *elasticache.DescribeReplicationGroupsOutput, lastPage bool) bool {
+			called = true
+			more := fn(page, lastPage)
+			if more && !lastPage {
+				c.waitForNextPage(ctx)
+			}
+			return more
+		}, opts...)
+		if called {
+			return retryNever, err
+		}
+		return retryTransient, err
+	})
+}
+
+func (c *ResilientClient) DescribeCacheClustersWithContext(ctx aws.Context, input *elasticache.DescribeCacheClustersInput, opts ...request.Option) (out *elasticache.DescribeCacheClustersOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeCacheClusters", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeCacheClustersWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DescribeCacheSubnetGroupsWithContext(ctx aws.Context, input *elasticache.DescribeCacheSubnetGroupsInput, opts ...request.Option) (out *elasticache.DescribeCacheSubnetGroupsOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeCacheSubnetGroups", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeCacheSubnetGroupsWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DescribeCacheParametersWithContext(ctx aws.Context, input *elasticache.DescribeCacheParametersInput, opts ...request.Option) (out *elasticache.DescribeCacheParametersOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeCacheParameters", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeCacheParametersWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyCacheClusterWithContext(ctx aws.Context, input *elasticache.ModifyCacheClusterInput, opts ...request.Option) (out *elasticache.ModifyCacheClusterOutput, err error) {
+	err = c.do(ctx, "elasticache", "ModifyCacheCluster", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyCacheClusterWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyReplicationGroupWithContext(ctx aws.Context, input *elasticache.ModifyReplicationGroupInput, opts ...request.Option) (out *elasticache.ModifyReplicationGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "ModifyReplicationGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyReplicationGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyReplicationGroupShardConfigurationWithContext(ctx aws.Context, input *elasticache.ModifyReplicationGroupShardConfigurationInput, opts ...request.Option) (out *elasticache.ModifyReplicationGroupShardConfigurationOutput, err error) {
+	err = c.do(ctx, "elasticache", "ModifyReplicationGroupShardConfiguration", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyReplicationGroupShardConfigurationWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyCacheParameterGroupWithContext(ctx aws.Context, input *elasticache.ModifyCacheParameterGroupInput, opts ...request.Option) (out *elasticache.CacheParameterGroupNameMessage, err error) {
+	err = c.do(ctx, "elasticache", "ModifyCacheParameterGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyCacheParameterGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateServerlessCacheWithContext(ctx aws.Context, input *elasticache.CreateServerlessCacheInput, opts ...request.Option) (out *elasticache.CreateServerlessCacheOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateServerlessCache", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateServerlessCacheWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DescribeServerlessCachesWithContext(ctx aws.Context, input *elasticache.DescribeServerlessCachesInput, opts ...request.Option) (out *elasticache.DescribeServerlessCachesOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeServerlessCaches", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeServerlessCachesWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyServerlessCacheWithContext(ctx aws.Context, input *elasticache.ModifyServerlessCacheInput, opts ...request.Option) (out *elasticache.ModifyServerlessCacheOutput, err error) {
+	err = c.do(ctx, "elasticache", "ModifyServerlessCache", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyServerlessCacheWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteServerlessCacheWithContext(ctx aws.Context, input *elasticache.DeleteServerlessCacheInput, opts ...request.Option) (out *elasticache.DeleteServerlessCacheOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteServerlessCache", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteServerlessCacheWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateServerlessCacheSnapshotWithContext(ctx aws.Context, input *elasticache.CreateServerlessCacheSnapshotInput, opts ...request.Option) (out *elasticache.CreateServerlessCacheSnapshotOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateServerlessCacheSnapshot", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateServerlessCacheSnapshotWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+// DescribeServerlessCacheSnapshotsPagesWithContext isn't retried once a page has been passed to fn, every
+// page waits for the rate limit
+func (c *ResilientClient) DescribeServerlessCacheSnapshotsPagesWithContext(ctx aws.Context, input *elasticache.DescribeServerlessCacheSnapshotsInput, fn func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool, opts ...request.Option) error {
+	return c.do(ctx, "elasticache", "DescribeServerlessCacheSnapshotsPages", func() (retryPolicy, error) {
+		called := false
+		err := c.elastiCache.DescribeServerlessCacheSnapshotsPagesWithContext(ctx, input, func(page *elasticache.DescribeServerlessCacheSnapshotsOutput, lastPage bool) bool {
+			called = true
+			more := fn(page, lastPage)
+			if more && !lastPage {
+				c.waitForNextPage(ctx)
+			}
+			return more
+		}, opts...)
+		if called {
+			return retryNever, err
+		}
+		return retryTransient, err
+	})
+}
+
+func (c *ResilientClient) CreateSnapshotWithContext(ctx aws.Context, input *elasticache.CreateSnapshotInput, opts ...request.Option) (out *elasticache.CreateSnapshotOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateSnapshot", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateSnapshotWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+// DescribeSnapshotsPagesWithContext isn't retried once a page has been passed to fn, every
+// page waits for the rate limit
+func (c *ResilientClient) DescribeSnapshotsPagesWithContext(ctx aws.Context, input *elasticache.DescribeSnapshotsInput, fn func(*elasticache.DescribeSnapshotsOutput, bool) bool, opts ...request.Option) error {
+	return c.do(ctx, "elasticache", "DescribeSnapshotsPages", func() (retryPolicy, error) {
+		called := false
+		err := c.elastiCache.DescribeSnapshotsPagesWithContext(ctx, input, func(page *elasticache.DescribeSnapshotsOutput, lastPage bool) bool {
+			called = true
+			more := fn(page, lastPage)
+			if more && !lastPage {
+				c.waitForNextPage(ctx)
+			}
+			return more
+		}, opts...)
+		if called {
+			return retryNever, err
+		}
+		return retryTransient, err
+	})
+}
+
+func (c *ResilientClient) ListTagsForResourceWithContext(ctx aws.Context, input *elasticache.ListTagsForResourceInput, opts ...request.Option) (out *elasticache.TagListMessage, err error) {
+	err = c.do(ctx, "elasticache", "ListTagsForResource", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ListTagsForResourceWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+// DescribeEventsPagesWithContext isn't retried once a page has been passed to fn, every
+// page waits for the rate limit
+func (c *ResilientClient) DescribeEventsPagesWithContext(ctx aws.Context, input *elasticache.DescribeEventsInput, fn func(*elasticache.DescribeEventsOutput, bool) bool, opts ...request.Option) error {
+	return c.do(ctx, "elasticache", "DescribeEventsPages", func() (retryPolicy, error) {
+		called := false
+		err := c.elastiCache.DescribeEventsPagesWithContext(ctx, input, func(page *elasticache.DescribeEventsOutput, lastPage bool) bool {
+			called = true
+			more := fn(page, lastPage)
+			if more && !lastPage {
+				c.waitForNextPage(ctx)
+			}
+			return more
+		}, opts...)
+		if called {
+			return retryNever, err
+		}
+		return retryTransient, err
+	})
+}
+
+func (c *ResilientClient) TestFailoverWithContext(ctx aws.Context, input *elasticache.TestFailoverInput, opts ...request.Option) (out *elasticache.TestFailoverOutput, err error) {
+	err = c.do(ctx, "elasticache", "TestFailover", func() (retryPolicy, error) {
+		out, err = c.elastiCache.TestFailoverWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) AddTagsToResourceWithContext(ctx aws.Context, input *elasticache.AddTagsToResourceInput, opts ...request.Option) (out *elasticache.TagListMessage, err error) {
+	err = c.do(ctx, "elasticache", "AddTagsToResource", func() (retryPolicy, error) {
+		out, err = c.elastiCache.AddTagsToResourceWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) RemoveTagsFromResourceWithContext(ctx aws.Context, input *elasticache.RemoveTagsFromResourceInput, opts ...request.Option) (out *elasticache.TagListMessage, err error) {
+	err = c.do(ctx, "elasticache", "RemoveTagsFromResource", func() (retryPolicy, error) {
+		out, err = c.elastiCache.RemoveTagsFromResourceWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) IncreaseReplicaCountWithContext(ctx aws.Context, input *elasticache.IncreaseReplicaCountInput, opts ...request.Option) (out *elasticache.IncreaseReplicaCountOutput, err error) {
+	err = c.do(ctx, "elasticache", "IncreaseReplicaCount", func() (retryPolicy, error) {
+		out, err = c.elastiCache.IncreaseReplicaCountWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DecreaseReplicaCountWithContext(ctx aws.Context, input *elasticache.DecreaseReplicaCountInput, opts ...request.Option) (out *elasticache.DecreaseReplicaCountOutput, err error) {
+	err = c.do(ctx, "elasticache", "DecreaseReplicaCount", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DecreaseReplicaCountWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateUserWithContext(ctx aws.Context, input *elasticache.CreateUserInput, opts ...request.Option) (out *elasticache.CreateUserOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateUser", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateUserWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteUserWithContext(ctx aws.Context, input *elasticache.DeleteUserInput, opts ...request.Option) (out *elasticache.DeleteUserOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteUser", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteUserWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateUserGroupWithContext(ctx aws.Context, input *elasticache.CreateUserGroupInput, opts ...request.Option) (out *elasticache.CreateUserGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "CreateUserGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.CreateUserGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DescribeUserGroupsWithContext(ctx aws.Context, input *elasticache.DescribeUserGroupsInput, opts ...request.Option) (out *elasticache.DescribeUserGroupsOutput, err error) {
+	err = c.do(ctx, "elasticache", "DescribeUserGroups", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DescribeUserGroupsWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) ModifyUserGroupWithContext(ctx aws.Context, input *elasticache.ModifyUserGroupInput, opts ...request.Option) (out *elasticache.ModifyUserGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "ModifyUserGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.ModifyUserGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteUserGroupWithContext(ctx aws.Context, input *elasticache.DeleteUserGroupInput, opts ...request.Option) (out *elasticache.DeleteUserGroupOutput, err error) {
+	err = c.do(ctx, "elasticache", "DeleteUserGroup", func() (retryPolicy, error) {
+		out, err = c.elastiCache.DeleteUserGroupWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) CreateSecretWithContext(ctx aws.Context, input *secretsmanager.CreateSecretInput, opts ...request.Option) (out *secretsmanager.CreateSecretOutput, err error) {
+	err = c.do(ctx, "secretsmanager", "CreateSecret", func() (retryPolicy, error) {
+		out, err = c.secretsManager.CreateSecretWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (out *secretsmanager.GetSecretValueOutput, err error) {
+	err = c.do(ctx, "secretsmanager", "GetSecretValue", func() (retryPolicy, error) {
+		out, err = c.secretsManager.GetSecretValueWithContext(ctx, input, opts...)
+		return retryTransient, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) PutSecretValueWithContext(ctx aws.Context, input *secretsmanager.PutSecretValueInput, opts ...request.Option) (out *secretsmanager.PutSecretValueOutput, err error) {
+	err = c.do(ctx, "secretsmanager", "PutSecretValue", func() (retryPolicy, error) {
+		out, err = c.secretsManager.PutSecretValueWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
+
+func (c *ResilientClient) DeleteSecretWithContext(ctx aws.Context, input *secretsmanager.DeleteSecretInput, opts ...request.Option) (out *secretsmanager.DeleteSecretOutput, err error) {
+	err = c.do(ctx, "secretsmanager", "DeleteSecret", func() (retryPolicy, error) {
+		out, err = c.secretsManager.DeleteSecretWithContext(ctx, input, opts...)
+		return retryThrottled, err
+	})
+	return out, err
+}
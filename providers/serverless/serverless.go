@@ -0,0 +1,698 @@
+package serverless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+)
+
+var _ providers.Provider = &ServerlessProvider{}
+
+// Errors returned for the features serverless caches don't have, as AWS manages their nodes
+var (
+	ErrParametersNotSupported        = providers.NotSupportedError("Serverless caches do not support cache parameters")
+	ErrMaintenanceWindowNotSupported = providers.NotSupportedError("Serverless caches do not have a maintenance window")
+	ErrNodeTypeNotSupported          = providers.NotSupportedError("Serverless caches do not have a node type")
+	ErrReplicasNotSupported          = providers.NotSupportedError("Serverless caches do not support setting the number of replicas")
+	ErrShardsNotSupported            = providers.NotSupportedError("Serverless caches do not support setting the number of shards")
+	ErrFailoverNotSupported          = providers.NotSupportedError("Serverless caches fail over automatically, testing a failover is not supported")
+	ErrAuthTokenNotSupported         = providers.NotSupportedError("Serverless caches do not support auth tokens, they have a user per binding")
+	ErrEngineUpgradeNotSupported     = providers.NotSupportedError("Upgrading the engine version is not supported for serverless caches")
+)
+
+// The default engine of serverless caches
+const defaultEngine = "redis"
+
+// ServerlessProvider is the ElastiCache Serverless broker provider
+type ServerlessProvider struct {
+	elastiCache  providers.ElastiCache
+	awsAccountID string
+	awsPartition string
+	awsRegion    string
+	logger       lager.Logger
+	users        *providers.Users
+}
+
+// NewProvider creates a new ElastiCache Serverless provider
+// The passwords of the binding users are stored in the secrets manager, in the same way as the Redis provider does.
+func NewProvider(
+	elastiCache providers.ElastiCache,
+	secretsManager providers.SecretsManager,
+	awsAccountID, awsPartition,
+	awsRegion string,
+	logger lager.Logger,
+	kmsKeyID string,
+	secretsManagerPath string,
+) *ServerlessProvider {
+	return &ServerlessProvider{
+		elastiCache:  elastiCache,
+		awsAccountID: awsAccountID,
+		awsPartition: awsPartition,
+		awsRegion:    awsRegion,
+		logger:       logger,
+		users:        providers.NewUsers(elastiCache, secretsManager, logger, kmsKeyID, secretsManagerPath),
+	}
+}
+
+// Provision creates a serverless cache with a user group
+// Serverless caches are placed in the subnets of the configured cache subnet group, as they don't use subnet groups.
+// The default user of the user group is switched off, every binding gets its own user.
+func (p *ServerlessProvider) Provision(ctx context.Context, instanceID string, params providers.ProvisionParameters) error {
+	if len(params.Parameters) > 0 {
+		return ErrParametersNotSupported
+	}
+	if params.PreferredMaintenanceWindow != "" {
+		return ErrMaintenanceWindowNotSupported
+	}
+
+	subnetIDs, err := p.getSubnetIDs(ctx, params.CacheSubnetGroupName)
+	if err != nil {
+		return err
+	}
+
+	engine := params.Engine
+	if engine == "" {
+		engine = defaultEngine
+	}
+
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+	input := &elasticache.CreateServerlessCacheInput{
+		Tags:                   []*elasticache.Tag{},
+		ServerlessCacheName:    aws.String(serverlessCacheName),
+		UserGroupId:            aws.String(serverlessCacheName),
+		Description:            aws.String(params.Description),
+		Engine:                 aws.String(engine),
+		SecurityGroupIds:       aws.StringSlice(params.SecurityGroupIds),
+		SubnetIds:              aws.StringSlice(subnetIDs),
+		SnapshotRetentionLimit: aws.Int64(params.SnapshotRetentionLimit),
+		CacheUsageLimits:       cacheUsageLimits(params.CacheUsageLimits),
+	}
+
+	if params.EngineVersion != "" {
+		input.SetMajorEngineVersion(strings.Split(params.EngineVersion, ".")[0])
+	}
+
+	if params.DailyBackupWindow != "" {
+		input.SetDailySnapshotTime(dailySnapshotTime(params.DailyBackupWindow))
+	}
+
+	if params.RestoreFromSnapshot != nil {
+		input.SetSnapshotArnsToRestore([]*string{aws.String(p.snapshotARN(*params.RestoreFromSnapshot))})
+	}
+
+	for tagName, tagValue := range params.Tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	steps := []providers.Step{
+		{
+			Name: "default user",
+			Do: func(ctx context.Context) error {
+				err := p.users.CreateDefaultUser(ctx, serverlessCacheName, instanceID, engine, providers.GeneratePassword())
+				if err != nil {
+					return fmt.Errorf("failed to create default user: %s", err.Error())
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				return p.users.DeleteDefaultUser(ctx, serverlessCacheName)
+			},
+		},
+		{
+			Name: "user group",
+			Do: func(ctx context.Context) error {
+				err := p.users.CreateUserGroup(ctx, serverlessCacheName, instanceID, engine)
+				if err != nil {
+					return fmt.Errorf("failed to create user group: %s", err.Error())
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				return p.users.DeleteUserGroup(ctx, serverlessCacheName)
+			},
+		},
+		{
+			Name: "serverless cache",
+			Do: func(ctx context.Context) error {
+				_, err := p.elastiCache.CreateServerlessCacheWithContext(ctx, input)
+				if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheAlreadyExistsFault) {
+					// A retried or concurrent request has created it already, and it uses the user group, so it must
+					// not be rolled back
+					p.logger.Info("serverless-cache-exists", lager.Data{"instance-id": instanceID})
+					return nil
+				}
+				return err
+			},
+		},
+	}
+
+	return providers.RunSteps(ctx, p.logger.Session("provision", lager.Data{"instance-id": instanceID}), steps)
+}
+
+func (p *ServerlessProvider) getSubnetIDs(ctx context.Context, cacheSubnetGroupName string) ([]string, error) {
+	output, err := p.elastiCache.DescribeCacheSubnetGroupsWithContext(ctx, &elasticache.DescribeCacheSubnetGroupsInput{
+		CacheSubnetGroupName: aws.String(cacheSubnetGroupName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.CacheSubnetGroups) == 0 {
+		return nil, fmt.Errorf("Invalid response from AWS: no CacheSubnetGroups found for %s", cacheSubnetGroupName)
+	}
+
+	subnetIDs := []string{}
+	for _, subnet := range output.CacheSubnetGroups[0].Subnets {
+		subnetIDs = append(subnetIDs, aws.StringValue(subnet.SubnetIdentifier))
+	}
+	return subnetIDs, nil
+}
+
+// cacheUsageLimits converts the usage limits of a plan, the zero limits are left unset
+func cacheUsageLimits(limits providers.CacheUsageLimits) *elasticache.CacheUsageLimits {
+	if limits.DataStorageMaximumGB == 0 && limits.ECPUPerSecondMaximum == 0 {
+		return nil
+	}
+	usageLimits := &elasticache.CacheUsageLimits{}
+	if limits.DataStorageMaximumGB > 0 {
+		usageLimits.SetDataStorage(&elasticache.DataStorage{
+			Maximum: aws.Int64(limits.DataStorageMaximumGB),
+			Unit:    aws.String(elasticache.DataStorageUnitGb),
+		})
+	}
+	if limits.ECPUPerSecondMaximum > 0 {
+		usageLimits.SetECPUPerSecond(&elasticache.ECPUPerSecond{
+			Maximum: aws.Int64(limits.ECPUPerSecondMaximum),
+		})
+	}
+	return usageLimits
+}
+
+// dailySnapshotTime returns the start of a daily backup window, as serverless caches only have a snapshot time
+func dailySnapshotTime(dailyBackupWindow string) string {
+	return strings.Split(dailyBackupWindow, "-")[0]
+}
+
+// UpdateReplicationGroup modifies the serverless cache and its tags
+// Only the usage limits and the daily snapshot time can be changed, the capacity is managed by AWS.
+func (p *ServerlessProvider) UpdateReplicationGroup(ctx context.Context, instanceID string, params providers.UpdateReplicationGroupParameters) error {
+	if params.PreferredMaintenanceWindow != "" {
+		return ErrMaintenanceWindowNotSupported
+	}
+	if params.CacheNodeType != "" {
+		return ErrNodeTypeNotSupported
+	}
+	if params.ReplicasPerNodeGroup != nil {
+		return ErrReplicasNotSupported
+	}
+	if params.ShardCount != nil {
+		return ErrShardsNotSupported
+	}
+	if params.EngineVersion != "" {
+		return ErrEngineUpgradeNotSupported
+	}
+
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+
+	input := &elasticache.ModifyServerlessCacheInput{
+		ServerlessCacheName: aws.String(serverlessCacheName),
+	}
+	modified := false
+
+	if params.DailyBackupWindow != "" {
+		input.SetDailySnapshotTime(dailySnapshotTime(params.DailyBackupWindow))
+		modified = true
+	}
+	if params.SnapshotRetentionLimit != nil {
+		input.SetSnapshotRetentionLimit(*params.SnapshotRetentionLimit)
+		modified = true
+	}
+	if params.CacheUsageLimits != nil {
+		usageLimits := cacheUsageLimits(*params.CacheUsageLimits)
+		if usageLimits == nil {
+			return errors.New("Removing the usage limits of a serverless cache is not supported")
+		}
+		input.SetCacheUsageLimits(usageLimits)
+		modified = true
+	}
+
+	if modified {
+		_, err := p.elastiCache.ModifyServerlessCacheWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.addTags(ctx, p.serverlessCacheARN(serverlessCacheName), params.Tags)
+}
+
+func (p *ServerlessProvider) addTags(ctx context.Context, resourceName string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	input := &elasticache.AddTagsToResourceInput{
+		ResourceName: aws.String(resourceName),
+		Tags:         []*elasticache.Tag{},
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	_, err := p.elastiCache.AddTagsToResourceWithContext(ctx, input)
+	return err
+}
+
+// UpdateParamGroupParameters returns an error, as serverless caches have no cache parameter group
+func (p *ServerlessProvider) UpdateParamGroupParameters(ctx context.Context, instanceID string, params providers.UpdateParamGroupParameters) error {
+	return ErrParametersNotSupported
+}
+
+// Deprovision deletes the serverless cache
+// If a final snapshot is requested it gets the tags of the cache, so it can be found by FindSnapshots.
+// Deprovision fails if the final snapshot can't be tagged, and tagging it is retried when the deprovision is retried
+// while the cache is being deleted.
+func (p *ServerlessProvider) Deprovision(ctx context.Context, instanceID string, params providers.DeprovisionParameters) error {
+	input := &elasticache.DeleteServerlessCacheInput{
+		ServerlessCacheName: aws.String(GenerateServerlessCacheName(instanceID)),
+	}
+	var tags map[string]string
+	if params.FinalSnapshotIdentifier != "" {
+		var err error
+		tags, err = p.GetInstanceTags(ctx, instanceID)
+		if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+			return providers.ErrInstanceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		input.SetFinalSnapshotName(params.FinalSnapshotIdentifier)
+	}
+
+	_, err := p.elastiCache.DeleteServerlessCacheWithContext(ctx, input)
+	if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+		return providers.ErrInstanceNotFound
+	}
+	if providers.IsAWSError(err, elasticache.ErrCodeInvalidServerlessCacheStateFault) {
+		serverlessCache, describeErr := p.describeServerlessCache(ctx, aws.StringValue(input.ServerlessCacheName))
+		if describeErr == nil && aws.StringValue(serverlessCache.Status) == "deleting" {
+			// A retried request, the serverless cache is already being deleted
+			return p.tagFinalSnapshot(ctx, instanceID, params.FinalSnapshotIdentifier, tags)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.tagFinalSnapshot(ctx, instanceID, params.FinalSnapshotIdentifier, tags)
+}
+
+// tagFinalSnapshot gives the final snapshot of a deleted cache the tags of the cache
+// Without them FindSnapshots can't find it, so it is an error instead of only being logged.
+func (p *ServerlessProvider) tagFinalSnapshot(ctx context.Context, instanceID, snapshotName string, tags map[string]string) error {
+	if snapshotName == "" {
+		return nil
+	}
+	err := p.addTags(ctx, p.snapshotARN(snapshotName), tags)
+	if err != nil {
+		p.logger.Error("tag-final-snapshot", err, lager.Data{
+			"instance-id":   instanceID,
+			"snapshot-name": snapshotName,
+		})
+		return fmt.Errorf("failed to tag the final snapshot %s: %w", snapshotName, err)
+	}
+	return nil
+}
+
+// DeleteCacheParameterGroup is a no-op, as serverless caches have no cache parameter group
+func (p *ServerlessProvider) DeleteCacheParameterGroup(ctx context.Context, instanceID string) error {
+	return nil
+}
+
+// DeleteUnusedCacheParameterGroups is a no-op, as serverless caches have no cache parameter group
+func (p *ServerlessProvider) DeleteUnusedCacheParameterGroups(ctx context.Context, instanceID string) error {
+	return nil
+}
+
+// DeleteUserGroup deletes the user group and the default user of an instance
+// It can only be called after the serverless cache was deleted.
+func (p *ServerlessProvider) DeleteUserGroup(ctx context.Context, instanceID string) error {
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+	err := p.users.DeleteUserGroup(ctx, serverlessCacheName)
+	if err != nil {
+		return err
+	}
+	return p.users.DeleteDefaultUser(ctx, serverlessCacheName)
+}
+
+// ProgressState returns with the state of an existing serverless cache
+// If the cache doesn't exist we return with the providers.NonExisting state
+func (p *ServerlessProvider) ProgressState(
+	ctx context.Context,
+	instanceID string,
+	operation string,
+	primaryNode string,
+) (providers.ServiceState, string, error) {
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+
+	serverlessCache, err := p.describeServerlessCache(ctx, serverlessCacheName)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+			return providers.NonExisting, fmt.Sprintf("Serverless cache does not exist: %s", serverlessCacheName), nil
+		}
+		return providers.ServiceState(""), "", err
+	}
+
+	if serverlessCache.Status == nil {
+		return providers.ServiceState(""), "", fmt.Errorf("Invalid response from AWS: status is missing for %s", serverlessCacheName)
+	}
+
+	return providers.ServiceState(*serverlessCache.Status), p.getMessage(serverlessCache), nil
+}
+
+func (p *ServerlessProvider) getMessage(serverlessCache *elasticache.ServerlessCache) string {
+	tmpl := "%-20s : %s"
+	msgs := []string{"---"}
+	msgs = append(msgs, fmt.Sprintf(tmpl, "status", aws.StringValue(serverlessCache.Status)))
+	msgs = append(msgs, fmt.Sprintf(tmpl, "cache name", aws.StringValue(serverlessCache.ServerlessCacheName)))
+	if serverlessCache.Engine != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "engine", *serverlessCache.Engine))
+	}
+	if serverlessCache.FullEngineVersion != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "engine version", *serverlessCache.FullEngineVersion))
+	}
+	if limits := serverlessCache.CacheUsageLimits; limits != nil {
+		if limits.DataStorage != nil && limits.DataStorage.Maximum != nil {
+			msgs = append(msgs, fmt.Sprintf(tmpl, "data storage limit",
+				fmt.Sprintf("%d %s", *limits.DataStorage.Maximum, aws.StringValue(limits.DataStorage.Unit))))
+		}
+		if limits.ECPUPerSecond != nil && limits.ECPUPerSecond.Maximum != nil {
+			msgs = append(msgs, fmt.Sprintf(tmpl, "ECPU/s limit", fmt.Sprint(*limits.ECPUPerSecond.Maximum)))
+		}
+	}
+	if serverlessCache.DailySnapshotTime != nil {
+		msgs = append(msgs, fmt.Sprintf(tmpl, "daily snapshot time", *serverlessCache.DailySnapshotTime))
+	}
+	return strings.Join(msgs, "\n           ")
+}
+
+func (p *ServerlessProvider) describeServerlessCache(ctx context.Context, serverlessCacheName string) (*elasticache.ServerlessCache, error) {
+	output, err := p.elastiCache.DescribeServerlessCachesWithContext(ctx, &elasticache.DescribeServerlessCachesInput{
+		ServerlessCacheName: aws.String(serverlessCacheName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.ServerlessCaches) == 0 {
+		return nil, fmt.Errorf("Invalid response from AWS: no ServerlessCaches found for %s", serverlessCacheName)
+	}
+
+	return output.ServerlessCaches[0], nil
+}
+
+// GenerateCredentials creates a user for the binding and returns it with the endpoint of the serverless cache
+// Serverless caches only accept TLS connections. A cache without a user group can't be bound, as it would accept the
+// connections of anyone in the VPC.
+func (p *ServerlessProvider) GenerateCredentials(ctx context.Context, instanceID, bindingID string) (*providers.Credentials, error) {
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+
+	serverlessCache, err := p.describeServerlessCache(ctx, serverlessCacheName)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+			return nil, providers.InstanceNotFoundError(fmt.Sprintf("Serverless cache does not exist: %s", serverlessCacheName))
+		}
+		return nil, err
+	}
+
+	if serverlessCache.Endpoint == nil {
+		return nil, fmt.Errorf("Invalid response from AWS: no endpoint returned for %s", serverlessCacheName)
+	}
+	if aws.StringValue(serverlessCache.UserGroupId) == "" {
+		return nil, fmt.Errorf("Serverless cache %s has no user group", serverlessCacheName)
+	}
+	host := aws.StringValue(serverlessCache.Endpoint.Address)
+	port := aws.Int64Value(serverlessCache.Endpoint.Port)
+
+	userName, password, err := p.users.CreateBindingUser(ctx, instanceID, bindingID, *serverlessCache.UserGroupId)
+	if err != nil {
+		return nil, err
+	}
+	uri := &url.URL{
+		Scheme: "rediss",
+		Host:   fmt.Sprintf("%s:%d", host, port),
+		User:   url.UserPassword(userName, password),
+	}
+	return &providers.Credentials{
+		Host:       host,
+		Port:       port,
+		Name:       serverlessCacheName,
+		Username:   userName,
+		Password:   password,
+		TLSEnabled: true,
+		URI:        uri.String(),
+	}, nil
+}
+
+// RevokeCredentials deletes the user of the binding, which also removes it from the user group
+func (p *ServerlessProvider) RevokeCredentials(ctx context.Context, instanceID, bindingID string) error {
+	return p.users.DeleteBindingUser(ctx, instanceID, bindingID)
+}
+
+func (p *ServerlessProvider) GetInstanceTags(ctx context.Context, instanceID string) (map[string]string, error) {
+	return p.listTags(ctx, p.serverlessCacheARN(GenerateServerlessCacheName(instanceID)))
+}
+
+func (p *ServerlessProvider) listTags(ctx context.Context, resourceName string) (map[string]string, error) {
+	awsTags, err := p.elastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{
+		ResourceName: aws.String(resourceName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for _, t := range awsTags.TagList {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+// FindInstance returns the state and the tags of the serverless cache of an instance, or nil if it doesn't exist
+func (p *ServerlessProvider) FindInstance(ctx context.Context, instanceID string) (*providers.ExistingInstance, error) {
+	serverlessCache, err := p.describeServerlessCache(ctx, GenerateServerlessCacheName(instanceID))
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	return &providers.ExistingInstance{
+		State: providers.ServiceState(aws.StringValue(serverlessCache.Status)),
+		Tags:  tags,
+	}, nil
+}
+
+func (p *ServerlessProvider) GetInstanceParameters(ctx context.Context, instanceID string) (providers.InstanceParameters, error) {
+	serverlessCacheName := GenerateServerlessCacheName(instanceID)
+	instanceParameters := providers.InstanceParameters{}
+
+	serverlessCache, err := p.describeServerlessCache(ctx, serverlessCacheName)
+	if err != nil {
+		if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheNotFoundFault) {
+			return instanceParameters, fmt.Errorf("Serverless cache does not exist: %s", serverlessCacheName)
+		}
+		return instanceParameters, err
+	}
+
+	instanceParameters.Engine = aws.StringValue(serverlessCache.Engine)
+	instanceParameters.EngineVersion = aws.StringValue(serverlessCache.FullEngineVersion)
+	if limits := serverlessCache.CacheUsageLimits; limits != nil {
+		instanceParameters.CacheUsageLimits = &providers.CacheUsageLimits{}
+		if limits.DataStorage != nil {
+			instanceParameters.CacheUsageLimits.DataStorageMaximumGB = aws.Int64Value(limits.DataStorage.Maximum)
+		}
+		if limits.ECPUPerSecond != nil {
+			instanceParameters.CacheUsageLimits.ECPUPerSecondMaximum = aws.Int64Value(limits.ECPUPerSecond.Maximum)
+		}
+	}
+
+	return instanceParameters, nil
+}
+
+func (p *ServerlessProvider) describeSnapshots(ctx context.Context, input *elasticache.DescribeServerlessCacheSnapshotsInput) ([]*elasticache.ServerlessCacheSnapshot, error) {
+	snapshots := []*elasticache.ServerlessCacheSnapshot{}
+	err := p.elastiCache.DescribeServerlessCacheSnapshotsPagesWithContext(ctx, input, func(page *elasticache.DescribeServerlessCacheSnapshotsOutput, lastPage bool) bool {
+		snapshots = append(snapshots, page.ServerlessCacheSnapshots...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.ServerlessCacheSnapshotName == nil || snapshot.CreateTime == nil {
+			return nil, errors.New("Invalid response from AWS: Missing values for serverless cache snapshot")
+		}
+	}
+	return snapshots, nil
+}
+
+// FindSnapshot returns a serverless cache snapshot with its tags, or nil if it doesn't exist
+func (p *ServerlessProvider) FindSnapshot(ctx context.Context, snapshotName string) (*providers.SnapshotInfo, error) {
+	snapshots, err := p.describeSnapshots(ctx, &elasticache.DescribeServerlessCacheSnapshotsInput{
+		ServerlessCacheSnapshotName: aws.String(snapshotName),
+	})
+	if providers.IsAWSError(err, elasticache.ErrCodeServerlessCacheSnapshotNotFoundFault) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	tags, err := p.listTags(ctx, p.snapshotARN(snapshotName))
+	if err != nil {
+		return nil, err
+	}
+	return &providers.SnapshotInfo{
+		Name:       snapshotName,
+		CreateTime: *snapshots[0].CreateTime,
+		Tags:       tags,
+	}, nil
+}
+
+// FindSnapshots returns the snapshots of an instance, including the final snapshot of a deleted instance
+func (p *ServerlessProvider) FindSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	return p.FindSnapshotsWithTags(ctx, map[string]string{"instance-id": instanceID})
+}
+
+// FindSnapshotsWithTags returns the snapshots which have all the given tags
+func (p *ServerlessProvider) FindSnapshotsWithTags(ctx context.Context, tags map[string]string) ([]providers.SnapshotInfo, error) {
+	snapshots, err := p.describeSnapshots(ctx, &elasticache.DescribeServerlessCacheSnapshotsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInfos := []providers.SnapshotInfo{}
+	for _, snapshot := range snapshots {
+		snapshotTags, err := p.listTags(ctx, p.snapshotARN(*snapshot.ServerlessCacheSnapshotName))
+		if err != nil {
+			return nil, err
+		}
+		if providers.HasTags(snapshotTags, tags) {
+			snapshotInfos = append(snapshotInfos, providers.SnapshotInfo{
+				Name:       *snapshot.ServerlessCacheSnapshotName,
+				CreateTime: *snapshot.CreateTime,
+				Tags:       snapshotTags,
+			})
+		}
+	}
+	return snapshotInfos, nil
+}
+
+// ListSnapshots returns the name, creation time, source and status of the snapshots of an existing instance
+func (p *ServerlessProvider) ListSnapshots(ctx context.Context, instanceID string) ([]providers.SnapshotInfo, error) {
+	snapshots, err := p.describeSnapshots(ctx, &elasticache.DescribeServerlessCacheSnapshotsInput{
+		ServerlessCacheName: aws.String(GenerateServerlessCacheName(instanceID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInfos := []providers.SnapshotInfo{}
+	for _, snapshot := range snapshots {
+		snapshotInfos = append(snapshotInfos, providers.SnapshotInfo{
+			Name:       *snapshot.ServerlessCacheSnapshotName,
+			CreateTime: *snapshot.CreateTime,
+			Source:     aws.StringValue(snapshot.SnapshotType),
+			Status:     aws.StringValue(snapshot.Status),
+		})
+	}
+	return snapshotInfos, nil
+}
+
+// CreateSnapshot creates a manual snapshot of the serverless cache
+// The snapshot gets the tags of the serverless cache, so it can be found by FindSnapshots.
+func (p *ServerlessProvider) CreateSnapshot(ctx context.Context, instanceID string, snapshotName string) error {
+	tags, err := p.GetInstanceTags(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	input := &elasticache.CreateServerlessCacheSnapshotInput{
+		ServerlessCacheName:         aws.String(GenerateServerlessCacheName(instanceID)),
+		ServerlessCacheSnapshotName: aws.String(snapshotName),
+	}
+	for tagName, tagValue := range tags {
+		input.Tags = append(input.Tags, &elasticache.Tag{
+			Key:   aws.String(tagName),
+			Value: aws.String(tagValue),
+		})
+	}
+
+	_, err = p.elastiCache.CreateServerlessCacheSnapshotWithContext(ctx, input)
+	return err
+}
+
+func (p *ServerlessProvider) StartFailoverTest(ctx context.Context, instanceID string) (string, error) {
+	return "", ErrFailoverNotSupported
+}
+
+func (p *ServerlessProvider) TestFailover(ctx context.Context, instanceID string, nodeGroupID string) (string, error) {
+	return "", ErrFailoverNotSupported
+}
+
+func (p *ServerlessProvider) FailoverTestState(ctx context.Context, instanceID string, nodeGroupID string, startTime time.Time) (providers.ServiceState, string, error) {
+	return providers.ServiceState(""), "", ErrFailoverNotSupported
+}
+
+func (p *ServerlessProvider) RestoreHighAvailability(ctx context.Context, instanceID string) (bool, error) {
+	return false, ErrFailoverNotSupported
+}
+
+// FindDegradedInstances returns no instances, as serverless caches don't have automatic failover or Multi-AZ to disable
+func (p *ServerlessProvider) FindDegradedInstances(ctx context.Context, brokerName string) ([]providers.DegradedInstance, error) {
+	return nil, nil
+}
+
+func (p *ServerlessProvider) RotateAuthToken(ctx context.Context, instanceID string) error {
+	return ErrAuthTokenNotSupported
+}
+
+func (p *ServerlessProvider) UpgradeEngineVersion(ctx context.Context, instanceID string, params providers.UpgradeEngineVersionParameters) error {
+	return ErrEngineUpgradeNotSupported
+}
+
+func (p *ServerlessProvider) serverlessCacheARN(serverlessCacheName string) string {
+	return fmt.Sprintf("arn:%s:elasticache:%s:%s:serverlesscache:%s", p.awsPartition, p.awsRegion, p.awsAccountID, serverlessCacheName)
+}
+
+func (p *ServerlessProvider) snapshotARN(snapshotName string) string {
+	return fmt.Sprintf("arn:%s:elasticache:%s:%s:serverlesscachesnapshot:%s", p.awsPartition, p.awsRegion, p.awsAccountID, snapshotName)
+}
+
+// GenerateServerlessCacheName generates a valid ElastiCache serverless cache name
+// A valid name must contain between 1 and 40 alphanumeric characters or hyphens, should start with a letter, and cannot end with a hyphen or contain two consecutive hyphens.
+func GenerateServerlessCacheName(instanceID string) string {
+	return providers.GenerateName("cf-", instanceID)
+}
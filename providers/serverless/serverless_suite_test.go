@@ -0,0 +1,13 @@
+package serverless_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestServerless(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Serverless Suite")
+}
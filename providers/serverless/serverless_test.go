@@ -0,0 +1,703 @@
+package serverless_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+	. "github.com/alphagov/paas-elasticache-broker/providers/serverless"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Provider", func() {
+	var (
+		mockElasticache     *mocks.FakeElastiCache
+		mockSecretsManager  *mocks.FakeSecretsManager
+		ctx                 context.Context
+		instanceID          string
+		serverlessCacheName string
+		cacheARN            string
+
+		provider *ServerlessProvider
+	)
+
+	BeforeEach(func() {
+		mockElasticache = &mocks.FakeElastiCache{}
+		mockSecretsManager = &mocks.FakeSecretsManager{}
+		ctx = context.Background()
+		instanceID = "foobar"
+		serverlessCacheName = "cf-qwkec4pxhft6q"
+		cacheARN = "arn:aws:elasticache:eu-west-1:123456789012:serverlesscache:" + serverlessCacheName
+
+		provider = NewProvider(
+			mockElasticache,
+			mockSecretsManager,
+			"123456789012",
+			"aws",
+			"eu-west-1",
+			lager.NewLogger("logger"),
+			"my-kms-key",
+			"elasticache-broker-test",
+		)
+	})
+
+	It("generates the same cache names as the redis provider", func() {
+		Expect(GenerateServerlessCacheName(instanceID)).To(Equal(serverlessCacheName))
+	})
+
+	Context("when provisioning", func() {
+		var params providers.ProvisionParameters
+
+		BeforeEach(func() {
+			params = providers.ProvisionParameters{
+				SecurityGroupIds:       []string{"sg-1"},
+				CacheSubnetGroupName:   "subnet-group",
+				DailyBackupWindow:      "03:00-04:00",
+				SnapshotRetentionLimit: 7,
+				Description:            "Cloud Foundry service",
+				Tags:                   map[string]string{"instance-id": instanceID},
+				EngineVersion:          "7.1",
+				CacheUsageLimits: providers.CacheUsageLimits{
+					DataStorageMaximumGB: 10,
+					ECPUPerSecondMaximum: 5000,
+				},
+			}
+			mockElasticache.DescribeCacheSubnetGroupsWithContextReturns(&elasticache.DescribeCacheSubnetGroupsOutput{
+				CacheSubnetGroups: []*elasticache.CacheSubnetGroup{
+					{
+						Subnets: []*elasticache.Subnet{
+							{SubnetIdentifier: aws.String("subnet-1")},
+							{SubnetIdentifier: aws.String("subnet-2")},
+						},
+					},
+				},
+			}, nil)
+		})
+
+		It("creates a serverless cache in the subnets of the subnet group", func() {
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, subnetGroupInput, _ := mockElasticache.DescribeCacheSubnetGroupsWithContextArgsForCall(0)
+			Expect(subnetGroupInput.CacheSubnetGroupName).To(Equal(aws.String("subnet-group")))
+
+			Expect(mockElasticache.CreateServerlessCacheWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.CreateServerlessCacheWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.CreateServerlessCacheInput{
+				ServerlessCacheName:    aws.String(serverlessCacheName),
+				UserGroupId:            aws.String(serverlessCacheName),
+				Description:            aws.String("Cloud Foundry service"),
+				Engine:                 aws.String("redis"),
+				MajorEngineVersion:     aws.String("7"),
+				SecurityGroupIds:       aws.StringSlice([]string{"sg-1"}),
+				SubnetIds:              aws.StringSlice([]string{"subnet-1", "subnet-2"}),
+				SnapshotRetentionLimit: aws.Int64(7),
+				DailySnapshotTime:      aws.String("03:00"),
+				CacheUsageLimits: &elasticache.CacheUsageLimits{
+					DataStorage: &elasticache.DataStorage{
+						Maximum: aws.Int64(10),
+						Unit:    aws.String("GB"),
+					},
+					ECPUPerSecond: &elasticache.ECPUPerSecond{
+						Maximum: aws.Int64(5000),
+					},
+				},
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}))
+		})
+
+		It("creates a user group with a switched off default user", func() {
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateUserWithContextCallCount()).To(Equal(1))
+			_, userInput, _ := mockElasticache.CreateUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(serverlessCacheName + "-default")))
+			Expect(userInput.UserName).To(Equal(aws.String("default")))
+			Expect(userInput.Engine).To(Equal(aws.String("redis")))
+			Expect(userInput.AccessString).To(Equal(aws.String("off -@all")))
+
+			Expect(mockElasticache.CreateUserGroupWithContextCallCount()).To(Equal(1))
+			_, userGroupInput, _ := mockElasticache.CreateUserGroupWithContextArgsForCall(0)
+			Expect(userGroupInput.UserGroupId).To(Equal(aws.String(serverlessCacheName)))
+			Expect(userGroupInput.UserIds).To(Equal(aws.StringSlice([]string{serverlessCacheName + "-default"})))
+		})
+
+		It("deletes the user group and the default user if creating the serverless cache fails", func() {
+			mockElasticache.CreateServerlessCacheWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError("some error (user group: rolled back, default user: rolled back)"))
+			_, userGroupInput, _ := mockElasticache.DeleteUserGroupWithContextArgsForCall(0)
+			Expect(userGroupInput.UserGroupId).To(Equal(aws.String(serverlessCacheName)))
+			_, userInput, _ := mockElasticache.DeleteUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(serverlessCacheName + "-default")))
+		})
+
+		It("doesn't delete the user group and the default user if the serverless cache already exists", func() {
+			mockElasticache.CreateServerlessCacheWithContextReturns(nil, awserr.New(elasticache.ErrCodeServerlessCacheAlreadyExistsFault, "already exists", nil))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.DeleteUserGroupWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+		})
+
+		It("reuses the default user and the user group left over from an earlier attempt", func() {
+			mockElasticache.CreateUserWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserAlreadyExistsFault, "already exists", nil))
+			mockElasticache.CreateUserGroupWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserGroupAlreadyExistsFault, "already exists", nil))
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.CreateServerlessCacheWithContextCallCount()).To(Equal(1))
+			Expect(mockElasticache.DeleteUserGroupWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+		})
+
+		It("doesn't set the usage limits if the plan has none", func() {
+			params.CacheUsageLimits = providers.CacheUsageLimits{}
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.CreateServerlessCacheWithContextArgsForCall(0)
+			Expect(input.CacheUsageLimits).To(BeNil())
+		})
+
+		It("restores from a serverless cache snapshot", func() {
+			params.RestoreFromSnapshot = aws.String("some-snapshot")
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.CreateServerlessCacheWithContextArgsForCall(0)
+			Expect(input.SnapshotArnsToRestore).To(Equal(aws.StringSlice([]string{
+				"arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:some-snapshot",
+			})))
+		})
+
+		It("refuses cache parameters", func() {
+			params.Parameters = map[string]string{"maxmemory-policy": "noeviction"}
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError(ErrParametersNotSupported))
+			Expect(mockElasticache.CreateServerlessCacheWithContextCallCount()).To(Equal(0))
+		})
+
+		It("refuses a maintenance window", func() {
+			params.PreferredMaintenanceWindow = "sun:23:00-mon:01:30"
+
+			err := provider.Provision(ctx, instanceID, params)
+			Expect(err).To(MatchError(ErrMaintenanceWindowNotSupported))
+			Expect(mockElasticache.CreateServerlessCacheWithContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when updating", func() {
+		It("modifies the usage limits, the snapshot time and the tags", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				DailyBackupWindow: "05:00-06:00",
+				CacheUsageLimits:  &providers.CacheUsageLimits{DataStorageMaximumGB: 20},
+				Tags:              map[string]string{"plan-id": "plan2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.ModifyServerlessCacheWithContextCallCount()).To(Equal(1))
+			_, input, _ := mockElasticache.ModifyServerlessCacheWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.ModifyServerlessCacheInput{
+				ServerlessCacheName: aws.String(serverlessCacheName),
+				DailySnapshotTime:   aws.String("05:00"),
+				CacheUsageLimits: &elasticache.CacheUsageLimits{
+					DataStorage: &elasticache.DataStorage{
+						Maximum: aws.Int64(20),
+						Unit:    aws.String("GB"),
+					},
+				},
+			}))
+
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+			_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(tagsInput.ResourceName).To(Equal(aws.String(cacheARN)))
+		})
+
+		It("only updates the tags if nothing else changes", func() {
+			err := provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				Tags: map[string]string{"plan-id": "plan2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockElasticache.ModifyServerlessCacheWithContextCallCount()).To(Equal(0))
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+		})
+
+		It("refuses changes serverless caches don't support", func() {
+			replicas := int64(2)
+			Expect(provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				PreferredMaintenanceWindow: "sun:23:00-mon:01:30",
+			})).To(MatchError(ErrMaintenanceWindowNotSupported))
+			Expect(provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				CacheNodeType: "cache.m5.large",
+			})).To(MatchError(ErrNodeTypeNotSupported))
+			Expect(provider.UpdateReplicationGroup(ctx, instanceID, providers.UpdateReplicationGroupParameters{
+				ReplicasPerNodeGroup: &replicas,
+			})).To(MatchError(ErrReplicasNotSupported))
+			Expect(mockElasticache.ModifyServerlessCacheWithContextCallCount()).To(Equal(0))
+		})
+
+		It("refuses cache parameters", func() {
+			err := provider.UpdateParamGroupParameters(ctx, instanceID, providers.UpdateParamGroupParameters{
+				Parameters: map[string]string{"maxmemory-policy": "noeviction"},
+			})
+			Expect(err).To(MatchError(ErrParametersNotSupported))
+		})
+	})
+
+	Context("when deprovisioning", func() {
+		It("deletes the serverless cache", func() {
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.DeleteServerlessCacheWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.DeleteServerlessCacheInput{
+				ServerlessCacheName: aws.String(serverlessCacheName),
+			}))
+		})
+
+		It("returns ErrInstanceNotFound if the serverless cache doesn't exist", func() {
+			mockElasticache.DeleteServerlessCacheWithContextReturns(nil, awserr.New(elasticache.ErrCodeServerlessCacheNotFoundFault, "not found", nil))
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).To(MatchError(providers.ErrInstanceNotFound))
+		})
+
+		It("succeeds if the serverless cache is being deleted already", func() {
+			mockElasticache.DeleteServerlessCacheWithContextReturns(nil, awserr.New(elasticache.ErrCodeInvalidServerlessCacheStateFault, "invalid state", nil))
+			mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+				ServerlessCaches: []*elasticache.ServerlessCache{{Status: aws.String("deleting")}},
+			}, nil)
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("takes a final snapshot with the tags of the cache", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{
+				FinalSnapshotIdentifier: "final-snapshot",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, input, _ := mockElasticache.DeleteServerlessCacheWithContextArgsForCall(0)
+			Expect(input.FinalSnapshotName).To(Equal(aws.String("final-snapshot")))
+
+			_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(tagsInput).To(Equal(&elasticache.AddTagsToResourceInput{
+				ResourceName: aws.String("arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:final-snapshot"),
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}))
+		})
+
+		It("returns the error if tagging the final snapshot fails, so the deprovision is retried", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+			mockElasticache.AddTagsToResourceWithContextReturns(nil, errors.New("some error"))
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{
+				FinalSnapshotIdentifier: "final-snapshot",
+			})
+			Expect(err).To(MatchError("failed to tag the final snapshot final-snapshot: some error"))
+		})
+
+		It("tags the final snapshot again if the serverless cache is being deleted already", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+			mockElasticache.DeleteServerlessCacheWithContextReturns(nil, awserr.New(elasticache.ErrCodeInvalidServerlessCacheStateFault, "invalid state", nil))
+			mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+				ServerlessCaches: []*elasticache.ServerlessCache{{Status: aws.String("deleting")}},
+			}, nil)
+
+			err := provider.Deprovision(ctx, instanceID, providers.DeprovisionParameters{
+				FinalSnapshotIdentifier: "final-snapshot",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mockElasticache.AddTagsToResourceWithContextCallCount()).To(Equal(1))
+			_, tagsInput, _ := mockElasticache.AddTagsToResourceWithContextArgsForCall(0)
+			Expect(tagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:final-snapshot")))
+		})
+	})
+
+	Context("when getting the progress state", func() {
+		It("returns the status of the serverless cache", func() {
+			mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+				ServerlessCaches: []*elasticache.ServerlessCache{
+					{
+						ServerlessCacheName: aws.String(serverlessCacheName),
+						Status:              aws.String("available"),
+						CacheUsageLimits: &elasticache.CacheUsageLimits{
+							DataStorage: &elasticache.DataStorage{Maximum: aws.Int64(10), Unit: aws.String("GB")},
+						},
+					},
+				},
+			}, nil)
+
+			state, message, err := provider.ProgressState(ctx, instanceID, "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.Available))
+			Expect(message).To(ContainSubstring("data storage limit   : 10 GB"))
+
+			_, input, _ := mockElasticache.DescribeServerlessCachesWithContextArgsForCall(0)
+			Expect(input.ServerlessCacheName).To(Equal(aws.String(serverlessCacheName)))
+		})
+
+		It("returns non-existing if the serverless cache is gone", func() {
+			mockElasticache.DescribeServerlessCachesWithContextReturns(nil,
+				awserr.New(elasticache.ErrCodeServerlessCacheNotFoundFault, "not found", nil))
+
+			state, _, err := provider.ProgressState(ctx, instanceID, "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(providers.NonExisting))
+		})
+	})
+
+	Context("when finding an instance", func() {
+		It("returns the state and the tags of the serverless cache", func() {
+			mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+				ServerlessCaches: []*elasticache.ServerlessCache{
+					{ServerlessCacheName: aws.String(serverlessCacheName), Status: aws.String("creating")},
+				},
+			}, nil)
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{{Key: aws.String("plan-id"), Value: aws.String("plan1")}},
+			}, nil)
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(Equal(&providers.ExistingInstance{
+				State: providers.Creating,
+				Tags:  map[string]string{"plan-id": "plan1"},
+			}))
+
+			_, input, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+			Expect(input.ResourceName).To(Equal(aws.String(cacheARN)))
+		})
+
+		It("returns nil if the serverless cache doesn't exist", func() {
+			mockElasticache.DescribeServerlessCachesWithContextReturns(nil,
+				awserr.New(elasticache.ErrCodeServerlessCacheNotFoundFault, "not found", nil))
+
+			instance, err := provider.FindInstance(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance).To(BeNil())
+		})
+	})
+
+	It("returns an instance not found error when generating credentials for a serverless cache which doesn't exist", func() {
+		mockElasticache.DescribeServerlessCachesWithContextReturns(nil,
+			awserr.New(elasticache.ErrCodeServerlessCacheNotFoundFault, "not found", nil))
+
+		_, err := provider.GenerateCredentials(ctx, instanceID, "test-binding")
+		Expect(err).To(MatchError("Serverless cache does not exist: " + serverlessCacheName))
+		Expect(errors.Is(err, providers.ErrInstanceNotFound)).To(BeTrue())
+	})
+
+	Context("when generating credentials for a serverless cache with a user group", func() {
+		var (
+			credentials *providers.Credentials
+			generateErr error
+		)
+
+		BeforeEach(func() {
+			mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+				ServerlessCaches: []*elasticache.ServerlessCache{
+					{
+						Endpoint: &elasticache.Endpoint{
+							Address: aws.String("test-host"),
+							Port:    aws.Int64(6379),
+						},
+						UserGroupId: aws.String(serverlessCacheName),
+					},
+				},
+			}, nil)
+			mockElasticache.DescribeUserGroupsWithContextReturns(&elasticache.DescribeUserGroupsOutput{
+				UserGroups: []*elasticache.UserGroup{
+					{
+						UserGroupId: aws.String(serverlessCacheName),
+						Engine:      aws.String("valkey"),
+					},
+				},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			credentials, generateErr = provider.GenerateCredentials(ctx, instanceID, "test-binding")
+		})
+
+		It("creates a user for the binding and adds it to the user group", func() {
+			Expect(generateErr).ToNot(HaveOccurred())
+
+			_, userInput, _ := mockElasticache.CreateUserWithContextArgsForCall(0)
+			Expect(userInput.UserId).To(Equal(aws.String(providers.GenerateBindingUserID("test-binding"))))
+			Expect(userInput.Engine).To(Equal(aws.String("valkey")))
+
+			_, groupInput, _ := mockElasticache.ModifyUserGroupWithContextArgsForCall(0)
+			Expect(groupInput.UserGroupId).To(Equal(aws.String(serverlessCacheName)))
+			Expect(groupInput.UserIdsToAdd).To(Equal(aws.StringSlice([]string{providers.GenerateBindingUserID("test-binding")})))
+		})
+
+		It("stores the password in the secrets manager", func() {
+			_, input, _ := mockSecretsManager.CreateSecretWithContextArgsForCall(0)
+			Expect(input.Name).To(Equal(aws.String("elasticache-broker-test/foobar/bindings/test-binding")))
+			Expect(input.SecretString).To(Equal(aws.String(credentials.Password)))
+			Expect(input.KmsKeyId).To(Equal(aws.String("my-kms-key")))
+		})
+
+		It("returns the credentials of the user", func() {
+			userName := providers.GenerateBindingUserID("test-binding")
+			Expect(credentials.Username).To(Equal(userName))
+			Expect(credentials.Password).To(HaveLen(providers.PasswordLength))
+			Expect(credentials.URI).To(Equal("rediss://" + userName + ":" + credentials.Password + "@test-host:6379"))
+		})
+	})
+
+	It("deletes the user and the secret of a binding when revoking its credentials", func() {
+		err := provider.RevokeCredentials(ctx, instanceID, "test-binding")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, userInput, _ := mockElasticache.DeleteUserWithContextArgsForCall(0)
+		Expect(userInput.UserId).To(Equal(aws.String(providers.GenerateBindingUserID("test-binding"))))
+		_, secretInput, _ := mockSecretsManager.DeleteSecretWithContextArgsForCall(0)
+		Expect(secretInput.SecretId).To(Equal(aws.String("elasticache-broker-test/foobar/bindings/test-binding")))
+	})
+
+	It("deletes the user group and the default user", func() {
+		mockElasticache.DeleteUserWithContextReturns(nil, awserr.New(elasticache.ErrCodeUserNotFoundFault, "not found", nil))
+
+		err := provider.DeleteUserGroup(ctx, instanceID)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, groupInput, _ := mockElasticache.DeleteUserGroupWithContextArgsForCall(0)
+		Expect(groupInput.UserGroupId).To(Equal(aws.String(serverlessCacheName)))
+		_, userInput, _ := mockElasticache.DeleteUserWithContextArgsForCall(0)
+		Expect(userInput.UserId).To(Equal(aws.String(serverlessCacheName + "-default")))
+	})
+
+	It("doesn't generate credentials for a serverless cache without a user group", func() {
+		mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+			ServerlessCaches: []*elasticache.ServerlessCache{
+				{
+					Endpoint: &elasticache.Endpoint{
+						Address: aws.String("cf-qwkec4pxhft6q.serverless.euw1.cache.amazonaws.com"),
+						Port:    aws.Int64(6379),
+					},
+				},
+			},
+		}, nil)
+
+		_, err := provider.GenerateCredentials(ctx, instanceID, "binding")
+		Expect(err).To(MatchError("Serverless cache " + serverlessCacheName + " has no user group"))
+		Expect(mockElasticache.CreateUserWithContextCallCount()).To(Equal(0))
+	})
+
+	It("returns the engine and usage limits as instance parameters", func() {
+		mockElasticache.DescribeServerlessCachesWithContextReturns(&elasticache.DescribeServerlessCachesOutput{
+			ServerlessCaches: []*elasticache.ServerlessCache{
+				{
+					Engine:            aws.String("valkey"),
+					FullEngineVersion: aws.String("8.0"),
+					CacheUsageLimits: &elasticache.CacheUsageLimits{
+						ECPUPerSecond: &elasticache.ECPUPerSecond{Maximum: aws.Int64(5000)},
+					},
+				},
+			},
+		}, nil)
+
+		instanceParameters, err := provider.GetInstanceParameters(ctx, instanceID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceParameters).To(Equal(providers.InstanceParameters{
+			Engine:           "valkey",
+			EngineVersion:    "8.0",
+			CacheUsageLimits: &providers.CacheUsageLimits{ECPUPerSecondMaximum: 5000},
+		}))
+	})
+
+	Context("snapshots", func() {
+		var createTime time.Time
+
+		BeforeEach(func() {
+			createTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			mockElasticache.DescribeServerlessCacheSnapshotsPagesWithContextStub = func(
+				ctx context.Context,
+				input *elasticache.DescribeServerlessCacheSnapshotsInput,
+				fn func(*elasticache.DescribeServerlessCacheSnapshotsOutput, bool) bool,
+				opts ...request.Option,
+			) error {
+				fn(&elasticache.DescribeServerlessCacheSnapshotsOutput{
+					ServerlessCacheSnapshots: []*elasticache.ServerlessCacheSnapshot{
+						{
+							ServerlessCacheSnapshotName: aws.String("snapshot-1"),
+							CreateTime:                  aws.Time(createTime),
+							SnapshotType:                aws.String("manual"),
+							Status:                      aws.String("available"),
+						},
+						{
+							ServerlessCacheSnapshotName: aws.String("snapshot-2"),
+							CreateTime:                  aws.Time(createTime),
+							SnapshotType:                aws.String("automated"),
+							Status:                      aws.String("creating"),
+						},
+					},
+				}, true)
+				return nil
+			}
+		})
+
+		It("lists the snapshots of the serverless cache", func() {
+			snapshots, err := provider.ListSnapshots(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]providers.SnapshotInfo{
+				{Name: "snapshot-1", CreateTime: createTime, Source: "manual", Status: "available"},
+				{Name: "snapshot-2", CreateTime: createTime, Source: "automated", Status: "creating"},
+			}))
+
+			_, input, _, _ := mockElasticache.DescribeServerlessCacheSnapshotsPagesWithContextArgsForCall(0)
+			Expect(input.ServerlessCacheName).To(Equal(aws.String(serverlessCacheName)))
+		})
+
+		It("finds the snapshots of an instance by their tags", func() {
+			mockElasticache.ListTagsForResourceWithContextStub = func(
+				ctx context.Context,
+				input *elasticache.ListTagsForResourceInput,
+				opts ...request.Option,
+			) (*elasticache.TagListMessage, error) {
+				if *input.ResourceName == "arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:snapshot-1" {
+					return &elasticache.TagListMessage{
+						TagList: []*elasticache.Tag{
+							{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+						},
+					}, nil
+				}
+				return &elasticache.TagListMessage{}, nil
+			}
+
+			snapshots, err := provider.FindSnapshots(ctx, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]providers.SnapshotInfo{
+				{Name: "snapshot-1", CreateTime: createTime, Tags: map[string]string{"instance-id": instanceID}},
+			}))
+		})
+
+		It("finds the snapshots which have all the given tags", func() {
+			mockElasticache.ListTagsForResourceWithContextStub = func(
+				ctx context.Context,
+				input *elasticache.ListTagsForResourceInput,
+				opts ...request.Option,
+			) (*elasticache.TagListMessage, error) {
+				spaceID := "other-space"
+				if *input.ResourceName == "arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:snapshot-2" {
+					spaceID = "space"
+				}
+				return &elasticache.TagListMessage{
+					TagList: []*elasticache.Tag{
+						{Key: aws.String("organization-id"), Value: aws.String("org")},
+						{Key: aws.String("space-id"), Value: aws.String(spaceID)},
+					},
+				}, nil
+			}
+
+			snapshots, err := provider.FindSnapshotsWithTags(ctx, map[string]string{
+				"organization-id": "org",
+				"space-id":        "space",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]providers.SnapshotInfo{
+				{Name: "snapshot-2", CreateTime: createTime, Tags: map[string]string{"organization-id": "org", "space-id": "space"}},
+			}))
+		})
+
+		It("finds a snapshot by its name", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+
+			snapshot, err := provider.FindSnapshot(ctx, "snapshot-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).To(Equal(&providers.SnapshotInfo{
+				Name: "snapshot-1", CreateTime: createTime, Tags: map[string]string{"instance-id": instanceID},
+			}))
+
+			_, input, _, _ := mockElasticache.DescribeServerlessCacheSnapshotsPagesWithContextArgsForCall(0)
+			Expect(input.ServerlessCacheSnapshotName).To(Equal(aws.String("snapshot-1")))
+			_, tagsInput, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+			Expect(tagsInput.ResourceName).To(Equal(aws.String("arn:aws:elasticache:eu-west-1:123456789012:serverlesscachesnapshot:snapshot-1")))
+		})
+
+		It("doesn't find a snapshot which doesn't exist", func() {
+			mockElasticache.DescribeServerlessCacheSnapshotsPagesWithContextStub = nil
+			mockElasticache.DescribeServerlessCacheSnapshotsPagesWithContextReturns(
+				awserr.New(elasticache.ErrCodeServerlessCacheSnapshotNotFoundFault, "not found", nil))
+
+			snapshot, err := provider.FindSnapshot(ctx, "snapshot-3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).To(BeNil())
+			Expect(mockElasticache.ListTagsForResourceWithContextCallCount()).To(Equal(0))
+		})
+
+		It("creates a snapshot with the tags of the serverless cache", func() {
+			mockElasticache.ListTagsForResourceWithContextReturns(&elasticache.TagListMessage{
+				TagList: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}, nil)
+
+			err := provider.CreateSnapshot(ctx, instanceID, "manual-snapshot")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, tagsInput, _ := mockElasticache.ListTagsForResourceWithContextArgsForCall(0)
+			Expect(tagsInput.ResourceName).To(Equal(aws.String(cacheARN)))
+
+			_, input, _ := mockElasticache.CreateServerlessCacheSnapshotWithContextArgsForCall(0)
+			Expect(input).To(Equal(&elasticache.CreateServerlessCacheSnapshotInput{
+				ServerlessCacheName:         aws.String(serverlessCacheName),
+				ServerlessCacheSnapshotName: aws.String("manual-snapshot"),
+				Tags: []*elasticache.Tag{
+					{Key: aws.String("instance-id"), Value: aws.String(instanceID)},
+				},
+			}))
+		})
+	})
+
+	It("doesn't support failover tests, auth tokens or engine upgrades", func() {
+		_, err := provider.StartFailoverTest(ctx, instanceID)
+		Expect(err).To(MatchError(ErrFailoverNotSupported))
+		Expect(provider.RotateAuthToken(ctx, instanceID)).To(MatchError(ErrAuthTokenNotSupported))
+		Expect(provider.UpgradeEngineVersion(ctx, instanceID, providers.UpgradeEngineVersionParameters{
+			EngineVersion: "8.0",
+		})).To(MatchError(ErrEngineUpgradeNotSupported))
+	})
+})
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// Step is a step of an operation which creates several resources, like provisioning an instance
+// Undo is the compensating action which deletes what Do created, it's nil if there is nothing to delete.
+type Step struct {
+	Name string
+	Do   func(ctx context.Context) error
+	Undo func(ctx context.Context) error
+}
+
+// CompensationResult is the outcome of the compensating action of a step, Err is nil if it succeeded
+type CompensationResult struct {
+	Step string
+	Err  error
+}
+
+// StepError is returned by RunSteps if a step fails
+// It wraps the error of the failed step and has the outcome of every compensating action, so it's visible what was left
+// behind.
+type StepError struct {
+	Step          string
+	Err           error
+	Compensations []CompensationResult
+}
+
+func (e *StepError) Error() string {
+	if len(e.Compensations) == 0 {
+		return e.Err.Error()
+	}
+	outcomes := make([]string, 0, len(e.Compensations))
+	for _, compensation := range e.Compensations {
+		if compensation.Err != nil {
+			outcomes = append(outcomes, fmt.Sprintf("%s: rollback failed: %s", compensation.Step, compensation.Err))
+		} else {
+			outcomes = append(outcomes, fmt.Sprintf("%s: rolled back", compensation.Step))
+		}
+	}
+	return fmt.Sprintf("%s (%s)", e.Err, strings.Join(outcomes, ", "))
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// RunSteps runs the steps in order
+// If a step fails, the compensating actions of the earlier steps are run in reverse order. The failed step isn't
+// compensated, as it may have failed because its resource already exists, so a step which creates several resources
+// has to be split into several steps.
+func RunSteps(ctx context.Context, logger lager.Logger, steps []Step) error {
+	for i, step := range steps {
+		err := step.Do(ctx)
+		if err == nil {
+			continue
+		}
+
+		logger.Error("step-failed", err, lager.Data{"step": step.Name})
+		stepErr := &StepError{Step: step.Name, Err: err}
+		for j := i - 1; j >= 0; j-- {
+			if steps[j].Undo == nil {
+				continue
+			}
+			undoErr := steps[j].Undo(ctx)
+			if undoErr != nil {
+				logger.Error("compensation-failed", undoErr, lager.Data{"step": steps[j].Name})
+			} else {
+				logger.Info("compensation-succeeded", lager.Data{"step": steps[j].Name})
+			}
+			stepErr.Compensations = append(stepErr.Compensations, CompensationResult{Step: steps[j].Name, Err: undoErr})
+		}
+		return stepErr
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package providers_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+)
+
+var _ = Describe("RunSteps", func() {
+	var (
+		ctx    context.Context
+		logger lager.Logger
+		log    *gbytes.Buffer
+		calls  []string
+	)
+
+	step := func(name string, doErr error, undoErr error) providers.Step {
+		return providers.Step{
+			Name: name,
+			Do: func(ctx context.Context) error {
+				calls = append(calls, "do "+name)
+				return doErr
+			},
+			Undo: func(ctx context.Context) error {
+				calls = append(calls, "undo "+name)
+				return undoErr
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		logger = lager.NewLogger("logger")
+		log = gbytes.NewBuffer()
+		logger.RegisterSink(lager.NewWriterSink(log, lager.INFO))
+		calls = nil
+	})
+
+	It("runs all the steps in order", func() {
+		err := providers.RunSteps(ctx, logger, []providers.Step{
+			step("first", nil, nil),
+			step("second", nil, nil),
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal([]string{"do first", "do second"}))
+	})
+
+	It("compensates the steps before the failed one in reverse order", func() {
+		stepErr := errors.New("some error")
+		last := step("third", nil, nil)
+		last.Undo = nil
+
+		err := providers.RunSteps(ctx, logger, []providers.Step{
+			step("first", nil, nil),
+			step("second", stepErr, nil),
+			last,
+		})
+
+		Expect(calls).To(Equal([]string{"do first", "do second", "undo first"}))
+		Expect(err).To(MatchError(stepErr))
+		Expect(err).To(MatchError("some error (first: rolled back)"))
+		Expect(log).To(gbytes.Say("step-failed"))
+		Expect(log).To(gbytes.Say("compensation-succeeded"))
+	})
+
+	It("skips steps without a compensating action", func() {
+		first := step("first", nil, nil)
+		first.Undo = nil
+
+		err := providers.RunSteps(ctx, logger, []providers.Step{
+			first,
+			step("second", errors.New("some error"), nil),
+		})
+
+		Expect(calls).To(Equal([]string{"do first", "do second"}))
+		Expect(err).To(MatchError("some error"))
+	})
+
+	It("carries on and returns the outcome if a compensating action fails", func() {
+		err := providers.RunSteps(ctx, logger, []providers.Step{
+			step("first", nil, nil),
+			step("second", nil, errors.New("undo error")),
+			step("third", errors.New("some error"), nil),
+		})
+
+		Expect(calls).To(Equal([]string{"do first", "do second", "do third", "undo second", "undo first"}))
+		var stepErr *providers.StepError
+		Expect(errors.As(err, &stepErr)).To(BeTrue())
+		Expect(stepErr.Step).To(Equal("third"))
+		Expect(stepErr.Compensations).To(Equal([]providers.CompensationResult{
+			{Step: "second", Err: errors.New("undo error")},
+			{Step: "first"},
+		}))
+		Expect(log).To(gbytes.Say("compensation-failed"))
+	})
+})
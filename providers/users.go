@@ -0,0 +1,263 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+const (
+	defaultUserName = "default"
+	// Binding users can run every command except the dangerous ones, like FLUSHALL, CONFIG or DEBUG
+	bindingUserAccessString = "on ~* +@all -@dangerous"
+
+	// How many times and how often adding a binding user is tried while the user group is being modified
+	userGroupModifyAttempts = 10
+	userGroupModifyDelay    = 3 * time.Second
+)
+
+// Users manages the ElastiCache users of the instances which have a user per binding
+// Replication groups and serverless caches use the same users and user groups, so it's used by both providers.
+type Users struct {
+	elastiCache        ElastiCache
+	secretsManager     SecretsManager
+	logger             lager.Logger
+	kmsKeyID           string
+	secretsManagerPath string
+	sleep              func(ctx context.Context, d time.Duration) error
+}
+
+// NewUsers creates a new Users, the passwords of the binding users are stored under the secrets manager path
+func NewUsers(
+	elastiCache ElastiCache,
+	secretsManager SecretsManager,
+	logger lager.Logger,
+	kmsKeyID string,
+	secretsManagerPath string,
+) *Users {
+	return &Users{
+		elastiCache:        elastiCache,
+		secretsManager:     secretsManager,
+		logger:             logger,
+		kmsKeyID:           kmsKeyID,
+		secretsManagerPath: strings.TrimRight(secretsManagerPath, "/"),
+		sleep:              sleepWithContext,
+	}
+}
+
+// CreateDefaultUser creates the default user of a user group
+//
+// ElastiCache requires every user group to have a user called "default", which is used when a client doesn't send
+// a user name. We switch it off, so only the users created for the bindings have access to the instance. A default
+// user left over from an earlier attempt to provision the instance is reused, as it's switched off as well.
+func (u *Users) CreateDefaultUser(ctx context.Context, userGroupID, instanceID, engine, password string) error {
+	userID := getDefaultUserID(userGroupID)
+	_, err := u.elastiCache.CreateUserWithContext(ctx, &elasticache.CreateUserInput{
+		UserId:       aws.String(userID),
+		UserName:     aws.String(defaultUserName),
+		Engine:       aws.String(engine),
+		AccessString: aws.String("off -@all"),
+		Passwords:    aws.StringSlice([]string{password}),
+		Tags:         chargeableEntityTags(instanceID),
+	})
+	if IsAWSError(err, elasticache.ErrCodeUserAlreadyExistsFault) {
+		u.logger.Info("default-user-exists", lager.Data{"user-id": userID})
+		return nil
+	}
+	return err
+}
+
+// CreateUserGroup creates the user group of an instance with its default user
+// A user group left over from an earlier attempt to provision the instance is reused.
+func (u *Users) CreateUserGroup(ctx context.Context, userGroupID, instanceID, engine string) error {
+	_, err := u.elastiCache.CreateUserGroupWithContext(ctx, &elasticache.CreateUserGroupInput{
+		UserGroupId: aws.String(userGroupID),
+		Engine:      aws.String(engine),
+		UserIds:     aws.StringSlice([]string{getDefaultUserID(userGroupID)}),
+		Tags:        chargeableEntityTags(instanceID),
+	})
+	if IsAWSError(err, elasticache.ErrCodeUserGroupAlreadyExistsFault) {
+		u.logger.Info("user-group-exists", lager.Data{"user-group-id": userGroupID})
+		return nil
+	}
+	return err
+}
+
+// DeleteUserGroup deletes a user group, it succeeds if the user group doesn't exist
+// It can only be called after the replication group or serverless cache using it was deleted.
+func (u *Users) DeleteUserGroup(ctx context.Context, userGroupID string) error {
+	_, err := u.elastiCache.DeleteUserGroupWithContext(ctx, &elasticache.DeleteUserGroupInput{
+		UserGroupId: aws.String(userGroupID),
+	})
+	if err != nil && !IsAWSError(err, elasticache.ErrCodeUserGroupNotFoundFault) {
+		return err
+	}
+	return nil
+}
+
+// DeleteDefaultUser deletes the default user of a user group, it succeeds if the user doesn't exist
+func (u *Users) DeleteDefaultUser(ctx context.Context, userGroupID string) error {
+	_, err := u.elastiCache.DeleteUserWithContext(ctx, &elasticache.DeleteUserInput{
+		UserId: aws.String(getDefaultUserID(userGroupID)),
+	})
+	if err != nil && !IsAWSError(err, elasticache.ErrCodeUserNotFoundFault) {
+		return err
+	}
+	return nil
+}
+
+// CreateBindingUser creates a user for a binding and adds it to a user group
+// It returns with the user name and password. If the user exists already, because the bind request is retried, the
+// user and the password stored in its secret are reused. An existing user is only deleted, so the next attempt creates
+// it again, if its secret doesn't exist, as an app may already use the credentials returned by an earlier attempt.
+func (u *Users) CreateBindingUser(ctx context.Context, instanceID, bindingID, userGroupID string) (string, string, error) {
+	userGroups, err := u.elastiCache.DescribeUserGroupsWithContext(ctx, &elasticache.DescribeUserGroupsInput{
+		UserGroupId: aws.String(userGroupID),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(userGroups.UserGroups) == 0 {
+		return "", "", fmt.Errorf("Invalid response from AWS: no user groups returned for %s", userGroupID)
+	}
+	userGroup := userGroups.UserGroups[0]
+
+	userID := GenerateBindingUserID(bindingID)
+	password := GeneratePassword()
+
+	_, err = u.elastiCache.CreateUserWithContext(ctx, &elasticache.CreateUserInput{
+		UserId:       aws.String(userID),
+		UserName:     aws.String(userID),
+		Engine:       userGroup.Engine,
+		AccessString: aws.String(bindingUserAccessString),
+		Passwords:    aws.StringSlice([]string{password}),
+		Tags:         chargeableEntityTags(instanceID),
+	})
+	reused := false
+	if IsAWSError(err, elasticache.ErrCodeUserAlreadyExistsFault) {
+		u.logger.Info("binding-user-exists", lager.Data{"instance-id": instanceID, "binding-id": bindingID})
+		password, err = u.getBindingPassword(ctx, instanceID, bindingID)
+		reused = !IsAWSError(err, secretsmanager.ErrCodeResourceNotFoundException)
+	} else if err == nil {
+		err = u.createBindingSecret(ctx, instanceID, bindingID, password)
+	}
+	if err == nil && !hasUser(userGroup, userID) {
+		err = u.addUserToUserGroup(ctx, userGroupID, userID)
+	}
+	if err != nil {
+		if reused {
+			return "", "", err
+		}
+		if revokeErr := u.DeleteBindingUser(ctx, instanceID, bindingID); revokeErr != nil {
+			u.logger.Error("revoke-credentials", revokeErr, lager.Data{
+				"instance-id": instanceID,
+				"binding-id":  bindingID,
+			})
+		}
+		return "", "", err
+	}
+
+	return userID, password, nil
+}
+
+// addUserToUserGroup adds a user to a user group
+// A user group can't be modified while an earlier change is being applied, e.g. when several apps are bound at the same
+// time, so it waits and tries again a few times.
+func (u *Users) addUserToUserGroup(ctx context.Context, userGroupID, userID string) error {
+	for attempt := 1; ; attempt++ {
+		_, err := u.elastiCache.ModifyUserGroupWithContext(ctx, &elasticache.ModifyUserGroupInput{
+			UserGroupId:  aws.String(userGroupID),
+			UserIdsToAdd: aws.StringSlice([]string{userID}),
+		})
+		if !IsAWSError(err, elasticache.ErrCodeInvalidUserGroupStateFault) || attempt == userGroupModifyAttempts {
+			return err
+		}
+		u.logger.Info("user-group-being-modified", lager.Data{"user-group-id": userGroupID, "attempt": attempt})
+		if sleepErr := u.sleep(ctx, userGroupModifyDelay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (u *Users) getBindingPassword(ctx context.Context, instanceID, bindingID string) (string, error) {
+	secret, err := u.secretsManager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(u.getBindingPasswordPath(instanceID, bindingID)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(secret.SecretString), nil
+}
+
+// DeleteBindingUser deletes the user of a binding and its secret, it succeeds if they don't exist
+// Deleting the user also removes it from the user group.
+func (u *Users) DeleteBindingUser(ctx context.Context, instanceID, bindingID string) error {
+	_, err := u.elastiCache.DeleteUserWithContext(ctx, &elasticache.DeleteUserInput{
+		UserId: aws.String(GenerateBindingUserID(bindingID)),
+	})
+	if err != nil && !IsAWSError(err, elasticache.ErrCodeUserNotFoundFault) {
+		return err
+	}
+
+	_, err = u.secretsManager.DeleteSecretWithContext(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:             aws.String(u.getBindingPasswordPath(instanceID, bindingID)),
+		RecoveryWindowInDays: aws.Int64(7),
+	})
+	if err != nil && !IsAWSError(err, secretsmanager.ErrCodeResourceNotFoundException) {
+		return err
+	}
+	return nil
+}
+
+func (u *Users) createBindingSecret(ctx context.Context, instanceID, bindingID, password string) error {
+	_, err := u.secretsManager.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+		Name:         aws.String(u.getBindingPasswordPath(instanceID, bindingID)),
+		SecretString: aws.String(password),
+		KmsKeyId:     aws.String(u.kmsKeyID),
+		Tags: []*secretsmanager.Tag{
+			{
+				Key:   aws.String("chargeable_entity"),
+				Value: aws.String(instanceID),
+			},
+		},
+	})
+	return err
+}
+
+func (u *Users) getBindingPasswordPath(instanceID, bindingID string) string {
+	return fmt.Sprintf("%s/%s/bindings/%s", u.secretsManagerPath, instanceID, bindingID)
+}
+
+func hasUser(userGroup *elasticache.UserGroup, userID string) bool {
+	for _, id := range aws.StringValueSlice(userGroup.UserIds) {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func getDefaultUserID(userGroupID string) string {
+	return userGroupID + "-default"
+}
+
+func chargeableEntityTags(instanceID string) []*elasticache.Tag {
+	return []*elasticache.Tag{
+		{
+			Key:   aws.String("chargeable_entity"),
+			Value: aws.String(instanceID),
+		},
+	}
+}
+
+// GenerateBindingUserID generates a valid ElastiCache user ID for a binding
+// A valid ID must contain between 1 and 40 alphanumeric characters or hyphens, should start with a letter, and cannot end with a hyphen or contain two consecutive hyphens.
+func GenerateBindingUserID(bindingID string) string {
+	return GenerateName("cf-binding-", bindingID)
+}
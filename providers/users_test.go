@@ -0,0 +1,84 @@
+package providers_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/alphagov/paas-elasticache-broker/providers"
+	"github.com/alphagov/paas-elasticache-broker/providers/mocks"
+)
+
+var _ = Describe("Users", func() {
+	var (
+		mockElasticache    *mocks.FakeElastiCache
+		mockSecretsManager *mocks.FakeSecretsManager
+		ctx                context.Context
+		userGroupID        string
+		bindingUserID      string
+
+		users  *providers.Users
+		sleeps *[]time.Duration
+	)
+
+	BeforeEach(func() {
+		mockElasticache = &mocks.FakeElastiCache{}
+		mockSecretsManager = &mocks.FakeSecretsManager{}
+		ctx = context.Background()
+		userGroupID = "cf-qwkec4pxhft6q"
+		bindingUserID = providers.GenerateBindingUserID("test-binding")
+
+		mockElasticache.DescribeUserGroupsWithContextReturns(&elasticache.DescribeUserGroupsOutput{
+			UserGroups: []*elasticache.UserGroup{
+				{
+					UserGroupId: aws.String(userGroupID),
+					Engine:      aws.String("redis"),
+				},
+			},
+		}, nil)
+
+		users = providers.NewUsers(mockElasticache, mockSecretsManager, lager.NewLogger("logger"), "my-kms-key", "elasticache-broker-test")
+		sleeps = users.ExportRecordSleeps()
+	})
+
+	Context("when the user group is being modified", func() {
+		BeforeEach(func() {
+			stateErr := awserr.New(elasticache.ErrCodeInvalidUserGroupStateFault, "modifying", nil)
+			mockElasticache.ModifyUserGroupWithContextReturnsOnCall(0, nil, stateErr)
+			mockElasticache.ModifyUserGroupWithContextReturnsOnCall(1, nil, stateErr)
+		})
+
+		It("waits and adds the binding user again", func() {
+			userName, _, err := users.CreateBindingUser(ctx, "foobar", "test-binding", userGroupID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(userName).To(Equal(bindingUserID))
+			Expect(mockElasticache.ModifyUserGroupWithContextCallCount()).To(Equal(3))
+			Expect(*sleeps).To(Equal([]time.Duration{3 * time.Second, 3 * time.Second}))
+			Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(0))
+		})
+
+		It("gives up and deletes the binding user and the secret if it's modified for too long", func() {
+			mockElasticache.ModifyUserGroupWithContextReturns(nil,
+				awserr.New(elasticache.ErrCodeInvalidUserGroupStateFault, "modifying", nil))
+
+			_, _, err := users.CreateBindingUser(ctx, "foobar", "test-binding", userGroupID)
+			Expect(err).To(HaveOccurred())
+			Expect(mockElasticache.ModifyUserGroupWithContextCallCount()).To(Equal(10))
+			Expect(*sleeps).To(HaveLen(9))
+			Expect(mockElasticache.DeleteUserWithContextCallCount()).To(Equal(1))
+			Expect(mockSecretsManager.DeleteSecretWithContextCallCount()).To(Equal(1))
+		})
+	})
+
+	It("generates valid user IDs for bindings", func() {
+		Expect(providers.GenerateBindingUserID("a-binding-guid")).To(MatchRegexp(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`))
+		Expect(len(providers.GenerateBindingUserID("a-binding-guid"))).To(BeNumerically("<=", 40))
+		Expect(providers.GenerateBindingUserID("binding-1")).ToNot(Equal(providers.GenerateBindingUserID("binding-2")))
+	})
+})